@@ -41,6 +41,7 @@ type Apollo struct {
 	payments           []PaymentI
 	isEstimateRequired bool
 	utxos              []common.Utxo
+	utxoStream         *utxoStream
 	preselectedUtxos   []common.Utxo
 	inputAddresses     []common.Address
 	tx                 *conway.ConwayTransaction
@@ -60,6 +61,8 @@ type Apollo struct {
 	ValidityStart      int64
 	totalCollateral    int64
 	referenceInputs    []shelley.ShelleyTransactionInput
+	referenceScripts   []common.Utxo
+	resolvedScripts    []ResolvedScript
 	collateralReturn   *babbage.BabbageTransactionOutput
 	// collateralOverlapRef holds the ref of an auto-selected collateral UTxO
 	// that is also allowed to serve as a regular spending input. It is set only
@@ -79,6 +82,7 @@ type Apollo struct {
 	usedUtxos                  map[string]bool
 	wallet                     Wallet
 	evaluationWitnessProviders []EvaluationWitnessProvider
+	dataSchemas                []DataSchema
 	certificates               []common.CertificateWrapper
 	withdrawals                map[string]withdrawalEntry
 	auxiliaryData              *auxData
@@ -90,6 +94,7 @@ type Apollo struct {
 	scriptHashes               []string
 	changeAddress              *common.Address
 	estimateExUnits            bool
+	autoValidityInterval       bool
 	forceFee                   bool
 	coinSelector               CoinSelector
 	err                        error
@@ -307,7 +312,9 @@ func (a *Apollo) SetFeePadding(padding int64) *Apollo {
 }
 
 // SetCoinSelector sets the coin selection algorithm used by Complete to
-// choose inputs. When unset, the package default selector is used.
+// choose inputs. When unset, the package default selector is used. A
+// StreamingCoinSelector lets Complete read input addresses only as far as the
+// selection needs.
 func (a *Apollo) SetCoinSelector(selector CoinSelector) *Apollo {
 	a.coinSelector = selector
 	return a
@@ -1173,9 +1180,11 @@ func (a *Apollo) Clone() *Apollo {
 		currentTreasury:            a.currentTreasury,
 		treasuryDonation:           a.treasuryDonation,
 		estimateExUnits:            a.estimateExUnits,
+		autoValidityInterval:       a.autoValidityInterval,
 		coinSelector:               a.coinSelector,
 		wallet:                     a.wallet,
 		evaluationWitnessProviders: append([]EvaluationWitnessProvider(nil), a.evaluationWitnessProviders...),
+		dataSchemas:                append([]DataSchema(nil), a.dataSchemas...),
 		err:                        a.err,
 		redeemers:                  make(map[string]redeemerEntry),
 		stakeRedeemers:             make(map[string]redeemerEntry),
@@ -1256,6 +1265,8 @@ func (a *Apollo) Clone() *Apollo {
 	clone.mint = append(clone.mint, a.mint...)
 	clone.collaterals = cloneUtxos(a.collaterals, clone, "collateral")
 	clone.referenceInputs = append(clone.referenceInputs, a.referenceInputs...)
+	clone.referenceScripts = cloneUtxos(a.referenceScripts, clone, "reference script")
+	clone.resolvedScripts = append(clone.resolvedScripts, a.resolvedScripts...)
 	for _, script := range a.nativescripts {
 		var scriptCopy common.NativeScript
 		if err := cloneCBORValue(script, &scriptCopy); err != nil {
@@ -1537,6 +1548,7 @@ func (a *Apollo) Complete() (*Apollo, error) {
 	}
 
 	// Load UTxOs from input addresses if needed (must happen before collateral selection)
+	defer a.closeUtxoStream()
 	if err := a.loadUtxos(); err != nil {
 		return a, err
 	}
@@ -1549,6 +1561,24 @@ func (a *Apollo) Complete() (*Apollo, error) {
 		return a, err
 	}
 
+	// Check datums and redeemers against registered schemas before any
+	// selection or evaluation work, so a malformed datum fails locally.
+	if err := a.validateDataSchemas(); err != nil {
+		return a, err
+	}
+
+	// Script inputs locked with a datum hash need the datum in the witness
+	// set; fetch any the caller did not add before fees are estimated.
+	if err := a.resolveWitnessDatums(); err != nil {
+		return a, err
+	}
+
+	// Supply scripts for script inputs and minting policies that have none
+	// attached, preferring registered reference script UTxOs.
+	if err := a.resolveScripts(); err != nil {
+		return a, err
+	}
+
 	// Auto-select collateral if needed (after UTxOs are loaded)
 	if err := a.setCollateral(); err != nil {
 		return a, err
@@ -1769,6 +1799,11 @@ func (a *Apollo) Complete() (*Apollo, error) {
 	if err := a.validateCollateral(); err != nil {
 		return a, err
 	}
+	if a.autoValidityInterval {
+		if err := a.applyNativeScriptValidity(allInputUtxos); err != nil {
+			return a, err
+		}
+	}
 
 	// Estimate the initial fee. The balanced evaluation loop below rebuilds the
 	// complete transaction shape (change, collateral, and ExUnits) until stable.
@@ -2018,20 +2053,81 @@ func (a *Apollo) Sign() (*Apollo, error) {
 	// if the body was mutated after a previous Id() call.
 	txHash := common.Blake2b256Hash(bodyCbor)
 
-	witness, err := a.wallet.SignTxBody(txHash)
-	if err != nil {
-		return a, fmt.Errorf("signing failed: %w", err)
+	var signed []common.VkeyWitness
+	if signer, ok := a.wallet.(MultiKeySigner); ok {
+		// A wallet holding several keys signs for every key hash the
+		// transaction needs that it controls, not just its primary key.
+		spent, err := a.txSpentUtxos()
+		if err != nil {
+			return a, fmt.Errorf("signing failed: %w", err)
+		}
+		required := a.vkeySignerHashes(spent)
+		for _, hash := range a.tx.Body.TxRequiredSigners.Items() {
+			required[hash] = struct{}{}
+		}
+		signed, err = signer.SignTxBodyFor(txHash, sortedSignerHashes(slices.Collect(maps.Keys(required))))
+		if err != nil {
+			return a, fmt.Errorf("signing failed: %w", err)
+		}
+		if len(signed) == 0 {
+			return a, errors.New("signing failed: the wallet holds none of the keys the transaction needs")
+		}
+	} else {
+		witness, err := a.wallet.SignTxBody(txHash)
+		if err != nil {
+			return a, fmt.Errorf("signing failed: %w", err)
+		}
+		signed = []common.VkeyWitness{witness}
 	}
 
 	var witnesses []common.VkeyWitness
 	if existing := a.tx.WitnessSet.VkeyWitnesses.Items(); existing != nil {
 		witnesses = existing
 	}
-	witnesses = append(witnesses, witness)
+	witnesses = append(witnesses, signed...)
 	a.tx.WitnessSet.VkeyWitnesses = cbor.NewSetType(witnesses, true)
 	return a, nil
 }
 
+// txSpentUtxos resolves the built transaction's spending and collateral
+// inputs. Inputs the builder has not seen, such as those of a transaction
+// loaded from CBOR, are looked up with UtxoByRef.
+func (a *Apollo) txSpentUtxos() ([]common.Utxo, error) {
+	known := make(map[string]common.Utxo, len(a.preselectedUtxos)+len(a.utxos)+len(a.collaterals))
+	for _, pool := range [][]common.Utxo{a.preselectedUtxos, a.utxos, a.collaterals} {
+		for _, utxo := range pool {
+			if validateUtxo(utxo) == nil {
+				known[utxoRef(utxo)] = utxo
+			}
+		}
+	}
+	inputs := slices.Concat(a.tx.Body.TxInputs.Items(), a.tx.Body.TxCollateral.Items())
+	resolved := make([]common.Utxo, 0, len(inputs))
+	for _, input := range inputs {
+		ref := utxoRef(common.Utxo{Id: input})
+		if utxo, ok := known[ref]; ok {
+			resolved = append(resolved, utxo)
+			continue
+		}
+		if a.Context == nil {
+			return nil, fmt.Errorf("input %s is unknown and no chain context is set to resolve it", ref)
+		}
+		utxo, err := backend.UtxoByRefContext(a.requestContext, a.Context, input.Id(), input.Index())
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve input %s: %w", ref, err)
+		}
+		if utxo == nil {
+			return nil, fmt.Errorf("failed to resolve input %s: %w", ref, backend.ErrUtxoNotFound)
+		}
+		if err := validateUtxo(*utxo); err != nil {
+			return nil, fmt.Errorf("failed to resolve input %s: %w", ref, err)
+		}
+		known[ref] = *utxo
+		resolved = append(resolved, *utxo)
+	}
+	return resolved, nil
+}
+
 // GetTx returns the built transaction.
 //
 // Apollo builds Conway transactions, so the concrete Conway type is returned:
@@ -2067,7 +2163,14 @@ func (a *Apollo) Submit() (common.Blake2b256, error) {
 
 // --- internal helpers ---
 
+// loadUtxos fills the pool from the input addresses, or from the wallet's
+// addresses when those hold none. With a StreamingCoinSelector over a
+// backend.UtxoStreamer it opens a stream instead, which later steps read only
+// as far as they need.
 func (a *Apollo) loadUtxos() error {
+	if a.openUtxoStream() {
+		return nil
+	}
 	for _, addr := range a.inputAddresses {
 		utxos, err := backend.UtxosContext(a.requestContext, a.Context, addr)
 		if err != nil {
@@ -2078,20 +2181,30 @@ func (a *Apollo) loadUtxos() error {
 		}
 		a.utxos = append(a.utxos, utxos...)
 	}
-	// If no UTxOs loaded and wallet is set, load from wallet address
+	// If no UTxOs loaded and wallet is set, load from the wallet's addresses
 	if len(a.utxos) == 0 && len(a.preselectedUtxos) == 0 && a.wallet != nil {
-		utxos, err := backend.UtxosContext(a.requestContext, a.Context, a.wallet.Address())
-		if err != nil {
-			return fmt.Errorf("failed to load wallet UTxOs: %w", err)
-		}
-		if err := validateUtxos(utxos); err != nil {
-			return fmt.Errorf("failed to load wallet UTxOs: %w", err)
+		for _, addr := range a.walletInputAddresses() {
+			utxos, err := backend.UtxosContext(a.requestContext, a.Context, addr)
+			if err != nil {
+				return fmt.Errorf("failed to load wallet UTxOs: %w", err)
+			}
+			if err := validateUtxos(utxos); err != nil {
+				return fmt.Errorf("failed to load wallet UTxOs: %w", err)
+			}
+			a.utxos = append(a.utxos, utxos...)
 		}
-		a.utxos = utxos
 	}
 	return nil
 }
 
+// walletInputAddresses returns the addresses the wallet spends from.
+func (a *Apollo) walletInputAddresses() []common.Address {
+	if provider, ok := a.wallet.(InputAddressProvider); ok {
+		return provider.InputAddresses()
+	}
+	return []common.Address{a.wallet.Address()}
+}
+
 func (a *Apollo) buildOutputs() ([]babbage.BabbageTransactionOutput, error) {
 	outputs := make([]babbage.BabbageTransactionOutput, 0, len(a.payments))
 	for _, payment := range a.payments {
@@ -2219,6 +2332,16 @@ func (a *Apollo) selectCoins(required, currentInput Value) ([]common.Utxo, error
 		// satisfy the non-empty input set rule. Prefer a pure-ADA UTxO so the
 		// change output does not have to carry native assets it did not need
 		// to, and pick deterministically so construction stays reproducible.
+		// A stream is read for one more UTxO only if none is left to pick.
+		if len(available) == 0 {
+			for utxo, err := range a.availableUtxoSeq() {
+				if err != nil {
+					return nil, err
+				}
+				available = append(available, utxo)
+				break
+			}
+		}
 		pick, pickErr := pickSingleInput(available)
 		if pickErr != nil {
 			return nil, pickErr
@@ -2230,11 +2353,27 @@ func (a *Apollo) selectCoins(required, currentInput Value) ([]common.Utxo, error
 			selector = defaultCoinSelector
 		}
 		var selErr error
-		selected, selErr = selector.Select(
-			a.requestContext,
-			available,
-			remaining,
-		)
+		if streaming, ok := selector.(StreamingCoinSelector); ok && a.utxoStream != nil {
+			read := len(a.utxos)
+			selected, selErr = streaming.SelectSeq(
+				a.requestContext,
+				a.availableUtxoSeq(),
+				remaining,
+			)
+			// Whatever the selector read from the stream joins the pool the
+			// selection is checked against below.
+			for _, utxo := range a.utxos[read:] {
+				if !a.isUsed(utxoRef(utxo)) {
+					available = append(available, utxo)
+				}
+			}
+		} else {
+			selected, selErr = selector.Select(
+				a.requestContext,
+				available,
+				remaining,
+			)
+		}
 		if selErr != nil {
 			return nil, selErr
 		}
@@ -2290,9 +2429,31 @@ func (a *Apollo) selectCoins(required, currentInput Value) ([]common.Utxo, error
 // script witness, not a vkey witness. The result never drops below the previous
 // estimate, so this can only raise a fee, never lower one.
 func (a *Apollo) estimatedWitnessCount(inputs []common.Utxo) int {
+	count := len(a.vkeySignerHashes(inputs))
+	// Never estimate below the previous behaviour.
+	if previous := 1 + len(a.requiredSigners); count < previous {
+		count = previous
+	}
+	return count
+}
+
+// vkeySignerHashes returns the set of key hashes whose vkey witnesses the
+// transaction needs: the wallet (unless it signs per key), explicit required
+// signers, key-locked inputs and collateral, withdrawals, and stake
+// certificates.
+func (a *Apollo) vkeySignerHashes(inputs []common.Utxo) map[common.Blake2b224]struct{} {
 	signers := make(map[common.Blake2b224]struct{})
-	if a.wallet != nil {
-		signers[a.wallet.PubKeyHash()] = struct{}{}
+	switch wallet := a.wallet.(type) {
+	case nil:
+	case NativeScriptWallet:
+		for _, hash := range wallet.ScriptSigners() {
+			signers[hash] = struct{}{}
+		}
+	case MultiKeySigner:
+		// A multi-key wallet signs only for the keys collected below, so its
+		// primary key needs no witness unless one of its inputs is spent.
+	default:
+		signers[wallet.PubKeyHash()] = struct{}{}
 	}
 	for _, hash := range a.requiredSigners {
 		signers[hash] = struct{}{}
@@ -2357,12 +2518,7 @@ func (a *Apollo) estimatedWitnessCount(inputs []common.Utxo) int {
 			addStakeCredential(certificate.StakeCredential)
 		}
 	}
-	count := len(signers)
-	// Never estimate below the previous behaviour.
-	if previous := 1 + len(a.requiredSigners); count < previous {
-		count = previous
-	}
-	return count
+	return signers
 }
 
 func (a *Apollo) estimateFee(inputs []common.Utxo, outputs []babbage.BabbageTransactionOutput) (int64, error) {
@@ -2919,6 +3075,37 @@ func (a *Apollo) buildBody(
 	return body, nil
 }
 
+// witnessNativeScripts returns the attached native scripts plus the wallet's
+// own script when a NativeScriptWallet's address is spent and the script was
+// not attached explicitly.
+func (a *Apollo) witnessNativeScripts(inputs []common.Utxo) []common.NativeScript {
+	scriptWallet, ok := a.wallet.(NativeScriptWallet)
+	if !ok {
+		return a.nativescripts
+	}
+	script := scriptWallet.NativeScript()
+	hash := script.Hash()
+	if a.hasScriptHash(hash.String()) {
+		return a.nativescripts
+	}
+	for _, utxo := range inputs {
+		if utxo.Output == nil {
+			continue
+		}
+		addr := utxo.Output.Address()
+		switch addr.Type() {
+		case common.AddressTypeScriptKey,
+			common.AddressTypeScriptScript,
+			common.AddressTypeScriptPointer,
+			common.AddressTypeScriptNone:
+			if addr.PaymentKeyHash() == hash {
+				return append(slices.Clone(a.nativescripts), script)
+			}
+		}
+	}
+	return a.nativescripts
+}
+
 func (a *Apollo) buildWitnessSet(inputs []common.Utxo) conway.ConwayTransactionWitnessSet {
 	ws := conway.ConwayTransactionWitnessSet{}
 
@@ -2931,8 +3118,8 @@ func (a *Apollo) buildWitnessSet(inputs []common.Utxo) conway.ConwayTransactionW
 	if len(a.v3scripts) > 0 {
 		ws.WsPlutusV3Scripts = cbor.NewSetType(a.v3scripts, true)
 	}
-	if len(a.nativescripts) > 0 {
-		ws.WsNativeScripts = cbor.NewSetType(a.nativescripts, true)
+	if nativeScripts := a.witnessNativeScripts(inputs); len(nativeScripts) > 0 {
+		ws.WsNativeScripts = cbor.NewSetType(nativeScripts, true)
 	}
 	if len(a.datums) > 0 {
 		ws.WsPlutusData = WitnessPlutusData(a.datums)
@@ -3242,6 +3429,10 @@ func (a *Apollo) isUsed(ref string) bool {
 			return true
 		}
 	}
+	// A UTxO referenced for its script cannot also be spent.
+	if a.isResolvedReferenceInput(ref) {
+		return true
+	}
 	for _, utxo := range a.collaterals {
 		// A collateral UTxO flagged for overlap is intentionally left available
 		// to coin selection so it can ALSO be picked as a regular spending input
@@ -3333,15 +3524,6 @@ func (a *Apollo) setCollateral() error {
 		}
 	}
 
-	candidates := a.utxos
-	if len(candidates) == 0 && a.wallet != nil {
-		loaded, err := backend.UtxosContext(a.requestContext, a.Context, a.wallet.Address())
-		if err != nil {
-			return fmt.Errorf("failed to load UTxOs for collateral selection: %w", err)
-		}
-		candidates = loaded
-	}
-
 	// collateralEligible reports whether a UTxO can back collateral: it must be
 	// vkey-locked (never a script address), hold a representable lovelace amount
 	// of at least minCollateral, and -- if it carries native assets -- leave a
@@ -3371,6 +3553,44 @@ func (a *Apollo) setCollateral() error {
 		return true
 	}
 
+	// A streamed pool is read on only until it holds a pure-lovelace
+	// candidate, and the wallet fallback is read the same way, so looking for
+	// collateral never loads a large address in full.
+	pureCandidate := func(utxo common.Utxo) bool {
+		return !a.isUsed(utxoRef(utxo)) && collateralEligible(utxo, true)
+	}
+	for a.utxoStream != nil && len(a.utxos) < streamedCollateralScan &&
+		!slices.ContainsFunc(a.utxos, pureCandidate) {
+		ok, err := a.readUtxo()
+		if err != nil {
+			return fmt.Errorf("failed to load UTxOs for collateral selection: %w", err)
+		}
+		if !ok {
+			break
+		}
+	}
+	candidates := a.utxos
+	if len(candidates) == 0 && a.wallet != nil {
+		if a.utxoStream != nil {
+			seq := backend.UtxosSeq(a.requestContext, a.Context, a.wallet.Address())
+			for utxo, err := range seq {
+				if err != nil {
+					return fmt.Errorf("failed to load UTxOs for collateral selection: %w", err)
+				}
+				candidates = append(candidates, utxo)
+				if len(candidates) >= streamedCollateralScan || pureCandidate(utxo) {
+					break
+				}
+			}
+		} else {
+			loaded, err := backend.UtxosContext(a.requestContext, a.Context, a.wallet.Address())
+			if err != nil {
+				return fmt.Errorf("failed to load UTxOs for collateral selection: %w", err)
+			}
+			candidates = loaded
+		}
+	}
+
 	// selectCollateral records the chosen UTxO as collateral, reserves it out of
 	// the coin-selection pool (markUsed), and sizes the preliminary total and
 	// return. The reservation is provisional: if coin selection cannot then meet
@@ -1,9 +1,11 @@
 package apollo
 
 import (
+	"bytes"
 	"context"
 	"encoding/hex"
 	"errors"
+	"path/filepath"
 	"strings"
 	"testing"
 
@@ -11,6 +13,7 @@ import (
 	"github.com/blinklabs-io/gouroboros/ledger/conway"
 
 	"github.com/Salvionied/apollo/v2/backend"
+	"github.com/Salvionied/apollo/v2/backend/replay"
 )
 
 type contextTrackingChain struct {
@@ -121,3 +124,45 @@ func TestApolloSubmitRejectsNilChainContext(t *testing.T) {
 }
 
 var _ backend.ContextChainContext = (*contextTrackingChain)(nil)
+
+func TestCompleteReplaysFromRecordedFixture(t *testing.T) {
+	cc := networkTestContext(t, 0)
+	walletAddr := syntheticAddress(t, common.AddressTypeKeyKey, 0)
+	payee := syntheticAddress(t, common.AddressTypeKeyNone, 0)
+	addTestUtxo(cc, walletAddr, 10_000_000, 0x01, 0)
+	addTestUtxo(cc, walletAddr, 3_000_000, 0x02, 1)
+
+	build := func(ctx backend.ChainContext) []byte {
+		t.Helper()
+		a, err := New(ctx).
+			SetWallet(NewExternalWallet(walletAddr)).
+			SetTtl(50000000).
+			PayToAddress(payee, 4_000_000).
+			Complete()
+		if err != nil {
+			t.Fatal(err)
+		}
+		txCbor, err := a.GetTxCbor()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return txCbor
+	}
+
+	recorder := replay.NewRecordingChainContext(cc)
+	want := build(recorder)
+	path := filepath.Join(t.TempDir(), "complete.json")
+	if err := recorder.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	offline, err := replay.LoadReplayChainContext(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := build(offline); !bytes.Equal(got, want) {
+		t.Fatalf("replayed transaction = %x, want %x", got, want)
+	}
+	if misses := offline.Misses(); len(misses) != 0 {
+		t.Fatalf("Misses() = %v", misses)
+	}
+}
@@ -30,6 +30,10 @@ const (
 	CapabilityEvaluateTxAdditionalUtxos
 	CapabilityUtxoByRef
 	CapabilityScriptCbor
+	// CapabilityDatumByHash indicates that the context implements
+	// DatumResolver. It is not part of AllCapabilities, which describes
+	// ChainContext's own methods.
+	CapabilityDatumByHash
 )
 
 // AllCapabilities is the set implied by the historic ChainContext contract.
@@ -64,7 +68,8 @@ type CapabilityReporter interface {
 
 // CapabilitiesOf returns the capabilities reported by ctx. Contexts that do
 // not implement CapabilityReporter are treated as supporting the historic
-// complete ChainContext contract.
+// complete ChainContext contract, plus CapabilityDatumByHash when they
+// implement DatumResolver or ContextDatumResolver.
 func CapabilitiesOf(ctx ChainContext) CapabilitySet {
 	if isNilInterface(ctx) {
 		return 0
@@ -72,7 +77,12 @@ func CapabilitiesOf(ctx ChainContext) CapabilitySet {
 	if reporter, ok := ctx.(CapabilityReporter); ok {
 		return reporter.Capabilities()
 	}
-	return CapabilitySet(AllCapabilities)
+	capabilities := CapabilitySet(AllCapabilities)
+	switch ctx.(type) {
+	case DatumResolver, ContextDatumResolver:
+		capabilities |= CapabilitySet(CapabilityDatumByHash)
+	}
+	return capabilities
 }
 
 // Supports reports whether ctx reports support for every requested capability.
@@ -104,6 +114,8 @@ func (c Capability) String() string {
 		return "UTxO reference queries"
 	case CapabilityScriptCbor:
 		return "script CBOR lookup"
+	case CapabilityDatumByHash:
+		return "datum lookup by hash"
 	default:
 		return fmt.Sprintf("unknown capability (%d)", c)
 	}
@@ -114,6 +126,10 @@ func (c Capability) String() string {
 // errors.As with UnsupportedError to inspect the operation.
 var ErrUnsupported = errors.New("backend operation unsupported")
 
+// ErrUtxoNotFound is returned by UtxoByRef when the output does not exist or
+// has been spent. It lets callers tell a definite answer from a failed query.
+var ErrUtxoNotFound = errors.New("utxo not found")
+
 // UnsupportedError identifies a backend operation that is unavailable.
 type UnsupportedError struct {
 	Backend    string
@@ -166,6 +182,15 @@ type ChainContext interface {
 	ScriptCbor(scriptHash common.Blake2b224) ([]byte, error)
 }
 
+// DatumResolver is an optional extension to ChainContext for backends that
+// can look up the preimage of a datum hash. Outputs locked with a datum hash
+// carry only the hash, so reading or spending them needs the datum from
+// somewhere else. It is separate from ChainContext so existing third-party
+// backends remain source compatible.
+type DatumResolver interface {
+	DatumByHash(datumHash common.Blake2b256) (*common.Datum, error)
+}
+
 // ValidateAdditionalUtxo verifies that a resolved UTxO has both pieces needed
 // by backend evaluation APIs. TransactionInput and TransactionOutput are
 // interfaces, so this also rejects typed nil pointers stored in either field.
@@ -179,6 +204,13 @@ func ValidateAdditionalUtxo(utxo common.Utxo) error {
 	return nil
 }
 
+// IsNilChainContext reports whether chainContext is nil or a typed nil
+// pointer, which would otherwise pass a wrapper's construction and panic on
+// first use.
+func IsNilChainContext(chainContext ChainContext) bool {
+	return isNilInterface(chainContext)
+}
+
 func isNilInterface(value any) bool {
 	if value == nil {
 		return true
@@ -8,10 +8,10 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"iter"
 	"math"
 	"math/big"
 	"net/http"
-	"sort"
 	"strconv"
 	"strings"
 	"sync"
@@ -52,7 +52,7 @@ type BlockFrostChainContext struct {
 // fallback unreachable, because callers gate on it and pass nil when it is
 // absent, which broke chained and indexing-lagged inputs outright.
 func (b *BlockFrostChainContext) Capabilities() backend.CapabilitySet {
-	return backend.CapabilitySet(backend.AllCapabilities)
+	return backend.CapabilitySet(backend.AllCapabilities | backend.CapabilityDatumByHash)
 }
 
 const (
@@ -114,7 +114,12 @@ func (b *BlockFrostChainContext) requestContext(
 		if len(snippet) > maxBlockfrostErrorSnippetSize {
 			snippet = snippet[:maxBlockfrostErrorSnippetSize]
 		}
-		return nil, fmt.Errorf("blockfrost API error %d: %s", resp.StatusCode, string(snippet))
+		return nil, &backend.StatusError{
+			Backend:    "blockfrost",
+			StatusCode: resp.StatusCode,
+			Message:    string(snippet),
+			RetryAfter: backend.ParseRetryAfter(resp.Header.Get("Retry-After"), time.Now()),
+		}
 	}
 	return data, nil
 }
@@ -126,17 +131,7 @@ func (b *BlockFrostChainContext) ProtocolParams() (backend.ProtocolParameters, e
 func (b *BlockFrostChainContext) ProtocolParamsContext(ctx context.Context) (backend.ProtocolParameters, error) {
 	b.mu.Lock()
 	if b.cachedParams != nil && time.Since(b.paramsCacheAt) < cacheExpiry {
-		pp := *b.cachedParams
-		// Deep copy CostModels to prevent callers from mutating the cache.
-		if pp.CostModels != nil {
-			cm := make(map[string][]int64, len(pp.CostModels))
-			for k, v := range pp.CostModels {
-				dup := make([]int64, len(v))
-				copy(dup, v)
-				cm[k] = dup
-			}
-			pp.CostModels = cm
-		}
+		pp := backendutil.CloneProtocolParams(*b.cachedParams)
 		b.mu.Unlock()
 		return pp, nil
 	}
@@ -157,17 +152,7 @@ func (b *BlockFrostChainContext) ProtocolParamsContext(ctx context.Context) (bac
 		return backend.ProtocolParameters{}, err
 	}
 
-	// Deep copy CostModels before storing to prevent callers from mutating the cache.
-	cached := pp
-	if cached.CostModels != nil {
-		cm := make(map[string][]int64, len(cached.CostModels))
-		for k, v := range cached.CostModels {
-			dup := make([]int64, len(v))
-			copy(dup, v)
-			cm[k] = dup
-		}
-		cached.CostModels = cm
-	}
+	cached := backendutil.CloneProtocolParams(pp)
 
 	b.mu.Lock()
 	b.cachedParams = &cached
@@ -283,35 +268,48 @@ func (b *BlockFrostChainContext) Utxos(address common.Address) ([]common.Utxo, e
 }
 
 func (b *BlockFrostChainContext) UtxosContext(ctx context.Context, address common.Address) ([]common.Utxo, error) {
-	const maxPages = 1000
-	var allUtxos []common.Utxo
-	resolver := newScriptRefResolver(ctx, b)
+	return backend.CollectUtxos(b.UtxosSeq(ctx, address))
+}
 
-	for page := 1; page <= maxPages+1; page++ {
-		path := fmt.Sprintf("/addresses/%s/utxos?page=%d", address.String(), page)
-		data, err := b.requestContext(ctx, "GET", path, nil, "")
-		if err != nil {
-			return nil, err
-		}
+// UtxosSeq streams an address's UTxOs a page at a time. Each page is fetched
+// and hydrated only once the previous one has been consumed.
+func (b *BlockFrostChainContext) UtxosSeq(ctx context.Context, address common.Address) iter.Seq2[common.Utxo, error] {
+	const maxPages = 1000
+	return func(yield func(common.Utxo, error) bool) {
+		resolver := newScriptRefResolver(ctx, b)
+		for page := 1; page <= maxPages+1; page++ {
+			path := fmt.Sprintf("/addresses/%s/utxos?page=%d", address.String(), page)
+			data, err := b.requestContext(ctx, "GET", path, nil, "")
+			if err != nil {
+				yield(common.Utxo{}, err)
+				return
+			}
 
-		var rawUtxos []bfAddressUTxO
-		if err := json.Unmarshal(data, &rawUtxos); err != nil {
-			return nil, err
-		}
-		if len(rawUtxos) == 0 {
-			return allUtxos, nil
-		}
-		if page > maxPages {
-			return nil, fmt.Errorf("UTxO pagination exceeded %d pages; results may be incomplete", maxPages)
-		}
+			var rawUtxos []bfAddressUTxO
+			if err := json.Unmarshal(data, &rawUtxos); err != nil {
+				yield(common.Utxo{}, err)
+				return
+			}
+			if len(rawUtxos) == 0 {
+				return
+			}
+			if page > maxPages {
+				yield(common.Utxo{}, fmt.Errorf("UTxO pagination exceeded %d pages; results may be incomplete", maxPages))
+				return
+			}
 
-		utxos, err := b.hydrateUtxoPage(rawUtxos, address, resolver.resolve)
-		if err != nil {
-			return nil, err
+			utxos, err := b.hydrateUtxoPage(rawUtxos, address, resolver.resolve)
+			if err != nil {
+				yield(common.Utxo{}, err)
+				return
+			}
+			for _, utxo := range utxos {
+				if !yield(utxo, nil) {
+					return
+				}
+			}
 		}
-		allUtxos = append(allUtxos, utxos...)
 	}
-	return allUtxos, nil
 }
 
 func (b *BlockFrostChainContext) SubmitTx(txCbor []byte) (common.Blake2b256, error) {
@@ -827,7 +825,7 @@ func (b *BlockFrostChainContext) UtxoByRefContext(
 			return &utxo, nil
 		}
 	}
-	return nil, errors.New("utxo not found")
+	return nil, backend.ErrUtxoNotFound
 }
 
 func (b *BlockFrostChainContext) ScriptCbor(scriptHash common.Blake2b224) ([]byte, error) {
@@ -857,6 +855,29 @@ func (b *BlockFrostChainContext) ScriptCborContext(
 	return scriptCbor, nil
 }
 
+func (b *BlockFrostChainContext) DatumByHash(datumHash common.Blake2b256) (*common.Datum, error) {
+	return b.DatumByHashContext(context.Background(), datumHash)
+}
+
+func (b *BlockFrostChainContext) DatumByHashContext(
+	ctx context.Context,
+	datumHash common.Blake2b256,
+) (*common.Datum, error) {
+	hashHex := hex.EncodeToString(datumHash.Bytes())
+	path := fmt.Sprintf("/scripts/datum/%s/cbor", hashHex)
+	data, err := b.requestContext(ctx, "GET", path, nil, "")
+	if err != nil {
+		return nil, err
+	}
+	var result struct {
+		Cbor string `json:"cbor"`
+	}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, err
+	}
+	return backendutil.DecodeDatumHex(result.Cbor)
+}
+
 // --- BlockFrost evaluate-with-utxos request types ---
 //
 // /utils/txs/evaluate/utxos accepts resolved additional UTxOs as [txIn, txOut]
@@ -1005,33 +1026,11 @@ func (p *bfProtocolParams) toProtocolParams() (backend.ProtocolParameters, error
 		}
 	}
 	if pp.CostModels == nil && len(p.CostModels) > 0 {
-		var arrayModels map[string][]int64
-		if err := json.Unmarshal(p.CostModels, &arrayModels); err == nil {
-			pp.CostModels = arrayModels
-		} else {
-			// Fall back to keyed format (map[string]map[string]int64).
-			var keyedModels map[string]map[string]int64
-			if err := json.Unmarshal(p.CostModels, &keyedModels); err != nil {
-				return pp, fmt.Errorf("failed to parse cost models: %w", err)
-			}
-			pp.CostModels = make(map[string][]int64, len(keyedModels))
-			for lang, costs := range keyedModels {
-				// Sort parameter names alphabetically. The Cardano ledger
-				// serializes cost models as a flat list of integers whose
-				// positions correspond to alphabetically-sorted parameter
-				// names (see IntersectMBO/cardano-ledger#2902).
-				sortedKeys := make([]string, 0, len(costs))
-				for k := range costs {
-					sortedKeys = append(sortedKeys, k)
-				}
-				sort.Strings(sortedKeys)
-				values := make([]int64, 0, len(costs))
-				for _, k := range sortedKeys {
-					values = append(values, costs[k])
-				}
-				pp.CostModels[lang] = values
-			}
+		models, err := backendutil.ParseCostModels(p.CostModels)
+		if err != nil {
+			return pp, err
 		}
+		pp.CostModels = models
 	}
 
 	return pp, nil
@@ -51,8 +51,8 @@ func TestRequestContextPropagatesCancellation(t *testing.T) {
 
 func TestBlockfrostCapabilities(t *testing.T) {
 	ctx := NewBlockFrostChainContext("http://localhost", 0, "")
-	if !backend.Supports(ctx, backend.CapabilityEvaluateTx|backend.CapabilityScriptCbor) {
-		t.Fatalf("Blockfrost capabilities = %b, want evaluation and script lookup", ctx.Capabilities())
+	if !backend.Supports(ctx, backend.CapabilityEvaluateTx|backend.CapabilityScriptCbor|backend.CapabilityDatumByHash) {
+		t.Fatalf("Blockfrost capabilities = %b, want evaluation, script and datum lookup", ctx.Capabilities())
 	}
 	// EvaluateTx honours additionalUtxos via the /evaluate/utxos fallback, and
 	// callers pass nil to backends that do not report the capability, so
@@ -127,6 +127,34 @@ func TestHydrateUtxoResolvesInlineDatumAndReferenceScript(t *testing.T) {
 	}
 }
 
+func TestDatumByHashKeepsProviderCbor(t *testing.T) {
+	// 0x9f182aff is an indefinite-length list holding 42. Re-encoding it
+	// would produce a different hash, so the provider's bytes must be kept.
+	datumCbor := []byte{0x9f, 0x18, 0x2a, 0xff}
+	datumHash := common.Blake2b256Hash(datumCbor)
+	hashHex := hex.EncodeToString(datumHash.Bytes())
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v0/scripts/datum/"+hashHex+"/cbor" {
+			http.NotFound(w, r)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]string{"cbor": hex.EncodeToString(datumCbor)})
+	}))
+	defer server.Close()
+
+	ctx := NewBlockFrostChainContext(server.URL, 0, "")
+	datum, err := backend.DatumByHashContext(context.Background(), ctx, datumHash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(datum.Cbor(), datumCbor) {
+		t.Fatalf("datum CBOR = %x, want %x", datum.Cbor(), datumCbor)
+	}
+	if _, err := ctx.DatumByHash(common.Blake2b256{0x01}); err == nil {
+		t.Fatal("expected an unknown datum hash to fail")
+	}
+}
+
 func TestUtxosHydratesDuplicateReferenceScriptsOnce(t *testing.T) {
 	addr := testAddress(t)
 	script := common.PlutusV2Script([]byte{0x01, 0x02})
@@ -178,6 +206,50 @@ func TestUtxosHydratesDuplicateReferenceScriptsOnce(t *testing.T) {
 	}
 }
 
+func TestUtxosSeqFetchesPagesOnDemand(t *testing.T) {
+	addr := testAddress(t)
+	var pages []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v0/addresses/"+addr.String()+"/utxos" {
+			http.NotFound(w, r)
+			return
+		}
+		page := r.URL.Query().Get("page")
+		pages = append(pages, page)
+		if page == "3" {
+			_ = json.NewEncoder(w).Encode([]bfAddressUTxO{})
+			return
+		}
+		_ = json.NewEncoder(w).Encode([]bfAddressUTxO{{
+			TxHash:      strings.Repeat(page, 64),
+			OutputIndex: 0,
+			Address:     addr.String(),
+			Amount:      []bfAddressAmount{{Unit: "lovelace", Quantity: "1000000"}},
+		}})
+	}))
+	defer server.Close()
+
+	ctx := NewBlockFrostChainContext(server.URL, 0, "")
+	for _, err := range ctx.UtxosSeq(t.Context(), addr) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		break
+	}
+	if strings.Join(pages, ",") != "1" {
+		t.Fatalf("pages requested after the first UTxO = %v, want [1]", pages)
+	}
+
+	pages = nil
+	utxos, err := backend.CollectUtxos(ctx.UtxosSeq(t.Context(), addr))
+	if err != nil || len(utxos) != 2 {
+		t.Fatalf("UtxosSeq() collected %d UTxOs, %v; want 2", len(utxos), err)
+	}
+	if strings.Join(pages, ",") != "1,2,3" {
+		t.Fatalf("pages requested = %v, want [1 2 3]", pages)
+	}
+}
+
 func TestUtxosHydratesReferenceScriptsConcurrentlyInResponseOrder(t *testing.T) {
 	addr := testAddress(t)
 	const txHash = "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"
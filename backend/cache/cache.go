@@ -1,35 +1,116 @@
 package cache
 
 import (
+	"bytes"
 	"context"
+	"fmt"
+	"iter"
+	"slices"
 	"sync"
 	"time"
 
+	"github.com/blinklabs-io/gouroboros/cbor"
 	"github.com/blinklabs-io/gouroboros/ledger/common"
+	"github.com/blinklabs-io/gouroboros/ledger/conway"
 
 	"github.com/Salvionied/apollo/v2/backend"
+	"github.com/Salvionied/apollo/v2/internal/backendutil"
 )
 
-// CachedChainContext wraps another ChainContext with time-based caching.
+const maxCachedAddresses = 256
+
+// Config describes what a CachedChainContext caches.
+type Config struct {
+	// ParamsTTL bounds how long protocol and genesis parameters are reused.
+	// Cached protocol parameters are also dropped when CurrentEpoch reports a
+	// new epoch. Zero disables parameter caching.
+	ParamsTTL time.Duration
+	// ScriptCacheSize bounds the script CBOR lookups kept, least recently
+	// used first out. Zero disables script caching.
+	ScriptCacheSize int
+	// UtxoCacheSize bounds the UtxoByRef results kept. A cached UTxO stays
+	// cached after it is spent, except by a transaction submitted through
+	// this context, so lookups no longer report ErrUtxoNotFound for outputs
+	// spent elsewhere. Only enable it when every spend goes through this
+	// context. Zero disables UTxO reference caching.
+	UtxoCacheSize int
+	// UtxosTTL bounds how long an address's UTxOs are reused. Submitting a
+	// transaction drops the addresses holding its inputs. Keep it short: a
+	// spend by another wallet is only seen once the entry expires. Zero
+	// disables address caching.
+	UtxosTTL time.Duration
+}
+
+// CachedChainContext wraps another ChainContext with caching. Concurrent
+// lookups of the same parameters, script, UTxO or address share one request
+// to the wrapped context.
 type CachedChainContext struct {
-	inner backend.ChainContext
-	ttl   time.Duration
+	inner     backend.ChainContext
+	ttl       time.Duration
+	utxosTTL  time.Duration
+	now       func() time.Time
+	params    flightGroup[struct{}, backend.ProtocolParameters]
+	genesis   flightGroup[struct{}, backend.GenesisParameters]
+	scriptsIn flightGroup[common.Blake2b224, []byte]
+	refsIn    flightGroup[utxoRef, *common.Utxo]
+	utxosIn   flightGroup[string, []common.Utxo]
 
 	mu             sync.Mutex
 	cachedParams   *backend.ProtocolParameters
 	cachedGenesis  *backend.GenesisParameters
 	paramsCacheAt  time.Time
 	genesisCacheAt time.Time
+	// epoch is the last epoch CurrentEpoch reported; paramsGen changes when
+	// a new epoch invalidates the parameters, so a lookup that started
+	// before does not store stale ones.
+	epoch      uint64
+	epochKnown bool
+	paramsGen  uint64
+	scripts    *lru[common.Blake2b224, []byte]
+	refs       *lru[utxoRef, common.Utxo]
+	addresses  *lru[string, addressUtxos]
+	// utxosGen changes whenever a submission invalidates UTxOs, for the same
+	// reason.
+	utxosGen uint64
+}
+
+type utxoRef struct {
+	txHash common.Blake2b256
+	index  uint32
+}
+
+type addressUtxos struct {
+	utxos    []common.Utxo
+	cachedAt time.Time
 }
 
-var _ backend.ContextChainContext = (*CachedChainContext)(nil)
+var (
+	_ backend.ContextChainContext  = (*CachedChainContext)(nil)
+	_ backend.ContextDatumResolver = (*CachedChainContext)(nil)
+	_ backend.UtxoStreamer         = (*CachedChainContext)(nil)
+)
 
-// NewCachedChainContext creates a new cached wrapper around the given ChainContext.
+// NewCachedChainContext creates a new cached wrapper around the given
+// ChainContext that caches protocol and genesis parameters for ttl.
 func NewCachedChainContext(inner backend.ChainContext, ttl time.Duration) *CachedChainContext {
-	return &CachedChainContext{
-		inner: inner,
-		ttl:   ttl,
+	return NewCachedChainContextWithConfig(inner, Config{ParamsTTL: ttl})
+}
+
+// NewCachedChainContextWithConfig creates a cached wrapper around the given
+// ChainContext as described by cfg.
+func NewCachedChainContextWithConfig(inner backend.ChainContext, cfg Config) *CachedChainContext {
+	c := &CachedChainContext{
+		inner:    inner,
+		ttl:      cfg.ParamsTTL,
+		utxosTTL: cfg.UtxosTTL,
+		now:      time.Now,
+		scripts:  newLRU[common.Blake2b224, []byte](cfg.ScriptCacheSize),
+		refs:     newLRU[utxoRef, common.Utxo](cfg.UtxoCacheSize),
 	}
+	if cfg.UtxosTTL > 0 {
+		c.addresses = newLRU[string, addressUtxos](maxCachedAddresses)
+	}
+	return c
 }
 
 // Capabilities preserves the feature set of the wrapped context.
@@ -43,46 +124,36 @@ func (c *CachedChainContext) ProtocolParams() (backend.ProtocolParameters, error
 
 func (c *CachedChainContext) ProtocolParamsContext(ctx context.Context) (backend.ProtocolParameters, error) {
 	c.mu.Lock()
-	if c.cachedParams != nil && time.Since(c.paramsCacheAt) < c.ttl {
-		pp := *c.cachedParams
-		// Deep copy CostModels to prevent callers from mutating the cache.
-		if pp.CostModels != nil {
-			cm := make(map[string][]int64, len(pp.CostModels))
-			for k, v := range pp.CostModels {
-				dup := make([]int64, len(v))
-				copy(dup, v)
-				cm[k] = dup
-			}
-			pp.CostModels = cm
-		}
+	if c.cachedParams != nil && c.now().Sub(c.paramsCacheAt) < c.ttl {
+		pp := backendutil.CloneProtocolParams(*c.cachedParams)
 		c.mu.Unlock()
 		return pp, nil
 	}
 	c.mu.Unlock()
 
-	pp, err := backend.ProtocolParamsContext(ctx, c.inner)
-	if err != nil {
-		return pp, err
-	}
+	pp, err := c.params.do(ctx, struct{}{}, func() (backend.ProtocolParameters, error) {
+		c.mu.Lock()
+		gen := c.paramsGen
+		c.mu.Unlock()
 
-	// Deep copy CostModels before storing to prevent callers from mutating the cache.
-	cached := pp
-	if cached.CostModels != nil {
-		cm := make(map[string][]int64, len(cached.CostModels))
-		for k, v := range cached.CostModels {
-			dup := make([]int64, len(v))
-			copy(dup, v)
-			cm[k] = dup
+		pp, err := backend.ProtocolParamsContext(ctx, c.inner)
+		if err != nil {
+			return pp, err
+		}
+		cached := backendutil.CloneProtocolParams(pp)
+		c.mu.Lock()
+		if c.paramsGen == gen {
+			c.cachedParams = &cached
+			c.paramsCacheAt = c.now()
 		}
-		cached.CostModels = cm
+		c.mu.Unlock()
+		return pp, nil
+	})
+	if err != nil {
+		return pp, err
 	}
-
-	c.mu.Lock()
-	c.cachedParams = &cached
-	c.paramsCacheAt = time.Now()
-	c.mu.Unlock()
-
-	return pp, nil
+	// Callers sharing one lookup each get their own copy.
+	return backendutil.CloneProtocolParams(pp), nil
 }
 
 func (c *CachedChainContext) GenesisParams() (backend.GenesisParameters, error) {
@@ -91,24 +162,24 @@ func (c *CachedChainContext) GenesisParams() (backend.GenesisParameters, error)
 
 func (c *CachedChainContext) GenesisParamsContext(ctx context.Context) (backend.GenesisParameters, error) {
 	c.mu.Lock()
-	if c.cachedGenesis != nil && time.Since(c.genesisCacheAt) < c.ttl {
+	if c.cachedGenesis != nil && c.now().Sub(c.genesisCacheAt) < c.ttl {
 		gp := *c.cachedGenesis
 		c.mu.Unlock()
 		return gp, nil
 	}
 	c.mu.Unlock()
 
-	gp, err := backend.GenesisParamsContext(ctx, c.inner)
-	if err != nil {
-		return gp, err
-	}
-
-	c.mu.Lock()
-	c.cachedGenesis = &gp
-	c.genesisCacheAt = time.Now()
-	c.mu.Unlock()
-
-	return gp, nil
+	return c.genesis.do(ctx, struct{}{}, func() (backend.GenesisParameters, error) {
+		gp, err := backend.GenesisParamsContext(ctx, c.inner)
+		if err != nil {
+			return gp, err
+		}
+		c.mu.Lock()
+		c.cachedGenesis = &gp
+		c.genesisCacheAt = c.now()
+		c.mu.Unlock()
+		return gp, nil
+	})
 }
 
 func (c *CachedChainContext) NetworkId() uint8 {
@@ -119,8 +190,22 @@ func (c *CachedChainContext) CurrentEpoch() (uint64, error) {
 	return c.CurrentEpochContext(context.Background())
 }
 
+// CurrentEpochContext is not cached, but a new epoch drops the cached
+// protocol parameters, which may change at the boundary.
 func (c *CachedChainContext) CurrentEpochContext(ctx context.Context) (uint64, error) {
-	return backend.CurrentEpochContext(ctx, c.inner)
+	epoch, err := backend.CurrentEpochContext(ctx, c.inner)
+	if err != nil {
+		return epoch, err
+	}
+	c.mu.Lock()
+	if c.epochKnown && epoch != c.epoch {
+		c.cachedParams = nil
+		c.paramsGen++
+	}
+	c.epoch = epoch
+	c.epochKnown = true
+	c.mu.Unlock()
+	return epoch, nil
 }
 
 func (c *CachedChainContext) MaxTxFee() (uint64, error) {
@@ -144,15 +229,110 @@ func (c *CachedChainContext) Utxos(address common.Address) ([]common.Utxo, error
 }
 
 func (c *CachedChainContext) UtxosContext(ctx context.Context, address common.Address) ([]common.Utxo, error) {
-	return backend.UtxosContext(ctx, c.inner, address)
+	if c.addresses == nil {
+		return backend.UtxosContext(ctx, c.inner, address)
+	}
+	key := address.String()
+	c.mu.Lock()
+	if entry, ok := c.addresses.get(key); ok && c.now().Sub(entry.cachedAt) < c.utxosTTL {
+		utxos := slices.Clone(entry.utxos)
+		c.mu.Unlock()
+		return utxos, nil
+	}
+	c.mu.Unlock()
+
+	utxos, err := c.utxosIn.do(ctx, key, func() ([]common.Utxo, error) {
+		c.mu.Lock()
+		gen := c.utxosGen
+		c.mu.Unlock()
+
+		utxos, err := backend.UtxosContext(ctx, c.inner, address)
+		if err != nil {
+			return nil, err
+		}
+		c.mu.Lock()
+		if c.utxosGen == gen {
+			c.addresses.add(key, addressUtxos{utxos: slices.Clone(utxos), cachedAt: c.now()})
+		}
+		c.mu.Unlock()
+		return utxos, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return slices.Clone(utxos), nil
+}
+
+// UtxosSeq serves a cached address when one is fresh and otherwise streams
+// from the wrapped context. A stream may stop early, so it is not cached.
+func (c *CachedChainContext) UtxosSeq(ctx context.Context, address common.Address) iter.Seq2[common.Utxo, error] {
+	if c.addresses != nil {
+		c.mu.Lock()
+		entry, ok := c.addresses.get(address.String())
+		c.mu.Unlock()
+		if ok && c.now().Sub(entry.cachedAt) < c.utxosTTL {
+			return func(yield func(common.Utxo, error) bool) {
+				for _, utxo := range entry.utxos {
+					if !yield(utxo, nil) {
+						return
+					}
+				}
+			}
+		}
+	}
+	return backend.UtxosSeq(ctx, c.inner, address)
 }
 
 func (c *CachedChainContext) SubmitTx(txCbor []byte) (common.Blake2b256, error) {
 	return c.SubmitTxContext(context.Background(), txCbor)
 }
 
+// SubmitTxContext drops the cached UTxOs the transaction spends, whether or
+// not the submission reports success, since a failed submission may still
+// have reached the mempool.
 func (c *CachedChainContext) SubmitTxContext(ctx context.Context, txCbor []byte) (common.Blake2b256, error) {
-	return backend.SubmitTxContext(ctx, c.inner, txCbor)
+	txId, err := backend.SubmitTxContext(ctx, c.inner, txCbor)
+	c.invalidateSpent(txCbor)
+	return txId, err
+}
+
+// invalidateSpent drops the resolved references a transaction spends and the
+// cached addresses holding them. A transaction that does not decode drops
+// every cached address.
+func (c *CachedChainContext) invalidateSpent(txCbor []byte) {
+	inputs, err := spentInputs(txCbor)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.utxosGen++
+	if err != nil {
+		if c.addresses != nil {
+			c.addresses = newLRU[string, addressUtxos](maxCachedAddresses)
+		}
+		return
+	}
+	for ref := range inputs {
+		c.refs.remove(ref)
+	}
+	c.addresses.removeIf(func(_ string, entry addressUtxos) bool {
+		return slices.ContainsFunc(entry.utxos, func(utxo common.Utxo) bool {
+			_, spent := inputs[utxoRef{txHash: utxo.Id.Id(), index: utxo.Id.Index()}]
+			return spent
+		})
+	})
+}
+
+// spentInputs decodes the inputs of a transaction.
+func spentInputs(txCbor []byte) (map[utxoRef]struct{}, error) {
+	var tx conway.ConwayTransaction
+	if _, err := cbor.Decode(txCbor, &tx); err != nil {
+		return nil, fmt.Errorf("failed to decode transaction: %w", err)
+	}
+	inputs := make(map[utxoRef]struct{})
+	for _, input := range tx.Body.Inputs() {
+		inputs[utxoRef{txHash: input.Id(), index: input.Index()}] = struct{}{}
+	}
+	return inputs, nil
 }
 
 func (c *CachedChainContext) EvaluateTx(txCbor []byte, additionalUtxos []common.Utxo) (map[common.RedeemerKey]common.ExUnits, error) {
@@ -171,21 +351,83 @@ func (c *CachedChainContext) UtxoByRef(txHash common.Blake2b256, index uint32) (
 	return c.UtxoByRefContext(context.Background(), txHash, index)
 }
 
+// UtxoByRefContext caches resolved references, which cannot change while
+// they exist. Lookups that fail, including ErrUtxoNotFound, are not cached.
 func (c *CachedChainContext) UtxoByRefContext(
 	ctx context.Context,
 	txHash common.Blake2b256,
 	index uint32,
 ) (*common.Utxo, error) {
-	return backend.UtxoByRefContext(ctx, c.inner, txHash, index)
+	ref := utxoRef{txHash: txHash, index: index}
+	c.mu.Lock()
+	if utxo, ok := c.refs.get(ref); ok {
+		c.mu.Unlock()
+		return &utxo, nil
+	}
+	c.mu.Unlock()
+
+	utxo, err := c.refsIn.do(ctx, ref, func() (*common.Utxo, error) {
+		c.mu.Lock()
+		gen := c.utxosGen
+		c.mu.Unlock()
+
+		utxo, err := backend.UtxoByRefContext(ctx, c.inner, txHash, index)
+		if err != nil || utxo == nil {
+			return utxo, err
+		}
+		c.mu.Lock()
+		if c.utxosGen == gen {
+			c.refs.add(ref, *utxo)
+		}
+		c.mu.Unlock()
+		return utxo, nil
+	})
+	if err != nil || utxo == nil {
+		return utxo, err
+	}
+	dup := *utxo
+	return &dup, nil
 }
 
 func (c *CachedChainContext) ScriptCbor(scriptHash common.Blake2b224) ([]byte, error) {
 	return c.ScriptCborContext(context.Background(), scriptHash)
 }
 
+// ScriptCborContext caches scripts by hash, which identifies their content.
 func (c *CachedChainContext) ScriptCborContext(
 	ctx context.Context,
 	scriptHash common.Blake2b224,
 ) ([]byte, error) {
-	return backend.ScriptCborContext(ctx, c.inner, scriptHash)
+	c.mu.Lock()
+	if script, ok := c.scripts.get(scriptHash); ok {
+		c.mu.Unlock()
+		return bytes.Clone(script), nil
+	}
+	c.mu.Unlock()
+
+	script, err := c.scriptsIn.do(ctx, scriptHash, func() ([]byte, error) {
+		script, err := backend.ScriptCborContext(ctx, c.inner, scriptHash)
+		if err != nil {
+			return nil, err
+		}
+		c.mu.Lock()
+		c.scripts.add(scriptHash, bytes.Clone(script))
+		c.mu.Unlock()
+		return script, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return bytes.Clone(script), nil
+}
+
+func (c *CachedChainContext) DatumByHash(datumHash common.Blake2b256) (*common.Datum, error) {
+	return c.DatumByHashContext(context.Background(), datumHash)
+}
+
+func (c *CachedChainContext) DatumByHashContext(
+	ctx context.Context,
+	datumHash common.Blake2b256,
+) (*common.Datum, error) {
+	return backend.DatumByHashContext(ctx, c.inner, datumHash)
 }
@@ -1,7 +1,15 @@
 package cache
 
 import (
+	"errors"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
+
+	"github.com/blinklabs-io/gouroboros/cbor"
+	"github.com/blinklabs-io/gouroboros/ledger/common"
+	"github.com/blinklabs-io/gouroboros/ledger/shelley"
 
 	"github.com/Salvionied/apollo/v2/backend"
 	"github.com/Salvionied/apollo/v2/backend/fixed"
@@ -16,3 +24,275 @@ func TestCapabilitiesMatchWrappedContext(t *testing.T) {
 		t.Fatal("cache reported unsupported wrapped capability")
 	}
 }
+
+// countingContext serves the fixed test context and counts the calls that
+// reach it.
+type countingContext struct {
+	*fixed.FixedChainContext
+	epoch      atomic.Uint64
+	paramsGate chan struct{}
+	calls      sync.Map
+}
+
+func newCounting() *countingContext {
+	return &countingContext{FixedChainContext: fixed.NewEmptyFixedChainContext()}
+}
+
+func (s *countingContext) count(name string) {
+	n, _ := s.calls.LoadOrStore(name, new(atomic.Int64))
+	n.(*atomic.Int64).Add(1)
+}
+
+func (s *countingContext) callsTo(name string) int64 {
+	n, ok := s.calls.Load(name)
+	if !ok {
+		return 0
+	}
+	return n.(*atomic.Int64).Load()
+}
+
+func (s *countingContext) ProtocolParams() (backend.ProtocolParameters, error) {
+	s.count("ProtocolParams")
+	if s.paramsGate != nil {
+		<-s.paramsGate
+	}
+	return s.FixedChainContext.ProtocolParams()
+}
+
+func (s *countingContext) CurrentEpoch() (uint64, error) {
+	s.count("CurrentEpoch")
+	return s.epoch.Load(), nil
+}
+
+func (s *countingContext) Utxos(address common.Address) ([]common.Utxo, error) {
+	s.count("Utxos")
+	return s.FixedChainContext.Utxos(address)
+}
+
+func (s *countingContext) SubmitTx(txCbor []byte) (common.Blake2b256, error) {
+	s.count("SubmitTx")
+	return common.Blake2b256Hash(txCbor), nil
+}
+
+func (s *countingContext) UtxoByRef(txHash common.Blake2b256, index uint32) (*common.Utxo, error) {
+	s.count("UtxoByRef")
+	return s.FixedChainContext.UtxoByRef(txHash, index)
+}
+
+func (s *countingContext) ScriptCbor(scriptHash common.Blake2b224) ([]byte, error) {
+	s.count("ScriptCbor")
+	return append([]byte{0x82}, scriptHash[:2]...), nil
+}
+
+func testAddress(t *testing.T, tag byte) common.Address {
+	t.Helper()
+	var raw [57]byte
+	raw[1] = tag
+	raw[29] = 0xBB
+	addr, err := common.NewAddressFromBytes(raw[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return addr
+}
+
+func testUtxo(txId byte, index uint32) common.Utxo {
+	return common.Utxo{Id: shelley.ShelleyTransactionInput{TxId: common.Blake2b256{txId}, OutputIndex: index}}
+}
+
+// spendingTx encodes a transaction that spends the given inputs.
+func spendingTx(t *testing.T, inputs ...common.Utxo) []byte {
+	t.Helper()
+	encoded := make([]any, 0, len(inputs))
+	for _, input := range inputs {
+		txId := input.Id.Id()
+		encoded = append(encoded, []any{txId[:], input.Id.Index()})
+	}
+	txCbor, err := cbor.Encode([]any{map[uint]any{0: encoded, 1: []any{}, 2: 170000}, map[uint]any{}, true, nil})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return txCbor
+}
+
+func TestScriptAndUtxoLookupsAreCached(t *testing.T) {
+	inner := newCounting()
+	spendable := testUtxo(1, 0)
+	inner.AddUtxoByRef(spendable)
+	c := NewCachedChainContextWithConfig(inner, Config{ScriptCacheSize: 8, UtxoCacheSize: 8})
+
+	script, err := c.ScriptCbor(common.Blake2b224{0xAB, 0xCD})
+	if err != nil {
+		t.Fatal(err)
+	}
+	script[0] = 0
+	script, err = c.ScriptCbor(common.Blake2b224{0xAB, 0xCD})
+	if err != nil || script[0] != 0x82 {
+		t.Fatalf("ScriptCbor() = %x, %v; the cache was mutated through a returned slice", script, err)
+	}
+	if n := inner.callsTo("ScriptCbor"); n != 1 {
+		t.Fatalf("ScriptCbor calls = %d, want 1", n)
+	}
+
+	for range 2 {
+		if _, err := c.UtxoByRef(spendable.Id.Id(), 0); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := inner.callsTo("UtxoByRef"); n != 1 {
+		t.Fatalf("UtxoByRef calls = %d, want 1", n)
+	}
+
+	// Misses are not cached: the output may be created later.
+	for range 2 {
+		if _, err := c.UtxoByRef(spendable.Id.Id(), 1); !errors.Is(err, backend.ErrUtxoNotFound) {
+			t.Fatalf("UtxoByRef() error = %v, want ErrUtxoNotFound", err)
+		}
+	}
+	if n := inner.callsTo("UtxoByRef"); n != 3 {
+		t.Fatalf("UtxoByRef calls = %d, want 3", n)
+	}
+}
+
+func TestScriptCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	inner := newCounting()
+	c := NewCachedChainContextWithConfig(inner, Config{ScriptCacheSize: 2})
+	for _, tag := range []byte{1, 2, 1, 3, 1, 2} {
+		if _, err := c.ScriptCbor(common.Blake2b224{tag}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// 1 and 2 miss, 1 hits, 3 evicts 2, 1 hits, 2 misses again.
+	if n := inner.callsTo("ScriptCbor"); n != 4 {
+		t.Fatalf("ScriptCbor calls = %d, want 4", n)
+	}
+}
+
+func TestConcurrentParamsLookupsShareOneRequest(t *testing.T) {
+	inner := newCounting()
+	inner.paramsGate = make(chan struct{})
+	c := NewCachedChainContext(inner, time.Hour)
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 8)
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			pp, err := c.ProtocolParams()
+			if err == nil && pp.MinFeeConstant != 155381 {
+				err = errors.New("unexpected protocol parameters")
+			}
+			errs <- err
+		}()
+	}
+	// Let every caller reach the in-flight lookup before it completes.
+	time.Sleep(50 * time.Millisecond)
+	close(inner.paramsGate)
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := inner.callsTo("ProtocolParams"); n != 1 {
+		t.Fatalf("ProtocolParams calls = %d, want 1", n)
+	}
+}
+
+func TestNewEpochInvalidatesProtocolParams(t *testing.T) {
+	inner := newCounting()
+	inner.epoch.Store(100)
+	c := NewCachedChainContext(inner, time.Hour)
+
+	steps := []func() error{
+		func() error { _, err := c.ProtocolParams(); return err },
+		func() error { _, err := c.CurrentEpoch(); return err },
+		func() error { _, err := c.ProtocolParams(); return err },
+	}
+	for _, step := range steps {
+		if err := step(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := inner.callsTo("ProtocolParams"); n != 1 {
+		t.Fatalf("ProtocolParams calls = %d within an epoch, want 1", n)
+	}
+
+	inner.epoch.Store(101)
+	for _, step := range steps[1:] {
+		if err := step(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := inner.callsTo("ProtocolParams"); n != 2 {
+		t.Fatalf("ProtocolParams calls = %d after an epoch change, want 2", n)
+	}
+}
+
+func TestSubmitTxInvalidatesSpentUtxos(t *testing.T) {
+	inner := newCounting()
+	sender, other := testAddress(t, 0xAA), testAddress(t, 0xCC)
+	spent, kept := testUtxo(1, 0), testUtxo(2, 0)
+	inner.AddUtxo(sender, spent)
+	inner.AddUtxo(other, kept)
+	c := NewCachedChainContextWithConfig(inner, Config{UtxoCacheSize: 8, UtxosTTL: time.Minute})
+	clock := time.Unix(1_700_000_000, 0)
+	c.now = func() time.Time { return clock }
+
+	lookups := func() {
+		t.Helper()
+		for _, addr := range []common.Address{sender, other} {
+			if _, err := c.Utxos(addr); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if _, err := c.UtxoByRef(spent.Id.Id(), 0); err != nil {
+			t.Fatal(err)
+		}
+	}
+	lookups()
+	lookups()
+	if u, r := inner.callsTo("Utxos"), inner.callsTo("UtxoByRef"); u != 2 || r != 1 {
+		t.Fatalf("Utxos calls = %d, UtxoByRef calls = %d, want 2 and 1", u, r)
+	}
+
+	if _, err := c.SubmitTx(spendingTx(t, spent)); err != nil {
+		t.Fatal(err)
+	}
+	lookups()
+	if u, r := inner.callsTo("Utxos"), inner.callsTo("UtxoByRef"); u != 3 || r != 2 {
+		t.Fatalf("after submit: Utxos calls = %d, UtxoByRef calls = %d, want 3 and 2", u, r)
+	}
+
+	clock = clock.Add(time.Minute)
+	lookups()
+	if n := inner.callsTo("Utxos"); n != 5 {
+		t.Fatalf("after expiry: Utxos calls = %d, want 5", n)
+	}
+}
+
+func TestUtxoAndScriptCachesAreOptIn(t *testing.T) {
+	inner := newCounting()
+	spendable := testUtxo(1, 0)
+	inner.AddUtxoByRef(spendable)
+	c := NewCachedChainContext(inner, time.Hour)
+	addr := testAddress(t, 0xAA)
+	for range 2 {
+		if _, err := c.Utxos(addr); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := c.UtxoByRef(spendable.Id.Id(), 0); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := c.ScriptCbor(common.Blake2b224{0xAB, 0xCD}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, method := range []string{"Utxos", "UtxoByRef", "ScriptCbor"} {
+		if n := inner.callsTo(method); n != 2 {
+			t.Fatalf("%s calls = %d, want 2", method, n)
+		}
+	}
+}
@@ -1,4 +1,6 @@
-// Package cache wraps any chain backend with time-based caching of the
-// responses that rarely change, notably protocol and genesis parameters, to
-// cut repeated provider requests during a build.
+// Package cache wraps any chain backend with caching of the responses that
+// rarely or never change: protocol and genesis parameters on a TTL that a new
+// epoch cuts short, script CBOR and resolved UTxO references in bounded LRU
+// caches, and optionally an address's UTxOs for a short TTL. Concurrent
+// lookups of the same key share one request to the wrapped backend.
 package cache
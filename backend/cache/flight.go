@@ -0,0 +1,61 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// flightGroup de-duplicates concurrent lookups of the same key: the first
+// caller runs the lookup and the others wait for its result.
+type flightGroup[K comparable, V any] struct {
+	mu    sync.Mutex
+	calls map[K]*flight[V]
+}
+
+type flight[V any] struct {
+	done  chan struct{}
+	value V
+	err   error
+}
+
+// do runs fn for key unless a call for key is already in flight, in which
+// case it waits for that call's result. A waiter stops waiting when its own
+// ctx ends, and runs the lookup itself when the call it waited on was ended by
+// the first caller's context rather than by the backend.
+func (g *flightGroup[K, V]) do(ctx context.Context, key K, fn func() (V, error)) (V, error) {
+	for {
+		g.mu.Lock()
+		if g.calls == nil {
+			g.calls = make(map[K]*flight[V])
+		}
+		if f, ok := g.calls[key]; ok {
+			g.mu.Unlock()
+			select {
+			case <-f.done:
+			case <-ctx.Done():
+				var zero V
+				return zero, ctx.Err()
+			}
+			if isContextError(f.err) && ctx.Err() == nil {
+				continue
+			}
+			return f.value, f.err
+		}
+		f := &flight[V]{done: make(chan struct{})}
+		g.calls[key] = f
+		g.mu.Unlock()
+
+		f.value, f.err = fn()
+
+		g.mu.Lock()
+		delete(g.calls, key)
+		g.mu.Unlock()
+		close(f.done)
+		return f.value, f.err
+	}
+}
+
+func isContextError(err error) bool {
+	return errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
+}
@@ -0,0 +1,94 @@
+package cache
+
+import "container/list"
+
+// lru is a bounded map that evicts its least recently used entry. A nil lru
+// caches nothing. It is not safe for concurrent use; CachedChainContext
+// guards it with its mutex.
+type lru[K comparable, V any] struct {
+	size  int
+	order *list.List
+	items map[K]*list.Element
+}
+
+type lruEntry[K comparable, V any] struct {
+	key   K
+	value V
+}
+
+// newLRU returns an lru holding up to size entries, or nil when size is not
+// positive.
+func newLRU[K comparable, V any](size int) *lru[K, V] {
+	if size <= 0 {
+		return nil
+	}
+	return &lru[K, V]{
+		size:  size,
+		order: list.New(),
+		items: make(map[K]*list.Element, size),
+	}
+}
+
+func (l *lru[K, V]) get(key K) (V, bool) {
+	if l == nil {
+		var zero V
+		return zero, false
+	}
+	elem, ok := l.items[key]
+	if !ok {
+		var zero V
+		return zero, false
+	}
+	l.order.MoveToFront(elem)
+	return elem.Value.(*lruEntry[K, V]).value, true
+}
+
+func (l *lru[K, V]) add(key K, value V) {
+	if l == nil {
+		return
+	}
+	if elem, ok := l.items[key]; ok {
+		elem.Value.(*lruEntry[K, V]).value = value
+		l.order.MoveToFront(elem)
+		return
+	}
+	l.items[key] = l.order.PushFront(&lruEntry[K, V]{key: key, value: value})
+	if l.order.Len() > l.size {
+		oldest := l.order.Back()
+		l.order.Remove(oldest)
+		delete(l.items, oldest.Value.(*lruEntry[K, V]).key)
+	}
+}
+
+func (l *lru[K, V]) remove(key K) {
+	if l == nil {
+		return
+	}
+	if elem, ok := l.items[key]; ok {
+		l.order.Remove(elem)
+		delete(l.items, key)
+	}
+}
+
+// removeIf removes every entry for which match returns true.
+func (l *lru[K, V]) removeIf(match func(K, V) bool) {
+	if l == nil {
+		return
+	}
+	for elem := l.order.Front(); elem != nil; {
+		next := elem.Next()
+		entry := elem.Value.(*lruEntry[K, V])
+		if match(entry.key, entry.value) {
+			l.order.Remove(elem)
+			delete(l.items, entry.key)
+		}
+		elem = next
+	}
+}
+
+func (l *lru[K, V]) len() int {
+	if l == nil {
+		return 0
+	}
+	return l.order.Len()
+}
@@ -23,6 +23,16 @@ func TestCapabilitiesOfLegacyChainContextDefaultsToHistoricContract(t *testing.T
 	}
 }
 
+func TestCapabilitiesOfLegacyDatumResolverReportsDatumLookup(t *testing.T) {
+	want := CapabilitySet(AllCapabilities | CapabilityDatumByHash)
+	if got := CapabilitiesOf(legacyDatumChainContext{}); got != want {
+		t.Fatalf("CapabilitiesOf() = %b, want %b", got, want)
+	}
+	if got := CapabilityDatumByHash.String(); got != "datum lookup by hash" {
+		t.Fatalf("String() = %q", got)
+	}
+}
+
 func TestUnsupportedErrorIdentity(t *testing.T) {
 	err := NewUnsupportedError("test backend", CapabilityScriptCbor)
 	if !errors.Is(err, ErrUnsupported) {
@@ -60,3 +70,10 @@ func (legacyChainContext) UtxoByRef(common.Blake2b256, uint32) (*common.Utxo, er
 	return nil, nil
 }
 func (legacyChainContext) ScriptCbor(common.Blake2b224) ([]byte, error) { return nil, nil }
+
+// legacyDatumChainContext is a legacy context that can also resolve datums.
+type legacyDatumChainContext struct{ legacyChainContext }
+
+func (legacyDatumChainContext) DatumByHash(common.Blake2b256) (*common.Datum, error) {
+	return nil, nil
+}
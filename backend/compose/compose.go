@@ -0,0 +1,306 @@
+package compose
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"iter"
+
+	"github.com/blinklabs-io/gouroboros/ledger/common"
+
+	"github.com/Salvionied/apollo/v2/backend"
+)
+
+const backendName = "composed backend"
+
+// routable lists the capabilities that correspond to a method and so can be
+// routed. CapabilityEvaluateTxAdditionalUtxos qualifies EvaluateTx and is
+// served by whichever context EvaluateTx is routed to.
+var routable = []backend.Capability{
+	backend.CapabilityProtocolParams,
+	backend.CapabilityGenesisParams,
+	backend.CapabilityCurrentEpoch,
+	backend.CapabilityMaxTxFee,
+	backend.CapabilityTip,
+	backend.CapabilityUtxos,
+	backend.CapabilitySubmitTx,
+	backend.CapabilityEvaluateTx,
+	backend.CapabilityUtxoByRef,
+	backend.CapabilityScriptCbor,
+	backend.CapabilityDatumByHash,
+}
+
+// Config describes how a ComposedChainContext routes its methods.
+type Config struct {
+	// Routes assigns a capability to the context that serves it. Each key
+	// must be a single routable capability, and the context must report it.
+	Routes map[backend.Capability]backend.ChainContext
+	// Default serves every capability without a route that it reports.
+	// Optional.
+	Default backend.ChainContext
+	// Required lists the capabilities that must have a provider.
+	// NewComposedChainContext fails when one of them has none.
+	Required backend.CapabilitySet
+}
+
+// ComposedChainContext implements backend.ChainContext by forwarding each
+// method to the context routed for its capability. Methods without a route
+// return an UnsupportedError.
+type ComposedChainContext struct {
+	routes       map[backend.Capability]backend.ChainContext
+	capabilities backend.CapabilitySet
+	networkId    uint8
+}
+
+var (
+	_ backend.ContextChainContext  = (*ComposedChainContext)(nil)
+	_ backend.ContextDatumResolver = (*ComposedChainContext)(nil)
+	_ backend.UtxoStreamer         = (*ComposedChainContext)(nil)
+)
+
+// NewComposedChainContext builds a composed context from cfg. It fails when a
+// route is invalid, when a required capability has no provider, or when the
+// routed contexts disagree on the network.
+func NewComposedChainContext(cfg Config) (*ComposedChainContext, error) {
+	routes := make(map[backend.Capability]backend.ChainContext, len(routable))
+	for capability, chainContext := range cfg.Routes {
+		if !isRoutable(capability) {
+			return nil, fmt.Errorf("cannot route %s: route a single method capability", capability)
+		}
+		if backend.IsNilChainContext(chainContext) {
+			return nil, fmt.Errorf("route for %s has a nil chain context", capability)
+		}
+		if !backend.Supports(chainContext, capability) {
+			return nil, fmt.Errorf("route for %s goes to a chain context that does not support it", capability)
+		}
+		routes[capability] = chainContext
+	}
+	if !backend.IsNilChainContext(cfg.Default) {
+		for _, capability := range routable {
+			if _, ok := routes[capability]; !ok && backend.Supports(cfg.Default, capability) {
+				routes[capability] = cfg.Default
+			}
+		}
+	}
+	if len(routes) == 0 {
+		return nil, errors.New("no routes: configure Routes or a Default chain context")
+	}
+
+	var capabilities backend.CapabilitySet
+	for capability := range routes {
+		capabilities |= backend.CapabilitySet(capability)
+	}
+	if evaluator, ok := routes[backend.CapabilityEvaluateTx]; ok &&
+		backend.Supports(evaluator, backend.CapabilityEvaluateTxAdditionalUtxos) {
+		capabilities |= backend.CapabilitySet(backend.CapabilityEvaluateTxAdditionalUtxos)
+	}
+	if missing := cfg.Required &^ capabilities; missing != 0 {
+		// Report the lowest missing capability.
+		return nil, fmt.Errorf("no chain context provides required %s", backend.Capability(missing&-missing))
+	}
+
+	var networkId uint8
+	first := true
+	for _, capability := range routable {
+		chainContext, ok := routes[capability]
+		if !ok {
+			continue
+		}
+		id := chainContext.NetworkId()
+		if !first && id != networkId {
+			return nil, fmt.Errorf("route for %s is on network %d, other routes are on network %d", capability, id, networkId)
+		}
+		networkId, first = id, false
+	}
+
+	return &ComposedChainContext{
+		routes:       routes,
+		capabilities: capabilities,
+		networkId:    networkId,
+	}, nil
+}
+
+func isRoutable(capability backend.Capability) bool {
+	for _, c := range routable {
+		if c == capability {
+			return true
+		}
+	}
+	return false
+}
+
+// Capabilities reports the union of the routed capabilities.
+func (c *ComposedChainContext) Capabilities() backend.CapabilitySet {
+	return c.capabilities
+}
+
+// route returns the context routed for capability, or an UnsupportedError
+// when there is none.
+func (c *ComposedChainContext) route(ctx context.Context, capability backend.Capability) (backend.ChainContext, error) {
+	if chainContext, ok := c.routes[capability]; ok {
+		return chainContext, nil
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return nil, backend.NewUnsupportedError(backendName, capability)
+}
+
+func (c *ComposedChainContext) ProtocolParams() (backend.ProtocolParameters, error) {
+	return c.ProtocolParamsContext(context.Background())
+}
+
+func (c *ComposedChainContext) ProtocolParamsContext(ctx context.Context) (backend.ProtocolParameters, error) {
+	chainContext, err := c.route(ctx, backend.CapabilityProtocolParams)
+	if err != nil {
+		return backend.ProtocolParameters{}, err
+	}
+	return backend.ProtocolParamsContext(ctx, chainContext)
+}
+
+func (c *ComposedChainContext) GenesisParams() (backend.GenesisParameters, error) {
+	return c.GenesisParamsContext(context.Background())
+}
+
+func (c *ComposedChainContext) GenesisParamsContext(ctx context.Context) (backend.GenesisParameters, error) {
+	chainContext, err := c.route(ctx, backend.CapabilityGenesisParams)
+	if err != nil {
+		return backend.GenesisParameters{}, err
+	}
+	return backend.GenesisParamsContext(ctx, chainContext)
+}
+
+func (c *ComposedChainContext) NetworkId() uint8 {
+	return c.networkId
+}
+
+func (c *ComposedChainContext) CurrentEpoch() (uint64, error) {
+	return c.CurrentEpochContext(context.Background())
+}
+
+func (c *ComposedChainContext) CurrentEpochContext(ctx context.Context) (uint64, error) {
+	chainContext, err := c.route(ctx, backend.CapabilityCurrentEpoch)
+	if err != nil {
+		return 0, err
+	}
+	return backend.CurrentEpochContext(ctx, chainContext)
+}
+
+func (c *ComposedChainContext) MaxTxFee() (uint64, error) {
+	return c.MaxTxFeeContext(context.Background())
+}
+
+func (c *ComposedChainContext) MaxTxFeeContext(ctx context.Context) (uint64, error) {
+	chainContext, err := c.route(ctx, backend.CapabilityMaxTxFee)
+	if err != nil {
+		return 0, err
+	}
+	return backend.MaxTxFeeContext(ctx, chainContext)
+}
+
+func (c *ComposedChainContext) Tip() (uint64, error) {
+	return c.TipContext(context.Background())
+}
+
+func (c *ComposedChainContext) TipContext(ctx context.Context) (uint64, error) {
+	chainContext, err := c.route(ctx, backend.CapabilityTip)
+	if err != nil {
+		return 0, err
+	}
+	return backend.TipContext(ctx, chainContext)
+}
+
+func (c *ComposedChainContext) Utxos(address common.Address) ([]common.Utxo, error) {
+	return c.UtxosContext(context.Background(), address)
+}
+
+func (c *ComposedChainContext) UtxosContext(ctx context.Context, address common.Address) ([]common.Utxo, error) {
+	chainContext, err := c.route(ctx, backend.CapabilityUtxos)
+	if err != nil {
+		return nil, err
+	}
+	return backend.UtxosContext(ctx, chainContext, address)
+}
+
+// UtxosSeq streams from the context routed for CapabilityUtxos.
+func (c *ComposedChainContext) UtxosSeq(ctx context.Context, address common.Address) iter.Seq2[common.Utxo, error] {
+	chainContext, err := c.route(ctx, backend.CapabilityUtxos)
+	if err != nil {
+		return func(yield func(common.Utxo, error) bool) { yield(common.Utxo{}, err) }
+	}
+	return backend.UtxosSeq(ctx, chainContext, address)
+}
+
+func (c *ComposedChainContext) SubmitTx(txCbor []byte) (common.Blake2b256, error) {
+	return c.SubmitTxContext(context.Background(), txCbor)
+}
+
+func (c *ComposedChainContext) SubmitTxContext(ctx context.Context, txCbor []byte) (common.Blake2b256, error) {
+	chainContext, err := c.route(ctx, backend.CapabilitySubmitTx)
+	if err != nil {
+		return common.Blake2b256{}, err
+	}
+	return backend.SubmitTxContext(ctx, chainContext, txCbor)
+}
+
+func (c *ComposedChainContext) EvaluateTx(txCbor []byte, additionalUtxos []common.Utxo) (map[common.RedeemerKey]common.ExUnits, error) {
+	return c.EvaluateTxContext(context.Background(), txCbor, additionalUtxos)
+}
+
+func (c *ComposedChainContext) EvaluateTxContext(
+	ctx context.Context,
+	txCbor []byte,
+	additionalUtxos []common.Utxo,
+) (map[common.RedeemerKey]common.ExUnits, error) {
+	chainContext, err := c.route(ctx, backend.CapabilityEvaluateTx)
+	if err != nil {
+		return nil, err
+	}
+	return backend.EvaluateTxContext(ctx, chainContext, txCbor, additionalUtxos)
+}
+
+func (c *ComposedChainContext) UtxoByRef(txHash common.Blake2b256, index uint32) (*common.Utxo, error) {
+	return c.UtxoByRefContext(context.Background(), txHash, index)
+}
+
+func (c *ComposedChainContext) UtxoByRefContext(
+	ctx context.Context,
+	txHash common.Blake2b256,
+	index uint32,
+) (*common.Utxo, error) {
+	chainContext, err := c.route(ctx, backend.CapabilityUtxoByRef)
+	if err != nil {
+		return nil, err
+	}
+	return backend.UtxoByRefContext(ctx, chainContext, txHash, index)
+}
+
+func (c *ComposedChainContext) ScriptCbor(scriptHash common.Blake2b224) ([]byte, error) {
+	return c.ScriptCborContext(context.Background(), scriptHash)
+}
+
+func (c *ComposedChainContext) ScriptCborContext(
+	ctx context.Context,
+	scriptHash common.Blake2b224,
+) ([]byte, error) {
+	chainContext, err := c.route(ctx, backend.CapabilityScriptCbor)
+	if err != nil {
+		return nil, err
+	}
+	return backend.ScriptCborContext(ctx, chainContext, scriptHash)
+}
+
+func (c *ComposedChainContext) DatumByHash(datumHash common.Blake2b256) (*common.Datum, error) {
+	return c.DatumByHashContext(context.Background(), datumHash)
+}
+
+func (c *ComposedChainContext) DatumByHashContext(
+	ctx context.Context,
+	datumHash common.Blake2b256,
+) (*common.Datum, error) {
+	chainContext, err := c.route(ctx, backend.CapabilityDatumByHash)
+	if err != nil {
+		return nil, err
+	}
+	return backend.DatumByHashContext(ctx, chainContext, datumHash)
+}
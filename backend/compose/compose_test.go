@@ -0,0 +1,317 @@
+package compose
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/blinklabs-io/gouroboros/ledger/common"
+
+	"github.com/Salvionied/apollo/v2/backend"
+	"github.com/Salvionied/apollo/v2/backend/fixed"
+)
+
+type ctxKey struct{}
+
+// stubContext reports a fixed capability set and records which of its
+// context-aware methods were called, and with which context value.
+type stubContext struct {
+	backend.ChainContext
+	name         string
+	capabilities backend.CapabilitySet
+	networkId    uint8
+	calls        []string
+	ctxValues    []any
+}
+
+func newStub(name string, capabilities backend.Capability) *stubContext {
+	return &stubContext{
+		ChainContext: fixed.NewEmptyFixedChainContext(),
+		name:         name,
+		capabilities: backend.CapabilitySet(capabilities),
+	}
+}
+
+func (s *stubContext) record(ctx context.Context, call string) {
+	s.calls = append(s.calls, call)
+	s.ctxValues = append(s.ctxValues, ctx.Value(ctxKey{}))
+}
+
+func (s *stubContext) Capabilities() backend.CapabilitySet { return s.capabilities }
+func (s *stubContext) NetworkId() uint8                    { return s.networkId }
+
+func (s *stubContext) ProtocolParamsContext(ctx context.Context) (backend.ProtocolParameters, error) {
+	s.record(ctx, "ProtocolParams")
+	return backend.ProtocolParameters{MinFeeConstant: 1}, nil
+}
+
+func (s *stubContext) GenesisParamsContext(ctx context.Context) (backend.GenesisParameters, error) {
+	s.record(ctx, "GenesisParams")
+	return backend.GenesisParameters{}, nil
+}
+
+func (s *stubContext) CurrentEpochContext(ctx context.Context) (uint64, error) {
+	s.record(ctx, "CurrentEpoch")
+	return 1, nil
+}
+
+func (s *stubContext) MaxTxFeeContext(ctx context.Context) (uint64, error) {
+	s.record(ctx, "MaxTxFee")
+	return 1, nil
+}
+
+func (s *stubContext) TipContext(ctx context.Context) (uint64, error) {
+	s.record(ctx, "Tip")
+	return 1, nil
+}
+
+func (s *stubContext) UtxosContext(ctx context.Context, _ common.Address) ([]common.Utxo, error) {
+	s.record(ctx, "Utxos")
+	return nil, nil
+}
+
+func (s *stubContext) SubmitTxContext(ctx context.Context, _ []byte) (common.Blake2b256, error) {
+	s.record(ctx, "SubmitTx")
+	return common.Blake2b256{1}, nil
+}
+
+func (s *stubContext) EvaluateTxContext(
+	ctx context.Context,
+	_ []byte,
+	_ []common.Utxo,
+) (map[common.RedeemerKey]common.ExUnits, error) {
+	s.record(ctx, "EvaluateTx")
+	return nil, nil
+}
+
+func (s *stubContext) UtxoByRefContext(ctx context.Context, _ common.Blake2b256, _ uint32) (*common.Utxo, error) {
+	s.record(ctx, "UtxoByRef")
+	return nil, backend.ErrUtxoNotFound
+}
+
+func (s *stubContext) ScriptCborContext(ctx context.Context, _ common.Blake2b224) ([]byte, error) {
+	s.record(ctx, "ScriptCbor")
+	return []byte{0x01}, nil
+}
+
+func TestRoutesForwardWithContext(t *testing.T) {
+	utxos := newStub("kupo", backend.CapabilityUtxos|backend.CapabilityUtxoByRef|backend.CapabilityScriptCbor)
+	evaluator := newStub("blockfrost", backend.AllCapabilities)
+	node := newStub("node", backend.CapabilitySubmitTx|backend.CapabilityProtocolParams|backend.CapabilityTip)
+	composed, err := NewComposedChainContext(Config{
+		Routes: map[backend.Capability]backend.ChainContext{
+			backend.CapabilityUtxos:          utxos,
+			backend.CapabilityUtxoByRef:      utxos,
+			backend.CapabilityEvaluateTx:     evaluator,
+			backend.CapabilitySubmitTx:       node,
+			backend.CapabilityProtocolParams: node,
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.WithValue(context.Background(), ctxKey{}, "request")
+	if _, err := composed.UtxosContext(ctx, common.Address{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := composed.UtxoByRefContext(ctx, common.Blake2b256{}, 0); err == nil {
+		t.Fatal("UtxoByRefContext() did not return the routed context's error")
+	}
+	if _, err := composed.EvaluateTxContext(ctx, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := composed.SubmitTxContext(ctx, nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := composed.ProtocolParams(); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		stub  *stubContext
+		calls string
+	}{
+		{utxos, "Utxos,UtxoByRef"},
+		{evaluator, "EvaluateTx"},
+		{node, "SubmitTx,ProtocolParams"},
+	} {
+		if got := strings.Join(tc.stub.calls, ","); got != tc.calls {
+			t.Errorf("%s calls = %s, want %s", tc.stub.name, got, tc.calls)
+		}
+	}
+	if node.ctxValues[0] != "request" || evaluator.ctxValues[0] != "request" {
+		t.Fatal("caller context was not forwarded")
+	}
+	if node.ctxValues[1] != nil {
+		t.Fatal("ProtocolParams() did not use a background context")
+	}
+
+	// Tip is reported by node but was not routed.
+	if _, err := composed.Tip(); !errors.Is(err, backend.ErrUnsupported) {
+		t.Fatalf("Tip() error = %v, want ErrUnsupported", err)
+	}
+	var unsupported *backend.UnsupportedError
+	if _, err := composed.ScriptCbor(common.Blake2b224{}); !errors.As(err, &unsupported) ||
+		unsupported.Capability != backend.CapabilityScriptCbor {
+		t.Fatalf("ScriptCbor() error = %v", err)
+	}
+}
+
+func TestCapabilitiesAreTheUnionOfRoutes(t *testing.T) {
+	evaluator := newStub("evaluator", backend.CapabilityEvaluateTx|backend.CapabilityEvaluateTxAdditionalUtxos)
+	submitter := newStub("submitter", backend.CapabilitySubmitTx)
+	composed, err := NewComposedChainContext(Config{
+		Routes: map[backend.Capability]backend.ChainContext{
+			backend.CapabilityEvaluateTx: evaluator,
+			backend.CapabilitySubmitTx:   submitter,
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := backend.CapabilitySet(backend.CapabilityEvaluateTx |
+		backend.CapabilityEvaluateTxAdditionalUtxos | backend.CapabilitySubmitTx)
+	if got := backend.CapabilitiesOf(composed); got != want {
+		t.Fatalf("Capabilities() = %b, want %b", got, want)
+	}
+}
+
+func TestDefaultServesUnroutedCapabilities(t *testing.T) {
+	submitter := newStub("submitter", backend.CapabilitySubmitTx)
+	defaults := fixed.NewEmptyFixedChainContext()
+	datum := common.Datum{}
+	if err := datum.UnmarshalCBOR([]byte{0xd8, 0x79, 0x80}); err != nil {
+		t.Fatal(err)
+	}
+	datumHash, err := defaults.AddDatum(datum)
+	if err != nil {
+		t.Fatal(err)
+	}
+	composed, err := NewComposedChainContext(Config{
+		Routes: map[backend.Capability]backend.ChainContext{
+			backend.CapabilitySubmitTx: submitter,
+		},
+		Default:  defaults,
+		Required: backend.CapabilitySet(backend.CapabilitySubmitTx | backend.CapabilityProtocolParams),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := composed.SubmitTx(nil); err != nil || len(submitter.calls) != 1 {
+		t.Fatalf("SubmitTx() = %v, submitter calls %v", err, submitter.calls)
+	}
+	pp, err := composed.ProtocolParams()
+	if err != nil || pp.MinFeeConstant != 155381 {
+		t.Fatalf("ProtocolParams() = %d, %v", pp.MinFeeConstant, err)
+	}
+	if _, err := backend.DatumByHashContext(context.Background(), composed, datumHash); err != nil {
+		t.Fatalf("DatumByHash() error = %v", err)
+	}
+	// The fixed context reports no evaluation, so the default does not serve it.
+	if backend.Supports(composed, backend.CapabilityEvaluateTx) {
+		t.Fatal("composed context reported evaluation its default does not support")
+	}
+	if _, err := composed.EvaluateTx(nil, nil); !errors.Is(err, backend.ErrUnsupported) {
+		t.Fatalf("EvaluateTx() error = %v, want ErrUnsupported", err)
+	}
+}
+
+func TestNewComposedChainContextRejectsInvalidConfig(t *testing.T) {
+	var nilStub *stubContext
+	mainnet := newStub("mainnet", backend.AllCapabilities)
+	mainnet.networkId = 1
+	for _, tc := range []struct {
+		name string
+		cfg  Config
+		want string
+	}{
+		{
+			name: "empty",
+			cfg:  Config{},
+			want: "no routes",
+		},
+		{
+			name: "missing required capability",
+			cfg: Config{
+				Routes:   map[backend.Capability]backend.ChainContext{backend.CapabilityUtxos: newStub("kupo", backend.CapabilityUtxos)},
+				Required: backend.CapabilitySet(backend.CapabilityUtxos | backend.CapabilitySubmitTx),
+			},
+			want: "required transaction submission",
+		},
+		{
+			name: "missing additional UTxO evaluation",
+			cfg: Config{
+				Routes:   map[backend.Capability]backend.ChainContext{backend.CapabilityEvaluateTx: newStub("ogmios", backend.CapabilityEvaluateTx)},
+				Required: backend.CapabilitySet(backend.CapabilityEvaluateTxAdditionalUtxos),
+			},
+			want: "required transaction evaluation with additional UTxOs",
+		},
+		{
+			name: "route to unsupported capability",
+			cfg: Config{
+				Routes: map[backend.Capability]backend.ChainContext{backend.CapabilitySubmitTx: newStub("kupo", backend.CapabilityUtxos)},
+			},
+			want: "does not support it",
+		},
+		{
+			name: "combined capability key",
+			cfg: Config{
+				Routes: map[backend.Capability]backend.ChainContext{
+					backend.CapabilityUtxos | backend.CapabilityUtxoByRef: newStub("kupo", backend.AllCapabilities),
+				},
+			},
+			want: "cannot route",
+		},
+		{
+			name: "qualifier key",
+			cfg: Config{
+				Routes: map[backend.Capability]backend.ChainContext{
+					backend.CapabilityEvaluateTxAdditionalUtxos: newStub("ogmios", backend.AllCapabilities),
+				},
+			},
+			want: "cannot route",
+		},
+		{
+			name: "typed nil route",
+			cfg: Config{
+				Routes: map[backend.Capability]backend.ChainContext{backend.CapabilityTip: nilStub},
+			},
+			want: "nil chain context",
+		},
+		{
+			name: "network mismatch",
+			cfg: Config{
+				Routes: map[backend.Capability]backend.ChainContext{
+					backend.CapabilityTip:      newStub("testnet", backend.AllCapabilities),
+					backend.CapabilitySubmitTx: mainnet,
+				},
+			},
+			want: "network",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := NewComposedChainContext(tc.cfg)
+			if err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Fatalf("NewComposedChainContext() error = %v, want %q", err, tc.want)
+			}
+		})
+	}
+}
+
+func TestUnroutedCallRespectsCancellation(t *testing.T) {
+	composed, err := NewComposedChainContext(Config{
+		Routes: map[backend.Capability]backend.ChainContext{
+			backend.CapabilitySubmitTx: newStub("node", backend.CapabilitySubmitTx),
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := composed.TipContext(ctx); !errors.Is(err, context.Canceled) {
+		t.Fatalf("TipContext() error = %v, want context.Canceled", err)
+	}
+}
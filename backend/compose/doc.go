@@ -0,0 +1,4 @@
+// Package compose combines several chain backends into one by routing each
+// capability to the backend that should serve it, for example UTxO queries to
+// Kupo, evaluation to Blockfrost and submission to a local node.
+package compose
@@ -3,6 +3,7 @@ package backend
 import (
 	"context"
 	"errors"
+	"fmt"
 
 	"github.com/blinklabs-io/gouroboros/ledger/common"
 )
@@ -26,6 +27,11 @@ type ContextChainContext interface {
 	ScriptCborContext(ctx context.Context, scriptHash common.Blake2b224) ([]byte, error)
 }
 
+// ContextDatumResolver is the context-aware form of DatumResolver.
+type ContextDatumResolver interface {
+	DatumByHashContext(ctx context.Context, datumHash common.Blake2b256) (*common.Datum, error)
+}
+
 func normalizeContext(ctx context.Context) context.Context {
 	if ctx == nil {
 		return context.Background()
@@ -167,6 +173,48 @@ func ScriptCborContext(
 	return chainContext.ScriptCbor(scriptHash)
 }
 
+// DatumByHashContext looks up the datum with the given hash through the
+// context-aware or historic DatumResolver method, whichever chainContext
+// implements, and checks that the datum returned hashes to datumHash. It
+// returns an UnsupportedError for CapabilityDatumByHash when chainContext
+// cannot look up datums.
+func DatumByHashContext(
+	ctx context.Context,
+	chainContext ChainContext,
+	datumHash common.Blake2b256,
+) (*common.Datum, error) {
+	if isNilInterface(chainContext) {
+		return nil, errNilChainContext
+	}
+	var datum *common.Datum
+	var err error
+	switch cc := chainContext.(type) {
+	case ContextDatumResolver:
+		datum, err = cc.DatumByHashContext(normalizeContext(ctx), datumHash)
+	case DatumResolver:
+		datum, err = cc.DatumByHash(datumHash)
+	default:
+		return nil, NewUnsupportedError("", CapabilityDatumByHash)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if datum == nil {
+		return nil, fmt.Errorf("datum %s not found", datumHash.String())
+	}
+	datumCbor := datum.Cbor()
+	if len(datumCbor) == 0 {
+		datumCbor, err = datum.MarshalCBOR()
+		if err != nil {
+			return nil, fmt.Errorf("encode datum %s: %w", datumHash.String(), err)
+		}
+	}
+	if computed := common.Blake2b256Hash(datumCbor); computed != datumHash {
+		return nil, fmt.Errorf("datum %s: fetched datum hashes to %s", datumHash.String(), computed.String())
+	}
+	return datum, nil
+}
+
 // BindContext returns a ChainContext whose blocking methods dispatch through
 // ctx. This is useful when passing a context through an API that still accepts
 // only the historic ChainContext interface.
@@ -228,3 +276,7 @@ func (b boundChainContext) UtxoByRef(txHash common.Blake2b256, index uint32) (*c
 func (b boundChainContext) ScriptCbor(scriptHash common.Blake2b224) ([]byte, error) {
 	return ScriptCborContext(b.Context, b.ChainContext, scriptHash)
 }
+
+func (b boundChainContext) DatumByHash(datumHash common.Blake2b256) (*common.Datum, error) {
+	return DatumByHashContext(b.Context, b.ChainContext, datumHash)
+}
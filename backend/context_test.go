@@ -3,9 +3,11 @@ package backend
 import (
 	"context"
 	"errors"
+	"math/big"
 	"testing"
 
 	"github.com/blinklabs-io/gouroboros/ledger/common"
+	"github.com/blinklabs-io/plutigo/data"
 )
 
 type trackingLegacyContext struct {
@@ -141,4 +143,52 @@ func TestBindContextDispatchesThroughHistoricInterface(t *testing.T) {
 	}
 }
 
+type datumContext struct {
+	legacyChainContext
+	datum    *common.Datum
+	received context.Context
+}
+
+func (c *datumContext) DatumByHashContext(ctx context.Context, _ common.Blake2b256) (*common.Datum, error) {
+	c.received = ctx
+	return c.datum, nil
+}
+
+func TestDatumByHashContextChecksHash(t *testing.T) {
+	datum := &common.Datum{Data: data.NewInteger(big.NewInt(42))}
+	datumCbor, err := datum.MarshalCBOR()
+	if err != nil {
+		t.Fatal(err)
+	}
+	hash := common.Blake2b256Hash(datumCbor)
+	chainContext := &datumContext{datum: datum}
+
+	got, err := DatumByHashContext(context.Background(), chainContext, hash)
+	if err != nil {
+		t.Fatalf("DatumByHashContext: %v", err)
+	}
+	if got != datum || chainContext.received == nil {
+		t.Fatalf("DatumByHashContext = %v, received context %v", got, chainContext.received)
+	}
+
+	if _, err := DatumByHashContext(context.Background(), chainContext, common.Blake2b256{0x01}); err == nil {
+		t.Fatal("DatumByHashContext accepted a datum with the wrong hash")
+	}
+	chainContext.datum = nil
+	if _, err := DatumByHashContext(context.Background(), chainContext, hash); err == nil {
+		t.Fatal("DatumByHashContext accepted a nil datum")
+	}
+}
+
+func TestDatumByHashContextUnsupported(t *testing.T) {
+	_, err := DatumByHashContext(context.Background(), legacyChainContext{}, common.Blake2b256{})
+	if !errors.Is(err, ErrUnsupported) {
+		t.Fatalf("DatumByHashContext error = %v, want ErrUnsupported", err)
+	}
+	_, err = BindContext(context.Background(), legacyChainContext{}).(DatumResolver).DatumByHash(common.Blake2b256{})
+	if !errors.Is(err, ErrUnsupported) {
+		t.Fatalf("bound DatumByHash error = %v, want ErrUnsupported", err)
+	}
+}
+
 var _ ContextChainContext = (*trackingContextChain)(nil)
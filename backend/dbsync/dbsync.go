@@ -0,0 +1,642 @@
+package dbsync
+
+import (
+	"context"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/blinklabs-io/gouroboros/cbor"
+	"github.com/blinklabs-io/gouroboros/ledger/babbage"
+	"github.com/blinklabs-io/gouroboros/ledger/common"
+	"github.com/blinklabs-io/gouroboros/ledger/mary"
+	"github.com/blinklabs-io/gouroboros/ledger/shelley"
+
+	"github.com/Salvionied/apollo/v2/backend"
+	"github.com/Salvionied/apollo/v2/internal/backendutil"
+)
+
+// DbSyncChainContext implements backend.ChainContext against a db-sync
+// Postgres database.
+type DbSyncChainContext struct {
+	db        *sql.DB
+	networkId uint8
+
+	mu            sync.Mutex
+	cachedParams  *backend.ProtocolParameters
+	paramsCacheAt time.Time
+}
+
+var _ backend.ContextChainContext = (*DbSyncChainContext)(nil)
+
+// Capabilities reports the ChainContext feature set db-sync can serve. The
+// database is a read-only view of the chain: it cannot submit or evaluate
+// transactions and does not store the genesis configuration. Datums are
+// looked up in the datum table.
+func (d *DbSyncChainContext) Capabilities() backend.CapabilitySet {
+	unsupported := backend.CapabilitySubmitTx | backend.CapabilityEvaluateTx |
+		backend.CapabilityEvaluateTxAdditionalUtxos | backend.CapabilityGenesisParams
+	return backend.CapabilitySet(backend.AllCapabilities|backend.CapabilityDatumByHash) &^
+		backend.CapabilitySet(unsupported)
+}
+
+const (
+	backendName = "db-sync"
+	cacheExpiry = 5 * time.Minute
+)
+
+// NewDbSyncChainContext creates a new db-sync backend over db, which must be
+// opened with a Postgres driver. The caller owns db and closes it.
+func NewDbSyncChainContext(db *sql.DB, networkId uint8) *DbSyncChainContext {
+	return &DbSyncChainContext{
+		db:        db,
+		networkId: networkId,
+	}
+}
+
+// The queries target the default db-sync schema, where an output is unspent
+// while no tx_in references it. Numeric columns are cast to text so they
+// scan the same way with every Postgres driver.
+const (
+	protocolParamsQuery = `SELECT
+	epoch_param.min_fee_a, epoch_param.min_fee_b, epoch_param.max_block_size,
+	epoch_param.max_tx_size, epoch_param.max_bh_size,
+	epoch_param.key_deposit::text, epoch_param.pool_deposit::text,
+	epoch_param.influence, epoch_param.monetary_expand_rate,
+	epoch_param.treasury_growth_rate, epoch_param.decentralisation,
+	epoch_param.extra_entropy, epoch_param.protocol_major, epoch_param.protocol_minor,
+	epoch_param.min_utxo_value::text, epoch_param.min_pool_cost::text,
+	cost_model.costs::text, epoch_param.price_mem, epoch_param.price_step,
+	epoch_param.max_tx_ex_mem::text, epoch_param.max_tx_ex_steps::text,
+	epoch_param.max_block_ex_mem::text, epoch_param.max_block_ex_steps::text,
+	epoch_param.max_val_size::text, epoch_param.collateral_percent,
+	epoch_param.max_collateral_inputs, epoch_param.coins_per_utxo_size::text,
+	epoch_param.min_fee_ref_script_cost_per_byte::text
+FROM epoch_param
+LEFT JOIN cost_model ON cost_model.id = epoch_param.cost_model_id
+ORDER BY epoch_param.epoch_no DESC
+LIMIT 1`
+
+	tipQuery = `SELECT slot_no, epoch_no
+FROM block
+WHERE slot_no IS NOT NULL
+ORDER BY id DESC
+LIMIT 1`
+
+	// utxoSelect returns one row per output and native asset; outputs
+	// without assets have NULL asset columns.
+	utxoSelect = `SELECT
+	tx_out.id, tx.hash, tx_out.index, tx_out.address, tx_out.value::text,
+	tx_out.data_hash, datum.bytes,
+	script.type::text, script.hash, script.bytes,
+	multi_asset.policy, multi_asset.name, ma_tx_out.quantity::text
+FROM tx_out
+JOIN tx ON tx.id = tx_out.tx_id
+LEFT JOIN tx_in ON tx_in.tx_out_id = tx_out.tx_id AND tx_in.tx_out_index = tx_out.index
+LEFT JOIN datum ON datum.id = tx_out.inline_datum_id
+LEFT JOIN script ON script.id = tx_out.reference_script_id
+LEFT JOIN ma_tx_out ON ma_tx_out.tx_out_id = tx_out.id
+LEFT JOIN multi_asset ON multi_asset.id = ma_tx_out.ident
+`
+
+	utxosByAddressQuery = utxoSelect + `WHERE tx_out.address = $1 AND tx_in.id IS NULL
+ORDER BY tx.hash, tx_out.index, ma_tx_out.id`
+
+	utxoByRefQuery = utxoSelect + `WHERE tx.hash = $1 AND tx_out.index = $2 AND tx_in.id IS NULL
+ORDER BY ma_tx_out.id`
+
+	scriptQuery = `SELECT bytes FROM script WHERE hash = $1 LIMIT 1`
+
+	datumQuery = `SELECT bytes FROM datum WHERE hash = $1 LIMIT 1`
+)
+
+func (d *DbSyncChainContext) ProtocolParams() (backend.ProtocolParameters, error) {
+	return d.ProtocolParamsContext(context.Background())
+}
+
+// ProtocolParamsContext reads the parameters of the latest epoch_param row.
+func (d *DbSyncChainContext) ProtocolParamsContext(ctx context.Context) (backend.ProtocolParameters, error) {
+	d.mu.Lock()
+	if d.cachedParams != nil && time.Since(d.paramsCacheAt) < cacheExpiry {
+		pp := backendutil.CloneProtocolParams(*d.cachedParams)
+		d.mu.Unlock()
+		return pp, nil
+	}
+	d.mu.Unlock()
+
+	var row epochParamRow
+	err := d.db.QueryRowContext(ctx, protocolParamsQuery).Scan(
+		&row.MinFeeA, &row.MinFeeB, &row.MaxBlockSize,
+		&row.MaxTxSize, &row.MaxBhSize,
+		&row.KeyDeposit, &row.PoolDeposit,
+		&row.Influence, &row.MonetaryExpandRate,
+		&row.TreasuryGrowthRate, &row.Decentralisation,
+		&row.ExtraEntropy, &row.ProtocolMajor, &row.ProtocolMinor,
+		&row.MinUtxoValue, &row.MinPoolCost,
+		&row.CostModels, &row.PriceMem, &row.PriceStep,
+		&row.MaxTxExMem, &row.MaxTxExSteps,
+		&row.MaxBlockExMem, &row.MaxBlockExSteps,
+		&row.MaxValSize, &row.CollateralPercent,
+		&row.MaxCollateralInputs, &row.CoinsPerUtxoSize,
+		&row.MinFeeRefScriptCostPerByte,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return backend.ProtocolParameters{}, errors.New("db-sync has no epoch parameters")
+	}
+	if err != nil {
+		return backend.ProtocolParameters{}, fmt.Errorf("failed to query epoch parameters: %w", err)
+	}
+	pp, err := row.toProtocolParams()
+	if err != nil {
+		return backend.ProtocolParameters{}, err
+	}
+
+	cached := backendutil.CloneProtocolParams(pp)
+	d.mu.Lock()
+	d.cachedParams = &cached
+	d.paramsCacheAt = time.Now()
+	d.mu.Unlock()
+
+	return pp, nil
+}
+
+func (d *DbSyncChainContext) GenesisParams() (backend.GenesisParameters, error) {
+	return d.GenesisParamsContext(context.Background())
+}
+
+func (d *DbSyncChainContext) GenesisParamsContext(ctx context.Context) (backend.GenesisParameters, error) {
+	if err := ctx.Err(); err != nil {
+		return backend.GenesisParameters{}, err
+	}
+	return backend.GenesisParameters{}, backend.NewUnsupportedError(backendName, backend.CapabilityGenesisParams)
+}
+
+func (d *DbSyncChainContext) NetworkId() uint8 {
+	return d.networkId
+}
+
+func (d *DbSyncChainContext) CurrentEpoch() (uint64, error) {
+	return d.CurrentEpochContext(context.Background())
+}
+
+func (d *DbSyncChainContext) CurrentEpochContext(ctx context.Context) (uint64, error) {
+	_, epoch, err := d.tip(ctx)
+	return epoch, err
+}
+
+func (d *DbSyncChainContext) MaxTxFee() (uint64, error) {
+	return d.MaxTxFeeContext(context.Background())
+}
+
+func (d *DbSyncChainContext) MaxTxFeeContext(ctx context.Context) (uint64, error) {
+	pp, err := d.ProtocolParamsContext(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return backend.ComputeMaxTxFee(pp)
+}
+
+func (d *DbSyncChainContext) Tip() (uint64, error) {
+	return d.TipContext(context.Background())
+}
+
+func (d *DbSyncChainContext) TipContext(ctx context.Context) (uint64, error) {
+	slot, _, err := d.tip(ctx)
+	return slot, err
+}
+
+// tip returns the slot and epoch of the latest block db-sync has stored.
+// Epoch boundary blocks carry no slot and are skipped.
+func (d *DbSyncChainContext) tip(ctx context.Context) (uint64, uint64, error) {
+	var slot, epoch int64
+	err := d.db.QueryRowContext(ctx, tipQuery).Scan(&slot, &epoch)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, 0, errors.New("db-sync has no blocks")
+	}
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to query tip: %w", err)
+	}
+	if slot < 0 || epoch < 0 {
+		return 0, 0, fmt.Errorf("invalid tip slot %d epoch %d", slot, epoch)
+	}
+	return uint64(slot), uint64(epoch), nil
+}
+
+func (d *DbSyncChainContext) Utxos(address common.Address) ([]common.Utxo, error) {
+	return d.UtxosContext(context.Background(), address)
+}
+
+func (d *DbSyncChainContext) UtxosContext(ctx context.Context, address common.Address) ([]common.Utxo, error) {
+	utxos, err := d.queryUtxos(ctx, utxosByAddressQuery, address.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to query UTxOs of %s: %w", address.String(), err)
+	}
+	return utxos, nil
+}
+
+func (d *DbSyncChainContext) SubmitTx(txCbor []byte) (common.Blake2b256, error) {
+	return d.SubmitTxContext(context.Background(), txCbor)
+}
+
+func (d *DbSyncChainContext) SubmitTxContext(ctx context.Context, _ []byte) (common.Blake2b256, error) {
+	if err := ctx.Err(); err != nil {
+		return common.Blake2b256{}, err
+	}
+	return common.Blake2b256{}, backend.NewUnsupportedError(backendName, backend.CapabilitySubmitTx)
+}
+
+func (d *DbSyncChainContext) EvaluateTx(txCbor []byte, additionalUtxos []common.Utxo) (map[common.RedeemerKey]common.ExUnits, error) {
+	return d.EvaluateTxContext(context.Background(), txCbor, additionalUtxos)
+}
+
+func (d *DbSyncChainContext) EvaluateTxContext(
+	ctx context.Context,
+	_ []byte,
+	_ []common.Utxo,
+) (map[common.RedeemerKey]common.ExUnits, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return nil, backend.NewUnsupportedError(backendName, backend.CapabilityEvaluateTx)
+}
+
+func (d *DbSyncChainContext) UtxoByRef(txHash common.Blake2b256, index uint32) (*common.Utxo, error) {
+	return d.UtxoByRefContext(context.Background(), txHash, index)
+}
+
+func (d *DbSyncChainContext) UtxoByRefContext(
+	ctx context.Context,
+	txHash common.Blake2b256,
+	index uint32,
+) (*common.Utxo, error) {
+	utxos, err := d.queryUtxos(ctx, utxoByRefQuery, txHash.Bytes(), int64(index))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query UTxO %s#%d: %w", txHash.String(), index, err)
+	}
+	for _, utxo := range utxos {
+		if utxo.Id.Id() == txHash && utxo.Id.Index() == index {
+			return &utxo, nil
+		}
+	}
+	return nil, backend.ErrUtxoNotFound
+}
+
+func (d *DbSyncChainContext) ScriptCbor(scriptHash common.Blake2b224) ([]byte, error) {
+	return d.ScriptCborContext(context.Background(), scriptHash)
+}
+
+// ScriptCborContext returns the script bytes as db-sync stores them.
+func (d *DbSyncChainContext) ScriptCborContext(
+	ctx context.Context,
+	scriptHash common.Blake2b224,
+) ([]byte, error) {
+	hashHex := hex.EncodeToString(scriptHash.Bytes())
+	var scriptBytes []byte
+	err := d.db.QueryRowContext(ctx, scriptQuery, scriptHash.Bytes()).Scan(&scriptBytes)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("script %s not found", hashHex)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query script %s: %w", hashHex, err)
+	}
+	if len(scriptBytes) == 0 {
+		return nil, fmt.Errorf("db-sync has no CBOR for script %s", hashHex)
+	}
+	return scriptBytes, nil
+}
+
+func (d *DbSyncChainContext) DatumByHash(datumHash common.Blake2b256) (*common.Datum, error) {
+	return d.DatumByHashContext(context.Background(), datumHash)
+}
+
+func (d *DbSyncChainContext) DatumByHashContext(
+	ctx context.Context,
+	datumHash common.Blake2b256,
+) (*common.Datum, error) {
+	hashHex := hex.EncodeToString(datumHash.Bytes())
+	var datumBytes []byte
+	err := d.db.QueryRowContext(ctx, datumQuery, datumHash.Bytes()).Scan(&datumBytes)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("datum %s not found", hashHex)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query datum %s: %w", hashHex, err)
+	}
+	return backendutil.DecodeDatumHex(hex.EncodeToString(datumBytes))
+}
+
+// queryUtxos runs a utxoSelect query and folds the per-asset rows back into
+// one UTxO per output, in the order the query returns them.
+func (d *DbSyncChainContext) queryUtxos(ctx context.Context, query string, args ...any) ([]common.Utxo, error) {
+	rows, err := d.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var outputs []*utxoRow
+	byId := make(map[int64]*utxoRow)
+	for rows.Next() {
+		var (
+			row   utxoRow
+			asset assetRow
+		)
+		err := rows.Scan(
+			&row.Id, &row.TxHash, &row.Index, &row.Address, &row.Value,
+			&row.DataHash, &row.InlineDatum,
+			&row.ScriptType, &row.ScriptHash, &row.ScriptBytes,
+			&asset.Policy, &asset.Name, &asset.Quantity,
+		)
+		if err != nil {
+			return nil, err
+		}
+		output, ok := byId[row.Id]
+		if !ok {
+			output = &row
+			byId[row.Id] = output
+			outputs = append(outputs, output)
+		}
+		if asset.Quantity.Valid {
+			output.Assets = append(output.Assets, asset)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	utxos := make([]common.Utxo, 0, len(outputs))
+	for _, output := range outputs {
+		utxo, err := output.toUtxo()
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse UTxO %s#%d: %w",
+				hex.EncodeToString(output.TxHash), output.Index, err)
+		}
+		utxos = append(utxos, utxo)
+	}
+	return utxos, nil
+}
+
+// utxoRow is a tx_out row with its inline datum, reference script and
+// native assets.
+type utxoRow struct {
+	Id          int64
+	TxHash      []byte
+	Index       int64
+	Address     string
+	Value       string
+	DataHash    []byte
+	InlineDatum []byte
+	ScriptType  sql.NullString
+	ScriptHash  []byte
+	ScriptBytes []byte
+	Assets      []assetRow
+}
+
+type assetRow struct {
+	Policy   []byte
+	Name     []byte
+	Quantity sql.NullString
+}
+
+func (raw *utxoRow) toUtxo() (common.Utxo, error) {
+	if len(raw.TxHash) != common.Blake2b256Size {
+		return common.Utxo{}, fmt.Errorf("invalid tx hash length %d", len(raw.TxHash))
+	}
+	if raw.Index < 0 || raw.Index > math.MaxUint32 {
+		return common.Utxo{}, fmt.Errorf("output index %d out of uint32 range", raw.Index)
+	}
+	address, err := common.NewAddress(raw.Address)
+	if err != nil {
+		return common.Utxo{}, fmt.Errorf("invalid address %q: %w", raw.Address, err)
+	}
+	lovelace, err := strconv.ParseUint(raw.Value, 10, 64)
+	if err != nil {
+		return common.Utxo{}, fmt.Errorf("invalid value %q: %w", raw.Value, err)
+	}
+
+	assetData := make(map[common.Blake2b224]map[cbor.ByteString]*big.Int)
+	for _, asset := range raw.Assets {
+		if len(asset.Policy) != common.Blake2b224Size {
+			return common.Utxo{}, fmt.Errorf("invalid policy id length %d", len(asset.Policy))
+		}
+		policyId := common.NewBlake2b224(asset.Policy)
+		qty, ok := new(big.Int).SetString(asset.Quantity.String, 10)
+		if !ok || qty.Sign() < 0 {
+			return common.Utxo{}, fmt.Errorf("invalid asset quantity %q for %s", asset.Quantity.String, policyId.String())
+		}
+		if _, ok := assetData[policyId]; !ok {
+			assetData[policyId] = make(map[cbor.ByteString]*big.Int)
+		}
+		assetData[policyId][cbor.NewByteString(asset.Name)] = qty
+	}
+	var assets *common.MultiAsset[common.MultiAssetTypeOutput]
+	if len(assetData) > 0 {
+		ma := common.NewMultiAsset[common.MultiAssetTypeOutput](assetData)
+		assets = &ma
+	}
+
+	output := babbage.BabbageTransactionOutput{
+		OutputAddress: address,
+		OutputAmount: mary.MaryTransactionOutputValue{
+			Amount: lovelace,
+			Assets: assets,
+		},
+	}
+	// db-sync also fills data_hash for inline datums, so the inline datum
+	// takes precedence.
+	switch {
+	case raw.InlineDatum != nil:
+		opt, err := backendutil.InlineDatumOption(raw.InlineDatum)
+		if err != nil {
+			return common.Utxo{}, fmt.Errorf("failed to decode inline datum: %w", err)
+		}
+		output.DatumOption = opt
+	case len(raw.DataHash) > 0:
+		if len(raw.DataHash) != common.Blake2b256Size {
+			return common.Utxo{}, fmt.Errorf("invalid datum hash length %d", len(raw.DataHash))
+		}
+		opt, err := backendutil.DatumHashOption(common.NewBlake2b256(raw.DataHash))
+		if err != nil {
+			return common.Utxo{}, err
+		}
+		output.DatumOption = opt
+	}
+	if raw.ScriptType.Valid {
+		if len(raw.ScriptBytes) == 0 {
+			return common.Utxo{}, fmt.Errorf("db-sync has no CBOR for reference script %x", raw.ScriptHash)
+		}
+		scriptRef, err := backendutil.ScriptRefFromDbSync(raw.ScriptType.String, raw.ScriptBytes, hex.EncodeToString(raw.ScriptHash))
+		if err != nil {
+			return common.Utxo{}, fmt.Errorf("failed to decode reference script: %w", err)
+		}
+		output.TxOutScriptRef = scriptRef
+	}
+
+	return common.Utxo{
+		Id: shelley.ShelleyTransactionInput{
+			TxId:        common.NewBlake2b256(raw.TxHash),
+			OutputIndex: uint32(raw.Index), //nolint:gosec // range checked above
+		},
+		Output: &output,
+	}, nil
+}
+
+// epochParamRow is an epoch_param row joined with its cost models.
+type epochParamRow struct {
+	MinFeeA                    sql.NullInt64
+	MinFeeB                    sql.NullInt64
+	MaxBlockSize               sql.NullInt64
+	MaxTxSize                  sql.NullInt64
+	MaxBhSize                  sql.NullInt64
+	KeyDeposit                 sql.NullString
+	PoolDeposit                sql.NullString
+	Influence                  sql.NullFloat64
+	MonetaryExpandRate         sql.NullFloat64
+	TreasuryGrowthRate         sql.NullFloat64
+	Decentralisation           sql.NullFloat64
+	ExtraEntropy               []byte
+	ProtocolMajor              sql.NullInt64
+	ProtocolMinor              sql.NullInt64
+	MinUtxoValue               sql.NullString
+	MinPoolCost                sql.NullString
+	CostModels                 sql.NullString
+	PriceMem                   sql.NullFloat64
+	PriceStep                  sql.NullFloat64
+	MaxTxExMem                 sql.NullString
+	MaxTxExSteps               sql.NullString
+	MaxBlockExMem              sql.NullString
+	MaxBlockExSteps            sql.NullString
+	MaxValSize                 sql.NullString
+	CollateralPercent          sql.NullInt64
+	MaxCollateralInputs        sql.NullInt64
+	CoinsPerUtxoSize           sql.NullString
+	MinFeeRefScriptCostPerByte sql.NullString
+}
+
+// fieldParser collects the first conversion error so the protocol
+// parameters can be built in a single struct literal. Required columns fail
+// when NULL; optional ones are left zero.
+type fieldParser struct {
+	err error
+}
+
+func (f *fieldParser) missing(name string) {
+	if f.err == nil {
+		f.err = fmt.Errorf("missing %s", name)
+	}
+}
+
+func (f *fieldParser) int64(v sql.NullInt64, name string, required bool) int64 {
+	if !v.Valid {
+		if required {
+			f.missing(name)
+		}
+		return 0
+	}
+	return v.Int64
+}
+
+func (f *fieldParser) int(v sql.NullInt64, name string, required bool) int {
+	n := f.int64(v, name, required)
+	if f.err != nil {
+		return 0
+	}
+	bounded, err := backendutil.BoundedInt(n, name)
+	if err != nil {
+		f.err = err
+	}
+	return bounded
+}
+
+func (f *fieldParser) float64(v sql.NullFloat64, name string, required bool) float64 {
+	if !v.Valid {
+		if required {
+			f.missing(name)
+		}
+		return 0
+	}
+	return v.Float64
+}
+
+// integer returns a numeric column as a decimal string, as
+// backend.ProtocolParameters stores lovelace amounts.
+func (f *fieldParser) integer(v sql.NullString, name string, required bool) string {
+	if !v.Valid {
+		if required {
+			f.missing(name)
+		}
+		return ""
+	}
+	if _, ok := new(big.Int).SetString(v.String, 10); !ok && f.err == nil {
+		f.err = fmt.Errorf("invalid %s %q", name, v.String)
+	}
+	return v.String
+}
+
+// toProtocolParams converts an epoch_param row. As with the HTTP backends,
+// the fee, deposit, size, price and collateral parameters are required so a
+// NULL never silently becomes a zero fee.
+func (p *epochParamRow) toProtocolParams() (backend.ProtocolParameters, error) {
+	var f fieldParser
+	pp := backend.ProtocolParameters{
+		MinFeeCoefficient:     f.int64(p.MinFeeA, "min_fee_a", true),
+		MinFeeConstant:        f.int64(p.MinFeeB, "min_fee_b", true),
+		MaxBlockSize:          f.int(p.MaxBlockSize, "max_block_size", false),
+		MaxTxSize:             f.int(p.MaxTxSize, "max_tx_size", true),
+		MaxBlockHeaderSize:    f.int(p.MaxBhSize, "max_bh_size", false),
+		KeyDeposits:           f.integer(p.KeyDeposit, "key_deposit", true),
+		PoolDeposits:          f.integer(p.PoolDeposit, "pool_deposit", true),
+		PoolInfluence:         f.float64(p.Influence, "influence", false),
+		MonetaryExpansion:     f.float64(p.MonetaryExpandRate, "monetary_expand_rate", false),
+		TreasuryExpansion:     f.float64(p.TreasuryGrowthRate, "treasury_growth_rate", false),
+		DecentralizationParam: f.float64(p.Decentralisation, "decentralisation", false),
+		ProtocolMajorVersion:  f.int(p.ProtocolMajor, "protocol_major", false),
+		ProtocolMinorVersion:  f.int(p.ProtocolMinor, "protocol_minor", false),
+		MinUtxo:               f.integer(p.MinUtxoValue, "min_utxo_value", false),
+		MinPoolCost:           f.integer(p.MinPoolCost, "min_pool_cost", false),
+		PriceMem:              f.float64(p.PriceMem, "price_mem", true),
+		PriceStep:             f.float64(p.PriceStep, "price_step", true),
+		MaxTxExMem:            f.integer(p.MaxTxExMem, "max_tx_ex_mem", true),
+		MaxTxExSteps:          f.integer(p.MaxTxExSteps, "max_tx_ex_steps", true),
+		MaxBlockExMem:         f.integer(p.MaxBlockExMem, "max_block_ex_mem", false),
+		MaxBlockExSteps:       f.integer(p.MaxBlockExSteps, "max_block_ex_steps", false),
+		MaxValSize:            f.integer(p.MaxValSize, "max_val_size", true),
+		CollateralPercent:     f.int(p.CollateralPercent, "collateral_percent", true),
+		MaxCollateralInputs:   f.int(p.MaxCollateralInputs, "max_collateral_inputs", true),
+		CoinsPerUtxoByte:      f.integer(p.CoinsPerUtxoSize, "coins_per_utxo_size", true),
+	}
+	if f.err != nil {
+		return backend.ProtocolParameters{}, fmt.Errorf("db-sync epoch parameters: %w", f.err)
+	}
+	if len(p.ExtraEntropy) > 0 {
+		pp.ExtraEntropy = hex.EncodeToString(p.ExtraEntropy)
+	}
+
+	// The column is a double; its text form is the shortest decimal that
+	// round-trips, which is parsed exactly.
+	if p.MinFeeRefScriptCostPerByte.Valid {
+		price, err := backendutil.ParseRational(p.MinFeeRefScriptCostPerByte.String)
+		if err != nil {
+			return backend.ProtocolParameters{}, fmt.Errorf("invalid min_fee_ref_script_cost_per_byte: %w", err)
+		}
+		pp.MinFeeRefScriptCostPerByteRational = price
+		pp.MinFeeRefScriptCostPerByte, _ = price.Float64()
+	}
+
+	// cost_model.costs is keyed "PlutusV1" through "PlutusV4", which is the
+	// form ComputeScriptDataHash expects.
+	if p.CostModels.Valid {
+		models, err := backendutil.ParseCostModels([]byte(p.CostModels.String))
+		if err != nil {
+			return backend.ProtocolParameters{}, err
+		}
+		pp.CostModels = models
+	}
+	return pp, nil
+}
@@ -0,0 +1,416 @@
+package dbsync
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/blinklabs-io/gouroboros/ledger/common"
+
+	"github.com/Salvionied/apollo/v2/backend"
+)
+
+// fixtureDB stands in for a db-sync Postgres database. It answers the
+// package's query constants from fixture rows and records the arguments of
+// each query.
+type fixtureDB struct {
+	answers map[string]func(args []driver.Value) ([][]driver.Value, error)
+
+	mu    sync.Mutex
+	calls map[string][][]driver.Value
+}
+
+func newFixtureDB(t *testing.T, answers map[string]func(args []driver.Value) ([][]driver.Value, error)) (*fixtureDB, *DbSyncChainContext) {
+	t.Helper()
+	fx := &fixtureDB{answers: answers, calls: make(map[string][][]driver.Value)}
+	db := sql.OpenDB(fx)
+	t.Cleanup(func() { _ = db.Close() })
+	return fx, NewDbSyncChainContext(db, 0)
+}
+
+func rows(values ...[]driver.Value) func([]driver.Value) ([][]driver.Value, error) {
+	return func([]driver.Value) ([][]driver.Value, error) { return values, nil }
+}
+
+func (fx *fixtureDB) recorded(query string) [][]driver.Value {
+	fx.mu.Lock()
+	defer fx.mu.Unlock()
+	return fx.calls[query]
+}
+
+func (fx *fixtureDB) Connect(context.Context) (driver.Conn, error) { return fixtureConn{fx}, nil }
+func (fx *fixtureDB) Driver() driver.Driver                        { return fixtureDriver{fx} }
+
+type fixtureDriver struct{ fx *fixtureDB }
+
+func (d fixtureDriver) Open(string) (driver.Conn, error) { return fixtureConn(d), nil }
+
+type fixtureConn struct{ fx *fixtureDB }
+
+func (c fixtureConn) Prepare(string) (driver.Stmt, error) {
+	return nil, errors.New("fixture: prepared statements are not supported")
+}
+func (c fixtureConn) Close() error { return nil }
+func (c fixtureConn) Begin() (driver.Tx, error) {
+	return nil, errors.New("fixture: transactions are not supported")
+}
+
+func (c fixtureConn) QueryContext(_ context.Context, query string, named []driver.NamedValue) (driver.Rows, error) {
+	answer, ok := c.fx.answers[query]
+	if !ok {
+		return nil, fmt.Errorf("fixture: unexpected query %q", query)
+	}
+	args := make([]driver.Value, len(named))
+	for i, arg := range named {
+		args[i] = arg.Value
+	}
+	c.fx.mu.Lock()
+	c.fx.calls[query] = append(c.fx.calls[query], args)
+	c.fx.mu.Unlock()
+	values, err := answer(args)
+	if err != nil {
+		return nil, err
+	}
+	return &fixtureRows{values: values}, nil
+}
+
+type fixtureRows struct {
+	values [][]driver.Value
+	next   int
+}
+
+// Columns only reports the column count; the backend scans by position.
+func (r *fixtureRows) Columns() []string {
+	if len(r.values) == 0 {
+		return nil
+	}
+	return make([]string, len(r.values[0]))
+}
+
+func (r *fixtureRows) Close() error { return nil }
+
+func (r *fixtureRows) Next(dest []driver.Value) error {
+	if r.next >= len(r.values) {
+		return io.EOF
+	}
+	copy(dest, r.values[r.next])
+	r.next++
+	return nil
+}
+
+var (
+	testTxHash    = bytes.Repeat([]byte{0xaa}, 32)
+	testPolicyId  = bytes.Repeat([]byte{0xbb}, 28)
+	testDatumCbor = []byte{0xd8, 0x79, 0x80}
+)
+
+func testAddress(t *testing.T) common.Address {
+	t.Helper()
+	var raw [57]byte
+	raw[0] = 0x00
+	raw[1] = 0xAA
+	raw[29] = 0xBB
+	addr, err := common.NewAddressFromBytes(raw[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return addr
+}
+
+func epochParamRowValues() []driver.Value {
+	return []driver.Value{
+		int64(44), int64(155381), int64(90112),
+		int64(16384), int64(1100),
+		"2000000", "500000000",
+		0.3, 0.003,
+		0.2, 0.0,
+		nil, int64(10), int64(0),
+		nil, "170000000",
+		`{"PlutusV2": [1, 2, 3], "PlutusV3": [4, 5]}`, 0.0577, 0.0000721,
+		"14000000", "10000000000",
+		"62000000", "20000000000",
+		"5000", int64(150),
+		int64(3), "4310",
+		"12.5",
+	}
+}
+
+// utxoValues builds a utxoSelect row for output index of testTxHash.
+func utxoValues(t *testing.T, id int64, index int64) []driver.Value {
+	t.Helper()
+	return []driver.Value{
+		id, testTxHash, index, testAddress(t).String(), "2000000",
+		nil, nil,
+		nil, nil, nil,
+		nil, nil, nil,
+	}
+}
+
+func TestDbSyncCapabilities(t *testing.T) {
+	caps := NewDbSyncChainContext(nil, 0).Capabilities()
+	for _, capability := range []backend.Capability{
+		backend.CapabilityProtocolParams, backend.CapabilityCurrentEpoch, backend.CapabilityMaxTxFee,
+		backend.CapabilityTip, backend.CapabilityUtxos, backend.CapabilityUtxoByRef,
+		backend.CapabilityScriptCbor, backend.CapabilityDatumByHash,
+	} {
+		if !caps.Has(capability) {
+			t.Errorf("missing %s", capability)
+		}
+	}
+	for _, capability := range []backend.Capability{
+		backend.CapabilitySubmitTx, backend.CapabilityEvaluateTx,
+		backend.CapabilityEvaluateTxAdditionalUtxos, backend.CapabilityGenesisParams,
+	} {
+		if caps.Has(capability) {
+			t.Errorf("unexpected %s", capability)
+		}
+	}
+}
+
+func TestProtocolParams(t *testing.T) {
+	fx, ctx := newFixtureDB(t, map[string]func([]driver.Value) ([][]driver.Value, error){
+		protocolParamsQuery: rows(epochParamRowValues()),
+	})
+	pp, err := ctx.ProtocolParams()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pp.MinFeeCoefficient != 44 || pp.MinFeeConstant != 155381 || pp.MaxTxSize != 16384 {
+		t.Fatalf("fee parameters = %d/%d/%d", pp.MinFeeCoefficient, pp.MinFeeConstant, pp.MaxTxSize)
+	}
+	if pp.KeyDeposits != "2000000" || pp.CoinsPerUtxoByte != "4310" || pp.MaxTxExSteps != "10000000000" {
+		t.Fatalf("integer parameters = %+v", pp)
+	}
+	if pp.CollateralPercent != 150 || pp.MaxCollateralInputs != 3 || pp.ProtocolMajorVersion != 10 {
+		t.Fatalf("collateral/version parameters = %+v", pp)
+	}
+	if pp.MinUtxo != "" {
+		t.Fatalf("MinUtxo = %q, want empty for NULL", pp.MinUtxo)
+	}
+	if pp.MinFeeRefScriptCostPerByteRational == nil || pp.MinFeeRefScriptCostPerByteRational.Cmp(big.NewRat(25, 2)) != 0 {
+		t.Fatalf("ref script price = %v", pp.MinFeeRefScriptCostPerByteRational)
+	}
+	if len(pp.CostModels["PlutusV2"]) != 3 || len(pp.CostModels["PlutusV3"]) != 2 {
+		t.Fatalf("cost models = %v", pp.CostModels)
+	}
+
+	pp.CostModels["PlutusV2"][0] = 99
+	again, err := ctx.ProtocolParams()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again.CostModels["PlutusV2"][0] != 1 {
+		t.Fatal("cached cost models were mutated through a returned value")
+	}
+	if calls := len(fx.recorded(protocolParamsQuery)); calls != 1 {
+		t.Fatalf("epoch_param queried %d times, want 1", calls)
+	}
+}
+
+func TestProtocolParamsRejectsMissingRequiredField(t *testing.T) {
+	values := epochParamRowValues()
+	values[1] = nil // min_fee_b
+	_, ctx := newFixtureDB(t, map[string]func([]driver.Value) ([][]driver.Value, error){
+		protocolParamsQuery: rows(values),
+	})
+	if _, err := ctx.ProtocolParams(); err == nil || !strings.Contains(err.Error(), "min_fee_b") {
+		t.Fatalf("ProtocolParams() error = %v, want missing min_fee_b", err)
+	}
+}
+
+func TestProtocolParamsRejectsEmptyTable(t *testing.T) {
+	_, ctx := newFixtureDB(t, map[string]func([]driver.Value) ([][]driver.Value, error){
+		protocolParamsQuery: rows(),
+	})
+	if _, err := ctx.ProtocolParams(); err == nil {
+		t.Fatal("ProtocolParams() succeeded without an epoch_param row")
+	}
+}
+
+func TestTipAndCurrentEpoch(t *testing.T) {
+	_, ctx := newFixtureDB(t, map[string]func([]driver.Value) ([][]driver.Value, error){
+		tipQuery: rows([]driver.Value{int64(134_000_000), int64(512)}),
+	})
+	tip, err := ctx.Tip()
+	if err != nil || tip != 134_000_000 {
+		t.Fatalf("Tip() = %d, %v", tip, err)
+	}
+	epoch, err := ctx.CurrentEpoch()
+	if err != nil || epoch != 512 {
+		t.Fatalf("CurrentEpoch() = %d, %v", epoch, err)
+	}
+}
+
+func TestUtxosDecodesAssetsDatumsAndReferenceScripts(t *testing.T) {
+	// db-sync stores Plutus scripts without the CBOR byte-string layer the
+	// hash covers.
+	script := common.PlutusV2Script{0x42, 0x01, 0x02}
+	datumHash := bytes.Repeat([]byte{0xcd}, 32)
+
+	first := utxoValues(t, 7, 0)
+	first[6] = testDatumCbor
+	first[5] = common.Blake2b256Hash(testDatumCbor).Bytes()
+	first[7], first[8], first[9] = "plutusV2", script.Hash().Bytes(), []byte{0x01, 0x02}
+	first[10], first[11], first[12] = testPolicyId, []byte("token"), "18446744073709551616"
+	firstOther := append([]driver.Value(nil), first...)
+	firstOther[11], firstOther[12] = []byte{}, "5"
+	second := utxoValues(t, 8, 1)
+	second[5] = datumHash
+
+	fx, ctx := newFixtureDB(t, map[string]func([]driver.Value) ([][]driver.Value, error){
+		utxosByAddressQuery: rows(first, firstOther, second),
+	})
+	utxos, err := ctx.Utxos(testAddress(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if calls := fx.recorded(utxosByAddressQuery); len(calls) != 1 || calls[0][0] != testAddress(t).String() {
+		t.Fatalf("UTxO query arguments = %v", calls)
+	}
+	if len(utxos) != 2 {
+		t.Fatalf("Utxos() returned %d UTxOs, want 2", len(utxos))
+	}
+
+	out := utxos[0].Output
+	if out.Amount().Uint64() != 2_000_000 {
+		t.Fatalf("lovelace = %s", out.Amount())
+	}
+	policyId := common.NewBlake2b224(testPolicyId)
+	if qty := out.Assets().Asset(policyId, []byte("token")); qty == nil || qty.String() != "18446744073709551616" {
+		t.Fatalf("token quantity = %v", qty)
+	}
+	if qty := out.Assets().Asset(policyId, []byte{}); qty == nil || qty.Int64() != 5 {
+		t.Fatalf("empty-name asset quantity = %v", qty)
+	}
+	if out.Datum() == nil || !bytes.Equal(out.Datum().Cbor(), testDatumCbor) {
+		t.Fatal("inline datum was not preserved")
+	}
+	if out.ScriptRef() == nil || out.ScriptRef().Hash() != script.Hash() {
+		t.Fatal("reference script was not decoded")
+	}
+
+	out = utxos[1].Output
+	if out.Datum() != nil || out.DatumHash() == nil || !bytes.Equal(out.DatumHash().Bytes(), datumHash) {
+		t.Fatal("datum hash was not decoded")
+	}
+	if out.Assets() != nil {
+		t.Fatalf("second UTxO assets = %v", out.Assets())
+	}
+	if utxos[1].Id.Index() != 1 {
+		t.Fatalf("second UTxO index = %d", utxos[1].Id.Index())
+	}
+}
+
+func TestUtxosRejectsReferenceScriptHashMismatch(t *testing.T) {
+	row := utxoValues(t, 1, 0)
+	row[7], row[8], row[9] = "plutusV2", bytes.Repeat([]byte{0x11}, 28), []byte{0x01, 0x02}
+	_, ctx := newFixtureDB(t, map[string]func([]driver.Value) ([][]driver.Value, error){
+		utxosByAddressQuery: rows(row),
+	})
+	if _, err := ctx.Utxos(testAddress(t)); err == nil || !strings.Contains(err.Error(), "reference script") {
+		t.Fatalf("Utxos() error = %v, want a reference script error", err)
+	}
+}
+
+func TestUtxoByRef(t *testing.T) {
+	fx, ctx := newFixtureDB(t, map[string]func([]driver.Value) ([][]driver.Value, error){
+		utxoByRefQuery: func(args []driver.Value) ([][]driver.Value, error) {
+			if args[1] == int64(3) {
+				return [][]driver.Value{utxoValues(t, 1, 3)}, nil
+			}
+			return nil, nil
+		},
+	})
+	txHash := common.NewBlake2b256(testTxHash)
+	utxo, err := ctx.UtxoByRef(txHash, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if utxo.Id.Id() != txHash || utxo.Id.Index() != 3 {
+		t.Fatalf("UtxoByRef() = %s#%d", utxo.Id.Id(), utxo.Id.Index())
+	}
+	if calls := fx.recorded(utxoByRefQuery); !bytes.Equal(calls[0][0].([]byte), testTxHash) {
+		t.Fatalf("UtxoByRef() query arguments = %v", calls[0])
+	}
+
+	if _, err := ctx.UtxoByRef(txHash, 4); !errors.Is(err, backend.ErrUtxoNotFound) {
+		t.Fatalf("UtxoByRef() error = %v, want ErrUtxoNotFound", err)
+	}
+}
+
+func TestScriptCbor(t *testing.T) {
+	script := common.PlutusV3Script{0x42, 0x01, 0x02}
+	_, ctx := newFixtureDB(t, map[string]func([]driver.Value) ([][]driver.Value, error){
+		scriptQuery: func(args []driver.Value) ([][]driver.Value, error) {
+			if bytes.Equal(args[0].([]byte), script.Hash().Bytes()) {
+				return [][]driver.Value{{[]byte{0x01, 0x02}}}, nil
+			}
+			return nil, nil
+		},
+	})
+	got, err := ctx.ScriptCbor(script.Hash())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, []byte{0x01, 0x02}) {
+		t.Fatalf("ScriptCbor() = %x", got)
+	}
+	if _, err := ctx.ScriptCbor(common.Blake2b224{}); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Fatalf("ScriptCbor() error = %v, want not found", err)
+	}
+}
+
+func TestDatumByHash(t *testing.T) {
+	// 0x9f182aff is a non-canonical (indefinite-length) list; the stored
+	// bytes must be kept so the hash still matches.
+	datumCbor := []byte{0x9f, 0x18, 0x2a, 0xff}
+	hash := common.Blake2b256Hash(datumCbor)
+	_, ctx := newFixtureDB(t, map[string]func([]driver.Value) ([][]driver.Value, error){
+		datumQuery: rows([]driver.Value{datumCbor}),
+	})
+	datum, err := backend.DatumByHashContext(context.Background(), ctx, hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(datum.Cbor(), datumCbor) {
+		t.Fatalf("datum CBOR = %s", hex.EncodeToString(datum.Cbor()))
+	}
+}
+
+func TestUnsupportedOperations(t *testing.T) {
+	ctx := NewDbSyncChainContext(nil, 0)
+	if _, err := ctx.SubmitTx([]byte{0x84}); !errors.Is(err, backend.ErrUnsupported) {
+		t.Fatalf("SubmitTx() error = %v", err)
+	}
+	if _, err := ctx.EvaluateTx([]byte{0x84}, nil); !errors.Is(err, backend.ErrUnsupported) {
+		t.Fatalf("EvaluateTx() error = %v", err)
+	}
+	if _, err := ctx.GenesisParams(); !errors.Is(err, backend.ErrUnsupported) {
+		t.Fatalf("GenesisParams() error = %v", err)
+	}
+}
+
+func TestQueryErrorsAndCancellation(t *testing.T) {
+	queryErr := errors.New("relation \"block\" does not exist")
+	_, ctx := newFixtureDB(t, map[string]func([]driver.Value) ([][]driver.Value, error){
+		tipQuery: func([]driver.Value) ([][]driver.Value, error) { return nil, queryErr },
+	})
+	if _, err := ctx.Tip(); !errors.Is(err, queryErr) {
+		t.Fatalf("Tip() error = %v, want the query error", err)
+	}
+
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := ctx.UtxosContext(canceled, testAddress(t)); !errors.Is(err, context.Canceled) {
+		t.Fatalf("UtxosContext() error = %v, want context.Canceled", err)
+	}
+}
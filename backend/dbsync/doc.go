@@ -0,0 +1,10 @@
+// Package dbsync implements the Apollo chain backend by querying the Postgres
+// database of a cardano-db-sync instance directly. It reads UTxOs, scripts,
+// datums and protocol parameters from the default db-sync schema but cannot
+// submit or evaluate transactions, so it is usually composed with a backend
+// that can.
+//
+// The package takes a *sql.DB and does not import a Postgres driver; register
+// one such as github.com/jackc/pgx/v5/stdlib or github.com/lib/pq in the
+// calling program.
+package dbsync
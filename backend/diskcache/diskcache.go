@@ -0,0 +1,315 @@
+package diskcache
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"iter"
+	"strconv"
+	"time"
+
+	"github.com/blinklabs-io/gouroboros/cbor"
+	"github.com/blinklabs-io/gouroboros/ledger"
+	"github.com/blinklabs-io/gouroboros/ledger/common"
+	"github.com/blinklabs-io/gouroboros/ledger/shelley"
+
+	"github.com/Salvionied/apollo/v2/backend"
+	"github.com/Salvionied/apollo/v2/internal/backendutil"
+)
+
+const (
+	defaultMaxEntryBytes = 1 << 20
+	defaultMaxTotalBytes = 64 << 20
+)
+
+// ValidationPolicy decides when a UTxO read from disk is checked against the
+// wrapped backend. An output never changes once created, but it can be spent,
+// so the policy trades freshness for requests.
+type ValidationPolicy int
+
+const (
+	// ValidateAlways asks the backend first and falls back to a cached UTxO
+	// only when the backend fails with a retryable error. It is the zero
+	// value, so a spent output is never served unless asked for.
+	ValidateAlways ValidationPolicy = iota
+	// ValidateAfter serves cached UTxOs younger than Config.UtxoMaxAge and
+	// asks the backend about older ones, dropping those it no longer knows.
+	ValidateAfter
+	// ValidateNever serves cached UTxOs without asking the backend, so
+	// references stay resolvable after they are spent. It suits reference
+	// inputs and evaluating known transactions.
+	ValidateNever
+)
+
+// Config describes a DiskCachedChainContext.
+type Config struct {
+	// Dir holds the cache and is created if missing. Required. Entries are
+	// not tagged with a network, so use one directory per network.
+	Dir string
+	// MaxEntryBytes bounds a single entry; larger results are not stored.
+	// Zero uses 1 MiB.
+	MaxEntryBytes int64
+	// MaxTotalBytes bounds the directory; the least recently used entries
+	// are removed past it. Zero uses 64 MiB.
+	MaxTotalBytes int64
+	// UtxoPolicy decides when cached UTxOs are checked. The zero value is
+	// ValidateAlways.
+	UtxoPolicy ValidationPolicy
+	// UtxoMaxAge is how long ValidateAfter trusts a cached UTxO. Required
+	// with that policy.
+	UtxoMaxAge time.Duration
+}
+
+// DiskCachedChainContext wraps another ChainContext and keeps immutable
+// results - script CBOR, resolved UTxO references and genesis parameters - in
+// a directory, so they survive restarts. Failing to write the cache never
+// fails a lookup.
+type DiskCachedChainContext struct {
+	inner      backend.ChainContext
+	store      *store
+	policy     ValidationPolicy
+	utxoMaxAge time.Duration
+	now        func() time.Time
+}
+
+var (
+	_ backend.ContextChainContext  = (*DiskCachedChainContext)(nil)
+	_ backend.ContextDatumResolver = (*DiskCachedChainContext)(nil)
+	_ backend.UtxoStreamer         = (*DiskCachedChainContext)(nil)
+)
+
+// NewDiskCachedChainContext wraps inner with a cache in cfg.Dir. It fails
+// when the directory cannot be created or read, or the configuration is
+// invalid.
+func NewDiskCachedChainContext(inner backend.ChainContext, cfg Config) (*DiskCachedChainContext, error) {
+	if backend.IsNilChainContext(inner) {
+		return nil, errors.New("chain context is nil")
+	}
+	if cfg.Dir == "" {
+		return nil, errors.New("cache directory is required")
+	}
+	switch cfg.UtxoPolicy {
+	case ValidateNever, ValidateAlways:
+	case ValidateAfter:
+		if cfg.UtxoMaxAge <= 0 {
+			return nil, errors.New("ValidateAfter requires a positive UtxoMaxAge")
+		}
+	default:
+		return nil, fmt.Errorf("unknown UTxO validation policy %d", cfg.UtxoPolicy)
+	}
+	maxEntryBytes := cfg.MaxEntryBytes
+	if maxEntryBytes <= 0 {
+		maxEntryBytes = defaultMaxEntryBytes
+	}
+	maxTotalBytes := cfg.MaxTotalBytes
+	if maxTotalBytes <= 0 {
+		maxTotalBytes = defaultMaxTotalBytes
+	}
+	d := &DiskCachedChainContext{
+		inner:      inner,
+		policy:     cfg.UtxoPolicy,
+		utxoMaxAge: cfg.UtxoMaxAge,
+		now:        time.Now,
+	}
+	s, err := openStore(cfg.Dir, maxEntryBytes, maxTotalBytes, func() time.Time { return d.now() })
+	if err != nil {
+		return nil, err
+	}
+	d.store = s
+	return d, nil
+}
+
+// Capabilities preserves the feature set of the wrapped context.
+func (d *DiskCachedChainContext) Capabilities() backend.CapabilitySet {
+	return backend.CapabilitiesOf(d.inner)
+}
+
+func (d *DiskCachedChainContext) ProtocolParams() (backend.ProtocolParameters, error) {
+	return d.ProtocolParamsContext(context.Background())
+}
+
+// ProtocolParamsContext is not cached: the parameters change between epochs.
+func (d *DiskCachedChainContext) ProtocolParamsContext(ctx context.Context) (backend.ProtocolParameters, error) {
+	return backend.ProtocolParamsContext(ctx, d.inner)
+}
+
+func (d *DiskCachedChainContext) GenesisParams() (backend.GenesisParameters, error) {
+	return d.GenesisParamsContext(context.Background())
+}
+
+func (d *DiskCachedChainContext) GenesisParamsContext(ctx context.Context) (backend.GenesisParameters, error) {
+	key := strconv.Itoa(int(d.inner.NetworkId()))
+	if payload, _, err := d.store.get(kindGenesis, key); err == nil {
+		var gp backend.GenesisParameters
+		if err := json.Unmarshal(payload, &gp); err == nil {
+			return gp, nil
+		}
+		d.store.remove(kindGenesis, key)
+	}
+	gp, err := backend.GenesisParamsContext(ctx, d.inner)
+	if err != nil {
+		return gp, err
+	}
+	if payload, err := json.Marshal(gp); err == nil {
+		_ = d.store.put(kindGenesis, key, payload)
+	}
+	return gp, nil
+}
+
+func (d *DiskCachedChainContext) NetworkId() uint8 {
+	return d.inner.NetworkId()
+}
+
+func (d *DiskCachedChainContext) CurrentEpoch() (uint64, error) {
+	return d.CurrentEpochContext(context.Background())
+}
+
+func (d *DiskCachedChainContext) CurrentEpochContext(ctx context.Context) (uint64, error) {
+	return backend.CurrentEpochContext(ctx, d.inner)
+}
+
+func (d *DiskCachedChainContext) MaxTxFee() (uint64, error) {
+	return d.MaxTxFeeContext(context.Background())
+}
+
+func (d *DiskCachedChainContext) MaxTxFeeContext(ctx context.Context) (uint64, error) {
+	return backend.MaxTxFeeContext(ctx, d.inner)
+}
+
+func (d *DiskCachedChainContext) Tip() (uint64, error) {
+	return d.TipContext(context.Background())
+}
+
+func (d *DiskCachedChainContext) TipContext(ctx context.Context) (uint64, error) {
+	return backend.TipContext(ctx, d.inner)
+}
+
+func (d *DiskCachedChainContext) Utxos(address common.Address) ([]common.Utxo, error) {
+	return d.UtxosContext(context.Background(), address)
+}
+
+func (d *DiskCachedChainContext) UtxosContext(ctx context.Context, address common.Address) ([]common.Utxo, error) {
+	return backend.UtxosContext(ctx, d.inner, address)
+}
+
+func (d *DiskCachedChainContext) UtxosSeq(ctx context.Context, address common.Address) iter.Seq2[common.Utxo, error] {
+	return backend.UtxosSeq(ctx, d.inner, address)
+}
+
+func (d *DiskCachedChainContext) SubmitTx(txCbor []byte) (common.Blake2b256, error) {
+	return d.SubmitTxContext(context.Background(), txCbor)
+}
+
+func (d *DiskCachedChainContext) SubmitTxContext(ctx context.Context, txCbor []byte) (common.Blake2b256, error) {
+	return backend.SubmitTxContext(ctx, d.inner, txCbor)
+}
+
+func (d *DiskCachedChainContext) EvaluateTx(txCbor []byte, additionalUtxos []common.Utxo) (map[common.RedeemerKey]common.ExUnits, error) {
+	return d.EvaluateTxContext(context.Background(), txCbor, additionalUtxos)
+}
+
+func (d *DiskCachedChainContext) EvaluateTxContext(
+	ctx context.Context,
+	txCbor []byte,
+	additionalUtxos []common.Utxo,
+) (map[common.RedeemerKey]common.ExUnits, error) {
+	return backend.EvaluateTxContext(ctx, d.inner, txCbor, additionalUtxos)
+}
+
+func (d *DiskCachedChainContext) UtxoByRef(txHash common.Blake2b256, index uint32) (*common.Utxo, error) {
+	return d.UtxoByRefContext(context.Background(), txHash, index)
+}
+
+// UtxoByRefContext serves resolved references from disk as the validation
+// policy allows. Lookups that fail are not cached.
+func (d *DiskCachedChainContext) UtxoByRefContext(
+	ctx context.Context,
+	txHash common.Blake2b256,
+	index uint32,
+) (*common.Utxo, error) {
+	key := hex.EncodeToString(txHash[:]) + "-" + strconv.FormatUint(uint64(index), 10)
+	cached, storedAt, cacheErr := d.cachedUtxo(key, txHash, index)
+	if cacheErr == nil {
+		switch d.policy {
+		case ValidateNever:
+			return cached, nil
+		case ValidateAfter:
+			if d.now().Sub(storedAt) < d.utxoMaxAge {
+				return cached, nil
+			}
+		}
+	}
+
+	utxo, err := backend.UtxoByRefContext(ctx, d.inner, txHash, index)
+	switch {
+	case err == nil && utxo != nil:
+		if payload, err := cbor.Encode(utxo.Output); err == nil {
+			_ = d.store.put(kindUtxo, key, payload)
+		}
+		return utxo, nil
+	case errors.Is(err, backend.ErrUtxoNotFound):
+		// Spent or never created: stop serving it.
+		d.store.remove(kindUtxo, key)
+	case cacheErr == nil && d.policy == ValidateAlways && ctx.Err() == nil && backend.IsRetryable(err):
+		return cached, nil
+	}
+	return utxo, err
+}
+
+// cachedUtxo reads a UTxO from disk. An entry whose output no longer decodes
+// is dropped.
+func (d *DiskCachedChainContext) cachedUtxo(key string, txHash common.Blake2b256, index uint32) (*common.Utxo, time.Time, error) {
+	payload, storedAt, err := d.store.get(kindUtxo, key)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	output, err := ledger.NewTransactionOutputFromCbor(payload)
+	if err != nil {
+		d.store.remove(kindUtxo, key)
+		return nil, time.Time{}, fmt.Errorf("%w: %w", errCorrupt, err)
+	}
+	return &common.Utxo{
+		Id:     shelley.ShelleyTransactionInput{TxId: txHash, OutputIndex: index},
+		Output: output,
+	}, storedAt, nil
+}
+
+func (d *DiskCachedChainContext) ScriptCbor(scriptHash common.Blake2b224) ([]byte, error) {
+	return d.ScriptCborContext(context.Background(), scriptHash)
+}
+
+// ScriptCborContext caches scripts by hash, which identifies their content.
+// Cached bytes are served only if they still hash to scriptHash, so a file
+// changed in the directory is fetched again rather than trusted.
+func (d *DiskCachedChainContext) ScriptCborContext(
+	ctx context.Context,
+	scriptHash common.Blake2b224,
+) ([]byte, error) {
+	key := hex.EncodeToString(scriptHash[:])
+	// A missing, unreadable or mismatched entry falls through to the backend.
+	if payload, _, err := d.store.get(kindScript, key); err == nil {
+		if _, err := backendutil.ScriptFromCbor(scriptHash, payload); err == nil {
+			return payload, nil
+		}
+		d.store.remove(kindScript, key)
+	}
+	script, err := backend.ScriptCborContext(ctx, d.inner, scriptHash)
+	if err != nil {
+		return nil, err
+	}
+	_ = d.store.put(kindScript, key, script)
+	return script, nil
+}
+
+func (d *DiskCachedChainContext) DatumByHash(datumHash common.Blake2b256) (*common.Datum, error) {
+	return d.DatumByHashContext(context.Background(), datumHash)
+}
+
+func (d *DiskCachedChainContext) DatumByHashContext(
+	ctx context.Context,
+	datumHash common.Blake2b256,
+) (*common.Datum, error) {
+	return backend.DatumByHashContext(ctx, d.inner, datumHash)
+}
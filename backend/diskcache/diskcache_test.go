@@ -0,0 +1,325 @@
+package diskcache
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/blinklabs-io/gouroboros/cbor"
+	"github.com/blinklabs-io/gouroboros/ledger/babbage"
+	"github.com/blinklabs-io/gouroboros/ledger/common"
+	"github.com/blinklabs-io/gouroboros/ledger/mary"
+	"github.com/blinklabs-io/gouroboros/ledger/shelley"
+
+	"github.com/Salvionied/apollo/v2/backend"
+	"github.com/Salvionied/apollo/v2/backend/fixed"
+	"github.com/Salvionied/apollo/v2/internal/backendutil"
+)
+
+// stubContext serves the fixed test context and the scripts added with
+// addScript, counts the lookups that reach it and can fail UtxoByRef with a
+// scripted error.
+type stubContext struct {
+	*fixed.FixedChainContext
+	scripts map[common.Blake2b224][]byte
+	utxoErr error
+	calls   map[string]int
+}
+
+func newStub() *stubContext {
+	return &stubContext{
+		FixedChainContext: fixed.NewEmptyFixedChainContext(),
+		scripts:           make(map[common.Blake2b224][]byte),
+		calls:             make(map[string]int),
+	}
+}
+
+// addScript serves a Plutus V3 script of size bytes, all tag, and returns
+// its hash.
+func (s *stubContext) addScript(tag byte, size int) common.Blake2b224 {
+	script := bytes.Repeat([]byte{tag}, size)
+	hash := common.PlutusV3Script(script).Hash()
+	s.scripts[hash] = script
+	return hash
+}
+
+func (s *stubContext) GenesisParams() (backend.GenesisParameters, error) {
+	s.calls["GenesisParams"]++
+	return s.FixedChainContext.GenesisParams()
+}
+
+func (s *stubContext) ScriptCbor(scriptHash common.Blake2b224) ([]byte, error) {
+	s.calls["ScriptCbor"]++
+	if script, ok := s.scripts[scriptHash]; ok {
+		return script, nil
+	}
+	return s.FixedChainContext.ScriptCbor(scriptHash)
+}
+
+func (s *stubContext) UtxoByRef(txHash common.Blake2b256, index uint32) (*common.Utxo, error) {
+	s.calls["UtxoByRef"]++
+	if s.utxoErr != nil {
+		return nil, s.utxoErr
+	}
+	return s.FixedChainContext.UtxoByRef(txHash, index)
+}
+
+func open(t *testing.T, inner backend.ChainContext, cfg Config) *DiskCachedChainContext {
+	t.Helper()
+	d, err := NewDiskCachedChainContext(inner, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return d
+}
+
+func testUtxo(t *testing.T) common.Utxo {
+	t.Helper()
+	var raw [57]byte
+	raw[1] = 0xAA
+	raw[29] = 0xBB
+	addr, err := common.NewAddressFromBytes(raw[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	datum, err := backendutil.InlineDatumOption([]byte{0xd8, 0x79, 0x80})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return common.Utxo{
+		Id: shelley.ShelleyTransactionInput{TxId: common.Blake2b256{0x01}, OutputIndex: 2},
+		Output: babbage.BabbageTransactionOutput{
+			OutputAddress: addr,
+			OutputAmount:  mary.MaryTransactionOutputValue{Amount: 2_000_000},
+			DatumOption:   datum,
+		},
+	}
+}
+
+func TestResultsSurviveRestart(t *testing.T) {
+	dir := t.TempDir()
+	utxo := testUtxo(t)
+	first := newStub()
+	first.AddUtxoByRef(utxo)
+	scriptHash := first.addScript(0x0A, 5)
+	d := open(t, first, Config{Dir: dir, UtxoPolicy: ValidateNever})
+	wantScript, err := d.ScriptCbor(scriptHash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := d.UtxoByRef(utxo.Id.Id(), 2); err != nil {
+		t.Fatal(err)
+	}
+	wantGenesis, err := d.GenesisParams()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A new process: nothing in memory and a backend that knows nothing.
+	second := newStub()
+	d = open(t, second, Config{Dir: dir, UtxoPolicy: ValidateNever})
+	script, err := d.ScriptCbor(scriptHash)
+	if err != nil || !bytes.Equal(script, wantScript) {
+		t.Fatalf("ScriptCbor() = %x, %v; want %x", script, err, wantScript)
+	}
+	got, err := d.UtxoByRef(utxo.Id.Id(), 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Id.Id() != utxo.Id.Id() || got.Id.Index() != 2 {
+		t.Fatalf("UtxoByRef() id = %s#%d", got.Id.Id(), got.Id.Index())
+	}
+	wantOutput, _ := cbor.Encode(utxo.Output)
+	gotOutput, _ := cbor.Encode(got.Output)
+	if !bytes.Equal(gotOutput, wantOutput) {
+		t.Fatalf("UtxoByRef() output = %x, want %x", gotOutput, wantOutput)
+	}
+	if got.Output.Datum() == nil {
+		t.Fatal("cached output lost its inline datum")
+	}
+	genesis, err := d.GenesisParams()
+	if err != nil || genesis != wantGenesis {
+		t.Fatalf("GenesisParams() = %+v, %v; want %+v", genesis, err, wantGenesis)
+	}
+	if len(second.calls) != 0 {
+		t.Fatalf("backend calls after restart = %v, want none", second.calls)
+	}
+}
+
+func TestUtxoValidationPolicies(t *testing.T) {
+	utxo := testUtxo(t)
+	transient := &backend.StatusError{Backend: "test", StatusCode: 503}
+	for _, tc := range []struct {
+		name    string
+		cfg     Config
+		age     time.Duration
+		backend error
+		served  bool
+	}{
+		{"never trusts spent", Config{UtxoPolicy: ValidateNever}, time.Hour, backend.ErrUtxoNotFound, true},
+		{"after serves young", Config{UtxoPolicy: ValidateAfter, UtxoMaxAge: time.Minute}, time.Second, backend.ErrUtxoNotFound, true},
+		{"after drops old spent", Config{UtxoPolicy: ValidateAfter, UtxoMaxAge: time.Minute}, time.Hour, backend.ErrUtxoNotFound, false},
+		{"always drops spent", Config{UtxoPolicy: ValidateAlways}, 0, backend.ErrUtxoNotFound, false},
+		{"always survives outage", Config{UtxoPolicy: ValidateAlways}, 0, transient, true},
+		{"always reports rejection", Config{UtxoPolicy: ValidateAlways}, 0, errors.New("forbidden"), false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			inner := newStub()
+			inner.AddUtxoByRef(utxo)
+			tc.cfg.Dir = t.TempDir()
+			d := open(t, inner, tc.cfg)
+			clock := time.Unix(1_700_000_000, 0)
+			d.now = func() time.Time { return clock }
+			if _, err := d.UtxoByRef(utxo.Id.Id(), 2); err != nil {
+				t.Fatal(err)
+			}
+
+			clock = clock.Add(tc.age)
+			inner.utxoErr = tc.backend
+			got, err := d.UtxoByRef(utxo.Id.Id(), 2)
+			if tc.served != (err == nil && got != nil) {
+				t.Fatalf("UtxoByRef() = %v, %v; want served %v", got, err, tc.served)
+			}
+			if !tc.served && !errors.Is(err, tc.backend) {
+				t.Fatalf("UtxoByRef() error = %v, want %v", err, tc.backend)
+			}
+			_, statErr := os.Stat(filepath.Join(tc.cfg.Dir, "utxos", utxo.Id.Id().String()+"-2"))
+			if errors.Is(tc.backend, backend.ErrUtxoNotFound) && !tc.served && statErr == nil {
+				t.Fatal("spent UTxO was left on disk")
+			}
+		})
+	}
+}
+
+func TestUnreadableEntriesAreMisses(t *testing.T) {
+	scriptHash := newStub().addScript(0x0B, 2)
+	path := func(dir string) string {
+		return filepath.Join(dir, "scripts", scriptHash.String())
+	}
+	for _, tc := range []struct {
+		name   string
+		damage func([]byte) []byte
+	}{
+		{"other version", func(data []byte) []byte { data[4] = formatVersion + 1; return data }},
+		{"bad checksum", func(data []byte) []byte { data[len(data)-1] ^= 0xFF; return data }},
+		{"truncated", func(data []byte) []byte { return data[:headerSize-1] }},
+		{"wrong kind", func(data []byte) []byte { data[5] = byte(kindUtxo); return data }},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			inner := newStub()
+			inner.addScript(0x0B, 2)
+			d := open(t, inner, Config{Dir: dir})
+			if _, err := d.ScriptCbor(scriptHash); err != nil {
+				t.Fatal(err)
+			}
+			data, err := os.ReadFile(path(dir))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(path(dir), tc.damage(data), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			script, err := d.ScriptCbor(scriptHash)
+			if err != nil || !bytes.Equal(script, []byte{0x0B, 0x0B}) {
+				t.Fatalf("ScriptCbor() = %x, %v", script, err)
+			}
+			if inner.calls["ScriptCbor"] != 2 {
+				t.Fatalf("ScriptCbor calls = %d, want 2", inner.calls["ScriptCbor"])
+			}
+			// The entry was rewritten in the current format.
+			if data, err := os.ReadFile(path(dir)); err != nil || data[4] != formatVersion {
+				t.Fatalf("entry not rewritten: %v", err)
+			}
+		})
+	}
+}
+
+func TestScriptEntriesAreCheckedAgainstTheirHash(t *testing.T) {
+	dir := t.TempDir()
+	inner := newStub()
+	scriptHash := inner.addScript(0x0C, 3)
+	d := open(t, inner, Config{Dir: dir})
+	// A well-formed entry whose bytes belong to another script.
+	if err := d.store.put(kindScript, hex.EncodeToString(scriptHash[:]), []byte{0x0D, 0x0D, 0x0D}); err != nil {
+		t.Fatal(err)
+	}
+	script, err := d.ScriptCbor(scriptHash)
+	if err != nil || !bytes.Equal(script, []byte{0x0C, 0x0C, 0x0C}) {
+		t.Fatalf("ScriptCbor() = %x, %v", script, err)
+	}
+	if inner.calls["ScriptCbor"] != 1 {
+		t.Fatalf("ScriptCbor calls = %d, want 1", inner.calls["ScriptCbor"])
+	}
+	if _, err := d.ScriptCbor(scriptHash); err != nil || inner.calls["ScriptCbor"] != 1 {
+		t.Fatalf("replaced entry not served: %v, %d calls", err, inner.calls["ScriptCbor"])
+	}
+}
+
+func TestSizeLimits(t *testing.T) {
+	dir := t.TempDir()
+	inner := newStub()
+	d := open(t, inner, Config{Dir: dir, MaxEntryBytes: headerSize + 40, MaxTotalBytes: 3 * (headerSize + 32)})
+	clock := time.Unix(1_700_000_000, 0)
+	d.now = func() time.Time { clock = clock.Add(time.Second); return clock }
+
+	// Too large to store.
+	for range 2 {
+		if _, err := d.ScriptCbor(inner.addScript(0x01, 64)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if inner.calls["ScriptCbor"] != 2 {
+		t.Fatalf("oversized script was cached: %d calls", inner.calls["ScriptCbor"])
+	}
+
+	// Three entries fit; reading the first keeps it while the fourth evicts
+	// the least recently used one.
+	for _, tag := range []byte{0x02, 0x03, 0x04, 0x02, 0x05, 0x02, 0x03} {
+		if _, err := d.ScriptCbor(inner.addScript(tag, 32)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// 2, 3, 4 miss; 2 hits; 5 evicts 3; 2 hits; 3 misses again.
+	if got := inner.calls["ScriptCbor"] - 2; got != 5 {
+		t.Fatalf("ScriptCbor calls = %d, want 5", got)
+	}
+	var total int64
+	files, err := d.store.files()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range files {
+		total += f.size
+	}
+	if total > 3*(headerSize+32) {
+		t.Fatalf("cache holds %d bytes, over its limit", total)
+	}
+}
+
+func TestNewDiskCachedChainContextRejectsInvalidConfig(t *testing.T) {
+	var nilStub *stubContext
+	for _, tc := range []struct {
+		name  string
+		inner backend.ChainContext
+		cfg   Config
+		want  string
+	}{
+		{"nil context", nilStub, Config{Dir: t.TempDir()}, "nil"},
+		{"no directory", newStub(), Config{}, "directory is required"},
+		{"max age missing", newStub(), Config{Dir: t.TempDir(), UtxoPolicy: ValidateAfter}, "UtxoMaxAge"},
+		{"unknown policy", newStub(), Config{Dir: t.TempDir(), UtxoPolicy: 7}, "unknown"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := NewDiskCachedChainContext(tc.inner, tc.cfg)
+			if err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Fatalf("NewDiskCachedChainContext() error = %v, want %q", err, tc.want)
+			}
+		})
+	}
+}
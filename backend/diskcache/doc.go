@@ -0,0 +1,7 @@
+// Package diskcache wraps any chain backend with a cache on disk for results
+// that do not change: script CBOR by hash, resolved UTxO references and
+// genesis parameters. Short-lived tools that restart often reuse them instead
+// of fetching them again. Each entry is a small versioned file, and the
+// directory is kept under a size limit by evicting the least recently used
+// entries.
+package diskcache
@@ -0,0 +1,275 @@
+package diskcache
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Entry files start with a fixed header:
+//
+//	offset  size  field
+//	0       4     magic "APDC"
+//	4       1     format version
+//	5       1     entry kind
+//	6       8     stored at, Unix seconds, big endian
+//	14      4     CRC-32 (IEEE) of the payload, big endian
+//	18      -     payload
+//
+// An entry with another format version, a bad checksum or a kind that does
+// not match its directory is treated as missing, so a cache directory written
+// by a different release is rebuilt rather than misread.
+const (
+	formatVersion = 1
+	headerSize    = 18
+)
+
+var magic = [4]byte{'A', 'P', 'D', 'C'}
+
+type kind uint8
+
+const (
+	kindScript kind = iota + 1
+	kindUtxo
+	kindGenesis
+)
+
+// dir is the subdirectory holding the entries of a kind.
+func (k kind) dir() string {
+	switch k {
+	case kindScript:
+		return "scripts"
+	case kindUtxo:
+		return "utxos"
+	case kindGenesis:
+		return "genesis"
+	default:
+		return "unknown"
+	}
+}
+
+var kinds = []kind{kindScript, kindUtxo, kindGenesis}
+
+// errCorrupt marks an entry file that cannot be read back.
+var errCorrupt = errors.New("corrupt cache entry")
+
+// store keeps entries as one file per key, written to a temporary file and
+// renamed into place so readers never see a partial entry. Reads refresh an
+// entry's modification time, and the oldest entries are removed when the
+// directory grows past its limit.
+type store struct {
+	root          string
+	maxEntryBytes int64
+	maxTotalBytes int64
+	now           func() time.Time
+
+	mu    sync.Mutex
+	total int64
+}
+
+func openStore(root string, maxEntryBytes, maxTotalBytes int64, now func() time.Time) (*store, error) {
+	for _, k := range kinds {
+		if err := os.MkdirAll(filepath.Join(root, k.dir()), 0o700); err != nil {
+			return nil, fmt.Errorf("failed to create cache directory: %w", err)
+		}
+	}
+	s := &store{root: root, maxEntryBytes: maxEntryBytes, maxTotalBytes: maxTotalBytes, now: now}
+	files, err := s.files()
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range files {
+		s.total += f.size
+	}
+	return s, nil
+}
+
+func (s *store) path(k kind, key string) string {
+	return filepath.Join(s.root, k.dir(), key)
+}
+
+// get returns the payload stored for key and when it was stored. A missing
+// entry returns fs.ErrNotExist; an unreadable one is removed and returns
+// errCorrupt.
+func (s *store) get(k kind, key string) ([]byte, time.Time, error) {
+	path := s.path(k, key)
+	data, err := s.read(path)
+	if err != nil {
+		if errors.Is(err, errCorrupt) {
+			s.remove(k, key)
+		}
+		return nil, time.Time{}, err
+	}
+	payload, storedAt, err := decodeEntry(k, data)
+	if err != nil {
+		s.remove(k, key)
+		return nil, time.Time{}, err
+	}
+	// Reads keep an entry from being the next one evicted.
+	now := s.now()
+	_ = os.Chtimes(path, now, now)
+	return payload, storedAt, nil
+}
+
+// read reads an entry file, refusing files over the entry limit.
+func (s *store) read(path string) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	data, err := io.ReadAll(io.LimitReader(f, s.maxEntryBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > s.maxEntryBytes {
+		return nil, fmt.Errorf("%w: exceeds %d bytes", errCorrupt, s.maxEntryBytes)
+	}
+	return data, nil
+}
+
+// put stores payload for key. Payloads over the entry limit are not stored.
+func (s *store) put(k kind, key string, payload []byte) error {
+	if int64(headerSize+len(payload)) > s.maxEntryBytes {
+		return nil
+	}
+	dir := filepath.Join(s.root, k.dir())
+	tmp, err := os.CreateTemp(dir, ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	now := s.now()
+	data := encodeEntry(k, now, payload)
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	path := s.path(k, key)
+	var replaced int64
+	if info, err := os.Stat(path); err == nil {
+		replaced = info.Size()
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return err
+	}
+	_ = os.Chtimes(path, now, now)
+	s.mu.Lock()
+	s.total += int64(len(data)) - replaced
+	over := s.total > s.maxTotalBytes
+	s.mu.Unlock()
+	if over {
+		return s.evict()
+	}
+	return nil
+}
+
+func (s *store) remove(k kind, key string) {
+	path := s.path(k, key)
+	info, err := os.Stat(path)
+	if err != nil {
+		return
+	}
+	if os.Remove(path) == nil {
+		s.mu.Lock()
+		s.total -= info.Size()
+		s.mu.Unlock()
+	}
+}
+
+type storedFile struct {
+	path    string
+	size    int64
+	modTime time.Time
+}
+
+func (s *store) files() ([]storedFile, error) {
+	var files []storedFile
+	for _, k := range kinds {
+		entries, err := os.ReadDir(filepath.Join(s.root, k.dir()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read cache directory: %w", err)
+		}
+		for _, entry := range entries {
+			info, err := entry.Info()
+			if err != nil || !info.Mode().IsRegular() || strings.HasPrefix(entry.Name(), ".tmp-") {
+				continue
+			}
+			files = append(files, storedFile{
+				path:    filepath.Join(s.root, k.dir(), entry.Name()),
+				size:    info.Size(),
+				modTime: info.ModTime(),
+			})
+		}
+	}
+	return files, nil
+}
+
+// evict removes the least recently used entries until the directory is back
+// under its limit. It rescans the directory, since other processes may share
+// it.
+func (s *store) evict() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	files, err := s.files()
+	if err != nil {
+		return err
+	}
+	s.total = 0
+	for _, f := range files {
+		s.total += f.size
+	}
+	slices.SortFunc(files, func(a, b storedFile) int { return a.modTime.Compare(b.modTime) })
+	for _, f := range files {
+		if s.total <= s.maxTotalBytes {
+			break
+		}
+		if err := os.Remove(f.path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+		s.total -= f.size
+	}
+	return nil
+}
+
+func encodeEntry(k kind, storedAt time.Time, payload []byte) []byte {
+	data := make([]byte, headerSize, headerSize+len(payload))
+	copy(data, magic[:])
+	data[4] = formatVersion
+	data[5] = byte(k)
+	binary.BigEndian.PutUint64(data[6:14], uint64(storedAt.Unix()))
+	binary.BigEndian.PutUint32(data[14:18], crc32.ChecksumIEEE(payload))
+	return append(data, payload...)
+}
+
+func decodeEntry(k kind, data []byte) ([]byte, time.Time, error) {
+	if len(data) < headerSize || !bytes.Equal(data[:4], magic[:]) {
+		return nil, time.Time{}, fmt.Errorf("%w: bad header", errCorrupt)
+	}
+	if data[4] != formatVersion {
+		return nil, time.Time{}, fmt.Errorf("%w: format version %d, want %d", errCorrupt, data[4], formatVersion)
+	}
+	if kind(data[5]) != k {
+		return nil, time.Time{}, fmt.Errorf("%w: kind %d, want %d", errCorrupt, data[5], k)
+	}
+	payload := data[headerSize:]
+	if crc32.ChecksumIEEE(payload) != binary.BigEndian.Uint32(data[14:18]) {
+		return nil, time.Time{}, fmt.Errorf("%w: checksum mismatch", errCorrupt)
+	}
+	storedAt := time.Unix(int64(binary.BigEndian.Uint64(data[6:14])), 0)
+	return payload, storedAt, nil
+}
@@ -1,5 +1,6 @@
 // Package backend defines the ChainContext interface Apollo uses to reach a
 // Cardano chain, along with the protocol and genesis parameter types, the
-// optional context-aware and capability-reporting extensions, and helpers
-// shared by the concrete backend implementations.
+// optional context-aware, capability-reporting, datum lookup and UTxO
+// streaming extensions, and helpers shared by the concrete backend
+// implementations.
 package backend
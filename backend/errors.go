@@ -0,0 +1,160 @@
+package backend
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+// StatusError is a non-success HTTP response from a provider API. Backends
+// return it so callers can tell rate limiting and outages from requests the
+// provider rejected.
+type StatusError struct {
+	Backend    string
+	StatusCode int
+	// Message is a bounded snippet of the response body.
+	Message string
+	// RetryAfter is the delay requested by a Retry-After header, or zero.
+	RetryAfter time.Duration
+}
+
+func (e *StatusError) Error() string {
+	if e == nil {
+		return ""
+	}
+	return fmt.Sprintf("%s API error %d: %s", e.Backend, e.StatusCode, e.Message)
+}
+
+// Temporary reports whether the status means the same request may succeed
+// later: request timeouts, rate limiting and server-side outages.
+func (e *StatusError) Temporary() bool {
+	switch e.StatusCode {
+	case http.StatusRequestTimeout,
+		http.StatusTooManyRequests,
+		http.StatusInternalServerError,
+		http.StatusBadGateway,
+		http.StatusServiceUnavailable,
+		http.StatusGatewayTimeout:
+		return true
+	default:
+		return false
+	}
+}
+
+// RetryableError marks a failure as transient. Backends that are not HTTP
+// based wrap their transport's transient errors in it.
+type RetryableError struct {
+	Err error
+	// RetryAfter is the delay the backend asked for, or zero.
+	RetryAfter time.Duration
+}
+
+// NewRetryableError marks err as transient, with an optional requested delay.
+func NewRetryableError(err error, retryAfter time.Duration) *RetryableError {
+	return &RetryableError{Err: err, RetryAfter: retryAfter}
+}
+
+func (e *RetryableError) Error() string {
+	if e == nil || e.Err == nil {
+		return ""
+	}
+	return e.Err.Error()
+}
+
+func (e *RetryableError) Unwrap() error {
+	return e.Err
+}
+
+// PermanentError marks a failure as not worth retrying, overriding the
+// classification its wrapped error would otherwise get.
+type PermanentError struct {
+	Err error
+}
+
+// NewPermanentError marks err as permanent.
+func NewPermanentError(err error) *PermanentError {
+	return &PermanentError{Err: err}
+}
+
+func (e *PermanentError) Error() string {
+	if e == nil || e.Err == nil {
+		return ""
+	}
+	return e.Err.Error()
+}
+
+func (e *PermanentError) Unwrap() error {
+	return e.Err
+}
+
+// IsRetryable classifies a backend error. PermanentError and RetryableError
+// decide explicitly; a StatusError is retryable when its status is
+// temporary; transport failures - timeouts, refused or reset connections and
+// truncated responses - are retryable. Anything else, including
+// ErrUnsupported, ErrUtxoNotFound and cancellation, is permanent.
+func IsRetryable(err error) bool {
+	if err == nil {
+		return false
+	}
+	var permanent *PermanentError
+	if errors.As(err, &permanent) {
+		return false
+	}
+	var retryable *RetryableError
+	if errors.As(err, &retryable) {
+		return true
+	}
+	var status *StatusError
+	if errors.As(err, &status) {
+		return status.Temporary()
+	}
+	if errors.Is(err, context.Canceled) ||
+		errors.Is(err, ErrUnsupported) ||
+		errors.Is(err, ErrUtxoNotFound) {
+		return false
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) ||
+		errors.Is(err, io.EOF) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, context.DeadlineExceeded)
+}
+
+// RetryAfter returns the delay a backend asked for before the next attempt,
+// from a RetryableError or StatusError in err's chain.
+func RetryAfter(err error) (time.Duration, bool) {
+	var retryable *RetryableError
+	if errors.As(err, &retryable) && retryable.RetryAfter > 0 {
+		return retryable.RetryAfter, true
+	}
+	var status *StatusError
+	if errors.As(err, &status) && status.RetryAfter > 0 {
+		return status.RetryAfter, true
+	}
+	return 0, false
+}
+
+// ParseRetryAfter parses a Retry-After header value, either delay seconds or
+// an HTTP date, relative to now. It returns zero for an empty, invalid or
+// past value.
+func ParseRetryAfter(header string, now time.Time) time.Duration {
+	if header == "" {
+		return 0
+	}
+	if seconds, err := strconv.ParseUint(header, 10, 32); err == nil {
+		return time.Duration(seconds) * time.Second
+	}
+	when, err := http.ParseTime(header)
+	if err != nil || !when.After(now) {
+		return 0
+	}
+	return when.Sub(now)
+}
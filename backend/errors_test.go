@@ -0,0 +1,82 @@
+package backend
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestIsRetryable(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"nil", nil, false},
+		{"rate limited", &StatusError{StatusCode: http.StatusTooManyRequests}, true},
+		{"unavailable", fmt.Errorf("query: %w", &StatusError{StatusCode: http.StatusServiceUnavailable}), true},
+		{"bad request", &StatusError{StatusCode: http.StatusBadRequest}, false},
+		{"not found", &StatusError{StatusCode: http.StatusNotFound}, false},
+		{"marked retryable", NewRetryableError(errors.New("busy"), 0), true},
+		{"marked permanent", NewPermanentError(io.ErrUnexpectedEOF), false},
+		{"permanent status", NewPermanentError(&StatusError{StatusCode: http.StatusBadGateway}), false},
+		{"truncated response", io.ErrUnexpectedEOF, true},
+		{"connection refused", syscall.ECONNREFUSED, true},
+		{"deadline", context.DeadlineExceeded, true},
+		{"canceled", context.Canceled, false},
+		{"unsupported", NewUnsupportedError("test", CapabilityTip), false},
+		{"utxo not found", ErrUtxoNotFound, false},
+		{"other", errors.New("transaction rejected"), false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := IsRetryable(tc.err); got != tc.want {
+				t.Fatalf("IsRetryable(%v) = %v, want %v", tc.err, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	err := fmt.Errorf("tip: %w", &StatusError{StatusCode: http.StatusTooManyRequests, RetryAfter: 2 * time.Second})
+	if delay, ok := RetryAfter(err); !ok || delay != 2*time.Second {
+		t.Fatalf("RetryAfter() = %v, %v", delay, ok)
+	}
+	if delay, ok := RetryAfter(NewRetryableError(io.EOF, time.Second)); !ok || delay != time.Second {
+		t.Fatalf("RetryAfter() = %v, %v", delay, ok)
+	}
+	if _, ok := RetryAfter(io.EOF); ok {
+		t.Fatal("RetryAfter() reported a delay for an error without one")
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC)
+	for _, tc := range []struct {
+		header string
+		want   time.Duration
+	}{
+		{"", 0},
+		{"120", 2 * time.Minute},
+		{"0", 0},
+		{"-5", 0},
+		{"soon", 0},
+		{now.Add(30 * time.Second).Format(http.TimeFormat), 30 * time.Second},
+		{now.Add(-time.Minute).Format(http.TimeFormat), 0},
+	} {
+		if got := ParseRetryAfter(tc.header, now); got != tc.want {
+			t.Errorf("ParseRetryAfter(%q) = %v, want %v", tc.header, got, tc.want)
+		}
+	}
+}
+
+func TestStatusErrorMessage(t *testing.T) {
+	err := &StatusError{Backend: "blockfrost", StatusCode: http.StatusForbidden, Message: "invalid project token"}
+	if got, want := err.Error(), "blockfrost API error 403: invalid project token"; got != want {
+		t.Fatalf("Error() = %q, want %q", got, want)
+	}
+}
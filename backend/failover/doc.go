@@ -0,0 +1,5 @@
+// Package failover wraps an ordered list of chain backends so a call that
+// fails on one backend is retried on the next. Backends that keep failing are
+// skipped for a while by a circuit breaker, and a submission is only retried
+// after checking that the earlier attempt did not reach the chain.
+package failover
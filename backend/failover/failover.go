@@ -0,0 +1,553 @@
+package failover
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"iter"
+	"sync"
+	"time"
+
+	"github.com/blinklabs-io/gouroboros/ledger/common"
+
+	"github.com/Salvionied/apollo/v2/backend"
+	"github.com/Salvionied/apollo/v2/internal/backendutil"
+)
+
+const (
+	backendName = "failover backend"
+
+	defaultFailureThreshold = 3
+	defaultCooldown         = 30 * time.Second
+)
+
+// ErrNoHealthyBackend is returned when every backend able to serve a call has
+// an open circuit breaker.
+var ErrNoHealthyBackend = errors.New("no healthy backend")
+
+// ErrSubmitOutcomeUnknown is returned, with the transaction id, when a
+// submission failed after possibly reaching a backend and the next backend
+// rejected the resubmission. A transaction waiting in a mempool is rejected
+// this way, so it may still land: wait for it rather than build a replacement
+// that could pay twice.
+var ErrSubmitOutcomeUnknown = errors.New("transaction submission outcome unknown")
+
+// Config describes a FailoverChainContext.
+type Config struct {
+	// Backends are tried in order for every call. Required; all backends
+	// must be on the same network.
+	Backends []backend.ChainContext
+	// Retryable reports whether an error means the call should be retried on
+	// the next backend. Other errors are returned to the caller as the
+	// backend's answer. Nil uses Retryable.
+	Retryable func(error) bool
+	// FailureThreshold is the number of consecutive retryable failures that
+	// open a backend's circuit breaker. Zero uses 3.
+	FailureThreshold int
+	// Cooldown is how long an open circuit breaker skips its backend before
+	// letting calls through again. Zero uses 30 seconds.
+	Cooldown time.Duration
+}
+
+// Retryable is the default error classification. It fails over on transport
+// failures and temporary provider statuses such as 429 or 503, as
+// backend.IsRetryable reports them, and treats any other error, such as a
+// rejected transaction or a missing UTxO, as the backend's answer.
+func Retryable(err error) bool {
+	return backend.IsRetryable(err)
+}
+
+// FailoverChainContext implements backend.ChainContext over an ordered list
+// of backends. Each call goes to the first healthy backend that reports the
+// capability; a retryable error moves on to the next one.
+type FailoverChainContext struct {
+	backends  []*member
+	retryable func(error) bool
+	threshold int
+	cooldown  time.Duration
+	networkId uint8
+	now       func() time.Time
+}
+
+var (
+	_ backend.ContextChainContext  = (*FailoverChainContext)(nil)
+	_ backend.ContextDatumResolver = (*FailoverChainContext)(nil)
+	_ backend.UtxoStreamer         = (*FailoverChainContext)(nil)
+)
+
+// member is a backend and its circuit breaker state.
+type member struct {
+	index        int
+	chainContext backend.ChainContext
+
+	mu        sync.Mutex
+	failures  int
+	lastError error
+	openUntil time.Time
+}
+
+// BackendHealth describes the circuit breaker of one backend.
+type BackendHealth struct {
+	// Index is the backend's position in Config.Backends.
+	Index int
+	// Healthy is false while the circuit breaker is open.
+	Healthy bool
+	// ConsecutiveFailures counts retryable failures since the last success.
+	ConsecutiveFailures int
+	// LastError is the most recent retryable failure, if any.
+	LastError error
+	// OpenUntil is when an open circuit breaker next lets a call through.
+	OpenUntil time.Time
+}
+
+// NewFailoverChainContext creates a failover context from cfg. It fails when
+// no backend is given, a backend is nil, or the backends disagree on the
+// network.
+func NewFailoverChainContext(cfg Config) (*FailoverChainContext, error) {
+	if len(cfg.Backends) == 0 {
+		return nil, errors.New("at least one backend is required")
+	}
+	f := &FailoverChainContext{
+		retryable: cfg.Retryable,
+		threshold: cfg.FailureThreshold,
+		cooldown:  cfg.Cooldown,
+		now:       time.Now,
+	}
+	if f.retryable == nil {
+		f.retryable = Retryable
+	}
+	if f.threshold <= 0 {
+		f.threshold = defaultFailureThreshold
+	}
+	if f.cooldown <= 0 {
+		f.cooldown = defaultCooldown
+	}
+	for i, chainContext := range cfg.Backends {
+		if backend.IsNilChainContext(chainContext) {
+			return nil, fmt.Errorf("backend %d is nil", i)
+		}
+		networkId := chainContext.NetworkId()
+		if i > 0 && networkId != f.networkId {
+			return nil, fmt.Errorf("backend %d is on network %d, backend 0 is on network %d", i, networkId, f.networkId)
+		}
+		f.networkId = networkId
+		f.backends = append(f.backends, &member{index: i, chainContext: chainContext})
+	}
+	return f, nil
+}
+
+// Capabilities reports the union of the backends' capabilities.
+func (f *FailoverChainContext) Capabilities() backend.CapabilitySet {
+	var capabilities backend.CapabilitySet
+	for _, m := range f.backends {
+		capabilities |= backend.CapabilitiesOf(m.chainContext)
+	}
+	return capabilities
+}
+
+// Health reports the circuit breaker state of each backend, in order.
+func (f *FailoverChainContext) Health() []BackendHealth {
+	now := f.now()
+	health := make([]BackendHealth, 0, len(f.backends))
+	for _, m := range f.backends {
+		m.mu.Lock()
+		health = append(health, BackendHealth{
+			Index:               m.index,
+			Healthy:             !now.Before(m.openUntil),
+			ConsecutiveFailures: m.failures,
+			LastError:           m.lastError,
+			OpenUntil:           m.openUntil,
+		})
+		m.mu.Unlock()
+	}
+	return health
+}
+
+// available reports whether the circuit breaker lets a call through. Once
+// the cooldown has passed, calls are let through again; a failure then
+// reopens the breaker immediately because the failure count is still at the
+// threshold.
+func (m *member) available(now time.Time) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return !now.Before(m.openUntil)
+}
+
+func (m *member) succeeded() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.failures = 0
+	m.lastError = nil
+	m.openUntil = time.Time{}
+}
+
+func (m *member) failed(err error, now time.Time, threshold int, cooldown time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.failures++
+	m.lastError = err
+	if m.failures >= threshold {
+		m.openUntil = now.Add(cooldown)
+	}
+}
+
+// outcome is how an attempt on one backend ended.
+type outcome int
+
+const (
+	// answered means the backend returned a result or a non-retryable error.
+	answered outcome = iota
+	// skipped means the backend does not support the call.
+	skipped
+	// failed means the call should be retried on the next backend.
+	failed
+	// canceled means the caller's context ended.
+	canceled
+)
+
+// classify records the result of a call on m in its circuit breaker.
+func (f *FailoverChainContext) classify(ctx context.Context, m *member, err error) outcome {
+	switch {
+	case err == nil:
+		m.succeeded()
+		return answered
+	case errors.Is(err, backend.ErrUnsupported):
+		return skipped
+	case ctx.Err() != nil:
+		return canceled
+	case f.retryable(err):
+		m.failed(err, f.now(), f.threshold, f.cooldown)
+		return failed
+	default:
+		m.succeeded()
+		return answered
+	}
+}
+
+// call runs fn on each healthy backend that reports capability, in order,
+// until one answers.
+func call[T any](
+	ctx context.Context,
+	f *FailoverChainContext,
+	capability backend.Capability,
+	fn func(backend.ChainContext) (T, error),
+) (T, error) {
+	var zero T
+	var errs []error
+	supported, open := false, false
+	for _, m := range f.backends {
+		if !backend.Supports(m.chainContext, capability) {
+			continue
+		}
+		supported = true
+		if !m.available(f.now()) {
+			open = true
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return zero, err
+		}
+		result, err := fn(m.chainContext)
+		switch f.classify(ctx, m, err) {
+		case answered, canceled:
+			return result, err
+		default:
+			errs = append(errs, fmt.Errorf("backend %d: %w", m.index, err))
+		}
+	}
+	return zero, f.exhausted(ctx, capability, supported, open, errs)
+}
+
+// exhausted builds the error for a call no backend answered.
+func (f *FailoverChainContext) exhausted(
+	ctx context.Context,
+	capability backend.Capability,
+	supported, open bool,
+	errs []error,
+) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if !supported {
+		return backend.NewUnsupportedError(backendName, capability)
+	}
+	if open {
+		errs = append(errs, fmt.Errorf("%w for %s", ErrNoHealthyBackend, capability))
+	}
+	return errors.Join(errs...)
+}
+
+func (f *FailoverChainContext) ProtocolParams() (backend.ProtocolParameters, error) {
+	return f.ProtocolParamsContext(context.Background())
+}
+
+func (f *FailoverChainContext) ProtocolParamsContext(ctx context.Context) (backend.ProtocolParameters, error) {
+	return call(ctx, f, backend.CapabilityProtocolParams, func(cc backend.ChainContext) (backend.ProtocolParameters, error) {
+		return backend.ProtocolParamsContext(ctx, cc)
+	})
+}
+
+func (f *FailoverChainContext) GenesisParams() (backend.GenesisParameters, error) {
+	return f.GenesisParamsContext(context.Background())
+}
+
+func (f *FailoverChainContext) GenesisParamsContext(ctx context.Context) (backend.GenesisParameters, error) {
+	return call(ctx, f, backend.CapabilityGenesisParams, func(cc backend.ChainContext) (backend.GenesisParameters, error) {
+		return backend.GenesisParamsContext(ctx, cc)
+	})
+}
+
+func (f *FailoverChainContext) NetworkId() uint8 {
+	return f.networkId
+}
+
+func (f *FailoverChainContext) CurrentEpoch() (uint64, error) {
+	return f.CurrentEpochContext(context.Background())
+}
+
+func (f *FailoverChainContext) CurrentEpochContext(ctx context.Context) (uint64, error) {
+	return call(ctx, f, backend.CapabilityCurrentEpoch, func(cc backend.ChainContext) (uint64, error) {
+		return backend.CurrentEpochContext(ctx, cc)
+	})
+}
+
+func (f *FailoverChainContext) MaxTxFee() (uint64, error) {
+	return f.MaxTxFeeContext(context.Background())
+}
+
+func (f *FailoverChainContext) MaxTxFeeContext(ctx context.Context) (uint64, error) {
+	return call(ctx, f, backend.CapabilityMaxTxFee, func(cc backend.ChainContext) (uint64, error) {
+		return backend.MaxTxFeeContext(ctx, cc)
+	})
+}
+
+func (f *FailoverChainContext) Tip() (uint64, error) {
+	return f.TipContext(context.Background())
+}
+
+func (f *FailoverChainContext) TipContext(ctx context.Context) (uint64, error) {
+	return call(ctx, f, backend.CapabilityTip, func(cc backend.ChainContext) (uint64, error) {
+		return backend.TipContext(ctx, cc)
+	})
+}
+
+func (f *FailoverChainContext) Utxos(address common.Address) ([]common.Utxo, error) {
+	return f.UtxosContext(context.Background(), address)
+}
+
+func (f *FailoverChainContext) UtxosContext(ctx context.Context, address common.Address) ([]common.Utxo, error) {
+	return call(ctx, f, backend.CapabilityUtxos, func(cc backend.ChainContext) ([]common.Utxo, error) {
+		return backend.UtxosContext(ctx, cc, address)
+	})
+}
+
+// UtxosSeq streams from the first healthy backend. When a stream fails with
+// a retryable error it continues on the next backend, skipping the UTxOs
+// already yielded, so the caller never sees one twice.
+func (f *FailoverChainContext) UtxosSeq(ctx context.Context, address common.Address) iter.Seq2[common.Utxo, error] {
+	type ref struct {
+		txHash common.Blake2b256
+		index  uint32
+	}
+	return func(yield func(common.Utxo, error) bool) {
+		yielded := make(map[ref]struct{})
+		var errs []error
+		supported, open := false, false
+		for _, m := range f.backends {
+			if !backend.Supports(m.chainContext, backend.CapabilityUtxos) {
+				continue
+			}
+			supported = true
+			if !m.available(f.now()) {
+				open = true
+				continue
+			}
+			if err := ctx.Err(); err != nil {
+				yield(common.Utxo{}, err)
+				return
+			}
+			var failure error
+			for utxo, err := range backend.UtxosSeq(ctx, m.chainContext, address) {
+				if err != nil {
+					failure = err
+					break
+				}
+				key := ref{txHash: utxo.Id.Id(), index: utxo.Id.Index()}
+				if _, ok := yielded[key]; ok {
+					continue
+				}
+				yielded[key] = struct{}{}
+				if !yield(utxo, nil) {
+					m.succeeded()
+					return
+				}
+			}
+			switch f.classify(ctx, m, failure) {
+			case answered, canceled:
+				if failure != nil {
+					yield(common.Utxo{}, failure)
+				}
+				return
+			default:
+				errs = append(errs, fmt.Errorf("backend %d: %w", m.index, failure))
+			}
+		}
+		yield(common.Utxo{}, f.exhausted(ctx, backend.CapabilityUtxos, supported, open, errs))
+	}
+}
+
+func (f *FailoverChainContext) SubmitTx(txCbor []byte) (common.Blake2b256, error) {
+	return f.SubmitTxContext(context.Background(), txCbor)
+}
+
+// SubmitTxContext submits through the first healthy backend. A retryable
+// failure leaves it unknown whether the transaction reached the chain, so
+// before trying the next backend the transaction's first output is looked up
+// with UtxoByRef: if it exists the transaction is on chain and its hash is
+// returned, and if the lookup fails the transaction is not resubmitted. A
+// transaction still in a mempool is not visible this way, so when the
+// resubmission is rejected the status is checked again, and a transaction
+// still not on chain is reported with ErrSubmitOutcomeUnknown.
+func (f *FailoverChainContext) SubmitTxContext(ctx context.Context, txCbor []byte) (common.Blake2b256, error) {
+	var errs []error
+	supported, open, pending := false, false, false
+	for _, m := range f.backends {
+		if !backend.Supports(m.chainContext, backend.CapabilitySubmitTx) {
+			continue
+		}
+		supported = true
+		if !m.available(f.now()) {
+			open = true
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return common.Blake2b256{}, err
+		}
+		if pending {
+			txHash, onChain, err := f.onChain(ctx, txCbor)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("not resubmitting: %w", err))
+				return common.Blake2b256{}, errors.Join(errs...)
+			}
+			if onChain {
+				return txHash, nil
+			}
+		}
+		txHash, err := backend.SubmitTxContext(ctx, m.chainContext, txCbor)
+		switch f.classify(ctx, m, err) {
+		case answered:
+			if pending && err != nil {
+				return f.resubmissionRejected(ctx, txCbor, m, err, errs)
+			}
+			return txHash, err
+		case canceled:
+			return txHash, err
+		case failed:
+			pending = true
+		}
+		errs = append(errs, fmt.Errorf("backend %d: %w", m.index, err))
+	}
+	return common.Blake2b256{}, f.exhausted(ctx, backend.CapabilitySubmitTx, supported, open, errs)
+}
+
+// resubmissionRejected handles a rejection after an earlier submission may
+// have been accepted: the transaction is on chain, or its outcome is unknown.
+// The error is permanent so retry middleware does not submit it again.
+func (f *FailoverChainContext) resubmissionRejected(
+	ctx context.Context,
+	txCbor []byte,
+	m *member,
+	rejection error,
+	earlier []error,
+) (common.Blake2b256, error) {
+	txHash, onChain, err := f.onChain(ctx, txCbor)
+	if err == nil && onChain {
+		return txHash, nil
+	}
+	cause := fmt.Errorf("backend %d rejected the resubmission: %w", m.index, rejection)
+	if err != nil {
+		cause = errors.Join(cause, err)
+	}
+	return txHash, backend.NewPermanentError(fmt.Errorf(
+		"%w: transaction %s (earlier: %v): %w",
+		ErrSubmitOutcomeUnknown, txHash.String(), errors.Join(earlier...), cause,
+	))
+}
+
+// onChain reports whether the transaction's first output exists, which
+// means an earlier submission reached the chain.
+func (f *FailoverChainContext) onChain(ctx context.Context, txCbor []byte) (common.Blake2b256, bool, error) {
+	txHash, err := backendutil.TransactionId(txCbor)
+	if err != nil {
+		return common.Blake2b256{}, false, fmt.Errorf("cannot check transaction status: %w", err)
+	}
+	_, err = f.UtxoByRefContext(ctx, txHash, 0)
+	switch {
+	case err == nil:
+		return txHash, true, nil
+	case errors.Is(err, backend.ErrUtxoNotFound):
+		return txHash, false, nil
+	default:
+		return txHash, false, fmt.Errorf("cannot check status of transaction %s: %w", txHash.String(), err)
+	}
+}
+
+func (f *FailoverChainContext) EvaluateTx(txCbor []byte, additionalUtxos []common.Utxo) (map[common.RedeemerKey]common.ExUnits, error) {
+	return f.EvaluateTxContext(context.Background(), txCbor, additionalUtxos)
+}
+
+// EvaluateTxContext only uses backends that honour additionalUtxos when any
+// are given.
+func (f *FailoverChainContext) EvaluateTxContext(
+	ctx context.Context,
+	txCbor []byte,
+	additionalUtxos []common.Utxo,
+) (map[common.RedeemerKey]common.ExUnits, error) {
+	capability := backend.CapabilityEvaluateTx
+	if len(additionalUtxos) > 0 {
+		capability |= backend.CapabilityEvaluateTxAdditionalUtxos
+	}
+	return call(ctx, f, capability, func(cc backend.ChainContext) (map[common.RedeemerKey]common.ExUnits, error) {
+		return backend.EvaluateTxContext(ctx, cc, txCbor, additionalUtxos)
+	})
+}
+
+func (f *FailoverChainContext) UtxoByRef(txHash common.Blake2b256, index uint32) (*common.Utxo, error) {
+	return f.UtxoByRefContext(context.Background(), txHash, index)
+}
+
+func (f *FailoverChainContext) UtxoByRefContext(
+	ctx context.Context,
+	txHash common.Blake2b256,
+	index uint32,
+) (*common.Utxo, error) {
+	return call(ctx, f, backend.CapabilityUtxoByRef, func(cc backend.ChainContext) (*common.Utxo, error) {
+		return backend.UtxoByRefContext(ctx, cc, txHash, index)
+	})
+}
+
+func (f *FailoverChainContext) ScriptCbor(scriptHash common.Blake2b224) ([]byte, error) {
+	return f.ScriptCborContext(context.Background(), scriptHash)
+}
+
+func (f *FailoverChainContext) ScriptCborContext(
+	ctx context.Context,
+	scriptHash common.Blake2b224,
+) ([]byte, error) {
+	return call(ctx, f, backend.CapabilityScriptCbor, func(cc backend.ChainContext) ([]byte, error) {
+		return backend.ScriptCborContext(ctx, cc, scriptHash)
+	})
+}
+
+func (f *FailoverChainContext) DatumByHash(datumHash common.Blake2b256) (*common.Datum, error) {
+	return f.DatumByHashContext(context.Background(), datumHash)
+}
+
+func (f *FailoverChainContext) DatumByHashContext(
+	ctx context.Context,
+	datumHash common.Blake2b256,
+) (*common.Datum, error) {
+	return call(ctx, f, backend.CapabilityDatumByHash, func(cc backend.ChainContext) (*common.Datum, error) {
+		return backend.DatumByHashContext(ctx, cc, datumHash)
+	})
+}
@@ -0,0 +1,385 @@
+package failover
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/blinklabs-io/gouroboros/cbor"
+	"github.com/blinklabs-io/gouroboros/ledger/common"
+
+	"github.com/Salvionied/apollo/v2/backend"
+	"github.com/Salvionied/apollo/v2/backend/fixed"
+	"github.com/Salvionied/apollo/v2/internal/backendutil"
+)
+
+// stubContext serves the fixed test context but reports its own
+// capabilities, fails with scripted errors and counts calls.
+type stubContext struct {
+	*fixed.FixedChainContext
+	capabilities backend.CapabilitySet
+	networkId    uint8
+	tipErr       error
+	submitErr    error
+	utxoByRef    func() (*common.Utxo, error)
+	calls        map[string]int
+}
+
+func newStub(capabilities backend.Capability) *stubContext {
+	return &stubContext{
+		FixedChainContext: fixed.NewEmptyFixedChainContext(),
+		capabilities:      backend.CapabilitySet(capabilities),
+		calls:             make(map[string]int),
+	}
+}
+
+func (s *stubContext) Capabilities() backend.CapabilitySet { return s.capabilities }
+func (s *stubContext) NetworkId() uint8                    { return s.networkId }
+
+func (s *stubContext) Tip() (uint64, error) {
+	s.calls["Tip"]++
+	if s.tipErr != nil {
+		return 0, s.tipErr
+	}
+	return 42, nil
+}
+
+func (s *stubContext) SubmitTx(txCbor []byte) (common.Blake2b256, error) {
+	s.calls["SubmitTx"]++
+	if s.submitErr != nil {
+		return common.Blake2b256{}, s.submitErr
+	}
+	return backendutil.TransactionId(txCbor)
+}
+
+func (s *stubContext) EvaluateTx(_ []byte, _ []common.Utxo) (map[common.RedeemerKey]common.ExUnits, error) {
+	s.calls["EvaluateTx"]++
+	return map[common.RedeemerKey]common.ExUnits{}, nil
+}
+
+func (s *stubContext) UtxoByRef(txHash common.Blake2b256, index uint32) (*common.Utxo, error) {
+	s.calls["UtxoByRef"]++
+	if s.utxoByRef != nil {
+		return s.utxoByRef()
+	}
+	return s.FixedChainContext.UtxoByRef(txHash, index)
+}
+
+var errTransport = io.ErrUnexpectedEOF
+
+// testClock is a manually advanced clock for the circuit breakers.
+type testClock struct{ now time.Time }
+
+func (c *testClock) Now() time.Time          { return c.now }
+func (c *testClock) Advance(d time.Duration) { c.now = c.now.Add(d) }
+
+func newFailover(t *testing.T, cfg Config) (*FailoverChainContext, *testClock) {
+	t.Helper()
+	f, err := NewFailoverChainContext(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clock := &testClock{now: time.Unix(1_700_000_000, 0)}
+	f.now = clock.Now
+	return f, clock
+}
+
+func testTx(t *testing.T) []byte {
+	t.Helper()
+	txCbor, err := cbor.Encode([]any{map[uint]any{2: 170000}, map[uint]any{}, true, nil})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return txCbor
+}
+
+func TestReadsFailOverOnRetryableErrors(t *testing.T) {
+	first := newStub(backend.AllCapabilities)
+	first.tipErr = errTransport
+	second := newStub(backend.AllCapabilities)
+	f, _ := newFailover(t, Config{Backends: []backend.ChainContext{first, second}})
+
+	tip, err := f.Tip()
+	if err != nil || tip != 42 {
+		t.Fatalf("Tip() = %d, %v", tip, err)
+	}
+	if first.calls["Tip"] != 1 || second.calls["Tip"] != 1 {
+		t.Fatalf("calls = %v, %v", first.calls, second.calls)
+	}
+	if health := f.Health(); health[0].ConsecutiveFailures != 1 || !errors.Is(health[0].LastError, errTransport) {
+		t.Fatalf("Health()[0] = %+v", health[0])
+	}
+}
+
+func TestPermanentErrorsAreReturned(t *testing.T) {
+	first := newStub(backend.AllCapabilities)
+	second := newStub(backend.AllCapabilities)
+	f, _ := newFailover(t, Config{Backends: []backend.ChainContext{first, second}})
+
+	if _, err := f.UtxoByRef(common.Blake2b256{}, 0); !errors.Is(err, backend.ErrUtxoNotFound) {
+		t.Fatalf("UtxoByRef() error = %v, want ErrUtxoNotFound", err)
+	}
+	if second.calls["UtxoByRef"] != 0 {
+		t.Fatal("a definite answer was retried on the next backend")
+	}
+}
+
+func TestRetryableClassifiesErrors(t *testing.T) {
+	for _, tc := range []struct {
+		err  error
+		want bool
+	}{
+		{io.ErrUnexpectedEOF, true},
+		{&backend.StatusError{StatusCode: 503}, true},
+		{&backend.StatusError{StatusCode: 400}, false},
+		{backend.ErrUtxoNotFound, false},
+	} {
+		if got := Retryable(tc.err); got != tc.want {
+			t.Errorf("Retryable(%v) = %t, want %t", tc.err, got, tc.want)
+		}
+	}
+}
+
+func TestUnsupportedBackendsAreSkipped(t *testing.T) {
+	noEval := newStub(backend.AllCapabilities &^ backend.CapabilityEvaluateTx)
+	noAdditional := newStub(backend.AllCapabilities &^ backend.CapabilityEvaluateTxAdditionalUtxos)
+	full := newStub(backend.AllCapabilities)
+	f, _ := newFailover(t, Config{Backends: []backend.ChainContext{noEval, noAdditional, full}})
+
+	if _, err := f.EvaluateTx(nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	if noEval.calls["EvaluateTx"] != 0 || noAdditional.calls["EvaluateTx"] != 1 {
+		t.Fatalf("EvaluateTx() calls = %v, %v", noEval.calls, noAdditional.calls)
+	}
+	if _, err := f.EvaluateTx(nil, []common.Utxo{{}}); err != nil {
+		t.Fatal(err)
+	}
+	if noAdditional.calls["EvaluateTx"] != 1 || full.calls["EvaluateTx"] != 1 {
+		t.Fatal("EvaluateTx() with additional UTxOs went to a backend that ignores them")
+	}
+
+	// The fixed context returns ErrUnsupported for script lookups, which
+	// moves on without counting against the backend.
+	scripts := newStub(backend.AllCapabilities)
+	f, _ = newFailover(t, Config{Backends: []backend.ChainContext{scripts}})
+	if _, err := f.ScriptCbor(common.Blake2b224{}); !errors.Is(err, backend.ErrUnsupported) {
+		t.Fatalf("ScriptCbor() error = %v, want ErrUnsupported", err)
+	}
+	if f.Health()[0].ConsecutiveFailures != 0 {
+		t.Fatal("an unsupported call counted as a failure")
+	}
+
+	f, _ = newFailover(t, Config{Backends: []backend.ChainContext{newStub(backend.CapabilityUtxos)}})
+	var unsupported *backend.UnsupportedError
+	if _, err := f.Tip(); !errors.As(err, &unsupported) || unsupported.Capability != backend.CapabilityTip {
+		t.Fatalf("Tip() error = %v, want UnsupportedError", err)
+	}
+}
+
+func TestCircuitBreakerSkipsFailingBackend(t *testing.T) {
+	flaky := newStub(backend.AllCapabilities)
+	flaky.tipErr = errTransport
+	standby := newStub(backend.AllCapabilities)
+	f, clock := newFailover(t, Config{
+		Backends:         []backend.ChainContext{flaky, standby},
+		FailureThreshold: 2,
+		Cooldown:         time.Minute,
+	})
+
+	for range 4 {
+		if _, err := f.Tip(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if flaky.calls["Tip"] != 2 || standby.calls["Tip"] != 4 {
+		t.Fatalf("calls while open = %d, %d", flaky.calls["Tip"], standby.calls["Tip"])
+	}
+	if health := f.Health(); health[0].Healthy || !health[1].Healthy {
+		t.Fatalf("Health() = %+v", health)
+	}
+
+	// After the cooldown the backend is tried again and a success closes it.
+	clock.Advance(time.Minute)
+	flaky.tipErr = nil
+	if _, err := f.Tip(); err != nil {
+		t.Fatal(err)
+	}
+	if flaky.calls["Tip"] != 3 {
+		t.Fatal("backend was not retried after the cooldown")
+	}
+	if health := f.Health(); !health[0].Healthy || health[0].ConsecutiveFailures != 0 {
+		t.Fatalf("Health()[0] = %+v", health[0])
+	}
+}
+
+func TestAllBackendsOpen(t *testing.T) {
+	only := newStub(backend.AllCapabilities)
+	only.tipErr = errTransport
+	f, _ := newFailover(t, Config{Backends: []backend.ChainContext{only}, FailureThreshold: 1})
+	if _, err := f.Tip(); !errors.Is(err, errTransport) {
+		t.Fatalf("Tip() error = %v, want the transport error", err)
+	}
+	if _, err := f.Tip(); !errors.Is(err, ErrNoHealthyBackend) {
+		t.Fatalf("Tip() error = %v, want ErrNoHealthyBackend", err)
+	}
+	if only.calls["Tip"] != 1 {
+		t.Fatal("a backend with an open circuit breaker was called")
+	}
+}
+
+func TestSubmitTxChecksStatusBeforeResubmitting(t *testing.T) {
+	txCbor := testTx(t)
+	txHash, err := backendutil.TransactionId(txCbor)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("already on chain", func(t *testing.T) {
+		first := newStub(backend.AllCapabilities)
+		first.submitErr = errTransport
+		first.utxoByRef = func() (*common.Utxo, error) { return &common.Utxo{}, nil }
+		second := newStub(backend.AllCapabilities)
+		f, _ := newFailover(t, Config{Backends: []backend.ChainContext{first, second}})
+		got, err := f.SubmitTx(txCbor)
+		if err != nil || got != txHash {
+			t.Fatalf("SubmitTx() = %s, %v", got, err)
+		}
+		if second.calls["SubmitTx"] != 0 {
+			t.Fatal("a transaction already on chain was resubmitted")
+		}
+	})
+
+	t.Run("not on chain", func(t *testing.T) {
+		first := newStub(backend.AllCapabilities)
+		first.submitErr = errTransport
+		second := newStub(backend.AllCapabilities)
+		f, _ := newFailover(t, Config{Backends: []backend.ChainContext{first, second}})
+		got, err := f.SubmitTx(txCbor)
+		if err != nil || got != txHash {
+			t.Fatalf("SubmitTx() = %s, %v", got, err)
+		}
+		if first.calls["UtxoByRef"] != 1 || second.calls["SubmitTx"] != 1 {
+			t.Fatalf("calls = %v, %v", first.calls, second.calls)
+		}
+	})
+
+	t.Run("status unknown", func(t *testing.T) {
+		first := newStub(backend.AllCapabilities)
+		first.submitErr = errTransport
+		first.utxoByRef = func() (*common.Utxo, error) { return nil, errors.New("service unavailable") }
+		second := newStub(backend.AllCapabilities)
+		f, _ := newFailover(t, Config{Backends: []backend.ChainContext{first, second}})
+		if _, err := f.SubmitTx(txCbor); err == nil || !strings.Contains(err.Error(), "not resubmitting") {
+			t.Fatalf("SubmitTx() error = %v, want not resubmitting", err)
+		}
+		if second.calls["SubmitTx"] != 0 {
+			t.Fatal("a transaction with unknown status was resubmitted")
+		}
+	})
+
+	t.Run("rejected", func(t *testing.T) {
+		first := newStub(backend.AllCapabilities)
+		first.submitErr = errors.New("BadInputsUTxO")
+		second := newStub(backend.AllCapabilities)
+		f, _ := newFailover(t, Config{Backends: []backend.ChainContext{first, second}})
+		if _, err := f.SubmitTx(txCbor); err == nil || err.Error() != "BadInputsUTxO" {
+			t.Fatalf("SubmitTx() error = %v, want the rejection", err)
+		}
+		if second.calls["SubmitTx"] != 0 || first.calls["UtxoByRef"] != 0 {
+			t.Fatal("a rejected transaction was retried")
+		}
+	})
+
+	t.Run("resubmission rejected while pending", func(t *testing.T) {
+		first := newStub(backend.AllCapabilities)
+		first.submitErr = context.DeadlineExceeded
+		second := newStub(backend.AllCapabilities)
+		second.submitErr = errors.New("BadInputsUTxO: inputs already spent")
+		f, _ := newFailover(t, Config{Backends: []backend.ChainContext{first, second}})
+		got, err := f.SubmitTx(txCbor)
+		if !errors.Is(err, ErrSubmitOutcomeUnknown) || got != txHash {
+			t.Fatalf("SubmitTx() = %s, %v; want the id and ErrSubmitOutcomeUnknown", got, err)
+		}
+		if backend.IsRetryable(err) {
+			t.Fatal("an unknown submission outcome is retryable")
+		}
+		if first.calls["UtxoByRef"] != 2 {
+			t.Fatalf("status checks = %d, want 2", first.calls["UtxoByRef"])
+		}
+
+		// The transaction lands before the status is checked again.
+		lookups := 0
+		first.utxoByRef = func() (*common.Utxo, error) {
+			if lookups++; lookups == 1 {
+				return nil, backend.ErrUtxoNotFound
+			}
+			return &common.Utxo{}, nil
+		}
+		if got, err := f.SubmitTx(txCbor); err != nil || got != txHash {
+			t.Fatalf("SubmitTx() = %s, %v; want the id", got, err)
+		}
+	})
+
+	t.Run("unsupported first backend", func(t *testing.T) {
+		first := newStub(backend.AllCapabilities &^ backend.CapabilitySubmitTx)
+		second := newStub(backend.AllCapabilities)
+		f, _ := newFailover(t, Config{Backends: []backend.ChainContext{first, second}})
+		if _, err := f.SubmitTx(txCbor); err != nil {
+			t.Fatal(err)
+		}
+		if first.calls["SubmitTx"] != 0 || first.calls["UtxoByRef"] != 0 || second.calls["SubmitTx"] != 1 {
+			t.Fatalf("calls = %v, %v", first.calls, second.calls)
+		}
+	})
+}
+
+func TestCapabilitiesAreTheUnionOfBackends(t *testing.T) {
+	f, _ := newFailover(t, Config{Backends: []backend.ChainContext{
+		newStub(backend.CapabilityUtxos),
+		newStub(backend.CapabilitySubmitTx),
+	}})
+	want := backend.CapabilitySet(backend.CapabilityUtxos | backend.CapabilitySubmitTx)
+	if got := backend.CapabilitiesOf(f); got != want {
+		t.Fatalf("Capabilities() = %b, want %b", got, want)
+	}
+}
+
+func TestNewFailoverChainContextRejectsInvalidConfig(t *testing.T) {
+	var nilStub *stubContext
+	mainnet := newStub(backend.AllCapabilities)
+	mainnet.networkId = 1
+	for _, tc := range []struct {
+		name     string
+		backends []backend.ChainContext
+		want     string
+	}{
+		{"empty", nil, "at least one backend"},
+		{"typed nil", []backend.ChainContext{nilStub}, "backend 0 is nil"},
+		{"network mismatch", []backend.ChainContext{newStub(backend.AllCapabilities), mainnet}, "network"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := NewFailoverChainContext(Config{Backends: tc.backends})
+			if err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Fatalf("NewFailoverChainContext() error = %v, want %q", err, tc.want)
+			}
+		})
+	}
+}
+
+func TestCanceledCallIsNotRetried(t *testing.T) {
+	first := newStub(backend.AllCapabilities)
+	second := newStub(backend.AllCapabilities)
+	f, _ := newFailover(t, Config{Backends: []backend.ChainContext{first, second}})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := f.TipContext(ctx); !errors.Is(err, context.Canceled) {
+		t.Fatalf("TipContext() error = %v, want context.Canceled", err)
+	}
+	if first.calls["Tip"] != 0 || second.calls["Tip"] != 0 {
+		t.Fatal("a canceled call reached a backend")
+	}
+}
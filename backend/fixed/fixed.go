@@ -3,13 +3,14 @@ package fixed
 import (
 	"encoding/hex"
 	"errors"
-	"math/big"
+	"fmt"
 	"strconv"
 	"sync"
 
 	"github.com/blinklabs-io/gouroboros/ledger/common"
 
 	"github.com/Salvionied/apollo/v2/backend"
+	"github.com/Salvionied/apollo/v2/internal/backendutil"
 )
 
 // FixedChainContext is a backend with preset protocol/genesis parameters and UTxOs.
@@ -21,6 +22,7 @@ type FixedChainContext struct {
 	mu             sync.RWMutex
 	utxos          map[string][]common.Utxo // keyed by address string
 	utxosByRef     map[string]common.Utxo   // keyed by "txid#index"
+	datums         map[common.Blake2b256]common.Datum
 }
 
 // Capabilities reports the deterministic in-memory operations provided by the
@@ -30,7 +32,8 @@ func (f *FixedChainContext) Capabilities() backend.CapabilitySet {
 		backend.CapabilityGenesisParams |
 		backend.CapabilityMaxTxFee |
 		backend.CapabilityUtxos |
-		backend.CapabilityUtxoByRef)
+		backend.CapabilityUtxoByRef |
+		backend.CapabilityDatumByHash)
 }
 
 // NewFixedChainContext creates a new FixedChainContext with the given protocol parameters.
@@ -41,6 +44,7 @@ func NewFixedChainContext(pp backend.ProtocolParameters, gp backend.GenesisParam
 		networkId:      networkId,
 		utxos:          make(map[string][]common.Utxo),
 		utxosByRef:     make(map[string]common.Utxo),
+		datums:         make(map[common.Blake2b256]common.Datum),
 	}
 }
 
@@ -89,28 +93,31 @@ func (f *FixedChainContext) AddUtxoByRef(utxo common.Utxo) {
 	f.utxosByRef[utxoRefKey(utxo.Id.Id(), utxo.Id.Index())] = utxo
 }
 
+// AddDatum registers a datum for resolution by hash (DatumByHash) and returns
+// its hash, so tests can lock outputs with the hash alone.
+func (f *FixedChainContext) AddDatum(datum common.Datum) (common.Blake2b256, error) {
+	datumCbor := datum.Cbor()
+	if len(datumCbor) == 0 {
+		var err error
+		datumCbor, err = datum.MarshalCBOR()
+		if err != nil {
+			return common.Blake2b256{}, err
+		}
+		datum.SetCbor(datumCbor)
+	}
+	hash := common.Blake2b256Hash(datumCbor)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.datums[hash] = datum
+	return hash, nil
+}
+
 func utxoRefKey(txHash common.Blake2b256, index uint32) string {
 	return hex.EncodeToString(txHash.Bytes()) + "#" + strconv.Itoa(int(index))
 }
 
 func (f *FixedChainContext) ProtocolParams() (backend.ProtocolParameters, error) {
-	pp := f.protocolParams
-	if pp.CostModels != nil {
-		cm := make(map[string][]int64, len(pp.CostModels))
-		for k, v := range pp.CostModels {
-			dup := make([]int64, len(v))
-			copy(dup, v)
-			cm[k] = dup
-		}
-		pp.CostModels = cm
-	}
-	if pp.MinFeeRefScriptCostPerByteRational != nil {
-		pp.MinFeeRefScriptCostPerByteRational = new(big.Rat).Set(pp.MinFeeRefScriptCostPerByteRational)
-	}
-	if pp.MinFeeReferenceScriptsMultiplierRational != nil {
-		pp.MinFeeReferenceScriptsMultiplierRational = new(big.Rat).Set(pp.MinFeeReferenceScriptsMultiplierRational)
-	}
-	return pp, nil
+	return backendutil.CloneProtocolParams(f.protocolParams), nil
 }
 
 func (f *FixedChainContext) GenesisParams() (backend.GenesisParameters, error) {
@@ -167,9 +174,18 @@ func (f *FixedChainContext) UtxoByRef(txHash common.Blake2b256, index uint32) (*
 		u := utxo
 		return &u, nil
 	}
-	return nil, errors.New("utxo not found in fixed chain context")
+	return nil, fmt.Errorf("%w in fixed chain context", backend.ErrUtxoNotFound)
 }
 
 func (f *FixedChainContext) ScriptCbor(_ common.Blake2b224) ([]byte, error) {
 	return nil, backend.NewUnsupportedError("fixed chain context", backend.CapabilityScriptCbor)
 }
+
+func (f *FixedChainContext) DatumByHash(datumHash common.Blake2b256) (*common.Datum, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	if datum, ok := f.datums[datumHash]; ok {
+		return &datum, nil
+	}
+	return nil, errors.New("datum not found in fixed chain context")
+}
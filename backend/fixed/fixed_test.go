@@ -11,7 +11,7 @@ import (
 
 func TestFixedCapabilitiesAndUnsupportedOperations(t *testing.T) {
 	ctx := NewEmptyFixedChainContext()
-	if !backend.Supports(ctx, backend.CapabilityProtocolParams|backend.CapabilityUtxoByRef) {
+	if !backend.Supports(ctx, backend.CapabilityProtocolParams|backend.CapabilityUtxoByRef|backend.CapabilityDatumByHash) {
 		t.Fatal("expected fixed context capabilities to be reported")
 	}
 	if backend.Supports(ctx, backend.CapabilityCurrentEpoch|backend.CapabilityTip|backend.CapabilitySubmitTx|backend.CapabilityEvaluateTx) {
@@ -0,0 +1,3 @@
+// Package koios implements the Apollo chain backend on top of the
+// community-run Koios HTTP API, which needs no API key.
+package koios
@@ -0,0 +1,1031 @@
+package koios
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"math"
+	"math/big"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/blinklabs-io/gouroboros/cbor"
+	"github.com/blinklabs-io/gouroboros/ledger/babbage"
+	"github.com/blinklabs-io/gouroboros/ledger/common"
+	"github.com/blinklabs-io/gouroboros/ledger/mary"
+	"github.com/blinklabs-io/gouroboros/ledger/shelley"
+
+	"github.com/Salvionied/apollo/v2/backend"
+	"github.com/Salvionied/apollo/v2/internal/backendutil"
+)
+
+// KoiosChainContext implements backend.ChainContext using the Koios API.
+type KoiosChainContext struct {
+	baseUrl   string
+	token     string
+	networkId uint8
+	client    *http.Client
+
+	mu             sync.Mutex
+	cachedParams   *backend.ProtocolParameters
+	cachedGenesis  *backend.GenesisParameters
+	paramsCacheAt  time.Time
+	genesisCacheAt time.Time
+}
+
+var (
+	_ backend.ContextChainContext = (*KoiosChainContext)(nil)
+	_ backend.UtxoStreamer        = (*KoiosChainContext)(nil)
+)
+
+// Capabilities reports the ChainContext feature set implemented by Koios.
+// Every method is served: evaluation goes through the Koios Ogmios
+// passthrough, which accepts additional UTxOs, and datums are looked up with
+// datum_info.
+func (k *KoiosChainContext) Capabilities() backend.CapabilitySet {
+	return backend.CapabilitySet(backend.AllCapabilities | backend.CapabilityDatumByHash)
+}
+
+const (
+	cacheExpiry              = 5 * time.Minute
+	maxKoiosResponseBytes    = 10 * 1024 * 1024
+	maxKoiosErrorSnippetSize = 512
+	koiosPageSize            = 1000
+	maxKoiosUtxoPages        = 1000
+)
+
+// NewKoiosChainContext creates a new Koios backend. baseUrl is the Koios
+// instance, e.g. https://api.koios.rest or https://preprod.koios.rest; the
+// /api/v1 path is added when missing. token is an optional Koios API token
+// for higher rate limits and may be empty.
+func NewKoiosChainContext(baseUrl string, networkId uint8, token string) *KoiosChainContext {
+	baseUrl = strings.TrimRight(baseUrl, "/")
+	if !strings.HasSuffix(baseUrl, "/api/v1") {
+		baseUrl += "/api/v1"
+	}
+	return &KoiosChainContext{
+		baseUrl:   baseUrl,
+		token:     token,
+		networkId: networkId,
+		client:    &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+func (k *KoiosChainContext) requestContext(
+	ctx context.Context,
+	method, path string,
+	body io.Reader,
+	contentType string,
+) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, method, k.baseUrl+path, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if k.token != "" {
+		req.Header.Set("Authorization", "Bearer "+k.token)
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	resp, err := k.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp == nil || resp.Body == nil {
+		return nil, errors.New("koios: nil response")
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxKoiosResponseBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxKoiosResponseBytes {
+		return nil, fmt.Errorf("koios response body exceeds %d bytes", maxKoiosResponseBytes)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, &backend.StatusError{
+			Backend:    "koios",
+			StatusCode: resp.StatusCode,
+			Message:    errorSnippet(data),
+			RetryAfter: backend.ParseRetryAfter(resp.Header.Get("Retry-After"), time.Now()),
+		}
+	}
+	return data, nil
+}
+
+// postJSON POSTs payload as JSON and decodes the response into result.
+func (k *KoiosChainContext) postJSON(ctx context.Context, path string, payload, result any) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal koios request: %w", err)
+	}
+	data, err := k.requestContext(ctx, "POST", path, bytes.NewReader(body), "application/json")
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, result)
+}
+
+// getJSON GETs path and decodes the response into result.
+func (k *KoiosChainContext) getJSON(ctx context.Context, path string, result any) error {
+	data, err := k.requestContext(ctx, "GET", path, nil, "")
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, result)
+}
+
+func (k *KoiosChainContext) ProtocolParams() (backend.ProtocolParameters, error) {
+	return k.ProtocolParamsContext(context.Background())
+}
+
+func (k *KoiosChainContext) ProtocolParamsContext(ctx context.Context) (backend.ProtocolParameters, error) {
+	k.mu.Lock()
+	if k.cachedParams != nil && time.Since(k.paramsCacheAt) < cacheExpiry {
+		pp := backendutil.CloneProtocolParams(*k.cachedParams)
+		k.mu.Unlock()
+		return pp, nil
+	}
+	k.mu.Unlock()
+
+	var rows []koiosEpochParams
+	if err := k.getJSON(ctx, "/epoch_params?order=epoch_no.desc&limit=1", &rows); err != nil {
+		return backend.ProtocolParameters{}, err
+	}
+	if len(rows) == 0 {
+		return backend.ProtocolParameters{}, errors.New("koios returned no epoch parameters")
+	}
+	pp, err := rows[0].toProtocolParams()
+	if err != nil {
+		return backend.ProtocolParameters{}, err
+	}
+
+	cached := backendutil.CloneProtocolParams(pp)
+	k.mu.Lock()
+	k.cachedParams = &cached
+	k.paramsCacheAt = time.Now()
+	k.mu.Unlock()
+
+	return pp, nil
+}
+
+func (k *KoiosChainContext) GenesisParams() (backend.GenesisParameters, error) {
+	return k.GenesisParamsContext(context.Background())
+}
+
+func (k *KoiosChainContext) GenesisParamsContext(ctx context.Context) (backend.GenesisParameters, error) {
+	k.mu.Lock()
+	if k.cachedGenesis != nil && time.Since(k.genesisCacheAt) < cacheExpiry {
+		gp := *k.cachedGenesis
+		k.mu.Unlock()
+		return gp, nil
+	}
+	k.mu.Unlock()
+
+	var rows []koiosGenesis
+	if err := k.getJSON(ctx, "/genesis", &rows); err != nil {
+		return backend.GenesisParameters{}, err
+	}
+	if len(rows) == 0 {
+		return backend.GenesisParameters{}, errors.New("koios returned no genesis parameters")
+	}
+	gp, err := rows[0].toGenesisParams()
+	if err != nil {
+		return backend.GenesisParameters{}, err
+	}
+
+	k.mu.Lock()
+	k.cachedGenesis = &gp
+	k.genesisCacheAt = time.Now()
+	k.mu.Unlock()
+
+	return gp, nil
+}
+
+func (k *KoiosChainContext) NetworkId() uint8 {
+	return k.networkId
+}
+
+func (k *KoiosChainContext) CurrentEpoch() (uint64, error) {
+	return k.CurrentEpochContext(context.Background())
+}
+
+func (k *KoiosChainContext) CurrentEpochContext(ctx context.Context) (uint64, error) {
+	tip, err := k.tip(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return tip.EpochNo.uint64("epoch_no")
+}
+
+func (k *KoiosChainContext) MaxTxFee() (uint64, error) {
+	return k.MaxTxFeeContext(context.Background())
+}
+
+func (k *KoiosChainContext) MaxTxFeeContext(ctx context.Context) (uint64, error) {
+	pp, err := k.ProtocolParamsContext(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return backend.ComputeMaxTxFee(pp)
+}
+
+func (k *KoiosChainContext) Tip() (uint64, error) {
+	return k.TipContext(context.Background())
+}
+
+func (k *KoiosChainContext) TipContext(ctx context.Context) (uint64, error) {
+	tip, err := k.tip(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return tip.AbsSlot.uint64("abs_slot")
+}
+
+func (k *KoiosChainContext) tip(ctx context.Context) (koiosTip, error) {
+	var rows []koiosTip
+	if err := k.getJSON(ctx, "/tip", &rows); err != nil {
+		return koiosTip{}, err
+	}
+	if len(rows) == 0 {
+		return koiosTip{}, errors.New("koios returned no tip")
+	}
+	return rows[0], nil
+}
+
+func (k *KoiosChainContext) Utxos(address common.Address) ([]common.Utxo, error) {
+	return k.UtxosContext(context.Background(), address)
+}
+
+func (k *KoiosChainContext) UtxosContext(ctx context.Context, address common.Address) ([]common.Utxo, error) {
+	return backend.CollectUtxos(k.UtxosSeq(ctx, address))
+}
+
+// UtxosSeq pages through address_utxos, fetching each page only once the
+// previous one has been consumed. Koios caps a response at koiosPageSize
+// rows, so a full page means there may be more.
+func (k *KoiosChainContext) UtxosSeq(ctx context.Context, address common.Address) iter.Seq2[common.Utxo, error] {
+	request := struct {
+		Addresses []string `json:"_addresses"`
+		Extended  bool     `json:"_extended"`
+	}{
+		Addresses: []string{address.String()},
+		Extended:  true,
+	}
+	return func(yield func(common.Utxo, error) bool) {
+		for page := range maxKoiosUtxoPages {
+			path := fmt.Sprintf(
+				"/address_utxos?order=tx_hash.asc,tx_index.asc&offset=%d&limit=%d",
+				page*koiosPageSize,
+				koiosPageSize,
+			)
+			var rows []koiosUtxo
+			if err := k.postJSON(ctx, path, request, &rows); err != nil {
+				yield(common.Utxo{}, err)
+				return
+			}
+			for _, row := range rows {
+				utxo, err := row.toUtxo()
+				if err != nil {
+					yield(common.Utxo{}, fmt.Errorf("failed to parse UTxO %s#%s: %w", row.TxHash, row.TxIndex, err))
+					return
+				}
+				if !yield(utxo, nil) {
+					return
+				}
+			}
+			if len(rows) < koiosPageSize {
+				return
+			}
+		}
+		yield(common.Utxo{}, fmt.Errorf("UTxO pagination exceeded %d pages; results may be incomplete", maxKoiosUtxoPages))
+	}
+}
+
+func (k *KoiosChainContext) SubmitTx(txCbor []byte) (common.Blake2b256, error) {
+	return k.SubmitTxContext(context.Background(), txCbor)
+}
+
+func (k *KoiosChainContext) SubmitTxContext(ctx context.Context, txCbor []byte) (common.Blake2b256, error) {
+	data, err := k.requestContext(ctx, "POST", "/submittx", bytes.NewReader(txCbor), "application/cbor")
+	if err != nil {
+		return common.Blake2b256{}, err
+	}
+	var txHash string
+	if err := json.Unmarshal(data, &txHash); err != nil {
+		return common.Blake2b256{}, fmt.Errorf("unexpected submit response: %w", err)
+	}
+	return parseBlake2b256(txHash, "tx hash")
+}
+
+func (k *KoiosChainContext) EvaluateTx(txCbor []byte, additionalUtxos []common.Utxo) (map[common.RedeemerKey]common.ExUnits, error) {
+	return k.EvaluateTxContext(context.Background(), txCbor, additionalUtxos)
+}
+
+// EvaluateTxContext sends an Ogmios evaluateTransaction request through the
+// Koios /ogmios passthrough. Additional UTxOs are encoded in the Ogmios v6
+// additionalUtxo shape.
+func (k *KoiosChainContext) EvaluateTxContext(
+	ctx context.Context,
+	txCbor []byte,
+	additionalUtxos []common.Utxo,
+) (map[common.RedeemerKey]common.ExUnits, error) {
+	params := ogmiosEvaluateParams{}
+	params.Transaction.Cbor = hex.EncodeToString(txCbor)
+	for i, utxo := range additionalUtxos {
+		encoded, err := ogmiosUtxoFromUtxo(utxo)
+		if err != nil {
+			return nil, fmt.Errorf("invalid additional UTxO at index %d: %w", i, err)
+		}
+		params.AdditionalUtxo = append(params.AdditionalUtxo, encoded)
+	}
+	request := ogmiosRequest{
+		Jsonrpc: "2.0",
+		Method:  "evaluateTransaction",
+		Params:  params,
+	}
+	body, err := json.Marshal(request)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal evaluate request: %w", err)
+	}
+	data, err := k.requestContext(ctx, "POST", "/ogmios", bytes.NewReader(body), "application/json")
+	if err != nil {
+		return nil, err
+	}
+	return parseEvaluateResponse(data)
+}
+
+func (k *KoiosChainContext) UtxoByRef(txHash common.Blake2b256, index uint32) (*common.Utxo, error) {
+	return k.UtxoByRefContext(context.Background(), txHash, index)
+}
+
+func (k *KoiosChainContext) UtxoByRefContext(
+	ctx context.Context,
+	txHash common.Blake2b256,
+	index uint32,
+) (*common.Utxo, error) {
+	ref := hex.EncodeToString(txHash.Bytes()) + "#" + strconv.FormatUint(uint64(index), 10)
+	request := struct {
+		UtxoRefs []string `json:"_utxo_refs"`
+		Extended bool     `json:"_extended"`
+	}{
+		UtxoRefs: []string{ref},
+		Extended: true,
+	}
+	var rows []koiosUtxo
+	if err := k.postJSON(ctx, "/utxo_info", request, &rows); err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		utxo, err := row.toUtxo()
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse UTxO %s: %w", ref, err)
+		}
+		if utxo.Id.Id() != txHash || utxo.Id.Index() != index {
+			continue
+		}
+		if row.IsSpent {
+			return nil, fmt.Errorf("%w: %s is spent", backend.ErrUtxoNotFound, ref)
+		}
+		return &utxo, nil
+	}
+	return nil, backend.ErrUtxoNotFound
+}
+
+func (k *KoiosChainContext) ScriptCbor(scriptHash common.Blake2b224) ([]byte, error) {
+	return k.ScriptCborContext(context.Background(), scriptHash)
+}
+
+func (k *KoiosChainContext) ScriptCborContext(
+	ctx context.Context,
+	scriptHash common.Blake2b224,
+) ([]byte, error) {
+	hashHex := hex.EncodeToString(scriptHash.Bytes())
+	request := struct {
+		ScriptHashes []string `json:"_script_hashes"`
+	}{ScriptHashes: []string{hashHex}}
+	var rows []koiosScript
+	if err := k.postJSON(ctx, "/script_info", request, &rows); err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		if !strings.EqualFold(row.ScriptHash, hashHex) {
+			continue
+		}
+		if row.Bytes == "" {
+			return nil, fmt.Errorf("koios returned no CBOR for script %s", hashHex)
+		}
+		scriptCbor, err := hex.DecodeString(row.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("invalid script CBOR hex: %w", err)
+		}
+		return scriptCbor, nil
+	}
+	return nil, fmt.Errorf("script %s not found", hashHex)
+}
+
+func (k *KoiosChainContext) DatumByHash(datumHash common.Blake2b256) (*common.Datum, error) {
+	return k.DatumByHashContext(context.Background(), datumHash)
+}
+
+func (k *KoiosChainContext) DatumByHashContext(
+	ctx context.Context,
+	datumHash common.Blake2b256,
+) (*common.Datum, error) {
+	hashHex := hex.EncodeToString(datumHash.Bytes())
+	request := struct {
+		DatumHashes []string `json:"_datum_hashes"`
+	}{DatumHashes: []string{hashHex}}
+	var rows []struct {
+		DatumHash string `json:"datum_hash"`
+		Bytes     string `json:"bytes"`
+	}
+	if err := k.postJSON(ctx, "/datum_info", request, &rows); err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		if strings.EqualFold(row.DatumHash, hashHex) {
+			return backendutil.DecodeDatumHex(row.Bytes)
+		}
+	}
+	return nil, fmt.Errorf("datum %s not found", hashHex)
+}
+
+// errorSnippet bounds a response payload for inclusion in error messages.
+func errorSnippet(data []byte) string {
+	if len(data) > maxKoiosErrorSnippetSize {
+		data = data[:maxKoiosErrorSnippetSize]
+	}
+	return string(data)
+}
+
+func parseBlake2b256(hashHex, name string) (common.Blake2b256, error) {
+	hashBytes, err := hex.DecodeString(hashHex)
+	if err != nil {
+		return common.Blake2b256{}, fmt.Errorf("invalid %s hex %q: %w", name, hashHex, err)
+	}
+	if len(hashBytes) != common.Blake2b256Size {
+		return common.Blake2b256{}, fmt.Errorf("invalid %s length: expected %d bytes, got %d", name, common.Blake2b256Size, len(hashBytes))
+	}
+	var hash common.Blake2b256
+	copy(hash[:], hashBytes)
+	return hash, nil
+}
+
+// --- Koios response types ---
+
+// koiosNumber decodes a numeric Koios field. Koios serves lovelace amounts
+// and most genesis values as JSON strings and everything else as JSON
+// numbers, so both are accepted. A null or absent field decodes to "".
+type koiosNumber string
+
+func (n *koiosNumber) UnmarshalJSON(data []byte) error {
+	trimmed := bytes.TrimSpace(data)
+	if bytes.Equal(trimmed, []byte("null")) {
+		*n = ""
+		return nil
+	}
+	if len(trimmed) > 0 && trimmed[0] == '"' {
+		var s string
+		if err := json.Unmarshal(trimmed, &s); err != nil {
+			return err
+		}
+		*n = koiosNumber(s)
+		return nil
+	}
+	var number json.Number
+	if err := json.Unmarshal(trimmed, &number); err != nil {
+		return fmt.Errorf("invalid number %s: %w", trimmed, err)
+	}
+	*n = koiosNumber(number.String())
+	return nil
+}
+
+func (n koiosNumber) int64(name string) (int64, error) {
+	if n == "" {
+		return 0, fmt.Errorf("missing %s", name)
+	}
+	v, err := strconv.ParseInt(string(n), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s %q: %w", name, string(n), err)
+	}
+	return v, nil
+}
+
+func (n koiosNumber) uint64(name string) (uint64, error) {
+	if n == "" {
+		return 0, fmt.Errorf("missing %s", name)
+	}
+	v, err := strconv.ParseUint(string(n), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s %q: %w", name, string(n), err)
+	}
+	return v, nil
+}
+
+func (n koiosNumber) int(name string) (int, error) {
+	v, err := n.int64(name)
+	if err != nil {
+		return 0, err
+	}
+	return backendutil.BoundedInt(v, name)
+}
+
+func (n koiosNumber) float64(name string) (float64, error) {
+	if n == "" {
+		return 0, fmt.Errorf("missing %s", name)
+	}
+	v, err := strconv.ParseFloat(string(n), 64)
+	if err != nil || math.IsNaN(v) || math.IsInf(v, 0) {
+		return 0, fmt.Errorf("invalid %s %q", name, string(n))
+	}
+	return v, nil
+}
+
+// integer returns the decimal form of an integer field, for the protocol
+// parameters Apollo keeps as strings.
+func (n koiosNumber) integer(name string) (string, error) {
+	v, err := n.int64(name)
+	if err != nil {
+		return "", err
+	}
+	return strconv.FormatInt(v, 10), nil
+}
+
+// optional treats an omitted field as zero.
+func optional(n koiosNumber) koiosNumber {
+	if n == "" {
+		return "0"
+	}
+	return n
+}
+
+// fieldParser converts a run of fields, keeping the first error so a whole
+// response can be read in one struct literal and checked once.
+type fieldParser struct {
+	err error
+}
+
+func (f *fieldParser) int64(n koiosNumber, name string) int64 {
+	if f.err != nil {
+		return 0
+	}
+	v, err := n.int64(name)
+	f.err = err
+	return v
+}
+
+func (f *fieldParser) int(n koiosNumber, name string) int {
+	if f.err != nil {
+		return 0
+	}
+	v, err := n.int(name)
+	f.err = err
+	return v
+}
+
+func (f *fieldParser) float64(n koiosNumber, name string) float64 {
+	if f.err != nil {
+		return 0
+	}
+	v, err := n.float64(name)
+	f.err = err
+	return v
+}
+
+func (f *fieldParser) integer(n koiosNumber, name string) string {
+	if f.err != nil {
+		return ""
+	}
+	v, err := n.integer(name)
+	f.err = err
+	return v
+}
+
+type koiosEpochParams struct {
+	MinFeeA                    koiosNumber     `json:"min_fee_a"`
+	MinFeeB                    koiosNumber     `json:"min_fee_b"`
+	MaxBlockSize               koiosNumber     `json:"max_block_size"`
+	MaxTxSize                  koiosNumber     `json:"max_tx_size"`
+	MaxBhSize                  koiosNumber     `json:"max_bh_size"`
+	KeyDeposit                 koiosNumber     `json:"key_deposit"`
+	PoolDeposit                koiosNumber     `json:"pool_deposit"`
+	Influence                  koiosNumber     `json:"influence"`
+	MonetaryExpandRate         koiosNumber     `json:"monetary_expand_rate"`
+	TreasuryGrowthRate         koiosNumber     `json:"treasury_growth_rate"`
+	Decentralisation           koiosNumber     `json:"decentralisation"`
+	ExtraEntropy               *string         `json:"extra_entropy"`
+	ProtocolMajor              koiosNumber     `json:"protocol_major"`
+	ProtocolMinor              koiosNumber     `json:"protocol_minor"`
+	MinUtxoValue               koiosNumber     `json:"min_utxo_value"`
+	MinPoolCost                koiosNumber     `json:"min_pool_cost"`
+	CostModels                 json.RawMessage `json:"cost_models"`
+	PriceMem                   koiosNumber     `json:"price_mem"`
+	PriceStep                  koiosNumber     `json:"price_step"`
+	MaxTxExMem                 koiosNumber     `json:"max_tx_ex_mem"`
+	MaxTxExSteps               koiosNumber     `json:"max_tx_ex_steps"`
+	MaxBlockExMem              koiosNumber     `json:"max_block_ex_mem"`
+	MaxBlockExSteps            koiosNumber     `json:"max_block_ex_steps"`
+	MaxValSize                 koiosNumber     `json:"max_val_size"`
+	CollateralPercent          koiosNumber     `json:"collateral_percent"`
+	MaxCollateralInputs        koiosNumber     `json:"max_collateral_inputs"`
+	CoinsPerUtxoSize           koiosNumber     `json:"coins_per_utxo_size"`
+	MinFeeRefScriptCostPerByte koiosNumber     `json:"min_fee_ref_script_cost_per_byte"`
+}
+
+// toProtocolParams converts epoch_params. The fee, deposit, size, price and
+// collateral parameters are required: a missing one would otherwise become a
+// zero and produce an unbalanced transaction. The rest are optional and left
+// zero when Koios omits them.
+func (p *koiosEpochParams) toProtocolParams() (backend.ProtocolParameters, error) {
+	var f fieldParser
+	pp := backend.ProtocolParameters{
+		MinFeeCoefficient:     f.int64(p.MinFeeA, "min_fee_a"),
+		MinFeeConstant:        f.int64(p.MinFeeB, "min_fee_b"),
+		MaxBlockSize:          f.int(optional(p.MaxBlockSize), "max_block_size"),
+		MaxTxSize:             f.int(p.MaxTxSize, "max_tx_size"),
+		MaxBlockHeaderSize:    f.int(optional(p.MaxBhSize), "max_bh_size"),
+		KeyDeposits:           f.integer(p.KeyDeposit, "key_deposit"),
+		PoolDeposits:          f.integer(p.PoolDeposit, "pool_deposit"),
+		PoolInfluence:         f.float64(optional(p.Influence), "influence"),
+		MonetaryExpansion:     f.float64(optional(p.MonetaryExpandRate), "monetary_expand_rate"),
+		TreasuryExpansion:     f.float64(optional(p.TreasuryGrowthRate), "treasury_growth_rate"),
+		DecentralizationParam: f.float64(optional(p.Decentralisation), "decentralisation"),
+		ProtocolMajorVersion:  f.int(optional(p.ProtocolMajor), "protocol_major"),
+		ProtocolMinorVersion:  f.int(optional(p.ProtocolMinor), "protocol_minor"),
+		MinUtxo:               f.integer(optional(p.MinUtxoValue), "min_utxo_value"),
+		MinPoolCost:           f.integer(optional(p.MinPoolCost), "min_pool_cost"),
+		PriceMem:              f.float64(p.PriceMem, "price_mem"),
+		PriceStep:             f.float64(p.PriceStep, "price_step"),
+		MaxTxExMem:            f.integer(p.MaxTxExMem, "max_tx_ex_mem"),
+		MaxTxExSteps:          f.integer(p.MaxTxExSteps, "max_tx_ex_steps"),
+		MaxBlockExMem:         f.integer(optional(p.MaxBlockExMem), "max_block_ex_mem"),
+		MaxBlockExSteps:       f.integer(optional(p.MaxBlockExSteps), "max_block_ex_steps"),
+		MaxValSize:            f.integer(p.MaxValSize, "max_val_size"),
+		CollateralPercent:     f.int(p.CollateralPercent, "collateral_percent"),
+		MaxCollateralInputs:   f.int(p.MaxCollateralInputs, "max_collateral_inputs"),
+		CoinsPerUtxoByte:      f.integer(p.CoinsPerUtxoSize, "coins_per_utxo_size"),
+	}
+	if f.err != nil {
+		return backend.ProtocolParameters{}, fmt.Errorf("koios epoch parameters: %w", f.err)
+	}
+	if p.ExtraEntropy != nil {
+		pp.ExtraEntropy = *p.ExtraEntropy
+	}
+
+	// The reference-script price is a ledger rational; keep it exact.
+	if p.MinFeeRefScriptCostPerByte != "" {
+		price, err := backendutil.ParseRational(string(p.MinFeeRefScriptCostPerByte))
+		if err != nil {
+			return backend.ProtocolParameters{}, fmt.Errorf("invalid min_fee_ref_script_cost_per_byte: %w", err)
+		}
+		pp.MinFeeRefScriptCostPerByteRational = price
+		pp.MinFeeRefScriptCostPerByte, _ = price.Float64()
+	}
+
+	// Koios serves db-sync cost models keyed "PlutusV1" through "PlutusV4",
+	// which is the form ComputeScriptDataHash expects.
+	if len(p.CostModels) > 0 && string(p.CostModels) != "null" {
+		models, err := backendutil.ParseCostModels(p.CostModels)
+		if err != nil {
+			return backend.ProtocolParameters{}, err
+		}
+		pp.CostModels = models
+	}
+	return pp, nil
+}
+
+type koiosGenesis struct {
+	NetworkMagic      koiosNumber `json:"networkmagic"`
+	ActiveSlotsCoeff  koiosNumber `json:"activeslotcoeff"`
+	UpdateQuorum      koiosNumber `json:"updatequorum"`
+	MaxLovelaceSupply koiosNumber `json:"maxlovelacesupply"`
+	EpochLength       koiosNumber `json:"epochlength"`
+	SystemStart       koiosNumber `json:"systemstart"`
+	SlotsPerKesPeriod koiosNumber `json:"slotsperkesperiod"`
+	SlotLength        koiosNumber `json:"slotlength"`
+	MaxKesEvolutions  koiosNumber `json:"maxkesrevolutions"`
+	SecurityParam     koiosNumber `json:"securityparam"`
+}
+
+func (g *koiosGenesis) toGenesisParams() (backend.GenesisParameters, error) {
+	var f fieldParser
+	gp := backend.GenesisParameters{
+		NetworkMagic:           f.int(g.NetworkMagic, "networkmagic"),
+		ActiveSlotsCoefficient: f.float64(g.ActiveSlotsCoeff, "activeslotcoeff"),
+		UpdateQuorum:           f.int(g.UpdateQuorum, "updatequorum"),
+		MaxLovelaceSupply:      f.integer(g.MaxLovelaceSupply, "maxlovelacesupply"),
+		EpochLength:            f.int(g.EpochLength, "epochlength"),
+		SystemStart:            f.int64(g.SystemStart, "systemstart"),
+		SlotsPerKesPeriod:      f.int(g.SlotsPerKesPeriod, "slotsperkesperiod"),
+		SlotLength:             f.int(g.SlotLength, "slotlength"),
+		MaxKesEvolutions:       f.int(g.MaxKesEvolutions, "maxkesrevolutions"),
+		SecurityParam:          f.int(g.SecurityParam, "securityparam"),
+	}
+	if f.err != nil {
+		return backend.GenesisParameters{}, fmt.Errorf("koios genesis: %w", f.err)
+	}
+	return gp, nil
+}
+
+type koiosTip struct {
+	EpochNo koiosNumber `json:"epoch_no"`
+	AbsSlot koiosNumber `json:"abs_slot"`
+}
+
+// koiosUtxo is a row of address_utxos or utxo_info with _extended set.
+type koiosUtxo struct {
+	TxHash          string            `json:"tx_hash"`
+	TxIndex         koiosNumber       `json:"tx_index"`
+	Address         string            `json:"address"`
+	Value           koiosNumber       `json:"value"`
+	DatumHash       *string           `json:"datum_hash"`
+	InlineDatum     *koiosInlineDatum `json:"inline_datum"`
+	ReferenceScript *koiosScript      `json:"reference_script"`
+	AssetList       []koiosAsset      `json:"asset_list"`
+	IsSpent         bool              `json:"is_spent"`
+}
+
+type koiosInlineDatum struct {
+	Bytes string `json:"bytes"`
+}
+
+type koiosAsset struct {
+	PolicyId  string `json:"policy_id"`
+	AssetName string `json:"asset_name"`
+	Quantity  string `json:"quantity"`
+}
+
+// koiosScript is a script_info row or a UTxO's reference_script.
+type koiosScript struct {
+	ScriptHash string `json:"script_hash"`
+	Hash       string `json:"hash"`
+	Type       string `json:"type"`
+	Bytes      string `json:"bytes"`
+}
+
+func (raw *koiosUtxo) toUtxo() (common.Utxo, error) {
+	txId, err := parseBlake2b256(raw.TxHash, "tx hash")
+	if err != nil {
+		return common.Utxo{}, err
+	}
+	index, err := raw.TxIndex.uint64("tx_index")
+	if err != nil {
+		return common.Utxo{}, err
+	}
+	if index > math.MaxUint32 {
+		return common.Utxo{}, fmt.Errorf("output index %d exceeds uint32 range", index)
+	}
+	address, err := common.NewAddress(raw.Address)
+	if err != nil {
+		return common.Utxo{}, fmt.Errorf("invalid address %q: %w", raw.Address, err)
+	}
+	lovelace, err := raw.Value.uint64("value")
+	if err != nil {
+		return common.Utxo{}, err
+	}
+
+	assetData := make(map[common.Blake2b224]map[cbor.ByteString]*big.Int)
+	for _, asset := range raw.AssetList {
+		unit := asset.PolicyId + asset.AssetName
+		policyId, assetName, err := backendutil.ParseAssetUnit(unit)
+		if err != nil {
+			return common.Utxo{}, fmt.Errorf("invalid asset %q: %w", unit, err)
+		}
+		qty, ok := new(big.Int).SetString(asset.Quantity, 10)
+		if !ok || qty.Sign() < 0 {
+			return common.Utxo{}, fmt.Errorf("invalid asset quantity %q for %s", asset.Quantity, unit)
+		}
+		if _, ok := assetData[policyId]; !ok {
+			assetData[policyId] = make(map[cbor.ByteString]*big.Int)
+		}
+		assetData[policyId][assetName] = qty
+	}
+	var assets *common.MultiAsset[common.MultiAssetTypeOutput]
+	if len(assetData) > 0 {
+		ma := common.NewMultiAsset[common.MultiAssetTypeOutput](assetData)
+		assets = &ma
+	}
+
+	output := babbage.BabbageTransactionOutput{
+		OutputAddress: address,
+		OutputAmount: mary.MaryTransactionOutputValue{
+			Amount: lovelace,
+			Assets: assets,
+		},
+	}
+	switch {
+	case raw.InlineDatum != nil:
+		opt, err := inlineDatumOption(raw.InlineDatum.Bytes)
+		if err != nil {
+			return common.Utxo{}, fmt.Errorf("failed to decode inline datum: %w", err)
+		}
+		output.DatumOption = opt
+	case raw.DatumHash != nil && *raw.DatumHash != "":
+		hash, err := parseBlake2b256(*raw.DatumHash, "datum hash")
+		if err != nil {
+			return common.Utxo{}, err
+		}
+		opt, err := backendutil.DatumHashOption(hash)
+		if err != nil {
+			return common.Utxo{}, err
+		}
+		output.DatumOption = opt
+	}
+	if raw.ReferenceScript != nil {
+		scriptRef, err := raw.ReferenceScript.toScriptRef()
+		if err != nil {
+			return common.Utxo{}, fmt.Errorf("failed to decode reference script: %w", err)
+		}
+		output.TxOutScriptRef = scriptRef
+	}
+
+	return common.Utxo{
+		Id: shelley.ShelleyTransactionInput{
+			TxId:        txId,
+			OutputIndex: uint32(index), //nolint:gosec // range checked above
+		},
+		Output: &output,
+	}, nil
+}
+
+// toScriptRef decodes a reference script and checks it against the hash
+// Koios reports. Koios serves scripts as db-sync stores them.
+func (s *koiosScript) toScriptRef() (*common.ScriptRef, error) {
+	if s.Bytes == "" {
+		return nil, fmt.Errorf("koios returned no CBOR for reference script %s", s.Hash)
+	}
+	scriptBytes, err := hex.DecodeString(s.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("invalid reference script hex: %w", err)
+	}
+	return backendutil.ScriptRefFromDbSync(s.Type, scriptBytes, s.Hash)
+}
+
+// inlineDatumOption builds an inline datum option from datum CBOR hex.
+func inlineDatumOption(datumCborHex string) (*babbage.BabbageTransactionOutputDatumOption, error) {
+	datumBytes, err := hex.DecodeString(datumCborHex)
+	if err != nil {
+		return nil, fmt.Errorf("invalid inline datum CBOR hex %q: %w", datumCborHex, err)
+	}
+	return backendutil.InlineDatumOption(datumBytes)
+}
+
+// --- Ogmios passthrough types ---
+//
+// Koios forwards /ogmios bodies to Ogmios v6 unchanged, so requests use the
+// Ogmios JSON-RPC shape and additional UTxOs the v6 Utxo schema.
+
+type ogmiosRequest struct {
+	Jsonrpc string `json:"jsonrpc"`
+	Method  string `json:"method"`
+	Params  any    `json:"params"`
+}
+
+type ogmiosEvaluateParams struct {
+	Transaction struct {
+		Cbor string `json:"cbor"`
+	} `json:"transaction"`
+	AdditionalUtxo []ogmiosUtxo `json:"additionalUtxo,omitempty"`
+}
+
+type ogmiosUtxo struct {
+	Transaction struct {
+		Id string `json:"id"`
+	} `json:"transaction"`
+	Index   uint32 `json:"index"`
+	Address string `json:"address"`
+	// Value quantities are json.Number so large asset amounts are written
+	// exactly rather than through float64.
+	Value     map[string]map[string]json.Number `json:"value"`
+	DatumHash string                            `json:"datumHash,omitempty"`
+	Datum     string                            `json:"datum,omitempty"`
+	Script    *ogmiosScript                     `json:"script,omitempty"`
+}
+
+type ogmiosScript struct {
+	Language string `json:"language"`
+	Cbor     string `json:"cbor"`
+}
+
+func ogmiosUtxoFromUtxo(utxo common.Utxo) (ogmiosUtxo, error) {
+	if err := backend.ValidateAdditionalUtxo(utxo); err != nil {
+		return ogmiosUtxo{}, err
+	}
+	out := utxo.Output
+	encoded := ogmiosUtxo{
+		Index:   utxo.Id.Index(),
+		Address: out.Address().String(),
+		Value: map[string]map[string]json.Number{
+			"ada": {"lovelace": json.Number(out.Amount().String())},
+		},
+	}
+	encoded.Transaction.Id = hex.EncodeToString(utxo.Id.Id().Bytes())
+	if assets := out.Assets(); assets != nil {
+		for _, policyId := range assets.Policies() {
+			policyHex := hex.EncodeToString(policyId.Bytes())
+			if encoded.Value[policyHex] == nil {
+				encoded.Value[policyHex] = make(map[string]json.Number)
+			}
+			for _, assetName := range assets.Assets(policyId) {
+				encoded.Value[policyHex][hex.EncodeToString(assetName)] = json.Number(assets.Asset(policyId, assetName).String())
+			}
+		}
+	}
+	if datum := out.Datum(); datum != nil {
+		datumCbor := datum.Cbor()
+		if len(datumCbor) == 0 {
+			var err error
+			datumCbor, err = datum.MarshalCBOR()
+			if err != nil {
+				return ogmiosUtxo{}, fmt.Errorf("failed to encode inline datum: %w", err)
+			}
+		}
+		encoded.Datum = hex.EncodeToString(datumCbor)
+	} else if datumHash := out.DatumHash(); datumHash != nil {
+		encoded.DatumHash = hex.EncodeToString(datumHash.Bytes())
+	}
+	if script := out.ScriptRef(); script != nil {
+		var language string
+		switch script.(type) {
+		case common.PlutusV1Script:
+			language = "plutus:v1"
+		case common.PlutusV2Script:
+			language = "plutus:v2"
+		case common.PlutusV3Script:
+			language = "plutus:v3"
+		case common.NativeScript:
+			language = "native"
+		default:
+			return ogmiosUtxo{}, fmt.Errorf("unsupported reference script type %T", script)
+		}
+		encoded.Script = &ogmiosScript{
+			Language: language,
+			Cbor:     hex.EncodeToString(script.RawScriptBytes()),
+		}
+	}
+	return encoded, nil
+}
+
+// parseEvaluateResponse parses an Ogmios v6 evaluateTransaction response:
+// {"result":[{"validator":{"purpose":...,"index":...},"budget":{"memory":...,"cpu":...}}]}
+// or {"error":{"code":...,"message":...,"data":...}}. A response with no
+// results is an error, so redeemers never silently keep zero budgets.
+func parseEvaluateResponse(data []byte) (map[common.RedeemerKey]common.ExUnits, error) {
+	var envelope struct {
+		Result []struct {
+			Validator struct {
+				Purpose string `json:"purpose"`
+				Index   uint64 `json:"index"`
+			} `json:"validator"`
+			Budget struct {
+				Memory uint64 `json:"memory"`
+				Cpu    uint64 `json:"cpu"`
+			} `json:"budget"`
+		} `json:"result"`
+		Error *struct {
+			Code    int             `json:"code"`
+			Message string          `json:"message"`
+			Data    json.RawMessage `json:"data"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(data, &envelope); err != nil {
+		return nil, fmt.Errorf("failed to parse evaluate response: %w", err)
+	}
+	if envelope.Error != nil {
+		if len(envelope.Error.Data) > 0 && string(envelope.Error.Data) != "null" {
+			return nil, fmt.Errorf("script evaluation failed (code %d): %s: %s",
+				envelope.Error.Code, envelope.Error.Message, errorSnippet(envelope.Error.Data))
+		}
+		return nil, fmt.Errorf("script evaluation failed (code %d): %s", envelope.Error.Code, envelope.Error.Message)
+	}
+	if len(envelope.Result) == 0 {
+		return nil, fmt.Errorf("script evaluation returned no results: %s", errorSnippet(data))
+	}
+	result := make(map[common.RedeemerKey]common.ExUnits, len(envelope.Result))
+	for _, item := range envelope.Result {
+		tag, err := backendutil.ParseRedeemerTag(item.Validator.Purpose)
+		if err != nil {
+			return nil, fmt.Errorf("invalid redeemer purpose %q: %w", item.Validator.Purpose, err)
+		}
+		if item.Validator.Index > math.MaxUint32 {
+			return nil, fmt.Errorf("redeemer index %d exceeds uint32 range", item.Validator.Index)
+		}
+		if item.Budget.Memory > math.MaxInt64 || item.Budget.Cpu > math.MaxInt64 {
+			return nil, fmt.Errorf("ExUnits overflow for validator %s:%d: memory=%d cpu=%d",
+				item.Validator.Purpose, item.Validator.Index, item.Budget.Memory, item.Budget.Cpu)
+		}
+		key := common.RedeemerKey{Tag: tag, Index: uint32(item.Validator.Index)}
+		result[key] = common.ExUnits{Memory: int64(item.Budget.Memory), Steps: int64(item.Budget.Cpu)}
+	}
+	return result, nil
+}
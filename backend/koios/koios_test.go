@@ -0,0 +1,553 @@
+package koios
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/blinklabs-io/gouroboros/cbor"
+	"github.com/blinklabs-io/gouroboros/ledger/babbage"
+	"github.com/blinklabs-io/gouroboros/ledger/common"
+	"github.com/blinklabs-io/gouroboros/ledger/mary"
+	"github.com/blinklabs-io/gouroboros/ledger/shelley"
+
+	"github.com/Salvionied/apollo/v2/backend"
+)
+
+const (
+	testTxHash     = "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"
+	testPolicyId   = "bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb"
+	testAssetName  = "746f6b656e"
+	testDatumCbor  = "d87980"
+	epochParamsRow = `[{
+		"epoch_no": 512,
+		"min_fee_a": 44,
+		"min_fee_b": 155381,
+		"max_block_size": 90112,
+		"max_tx_size": 16384,
+		"max_bh_size": 1100,
+		"key_deposit": "2000000",
+		"pool_deposit": "500000000",
+		"influence": 0.3,
+		"monetary_expand_rate": 0.003,
+		"treasury_growth_rate": 0.2,
+		"decentralisation": 0,
+		"extra_entropy": null,
+		"protocol_major": 10,
+		"protocol_minor": 0,
+		"min_utxo_value": null,
+		"min_pool_cost": "170000000",
+		"cost_models": {"PlutusV2": [1, 2, 3], "PlutusV3": [4, 5]},
+		"price_mem": 0.0577,
+		"price_step": 0.0000721,
+		"max_tx_ex_mem": 14000000,
+		"max_tx_ex_steps": 10000000000,
+		"max_block_ex_mem": 62000000,
+		"max_block_ex_steps": 20000000000,
+		"max_val_size": 5000,
+		"collateral_percent": 150,
+		"max_collateral_inputs": 3,
+		"coins_per_utxo_size": "4310",
+		"min_fee_ref_script_cost_per_byte": 12.5
+	}]`
+)
+
+func testAddress(t *testing.T) common.Address {
+	t.Helper()
+	var raw [57]byte
+	raw[0] = 0x00
+	raw[1] = 0xAA
+	raw[29] = 0xBB
+	addr, err := common.NewAddressFromBytes(raw[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return addr
+}
+
+// newTestServer serves routes keyed by "METHOD /path" and fails the test on
+// any other request.
+func newTestServer(t *testing.T, routes map[string]http.HandlerFunc) *KoiosChainContext {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handler, ok := routes[r.Method+" "+r.URL.Path]
+		if !ok {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			http.NotFound(w, r)
+			return
+		}
+		handler(w, r)
+	}))
+	t.Cleanup(server.Close)
+	return NewKoiosChainContext(server.URL, 0, "")
+}
+
+func respond(body string) http.HandlerFunc {
+	return func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = io.WriteString(w, body)
+	}
+}
+
+func TestKoiosCapabilities(t *testing.T) {
+	ctx := NewKoiosChainContext("http://localhost", 0, "")
+	want := backend.CapabilitySet(backend.AllCapabilities | backend.CapabilityDatumByHash)
+	if got := ctx.Capabilities(); got != want {
+		t.Fatalf("Capabilities() = %b, want %b", got, want)
+	}
+}
+
+func TestNewKoiosChainContextAddsApiPath(t *testing.T) {
+	for _, baseUrl := range []string{"https://api.koios.rest", "https://api.koios.rest/", "https://api.koios.rest/api/v1"} {
+		if got := NewKoiosChainContext(baseUrl, 1, "").baseUrl; got != "https://api.koios.rest/api/v1" {
+			t.Fatalf("baseUrl for %q = %q", baseUrl, got)
+		}
+	}
+}
+
+func TestRequestSendsBearerToken(t *testing.T) {
+	var auth string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Authorization")
+		_, _ = io.WriteString(w, `[{"epoch_no": 512, "abs_slot": 1000}]`)
+	}))
+	defer server.Close()
+
+	ctx := NewKoiosChainContext(server.URL, 0, "secret")
+	if _, err := ctx.Tip(); err != nil {
+		t.Fatal(err)
+	}
+	if auth != "Bearer secret" {
+		t.Fatalf("Authorization = %q", auth)
+	}
+}
+
+func TestProtocolParams(t *testing.T) {
+	ctx := newTestServer(t, map[string]http.HandlerFunc{
+		"GET /api/v1/epoch_params": func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Query().Get("limit") != "1" || r.URL.Query().Get("order") != "epoch_no.desc" {
+				t.Errorf("epoch_params query = %q", r.URL.RawQuery)
+			}
+			_, _ = io.WriteString(w, epochParamsRow)
+		},
+	})
+
+	pp, err := ctx.ProtocolParams()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pp.MinFeeCoefficient != 44 || pp.MinFeeConstant != 155381 || pp.MaxTxSize != 16384 {
+		t.Fatalf("fee parameters = %d/%d/%d", pp.MinFeeCoefficient, pp.MinFeeConstant, pp.MaxTxSize)
+	}
+	if pp.KeyDeposits != "2000000" || pp.PoolDeposits != "500000000" || pp.CoinsPerUtxoByte != "4310" {
+		t.Fatalf("deposits = %s/%s, coins per byte %s", pp.KeyDeposits, pp.PoolDeposits, pp.CoinsPerUtxoByte)
+	}
+	if pp.MaxTxExSteps != "10000000000" || pp.MaxValSize != "5000" || pp.CollateralPercent != 150 {
+		t.Fatalf("limits = %s/%s/%d", pp.MaxTxExSteps, pp.MaxValSize, pp.CollateralPercent)
+	}
+	if pp.PriceMem != 0.0577 || pp.PriceStep != 0.0000721 || pp.MinUtxo != "0" {
+		t.Fatalf("prices = %v/%v, min utxo %q", pp.PriceMem, pp.PriceStep, pp.MinUtxo)
+	}
+	if pp.MinFeeRefScriptCostPerByteRational == nil ||
+		pp.MinFeeRefScriptCostPerByteRational.Cmp(big.NewRat(25, 2)) != 0 ||
+		pp.MinFeeRefScriptCostPerByte != 12.5 {
+		t.Fatalf("ref script price = %v (%v)", pp.MinFeeRefScriptCostPerByteRational, pp.MinFeeRefScriptCostPerByte)
+	}
+	if len(pp.CostModels["PlutusV2"]) != 3 || len(pp.CostModels["PlutusV3"]) != 2 {
+		t.Fatalf("cost models = %v", pp.CostModels)
+	}
+}
+
+func TestProtocolParamsRejectsMissingRequiredField(t *testing.T) {
+	ctx := newTestServer(t, map[string]http.HandlerFunc{
+		"GET /api/v1/epoch_params": respond(strings.Replace(epochParamsRow, `"min_fee_b": 155381,`, "", 1)),
+	})
+	if _, err := ctx.ProtocolParams(); err == nil || !strings.Contains(err.Error(), "min_fee_b") {
+		t.Fatalf("ProtocolParams() error = %v, want missing min_fee_b", err)
+	}
+}
+
+func TestGenesisParams(t *testing.T) {
+	ctx := newTestServer(t, map[string]http.HandlerFunc{
+		"GET /api/v1/genesis": respond(`[{
+			"networkmagic": "1",
+			"networkid": "Testnet",
+			"activeslotcoeff": "0.05",
+			"updatequorum": "5",
+			"maxlovelacesupply": "45000000000000000",
+			"epochlength": "432000",
+			"systemstart": 1654041600,
+			"slotsperkesperiod": "129600",
+			"slotlength": "1",
+			"maxkesrevolutions": "62",
+			"securityparam": "2160"
+		}]`),
+	})
+	gp, err := ctx.GenesisParams()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gp.NetworkMagic != 1 || gp.ActiveSlotsCoefficient != 0.05 || gp.EpochLength != 432000 ||
+		gp.SystemStart != 1654041600 || gp.MaxLovelaceSupply != "45000000000000000" || gp.SecurityParam != 2160 {
+		t.Fatalf("GenesisParams() = %+v", gp)
+	}
+}
+
+func TestTipAndCurrentEpoch(t *testing.T) {
+	ctx := newTestServer(t, map[string]http.HandlerFunc{
+		"GET /api/v1/tip": respond(`[{"hash": "ff", "epoch_no": 512, "abs_slot": 123456789, "block_no": 1}]`),
+	})
+	slot, err := ctx.Tip()
+	if err != nil || slot != 123456789 {
+		t.Fatalf("Tip() = %d, %v", slot, err)
+	}
+	epoch, err := ctx.CurrentEpoch()
+	if err != nil || epoch != 512 {
+		t.Fatalf("CurrentEpoch() = %d, %v", epoch, err)
+	}
+}
+
+func utxoRow(t *testing.T, index int, extra string) string {
+	t.Helper()
+	return `{
+		"tx_hash": "` + testTxHash + `",
+		"tx_index": ` + strconv.Itoa(index) + `,
+		"address": "` + testAddress(t).String() + `",
+		"value": "2000000",
+		"is_spent": false` + extra + `
+	}`
+}
+
+func TestUtxosDecodesAssetsDatumsAndReferenceScripts(t *testing.T) {
+	script := common.PlutusV2Script{0x42, 0x01, 0x02}
+	scriptHash := hex.EncodeToString(script.Hash().Bytes())
+	datumHash := strings.Repeat("cd", 32)
+	var requested struct {
+		Addresses []string `json:"_addresses"`
+		Extended  bool     `json:"_extended"`
+	}
+	ctx := newTestServer(t, map[string]http.HandlerFunc{
+		"POST /api/v1/address_utxos": func(w http.ResponseWriter, r *http.Request) {
+			if err := json.NewDecoder(r.Body).Decode(&requested); err != nil {
+				t.Error(err)
+			}
+			_, _ = io.WriteString(w, `[`+
+				utxoRow(t, 0, `,
+				"asset_list": [{"policy_id": "`+testPolicyId+`", "asset_name": "`+testAssetName+`", "quantity": "18446744073709551616"}],
+				"inline_datum": {"bytes": "`+testDatumCbor+`", "value": {"constructor": 0, "fields": []}},
+				"reference_script": {"hash": "`+scriptHash+`", "size": 3, "type": "plutusV2", "bytes": "`+hex.EncodeToString(script)+`"}`)+`,`+
+				utxoRow(t, 1, `,
+				"datum_hash": "`+datumHash+`",
+				"asset_list": [],
+				"inline_datum": null,
+				"reference_script": null`)+
+				`]`)
+		},
+	})
+
+	utxos, err := ctx.Utxos(testAddress(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(requested.Addresses) != 1 || requested.Addresses[0] != testAddress(t).String() || !requested.Extended {
+		t.Fatalf("address_utxos request = %+v", requested)
+	}
+	if len(utxos) != 2 {
+		t.Fatalf("Utxos() returned %d UTxOs, want 2", len(utxos))
+	}
+
+	first := utxos[0].Output
+	if first.Amount().Uint64() != 2_000_000 {
+		t.Fatalf("lovelace = %s", first.Amount())
+	}
+	policyBytes, _ := hex.DecodeString(testPolicyId)
+	nameBytes, _ := hex.DecodeString(testAssetName)
+	qty := first.Assets().Asset(common.NewBlake2b224(policyBytes), nameBytes)
+	if qty == nil || qty.String() != "18446744073709551616" {
+		t.Fatalf("asset quantity = %v", qty)
+	}
+	if first.Datum() == nil || hex.EncodeToString(first.Datum().Cbor()) != testDatumCbor {
+		t.Fatal("inline datum was not preserved")
+	}
+	if first.ScriptRef() == nil || first.ScriptRef().Hash() != script.Hash() {
+		t.Fatal("reference script was not decoded")
+	}
+
+	second := utxos[1].Output
+	if second.Datum() != nil || second.DatumHash() == nil ||
+		hex.EncodeToString(second.DatumHash().Bytes()) != datumHash {
+		t.Fatal("datum hash was not decoded")
+	}
+	if utxos[1].Id.Index() != 1 {
+		t.Fatalf("second UTxO index = %d", utxos[1].Id.Index())
+	}
+}
+
+func TestUtxosPaginates(t *testing.T) {
+	var offsets []string
+	ctx := newTestServer(t, map[string]http.HandlerFunc{
+		"POST /api/v1/address_utxos": func(w http.ResponseWriter, r *http.Request) {
+			offset := r.URL.Query().Get("offset")
+			offsets = append(offsets, offset)
+			rows := 1
+			if offset == "0" {
+				rows = koiosPageSize
+			}
+			parts := make([]string, rows)
+			for i := range parts {
+				parts[i] = utxoRow(t, len(offsets)*koiosPageSize+i, "")
+			}
+			_, _ = io.WriteString(w, "["+strings.Join(parts, ",")+"]")
+		},
+	})
+
+	utxos, err := ctx.Utxos(testAddress(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(utxos) != koiosPageSize+1 {
+		t.Fatalf("Utxos() returned %d UTxOs, want %d", len(utxos), koiosPageSize+1)
+	}
+	if len(offsets) != 2 || offsets[1] != "1000" {
+		t.Fatalf("requested offsets = %v", offsets)
+	}
+
+	// A stream stopped within the first page never requests the second.
+	offsets = nil
+	for _, err := range ctx.UtxosSeq(t.Context(), testAddress(t)) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		break
+	}
+	if len(offsets) != 1 {
+		t.Fatalf("requested offsets after stopping = %v, want only the first page", offsets)
+	}
+}
+
+func TestUtxoByRef(t *testing.T) {
+	var requested struct {
+		UtxoRefs []string `json:"_utxo_refs"`
+	}
+	ctx := newTestServer(t, map[string]http.HandlerFunc{
+		"POST /api/v1/utxo_info": func(w http.ResponseWriter, r *http.Request) {
+			if err := json.NewDecoder(r.Body).Decode(&requested); err != nil {
+				t.Error(err)
+			}
+			_, _ = io.WriteString(w, "["+utxoRow(t, 3, "")+"]")
+		},
+	})
+	txHash, err := parseBlake2b256(testTxHash, "tx hash")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	utxo, err := ctx.UtxoByRef(txHash, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(requested.UtxoRefs) != 1 || requested.UtxoRefs[0] != testTxHash+"#3" {
+		t.Fatalf("utxo_info request = %+v", requested)
+	}
+	if utxo.Id.Id() != txHash || utxo.Id.Index() != 3 {
+		t.Fatalf("UtxoByRef() = %+v", utxo.Id)
+	}
+	if _, err := ctx.UtxoByRef(txHash, 4); err == nil {
+		t.Fatal("UtxoByRef() returned a UTxO Koios did not report")
+	}
+}
+
+func TestUtxoByRefRejectsSpentOutput(t *testing.T) {
+	ctx := newTestServer(t, map[string]http.HandlerFunc{
+		"POST /api/v1/utxo_info": respond("[" + strings.Replace(utxoRow(t, 0, ""), `"is_spent": false`, `"is_spent": true`, 1) + "]"),
+	})
+	txHash, _ := parseBlake2b256(testTxHash, "tx hash")
+	if _, err := ctx.UtxoByRef(txHash, 0); err == nil || !strings.Contains(err.Error(), "spent") {
+		t.Fatalf("UtxoByRef() error = %v, want spent", err)
+	}
+}
+
+func TestSubmitTxPostsCbor(t *testing.T) {
+	txCbor := []byte{0x84, 0xa0, 0xa0, 0xf5, 0xf6}
+	ctx := newTestServer(t, map[string]http.HandlerFunc{
+		"POST /api/v1/submittx": func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Content-Type") != "application/cbor" {
+				t.Errorf("Content-Type = %q", r.Header.Get("Content-Type"))
+			}
+			body, _ := io.ReadAll(r.Body)
+			if !bytes.Equal(body, txCbor) {
+				t.Errorf("submitted body = %x", body)
+			}
+			w.WriteHeader(http.StatusAccepted)
+			_, _ = io.WriteString(w, `"`+testTxHash+`"`)
+		},
+	})
+	hash, err := ctx.SubmitTx(txCbor)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hex.EncodeToString(hash.Bytes()) != testTxHash {
+		t.Fatalf("SubmitTx() = %x", hash.Bytes())
+	}
+}
+
+func TestSubmitTxReportsApiError(t *testing.T) {
+	ctx := newTestServer(t, map[string]http.HandlerFunc{
+		"POST /api/v1/submittx": func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = io.WriteString(w, "transaction rejected")
+		},
+	})
+	if _, err := ctx.SubmitTx([]byte{0x80}); err == nil || !strings.Contains(err.Error(), "transaction rejected") {
+		t.Fatalf("SubmitTx() error = %v", err)
+	}
+}
+
+func TestEvaluateTxUsesOgmiosPassthrough(t *testing.T) {
+	var request struct {
+		Jsonrpc string `json:"jsonrpc"`
+		Method  string `json:"method"`
+		Params  struct {
+			Transaction struct {
+				Cbor string `json:"cbor"`
+			} `json:"transaction"`
+			AdditionalUtxo []map[string]json.RawMessage `json:"additionalUtxo"`
+		} `json:"params"`
+	}
+	ctx := newTestServer(t, map[string]http.HandlerFunc{
+		"POST /api/v1/ogmios": func(w http.ResponseWriter, r *http.Request) {
+			if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+				t.Error(err)
+			}
+			_, _ = io.WriteString(w, `{"jsonrpc": "2.0", "method": "evaluateTransaction", "result": [
+				{"validator": {"purpose": "spend", "index": 0}, "budget": {"memory": 1700, "cpu": 476468}}
+			]}`)
+		},
+	})
+
+	opt, err := inlineDatumOption(testDatumCbor)
+	if err != nil {
+		t.Fatal(err)
+	}
+	txHash, _ := parseBlake2b256(testTxHash, "tx hash")
+	additional := common.Utxo{
+		Id: shelley.ShelleyTransactionInput{TxId: txHash, OutputIndex: 2},
+		Output: &babbage.BabbageTransactionOutput{
+			OutputAddress: testAddress(t),
+			OutputAmount:  mary.MaryTransactionOutputValue{Amount: 3_000_000},
+			DatumOption:   opt,
+		},
+	}
+
+	units, err := ctx.EvaluateTx([]byte{0x84}, []common.Utxo{additional})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if request.Jsonrpc != "2.0" || request.Method != "evaluateTransaction" || request.Params.Transaction.Cbor != "84" {
+		t.Fatalf("ogmios request = %+v", request)
+	}
+	if len(request.Params.AdditionalUtxo) != 1 {
+		t.Fatalf("additionalUtxo = %v", request.Params.AdditionalUtxo)
+	}
+	sent := request.Params.AdditionalUtxo[0]
+	if string(sent["index"]) != "2" || string(sent["datum"]) != `"`+testDatumCbor+`"` ||
+		string(sent["value"]) != `{"ada":{"lovelace":3000000}}` {
+		t.Fatalf("additional UTxO = %v", sent)
+	}
+	key := common.RedeemerKey{Tag: common.RedeemerTagSpend, Index: 0}
+	if units[key] != (common.ExUnits{Memory: 1700, Steps: 476468}) {
+		t.Fatalf("EvaluateTx() = %v", units)
+	}
+}
+
+func TestEvaluateTxReportsOgmiosError(t *testing.T) {
+	ctx := newTestServer(t, map[string]http.HandlerFunc{
+		"POST /api/v1/ogmios": respond(`{"jsonrpc": "2.0", "error": {"code": 3010, "message": "Some scripts failed", "data": []}}`),
+	})
+	if _, err := ctx.EvaluateTx([]byte{0x84}, nil); err == nil || !strings.Contains(err.Error(), "3010") {
+		t.Fatalf("EvaluateTx() error = %v", err)
+	}
+}
+
+func TestScriptCbor(t *testing.T) {
+	script := common.PlutusV3Script{0x42, 0x05, 0x06}
+	hashHex := hex.EncodeToString(script.Hash().Bytes())
+	ctx := newTestServer(t, map[string]http.HandlerFunc{
+		"POST /api/v1/script_info": func(w http.ResponseWriter, r *http.Request) {
+			var request struct {
+				ScriptHashes []string `json:"_script_hashes"`
+			}
+			if err := json.NewDecoder(r.Body).Decode(&request); err != nil || len(request.ScriptHashes) != 1 ||
+				request.ScriptHashes[0] != hashHex {
+				t.Errorf("script_info request = %+v (%v)", request, err)
+			}
+			_, _ = io.WriteString(w, `[{"script_hash": "`+hashHex+`", "type": "plutusV3", "bytes": "`+hex.EncodeToString(script)+`", "size": 3}]`)
+		},
+	})
+	got, err := ctx.ScriptCbor(script.Hash())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, script) {
+		t.Fatalf("ScriptCbor() = %x", got)
+	}
+}
+
+func TestDatumByHash(t *testing.T) {
+	// 0x9f182aff is a non-canonical (indefinite-length) list; the provider
+	// bytes must be kept so the hash still matches.
+	datumCbor := []byte{0x9f, 0x18, 0x2a, 0xff}
+	hash := common.Blake2b256Hash(datumCbor)
+	ctx := newTestServer(t, map[string]http.HandlerFunc{
+		"POST /api/v1/datum_info": respond(`[{"datum_hash": "` + hex.EncodeToString(hash.Bytes()) + `", "bytes": "9f182aff", "value": {"list": [{"int": 42}]}}]`),
+	})
+	datum, err := backend.DatumByHashContext(context.Background(), ctx, hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(datum.Cbor(), datumCbor) {
+		t.Fatalf("datum CBOR = %x", datum.Cbor())
+	}
+}
+
+func TestRequestContextPropagatesCancellation(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	chainContext := NewKoiosChainContext("https://example.invalid", 0, "")
+	if _, err := chainContext.TipContext(ctx); !errors.Is(err, context.Canceled) {
+		t.Fatalf("TipContext() error = %v, want context.Canceled", err)
+	}
+}
+
+func TestReferenceScriptAcceptsUnwrappedPlutusBytes(t *testing.T) {
+	script := common.PlutusV2Script{0x42, 0x01, 0x02}
+	inner, err := cbor.Encode([]byte{0x01, 0x02})
+	if err != nil || !bytes.Equal(inner, script) {
+		t.Fatalf("test script is not a wrapped byte string: %x", inner)
+	}
+	ref := koiosScript{
+		Hash:  hex.EncodeToString(script.Hash().Bytes()),
+		Type:  "plutusV2",
+		Bytes: "0102",
+	}
+	scriptRef, err := ref.toScriptRef()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if scriptRef.Script.Hash() != script.Hash() {
+		t.Fatal("unwrapped script bytes decoded to the wrong script")
+	}
+
+	ref.Bytes = "0103"
+	if _, err := ref.toScriptRef(); err == nil {
+		t.Fatal("toScriptRef() accepted bytes that do not match the hash")
+	}
+}
@@ -8,11 +8,13 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"iter"
 	"math"
 	"math/big"
 	"net/http"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/blinklabs-io/gouroboros/cbor"
@@ -39,21 +41,76 @@ type MaestroChainContext struct {
 	apiKey string
 }
 
+var (
+	_ backend.ContextChainContext = (*MaestroChainContext)(nil)
+	_ backend.UtxoStreamer        = (*MaestroChainContext)(nil)
+)
+
 const (
-	maxMaestroEvaluateErrorResponseBytes = 64 * 1024
-	defaultMaestroHTTPTimeout            = 30 * time.Second
+	maxMaestroErrorResponseBytes = 64 * 1024
+	defaultMaestroHTTPTimeout    = 30 * time.Second
 )
 
+// contextTransport binds SDK requests to the caller's context and records
+// the last error response they receive.
 type contextTransport struct {
-	ctx  context.Context
-	base http.RoundTripper
+	ctx    context.Context
+	base   http.RoundTripper
+	status *responseStatus
 }
 
 func (t contextTransport) RoundTrip(req *http.Request) (*http.Response, error) {
-	return t.base.RoundTrip(req.Clone(t.ctx))
+	resp, err := t.base.RoundTrip(req.Clone(t.ctx))
+	if err != nil || resp.StatusCode < http.StatusMultipleChoices {
+		return resp, err
+	}
+	msg, err := io.ReadAll(io.LimitReader(resp.Body, maxMaestroErrorResponseBytes))
+	_ = resp.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read maestro error response with status %d: %w", resp.StatusCode, err)
+	}
+	// Hand the SDK the same bytes, so its own error handling is unchanged.
+	resp.Body = io.NopCloser(bytes.NewReader(msg))
+	t.status.record(statusError(resp, msg))
+	return resp, nil
+}
+
+// responseStatus holds the last error response an SDK call received. The SDK
+// reports those without their status code, so callers return the recorded
+// *backend.StatusError instead, which retry and failover can classify.
+type responseStatus struct {
+	mu  sync.Mutex
+	err *backend.StatusError
+}
+
+func (s *responseStatus) record(err *backend.StatusError) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.err = err
+}
+
+// wrap returns the recorded status error in place of a failed call's err.
+func (s *responseStatus) wrap(err error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err == nil || s.err == nil {
+		return err
+	}
+	return s.err
+}
+
+func statusError(resp *http.Response, msg []byte) *backend.StatusError {
+	return &backend.StatusError{
+		Backend:    "maestro",
+		StatusCode: resp.StatusCode,
+		Message:    strings.TrimSpace(string(msg)),
+		RetryAfter: backend.ParseRetryAfter(resp.Header.Get("Retry-After"), time.Now()),
+	}
 }
 
-func (m *MaestroChainContext) clientWithContext(ctx context.Context) *maestroClient.Client {
+// clientWithContext returns a copy of the SDK client whose requests use ctx,
+// and the status of the error responses they receive.
+func (m *MaestroChainContext) clientWithContext(ctx context.Context) (*maestroClient.Client, *responseStatus) {
 	if ctx == nil {
 		ctx = context.Background()
 	}
@@ -67,14 +124,16 @@ func (m *MaestroChainContext) clientWithContext(ctx context.Context) *maestroCli
 	if transport == nil {
 		transport = http.DefaultTransport
 	}
-	contextClient.Transport = contextTransport{ctx: ctx, base: transport}
+	status := &responseStatus{}
+	contextClient.Transport = contextTransport{ctx: ctx, base: transport, status: status}
 	client.HTTPClient = &contextClient
-	return &client
+	return &client, status
 }
 
 // Capabilities reports the Maestro operations supported by this client.
 func (m *MaestroChainContext) Capabilities() backend.CapabilitySet {
-	return backend.CapabilitySet(backend.AllCapabilities) &^ backend.CapabilitySet(backend.CapabilityGenesisParams)
+	capabilities := backend.CapabilitySet(backend.AllCapabilities | backend.CapabilityDatumByHash)
+	return capabilities &^ backend.CapabilitySet(backend.CapabilityGenesisParams)
 }
 
 // NewMaestroChainContext creates a new Maestro chain context.
@@ -129,9 +188,10 @@ func (m *MaestroChainContext) ProtocolParams() (backend.ProtocolParameters, erro
 }
 
 func (m *MaestroChainContext) ProtocolParamsContext(ctx context.Context) (backend.ProtocolParameters, error) {
-	resp, err := m.clientWithContext(ctx).ProtocolParameters()
+	client, status := m.clientWithContext(ctx)
+	resp, err := client.ProtocolParameters()
 	if err != nil {
-		return backend.ProtocolParameters{}, err
+		return backend.ProtocolParameters{}, status.wrap(err)
 	}
 
 	data := resp.Data
@@ -241,9 +301,10 @@ func (m *MaestroChainContext) CurrentEpoch() (uint64, error) {
 }
 
 func (m *MaestroChainContext) CurrentEpochContext(ctx context.Context) (uint64, error) {
-	resp, err := m.clientWithContext(ctx).CurrentEpoch()
+	client, status := m.clientWithContext(ctx)
+	resp, err := client.CurrentEpoch()
 	if err != nil {
-		return 0, err
+		return 0, status.wrap(err)
 	}
 	if resp.Data.EpochNo < 0 {
 		return 0, fmt.Errorf("invalid epoch value: %d", resp.Data.EpochNo)
@@ -268,9 +329,10 @@ func (m *MaestroChainContext) Tip() (uint64, error) {
 }
 
 func (m *MaestroChainContext) TipContext(ctx context.Context) (uint64, error) {
-	resp, err := m.clientWithContext(ctx).ChainTip()
+	client, status := m.clientWithContext(ctx)
+	resp, err := client.ChainTip()
 	if err != nil {
-		return 0, err
+		return 0, status.wrap(err)
 	}
 	if resp.Data.Slot < 0 {
 		return 0, fmt.Errorf("invalid slot value: %d", resp.Data.Slot)
@@ -283,39 +345,42 @@ func (m *MaestroChainContext) Utxos(address common.Address) ([]common.Utxo, erro
 }
 
 func (m *MaestroChainContext) UtxosContext(ctx context.Context, address common.Address) ([]common.Utxo, error) {
-	const maxPages = 1000
-	var allUtxos []common.Utxo
-	params := utils.NewParameters()
-	var lastCursor string
-	client := m.clientWithContext(ctx)
-
-	for range maxPages {
-		resp, err := client.UtxosAtAddress(address.String(), params)
-		if err != nil {
-			return nil, err
-		}
+	return backend.CollectUtxos(m.UtxosSeq(ctx, address))
+}
 
-		for _, raw := range resp.Data {
-			utxo, err := maestroUtxoToCommon(raw, address)
+// UtxosSeq follows Maestro's pagination cursor, fetching each page only once
+// the previous one has been consumed.
+func (m *MaestroChainContext) UtxosSeq(ctx context.Context, address common.Address) iter.Seq2[common.Utxo, error] {
+	const maxPages = 1000
+	return func(yield func(common.Utxo, error) bool) {
+		params := utils.NewParameters()
+		client, status := m.clientWithContext(ctx)
+		for range maxPages {
+			resp, err := client.UtxosAtAddress(address.String(), params)
 			if err != nil {
-				return nil, fmt.Errorf("failed to parse UTxO: %w", err)
+				yield(common.Utxo{}, status.wrap(err))
+				return
 			}
-			allUtxos = append(allUtxos, utxo)
-		}
 
-		lastCursor = resp.NextCursor
-		if lastCursor == "" {
-			break
-		}
-		params = utils.NewParameters()
-		params.Cursor(lastCursor)
-	}
+			for _, raw := range resp.Data {
+				utxo, err := maestroUtxoToCommon(raw, address)
+				if err != nil {
+					yield(common.Utxo{}, fmt.Errorf("failed to parse UTxO: %w", err))
+					return
+				}
+				if !yield(utxo, nil) {
+					return
+				}
+			}
 
-	if lastCursor != "" {
-		return nil, fmt.Errorf("UTxO pagination exceeded %d pages; results may be incomplete", maxPages)
+			if resp.NextCursor == "" {
+				return
+			}
+			params = utils.NewParameters()
+			params.Cursor(resp.NextCursor)
+		}
+		yield(common.Utxo{}, fmt.Errorf("UTxO pagination exceeded %d pages; results may be incomplete", maxPages))
 	}
-
-	return allUtxos, nil
 }
 
 func (m *MaestroChainContext) SubmitTx(txCbor []byte) (common.Blake2b256, error) {
@@ -328,9 +393,10 @@ func (m *MaestroChainContext) SubmitTxContext(ctx context.Context, txCbor []byte
 	// TxManagerSubmit instead, which posts the hex-encoded transaction
 	// CBOR to the documented POST /txmanager submit endpoint.
 	txCborHex := hex.EncodeToString(txCbor)
-	txHash, err := m.clientWithContext(ctx).TxManagerSubmit(txCborHex)
+	client, status := m.clientWithContext(ctx)
+	txHash, err := client.TxManagerSubmit(txCborHex)
 	if err != nil {
-		return common.Blake2b256{}, fmt.Errorf("maestro tx submission failed: %w", err)
+		return common.Blake2b256{}, fmt.Errorf("maestro tx submission failed: %w", status.wrap(err))
 	}
 	// The endpoint returns the tx hash as a plain-text body; tolerate JSON
 	// string quoting and surrounding whitespace.
@@ -367,9 +433,10 @@ func (m *MaestroChainContext) EvaluateTxContext(
 	txHex := hex.EncodeToString(txCbor)
 
 	if len(additionalUtxos) == 0 {
-		evalResp, err := m.clientWithContext(ctx).EvaluateTx(txHex)
+		client, status := m.clientWithContext(ctx)
+		evalResp, err := client.EvaluateTx(txHex)
 		if err != nil {
-			return nil, err
+			return nil, status.wrap(err)
 		}
 		return evaluationsToExUnits(evalResp)
 	}
@@ -477,11 +544,11 @@ func (m *MaestroChainContext) postEvaluateContext(
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		msg, err := io.ReadAll(io.LimitReader(resp.Body, maxMaestroEvaluateErrorResponseBytes))
+		msg, err := io.ReadAll(io.LimitReader(resp.Body, maxMaestroErrorResponseBytes))
 		if err != nil {
 			return nil, fmt.Errorf("failed to read maestro evaluate error response with status %d: %w", resp.StatusCode, err)
 		}
-		return nil, fmt.Errorf("maestro evaluate failed with status %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+		return nil, fmt.Errorf("maestro evaluate failed: %w", statusError(resp, msg))
 	}
 
 	var evalResp models.EvaluateTxResponse
@@ -536,9 +603,10 @@ func (m *MaestroChainContext) UtxoByRefContext(
 	index uint32,
 ) (*common.Utxo, error) {
 	hashHex := hex.EncodeToString(txHash.Bytes())
-	resp, err := m.clientWithContext(ctx).TransactionOutputFromReference(hashHex, int(index), nil)
+	client, status := m.clientWithContext(ctx)
+	resp, err := client.TransactionOutputFromReference(hashHex, int(index), nil)
 	if err != nil {
-		return nil, err
+		return nil, status.wrap(err)
 	}
 
 	addr, err := common.NewAddress(resp.Data.Address)
@@ -561,9 +629,10 @@ func (m *MaestroChainContext) ScriptCborContext(
 	scriptHash common.Blake2b224,
 ) ([]byte, error) {
 	hashHex := hex.EncodeToString(scriptHash.Bytes())
-	resp, err := m.clientWithContext(ctx).ScriptByHash(hashHex)
+	client, status := m.clientWithContext(ctx)
+	resp, err := client.ScriptByHash(hashHex)
 	if err != nil {
-		return nil, err
+		return nil, status.wrap(err)
 	}
 	if resp.Data.Bytes == "" {
 		return nil, errors.New("no script CBOR available")
@@ -571,6 +640,23 @@ func (m *MaestroChainContext) ScriptCborContext(
 	return hex.DecodeString(resp.Data.Bytes)
 }
 
+func (m *MaestroChainContext) DatumByHash(datumHash common.Blake2b256) (*common.Datum, error) {
+	return m.DatumByHashContext(context.Background(), datumHash)
+}
+
+func (m *MaestroChainContext) DatumByHashContext(
+	ctx context.Context,
+	datumHash common.Blake2b256,
+) (*common.Datum, error) {
+	hashHex := hex.EncodeToString(datumHash.Bytes())
+	client, status := m.clientWithContext(ctx)
+	resp, err := client.DatumFromHash(hashHex)
+	if err != nil {
+		return nil, status.wrap(err)
+	}
+	return backendutil.DecodeDatumHex(resp.Data.Bytes)
+}
+
 func maestroUtxoToCommon(raw models.Utxo, address common.Address) (common.Utxo, error) {
 	hashBytes, err := hex.DecodeString(raw.TxHash)
 	if err != nil {
@@ -12,6 +12,7 @@ import (
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/blinklabs-io/gouroboros/cbor"
 	"github.com/blinklabs-io/gouroboros/ledger/babbage"
@@ -33,7 +34,7 @@ func TestClientWithContextUsesBoundedFallback(t *testing.T) {
 	chainContext.client.HTTPClient = nil
 
 	//nolint:staticcheck // Verify the Maestro adapter's defensive nil-context fallback.
-	client := chainContext.clientWithContext(nil)
+	client, _ := chainContext.clientWithContext(nil)
 	if client.HTTPClient == nil {
 		t.Fatal("clientWithContext() returned a nil HTTP client")
 	}
@@ -106,7 +107,7 @@ func TestMaestroCapabilitiesAndUnsupportedGenesis(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	if !backend.Supports(ctx, backend.CapabilityEvaluateTxAdditionalUtxos|backend.CapabilityScriptCbor) {
+	if !backend.Supports(ctx, backend.CapabilityEvaluateTxAdditionalUtxos|backend.CapabilityScriptCbor|backend.CapabilityDatumByHash) {
 		t.Fatal("expected Maestro supported capabilities")
 	}
 	if backend.Supports(ctx, backend.CapabilityGenesisParams) {
@@ -357,6 +358,75 @@ func TestSubmitTxPostsCborToTxManager(t *testing.T) {
 	}
 }
 
+func TestSDKErrorResponsesReturnStatusError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/txmanager" {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte("transaction rejected"))
+			return
+		}
+		w.Header().Set("Retry-After", "7")
+		w.WriteHeader(http.StatusTooManyRequests)
+		_, _ = w.Write([]byte(`{"message":"rate limited"}`))
+	}))
+	defer server.Close()
+
+	ctx, err := NewMaestroChainContextWithNetwork(0, "project-id", "preprod")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx.client.BaseUrl = server.URL
+
+	_, err = ctx.Utxos(testAddress(t))
+	var statusErr *backend.StatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusTooManyRequests ||
+		statusErr.RetryAfter != 7*time.Second || !strings.Contains(statusErr.Message, "rate limited") {
+		t.Fatalf("Utxos error = %v, want a 429 StatusError", err)
+	}
+	if !backend.IsRetryable(err) {
+		t.Fatal("a 429 from the SDK path should be retryable")
+	}
+	if _, err := ctx.ProtocolParams(); !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusTooManyRequests {
+		t.Fatalf("ProtocolParams error = %v, want a 429 StatusError", err)
+	}
+
+	_, err = ctx.SubmitTx([]byte{0x84})
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusBadRequest {
+		t.Fatalf("SubmitTx error = %v, want a 400 StatusError", err)
+	}
+	if backend.IsRetryable(err) {
+		t.Fatal("a rejected submission should not be retryable")
+	}
+}
+
+func TestDatumByHashFetchesData(t *testing.T) {
+	datumCbor := []byte{0xd8, 0x79, 0x80}
+	datumHash := common.Blake2b256Hash(datumCbor)
+	var gotPath string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		_, _ = w.Write([]byte(`{"data":{"bytes":"` + hex.EncodeToString(datumCbor) + `","json":{}},"last_updated":{}}`))
+	}))
+	defer server.Close()
+
+	ctx, err := NewMaestroChainContextWithNetwork(0, "project-id", "preprod")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx.client.BaseUrl = server.URL
+
+	datum, err := ctx.DatumByHash(datumHash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gotPath != "/data/"+hex.EncodeToString(datumHash.Bytes()) {
+		t.Fatalf("datum path = %q", gotPath)
+	}
+	if !bytes.Equal(datum.Cbor(), datumCbor) {
+		t.Fatalf("datum CBOR = %x, want %x", datum.Cbor(), datumCbor)
+	}
+}
+
 func TestBuildEvaluateRequest(t *testing.T) {
 	var txId common.Blake2b256
 	idBytes, err := hex.DecodeString(testTxHashHex)
@@ -597,7 +667,7 @@ func TestEvaluateTxWithAdditionalUtxosBoundsHTTPErrorResponse(t *testing.T) {
 
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusBadRequest)
-		_, _ = w.Write([]byte(strings.Repeat("x", maxMaestroEvaluateErrorResponseBytes*2)))
+		_, _ = w.Write([]byte(strings.Repeat("x", maxMaestroErrorResponseBytes*2)))
 	}))
 	defer server.Close()
 
@@ -611,8 +681,8 @@ func TestEvaluateTxWithAdditionalUtxosBoundsHTTPErrorResponse(t *testing.T) {
 	if err == nil {
 		t.Fatal("expected error for non-200 evaluate response")
 	}
-	if len(err.Error()) > maxMaestroEvaluateErrorResponseBytes+100 {
-		t.Fatalf("evaluate error length = %d, want at most %d", len(err.Error()), maxMaestroEvaluateErrorResponseBytes+100)
+	if len(err.Error()) > maxMaestroErrorResponseBytes+100 {
+		t.Fatalf("evaluate error length = %d, want at most %d", len(err.Error()), maxMaestroErrorResponseBytes+100)
 	}
 }
 
@@ -0,0 +1,5 @@
+// Package node implements the Apollo chain backend by talking to a local
+// cardano-node over its node-to-client UNIX socket, so no indexer or API
+// service is needed. State is read with the LocalStateQuery mini-protocol and
+// transactions are submitted with LocalTxSubmission.
+package node
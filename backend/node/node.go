@@ -0,0 +1,543 @@
+package node
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math"
+	"math/big"
+	"net"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	ouroboros "github.com/blinklabs-io/gouroboros"
+	"github.com/blinklabs-io/gouroboros/cbor"
+	"github.com/blinklabs-io/gouroboros/ledger/babbage"
+	"github.com/blinklabs-io/gouroboros/ledger/common"
+	"github.com/blinklabs-io/gouroboros/ledger/conway"
+	"github.com/blinklabs-io/gouroboros/ledger/dijkstra"
+	"github.com/blinklabs-io/gouroboros/ledger/shelley"
+	"github.com/blinklabs-io/gouroboros/protocol/localstatequery"
+
+	"github.com/Salvionied/apollo/v2/backend"
+	"github.com/Salvionied/apollo/v2/internal/backendutil"
+)
+
+// NodeChainContext implements backend.ChainContext against a local
+// cardano-node. Each call opens its own node-to-client connection, as
+// cardano-cli does, so a context is safe for concurrent use and keeps working
+// across node restarts.
+type NodeChainContext struct {
+	socketPath   string
+	networkMagic uint32
+	networkId    uint8
+
+	mu             sync.Mutex
+	cachedParams   *backend.ProtocolParameters
+	cachedGenesis  *backend.GenesisParameters
+	paramsCacheAt  time.Time
+	genesisCacheAt time.Time
+}
+
+var _ backend.ContextChainContext = (*NodeChainContext)(nil)
+
+// Capabilities reports the ChainContext feature set a node can serve. The
+// node-to-client protocols have no script evaluation and no lookup of
+// scripts by hash.
+func (n *NodeChainContext) Capabilities() backend.CapabilitySet {
+	return backend.CapabilitySet(backend.AllCapabilities) &^
+		backend.CapabilitySet(backend.CapabilityEvaluateTx|backend.CapabilityEvaluateTxAdditionalUtxos|
+			backend.CapabilityScriptCbor)
+}
+
+const (
+	backendName = "cardano-node"
+	cacheExpiry = 5 * time.Minute
+)
+
+// NewNodeChainContext creates a backend for the cardano-node listening on the
+// UNIX socket at socketPath. networkMagic must match the node's network, e.g.
+// 764824073 for mainnet or 1 for preprod; networkId is the address network ID
+// (1 for mainnet, 0 for testnets).
+func NewNodeChainContext(socketPath string, networkMagic uint32, networkId uint8) *NodeChainContext {
+	return &NodeChainContext{
+		socketPath:   socketPath,
+		networkMagic: networkMagic,
+		networkId:    networkId,
+	}
+}
+
+// withConnection dials the node, completes the node-to-client handshake and
+// runs fn on the connection. Cancelling ctx closes the socket, which unblocks
+// any protocol exchange in flight.
+func (n *NodeChainContext) withConnection(ctx context.Context, fn func(*ouroboros.Connection) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "unix", n.socketPath)
+	if err != nil {
+		return fmt.Errorf("failed to connect to node socket %s: %w", n.socketPath, err)
+	}
+	stop := context.AfterFunc(ctx, func() { _ = conn.Close() })
+	defer stop()
+
+	errorChan := make(chan error, 10)
+	oConn, err := ouroboros.NewConnection(
+		ouroboros.WithConnection(conn),
+		ouroboros.WithNetworkMagic(n.networkMagic),
+		ouroboros.WithNodeToNode(false),
+		ouroboros.WithKeepAlive(false),
+		ouroboros.WithErrorChan(errorChan),
+	)
+	if err != nil {
+		_ = conn.Close()
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+		return fmt.Errorf("node-to-client handshake failed: %w", err)
+	}
+	defer oConn.Close()
+
+	err = fn(oConn)
+	if err == nil {
+		return nil
+	}
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return ctxErr
+	}
+	select {
+	case connErr := <-errorChan:
+		if connErr != nil {
+			return fmt.Errorf("%w (connection error: %v)", err, connErr)
+		}
+	default:
+	}
+	return err
+}
+
+// query runs fn with the LocalStateQuery client of a fresh connection. All
+// queries made by fn see the same ledger state.
+func (n *NodeChainContext) query(ctx context.Context, fn func(*localstatequery.Client) error) error {
+	return n.withConnection(ctx, func(conn *ouroboros.Connection) error {
+		return fn(conn.LocalStateQuery().Client)
+	})
+}
+
+func (n *NodeChainContext) ProtocolParams() (backend.ProtocolParameters, error) {
+	return n.ProtocolParamsContext(context.Background())
+}
+
+func (n *NodeChainContext) ProtocolParamsContext(ctx context.Context) (backend.ProtocolParameters, error) {
+	n.mu.Lock()
+	if n.cachedParams != nil && time.Since(n.paramsCacheAt) < cacheExpiry {
+		pp := backendutil.CloneProtocolParams(*n.cachedParams)
+		n.mu.Unlock()
+		return pp, nil
+	}
+	n.mu.Unlock()
+
+	var params common.ProtocolParameters
+	err := n.query(ctx, func(client *localstatequery.Client) error {
+		var err error
+		params, err = client.GetCurrentProtocolParams()
+		return err
+	})
+	if err != nil {
+		return backend.ProtocolParameters{}, fmt.Errorf("failed to query protocol parameters: %w", err)
+	}
+	pp, err := protocolParamsFromLedger(params)
+	if err != nil {
+		return backend.ProtocolParameters{}, err
+	}
+
+	cached := backendutil.CloneProtocolParams(pp)
+	n.mu.Lock()
+	n.cachedParams = &cached
+	n.paramsCacheAt = time.Now()
+	n.mu.Unlock()
+
+	return pp, nil
+}
+
+// protocolParamsFromLedger converts the ledger's protocol parameters. Apollo
+// builds Babbage-style outputs, so eras before Babbage are rejected.
+func protocolParamsFromLedger(params common.ProtocolParameters) (backend.ProtocolParameters, error) {
+	switch p := params.(type) {
+	case *dijkstra.DijkstraProtocolParameters:
+		pp := conwayProtocolParams(&p.ConwayProtocolParameters)
+		if p.RefScriptCostStride > 0 {
+			pp.MinFeeReferenceScriptsRange = int(p.RefScriptCostStride)
+		}
+		if multiplier := ratValue(p.RefScriptCostMultiplier); multiplier != nil && multiplier.Sign() > 0 {
+			pp.MinFeeReferenceScriptsMultiplierRational = multiplier
+		}
+		return pp, nil
+	case *conway.ConwayProtocolParameters:
+		return conwayProtocolParams(p), nil
+	case *babbage.BabbageProtocolParameters:
+		upgraded := conway.UpgradePParams(*p)
+		return conwayProtocolParams(&upgraded), nil
+	default:
+		return backend.ProtocolParameters{}, fmt.Errorf(
+			"node returned protocol parameters for an unsupported era (%T); Babbage or later is required",
+			params,
+		)
+	}
+}
+
+func conwayProtocolParams(p *conway.ConwayProtocolParameters) backend.ProtocolParameters {
+	pp := backend.ProtocolParameters{
+		MinFeeConstant:       uintToInt64(uint64(p.MinFeeB)),
+		MinFeeCoefficient:    uintToInt64(uint64(p.MinFeeA)),
+		MaxBlockSize:         uintToInt(uint64(p.MaxBlockBodySize)),
+		MaxTxSize:            uintToInt(uint64(p.MaxTxSize)),
+		MaxBlockHeaderSize:   uintToInt(uint64(p.MaxBlockHeaderSize)),
+		KeyDeposits:          strconv.FormatUint(uint64(p.KeyDeposit), 10),
+		PoolDeposits:         strconv.FormatUint(uint64(p.PoolDeposit), 10),
+		PoolInfluence:        ratFloat(p.A0),
+		MonetaryExpansion:    ratFloat(p.Rho),
+		TreasuryExpansion:    ratFloat(p.Tau),
+		ProtocolMajorVersion: uintToInt(uint64(p.ProtocolVersion.Major)),
+		ProtocolMinorVersion: uintToInt(uint64(p.ProtocolVersion.Minor)),
+		MinUtxo:              "0",
+		MinPoolCost:          strconv.FormatUint(p.MinPoolCost, 10),
+		PriceMem:             ratFloat(p.ExecutionCosts.MemPrice),
+		PriceStep:            ratFloat(p.ExecutionCosts.StepPrice),
+		MaxTxExMem:           strconv.FormatInt(p.MaxTxExUnits.Memory, 10),
+		MaxTxExSteps:         strconv.FormatInt(p.MaxTxExUnits.Steps, 10),
+		MaxBlockExMem:        strconv.FormatInt(p.MaxBlockExUnits.Memory, 10),
+		MaxBlockExSteps:      strconv.FormatInt(p.MaxBlockExUnits.Steps, 10),
+		MaxValSize:           strconv.FormatUint(uint64(p.MaxValueSize), 10),
+		CollateralPercent:    uintToInt(uint64(p.CollateralPercentage)),
+		MaxCollateralInputs:  uintToInt(uint64(p.MaxCollateralInputs)),
+		CoinsPerUtxoByte:     strconv.FormatUint(p.AdaPerUtxoByte, 10),
+		CostModels:           costModelsFromLedger(p.CostModels),
+	}
+	if price := ratValue(p.MinFeeRefScriptCostPerByte); price != nil {
+		pp.MinFeeRefScriptCostPerByteRational = price
+		pp.MinFeeRefScriptCostPerByte, _ = price.Float64()
+	}
+	return pp
+}
+
+// costModelsFromLedger names the ledger's cost models by language as
+// expected by ComputeScriptDataHash.
+func costModelsFromLedger(costModels map[uint][]int64) map[string][]int64 {
+	if costModels == nil {
+		return nil
+	}
+	models := make(map[string][]int64, len(costModels))
+	for language, costs := range costModels {
+		models["PlutusV"+strconv.FormatUint(uint64(language)+1, 10)] = append([]int64(nil), costs...)
+	}
+	return models
+}
+
+func ratValue(r *cbor.Rat) *big.Rat {
+	if r == nil || r.Rat == nil {
+		return nil
+	}
+	return new(big.Rat).Set(r.Rat)
+}
+
+func ratFloat(r *cbor.Rat) float64 {
+	value := ratValue(r)
+	if value == nil {
+		return 0
+	}
+	f, _ := value.Float64()
+	return f
+}
+
+func uintToInt(v uint64) int {
+	if v > math.MaxInt {
+		return math.MaxInt
+	}
+	return int(v)
+}
+
+func uintToInt64(v uint64) int64 {
+	if v > math.MaxInt64 {
+		return math.MaxInt64
+	}
+	return int64(v)
+}
+
+func (n *NodeChainContext) GenesisParams() (backend.GenesisParameters, error) {
+	return n.GenesisParamsContext(context.Background())
+}
+
+func (n *NodeChainContext) GenesisParamsContext(ctx context.Context) (backend.GenesisParameters, error) {
+	n.mu.Lock()
+	if n.cachedGenesis != nil && time.Since(n.genesisCacheAt) < cacheExpiry {
+		gp := *n.cachedGenesis
+		n.mu.Unlock()
+		return gp, nil
+	}
+	n.mu.Unlock()
+
+	var genesis *localstatequery.GenesisConfigResult
+	err := n.query(ctx, func(client *localstatequery.Client) error {
+		var err error
+		genesis, err = client.GetGenesisConfig()
+		return err
+	})
+	if err != nil {
+		return backend.GenesisParameters{}, fmt.Errorf("failed to query genesis config: %w", err)
+	}
+	gp, err := genesisParamsFromLedger(genesis)
+	if err != nil {
+		return backend.GenesisParameters{}, err
+	}
+
+	n.mu.Lock()
+	n.cachedGenesis = &gp
+	n.genesisCacheAt = time.Now()
+	n.mu.Unlock()
+
+	return gp, nil
+}
+
+func genesisParamsFromLedger(genesis *localstatequery.GenesisConfigResult) (backend.GenesisParameters, error) {
+	systemStart, err := systemStartTime(genesis.Start)
+	if err != nil {
+		return backend.GenesisParameters{}, err
+	}
+	coefficient, err := activeSlotsCoefficient(genesis.ActiveSlotsCoeff)
+	if err != nil {
+		return backend.GenesisParameters{}, err
+	}
+	return backend.GenesisParameters{
+		ActiveSlotsCoefficient: coefficient,
+		UpdateQuorum:           genesis.UpdateQuorum,
+		MaxLovelaceSupply:      strconv.FormatInt(genesis.MaxLovelaceSupply, 10),
+		NetworkMagic:           genesis.NetworkMagic,
+		EpochLength:            genesis.EpochLength,
+		SystemStart:            systemStart.Unix(),
+		SlotsPerKesPeriod:      genesis.SlotsPerKESPeriod,
+		SlotLength:             genesis.SlotLength,
+		MaxKesEvolutions:       genesis.MaxKESEvolutions,
+		SecurityParam:          genesis.SecurityParam,
+	}, nil
+}
+
+var picosecondsPerSecond = big.NewInt(1_000_000_000_000)
+
+// systemStartTime decodes the node's system start, which is sent as the year,
+// the day of the year counting from 1 and the picoseconds into that day.
+func systemStartTime(start localstatequery.SystemStartResult) (time.Time, error) {
+	if !start.Year.IsInt64() || start.Year.Int64() < 1 || start.Year.Int64() > 9999 {
+		return time.Time{}, fmt.Errorf("invalid system start year %s", start.Year.String())
+	}
+	if start.Day < 1 || start.Day > 366 {
+		return time.Time{}, fmt.Errorf("invalid system start day %d", start.Day)
+	}
+	seconds := new(big.Int).Quo(&start.Picoseconds, picosecondsPerSecond)
+	if start.Picoseconds.Sign() < 0 || !seconds.IsInt64() || seconds.Int64() >= 86400 {
+		return time.Time{}, fmt.Errorf("invalid system start time of day %s ps", start.Picoseconds.String())
+	}
+	return time.Date(int(start.Year.Int64()), time.January, start.Day, 0, 0, int(seconds.Int64()), 0, time.UTC), nil
+}
+
+// activeSlotsCoefficient decodes the [numerator, denominator] pair the node
+// sends for the active slots coefficient.
+func activeSlotsCoefficient(value []any) (float64, error) {
+	encoded, err := cbor.Encode(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid active slots coefficient: %w", err)
+	}
+	var coefficient cbor.Rat
+	if _, err := cbor.Decode(encoded, &coefficient); err != nil {
+		return 0, fmt.Errorf("invalid active slots coefficient: %w", err)
+	}
+	f, _ := coefficient.Float64()
+	return f, nil
+}
+
+func (n *NodeChainContext) NetworkId() uint8 {
+	return n.networkId
+}
+
+func (n *NodeChainContext) CurrentEpoch() (uint64, error) {
+	return n.CurrentEpochContext(context.Background())
+}
+
+func (n *NodeChainContext) CurrentEpochContext(ctx context.Context) (uint64, error) {
+	var epoch int
+	err := n.query(ctx, func(client *localstatequery.Client) error {
+		var err error
+		epoch, err = client.GetEpochNo()
+		return err
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to query current epoch: %w", err)
+	}
+	if epoch < 0 {
+		return 0, fmt.Errorf("node returned negative epoch %d", epoch)
+	}
+	return uint64(epoch), nil
+}
+
+func (n *NodeChainContext) MaxTxFee() (uint64, error) {
+	return n.MaxTxFeeContext(context.Background())
+}
+
+func (n *NodeChainContext) MaxTxFeeContext(ctx context.Context) (uint64, error) {
+	pp, err := n.ProtocolParamsContext(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return backend.ComputeMaxTxFee(pp)
+}
+
+func (n *NodeChainContext) Tip() (uint64, error) {
+	return n.TipContext(context.Background())
+}
+
+func (n *NodeChainContext) TipContext(ctx context.Context) (uint64, error) {
+	var slot uint64
+	err := n.query(ctx, func(client *localstatequery.Client) error {
+		point, err := client.GetChainPoint()
+		if err != nil {
+			return err
+		}
+		slot = point.Slot
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to query chain tip: %w", err)
+	}
+	return slot, nil
+}
+
+func (n *NodeChainContext) Utxos(address common.Address) ([]common.Utxo, error) {
+	return n.UtxosContext(context.Background(), address)
+}
+
+func (n *NodeChainContext) UtxosContext(ctx context.Context, address common.Address) ([]common.Utxo, error) {
+	var result *localstatequery.UTxOsResult
+	err := n.query(ctx, func(client *localstatequery.Client) error {
+		var err error
+		result, err = client.GetUTxOByAddress([]common.Address{address})
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query UTxOs: %w", err)
+	}
+	return utxosFromResults(result.Results)
+}
+
+// utxosFromResults converts a UTxO query result, ordered by reference so
+// results are deterministic.
+func utxosFromResults(results map[localstatequery.UtxoId]babbage.BabbageTransactionOutput) ([]common.Utxo, error) {
+	utxos := make([]common.Utxo, 0, len(results))
+	for id, output := range results {
+		if id.Idx < 0 || int64(id.Idx) > math.MaxUint32 {
+			return nil, fmt.Errorf("node returned invalid output index %d for %s", id.Idx, id.Hash.String())
+		}
+		out := output
+		utxos = append(utxos, common.Utxo{
+			Id: shelley.ShelleyTransactionInput{
+				TxId:        id.Hash,
+				OutputIndex: uint32(id.Idx),
+			},
+			Output: &out,
+		})
+	}
+	sort.Slice(utxos, func(i, j int) bool {
+		left, right := utxos[i].Id, utxos[j].Id
+		if c := bytes.Compare(left.Id().Bytes(), right.Id().Bytes()); c != 0 {
+			return c < 0
+		}
+		return left.Index() < right.Index()
+	})
+	return utxos, nil
+}
+
+func (n *NodeChainContext) SubmitTx(txCbor []byte) (common.Blake2b256, error) {
+	return n.SubmitTxContext(context.Background(), txCbor)
+}
+
+// SubmitTxContext submits the transaction tagged with the node's current
+// era, which must match the era the transaction was built for.
+func (n *NodeChainContext) SubmitTxContext(ctx context.Context, txCbor []byte) (common.Blake2b256, error) {
+	txHash, err := backendutil.TransactionId(txCbor)
+	if err != nil {
+		return common.Blake2b256{}, err
+	}
+	err = n.withConnection(ctx, func(conn *ouroboros.Connection) error {
+		era, err := conn.LocalStateQuery().Client.GetCurrentEra()
+		if err != nil {
+			return fmt.Errorf("failed to query current era: %w", err)
+		}
+		if era < 0 || era > math.MaxUint16 {
+			return fmt.Errorf("node returned invalid era %d", era)
+		}
+		return conn.LocalTxSubmission().Client.SubmitTx(uint16(era), txCbor)
+	})
+	if err != nil {
+		return common.Blake2b256{}, fmt.Errorf("failed to submit transaction: %w", err)
+	}
+	return txHash, nil
+}
+
+func (n *NodeChainContext) EvaluateTx(txCbor []byte, additionalUtxos []common.Utxo) (map[common.RedeemerKey]common.ExUnits, error) {
+	return n.EvaluateTxContext(context.Background(), txCbor, additionalUtxos)
+}
+
+func (n *NodeChainContext) EvaluateTxContext(
+	ctx context.Context,
+	_ []byte,
+	_ []common.Utxo,
+) (map[common.RedeemerKey]common.ExUnits, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return nil, backend.NewUnsupportedError(backendName, backend.CapabilityEvaluateTx)
+}
+
+func (n *NodeChainContext) UtxoByRef(txHash common.Blake2b256, index uint32) (*common.Utxo, error) {
+	return n.UtxoByRefContext(context.Background(), txHash, index)
+}
+
+func (n *NodeChainContext) UtxoByRefContext(
+	ctx context.Context,
+	txHash common.Blake2b256,
+	index uint32,
+) (*common.Utxo, error) {
+	var result *localstatequery.UTxOByTxInResult
+	err := n.query(ctx, func(client *localstatequery.Client) error {
+		var err error
+		result, err = client.GetUTxOByTxIn([]common.TransactionInput{
+			shelley.ShelleyTransactionInput{TxId: txHash, OutputIndex: index},
+		})
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query UTxO %s#%d: %w", txHash.String(), index, err)
+	}
+	utxos, err := utxosFromResults(result.Results)
+	if err != nil {
+		return nil, err
+	}
+	for _, utxo := range utxos {
+		if utxo.Id.Id() == txHash && utxo.Id.Index() == index {
+			return &utxo, nil
+		}
+	}
+	return nil, backend.ErrUtxoNotFound
+}
+
+func (n *NodeChainContext) ScriptCbor(scriptHash common.Blake2b224) ([]byte, error) {
+	return n.ScriptCborContext(context.Background(), scriptHash)
+}
+
+func (n *NodeChainContext) ScriptCborContext(ctx context.Context, _ common.Blake2b224) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return nil, backend.NewUnsupportedError(backendName, backend.CapabilityScriptCbor)
+}
@@ -0,0 +1,508 @@
+package node
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	ouroboros "github.com/blinklabs-io/gouroboros"
+	"github.com/blinklabs-io/gouroboros/cbor"
+	"github.com/blinklabs-io/gouroboros/ledger/babbage"
+	"github.com/blinklabs-io/gouroboros/ledger/common"
+	"github.com/blinklabs-io/gouroboros/ledger/conway"
+	"github.com/blinklabs-io/gouroboros/ledger/mary"
+	pcommon "github.com/blinklabs-io/gouroboros/protocol/common"
+	"github.com/blinklabs-io/gouroboros/protocol/localstatequery"
+	"github.com/blinklabs-io/gouroboros/protocol/localtxsubmission"
+
+	"github.com/Salvionied/apollo/v2/backend"
+)
+
+const testNetworkMagic = 42
+
+// mockNode is a node-to-client peer built from the gouroboros server side of
+// LocalStateQuery and LocalTxSubmission, listening on a UNIX socket.
+type mockNode struct {
+	socketPath string
+	era        int
+	answer     func(query any) (any, error)
+
+	mu        sync.Mutex
+	queries   []any
+	submitted []localtxsubmission.MsgSubmitTxTransaction
+	rejectTx  error
+}
+
+func newMockNode(t *testing.T, answer func(query any) (any, error)) *mockNode {
+	t.Helper()
+	// UNIX socket paths are limited to about 100 bytes, which t.TempDir can
+	// exceed on some systems.
+	dir, err := os.MkdirTemp("", "apollo-node")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = os.RemoveAll(dir) })
+	m := &mockNode{
+		socketPath: filepath.Join(dir, "node.socket"),
+		era:        conway.EraIdConway,
+		answer:     answer,
+	}
+	listener, err := net.Listen("unix", m.socketPath)
+	if err != nil {
+		t.Skipf("UNIX sockets unavailable: %v", err)
+	}
+	var wg sync.WaitGroup
+	var connsMu sync.Mutex
+	var conns []*ouroboros.Connection
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				oConn := m.serve(conn)
+				if oConn != nil {
+					connsMu.Lock()
+					conns = append(conns, oConn)
+					connsMu.Unlock()
+				}
+			}()
+		}
+	}()
+	t.Cleanup(func() {
+		_ = listener.Close()
+		wg.Wait()
+		connsMu.Lock()
+		defer connsMu.Unlock()
+		for _, conn := range conns {
+			_ = conn.Close()
+		}
+	})
+	return m
+}
+
+func (m *mockNode) serve(conn net.Conn) *ouroboros.Connection {
+	errorChan := make(chan error, 10)
+	go func() {
+		// The client hangs up after every call; those errors are expected.
+		for range errorChan {
+		}
+	}()
+	oConn, err := ouroboros.NewConnection(
+		ouroboros.WithConnection(conn),
+		ouroboros.WithNetworkMagic(testNetworkMagic),
+		ouroboros.WithServer(true),
+		ouroboros.WithNodeToNode(false),
+		ouroboros.WithErrorChan(errorChan),
+		ouroboros.WithLocalStateQueryConfig(localstatequery.NewConfig(
+			localstatequery.WithAcquireFunc(func(localstatequery.CallbackContext, localstatequery.AcquireTarget, bool) error {
+				return nil
+			}),
+			localstatequery.WithReleaseFunc(func(localstatequery.CallbackContext) error {
+				return nil
+			}),
+			localstatequery.WithQueryFunc(func(_ localstatequery.CallbackContext, wrapper localstatequery.QueryWrapper) (any, error) {
+				return m.handleQuery(wrapper.Query)
+			}),
+		)),
+		ouroboros.WithLocalTxSubmissionConfig(localtxsubmission.NewConfig(
+			localtxsubmission.WithSubmitTxFunc(func(_ localtxsubmission.CallbackContext, tx localtxsubmission.MsgSubmitTxTransaction) error {
+				m.mu.Lock()
+				defer m.mu.Unlock()
+				m.submitted = append(m.submitted, tx)
+				return m.rejectTx
+			}),
+		)),
+	)
+	if err != nil {
+		_ = conn.Close()
+		return nil
+	}
+	return oConn
+}
+
+// handleQuery answers the hard-fork era query itself and unwraps block
+// queries before handing them to the test's answer function.
+func (m *mockNode) handleQuery(query any) (any, error) {
+	if block, ok := query.(*localstatequery.BlockQuery); ok {
+		switch inner := block.Query.(type) {
+		case *localstatequery.HardForkQuery:
+			if _, ok := inner.Query.(*localstatequery.HardForkCurrentEraQuery); ok {
+				return m.era, nil
+			}
+		case *localstatequery.ShelleyQuery:
+			query = inner.Query
+		}
+	}
+	m.mu.Lock()
+	m.queries = append(m.queries, query)
+	m.mu.Unlock()
+	if m.answer == nil {
+		return nil, fmt.Errorf("unexpected query %T", query)
+	}
+	return m.answer(query)
+}
+
+// recorded returns the Shelley queries and transactions the node received.
+func (m *mockNode) recorded() ([]any, []localtxsubmission.MsgSubmitTxTransaction) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]any(nil), m.queries...), append([]localtxsubmission.MsgSubmitTxTransaction(nil), m.submitted...)
+}
+
+func (m *mockNode) context() *NodeChainContext {
+	return NewNodeChainContext(m.socketPath, testNetworkMagic, 0)
+}
+
+func testAddress(t *testing.T) common.Address {
+	t.Helper()
+	var raw [57]byte
+	raw[1] = 0xAA
+	raw[29] = 0xBB
+	addr, err := common.NewAddressFromBytes(raw[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return addr
+}
+
+func testProtocolParams() conway.ConwayProtocolParameters {
+	rat := func(num, den int64) *cbor.Rat { return &cbor.Rat{Rat: big.NewRat(num, den)} }
+	half := *rat(1, 2)
+	return conway.ConwayProtocolParameters{
+		MinFeeA:              44,
+		MinFeeB:              155381,
+		MaxBlockBodySize:     90112,
+		MaxTxSize:            16384,
+		MaxBlockHeaderSize:   1100,
+		KeyDeposit:           2000000,
+		PoolDeposit:          500000000,
+		MaxEpoch:             18,
+		NOpt:                 500,
+		A0:                   rat(3, 10),
+		Rho:                  rat(3, 1000),
+		Tau:                  rat(1, 5),
+		ProtocolVersion:      common.ProtocolParametersProtocolVersion{Major: 10, Minor: 0},
+		MinPoolCost:          170000000,
+		AdaPerUtxoByte:       4310,
+		CostModels:           map[uint][]int64{1: {1, 2, 3}, 2: {4, 5}},
+		ExecutionCosts:       common.ExUnitPrice{MemPrice: rat(577, 10000), StepPrice: rat(721, 10000000)},
+		MaxTxExUnits:         common.ExUnits{Memory: 14000000, Steps: 10000000000},
+		MaxBlockExUnits:      common.ExUnits{Memory: 62000000, Steps: 20000000000},
+		MaxValueSize:         5000,
+		CollateralPercentage: 150,
+		MaxCollateralInputs:  3,
+		PoolVotingThresholds: conway.PoolVotingThresholds{
+			MotionNoConfidence: half, CommitteeNormal: half, CommitteeNoConfidence: half,
+			HardForkInitiation: half, PpSecurityGroup: half,
+		},
+		DRepVotingThresholds: conway.DRepVotingThresholds{
+			MotionNoConfidence: half, CommitteeNormal: half, CommitteeNoConfidence: half,
+			UpdateToConstitution: half, HardForkInitiation: half, PpNetworkGroup: half,
+			PpEconomicGroup: half, PpTechnicalGroup: half, PpGovGroup: half, TreasuryWithdrawal: half,
+		},
+		GovActionValidityPeriod:    6,
+		GovActionDeposit:           100000000000,
+		DRepDeposit:                500000000,
+		DRepInactivityPeriod:       20,
+		MinFeeRefScriptCostPerByte: rat(25, 2),
+	}
+}
+
+func TestNodeCapabilities(t *testing.T) {
+	caps := NewNodeChainContext("/nonexistent", 1, 0).Capabilities()
+	for _, capability := range []backend.Capability{
+		backend.CapabilityProtocolParams, backend.CapabilityGenesisParams, backend.CapabilityCurrentEpoch,
+		backend.CapabilityTip, backend.CapabilityUtxos, backend.CapabilitySubmitTx, backend.CapabilityUtxoByRef,
+	} {
+		if !caps.Has(capability) {
+			t.Errorf("missing %s", capability)
+		}
+	}
+	for _, capability := range []backend.Capability{
+		backend.CapabilityEvaluateTx, backend.CapabilityScriptCbor, backend.CapabilityDatumByHash,
+	} {
+		if caps.Has(capability) {
+			t.Errorf("unexpectedly reports %s", capability)
+		}
+	}
+}
+
+func TestProtocolParams(t *testing.T) {
+	node := newMockNode(t, func(query any) (any, error) {
+		if _, ok := query.(*localstatequery.ShelleyCurrentProtocolParamsQuery); !ok {
+			return nil, fmt.Errorf("unexpected query %T", query)
+		}
+		return []any{testProtocolParams()}, nil
+	})
+	ctx := node.context()
+
+	pp, err := ctx.ProtocolParams()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pp.MinFeeCoefficient != 44 || pp.MinFeeConstant != 155381 || pp.MaxTxSize != 16384 {
+		t.Fatalf("fee parameters = %d/%d/%d", pp.MinFeeCoefficient, pp.MinFeeConstant, pp.MaxTxSize)
+	}
+	if pp.KeyDeposits != "2000000" || pp.CoinsPerUtxoByte != "4310" || pp.MaxTxExSteps != "10000000000" {
+		t.Fatalf("deposits and limits = %s/%s/%s", pp.KeyDeposits, pp.CoinsPerUtxoByte, pp.MaxTxExSteps)
+	}
+	if pp.PriceMem != 0.0577 || pp.PriceStep != 0.0000721 || pp.ProtocolMajorVersion != 10 {
+		t.Fatalf("prices = %v/%v, protocol %d", pp.PriceMem, pp.PriceStep, pp.ProtocolMajorVersion)
+	}
+	if pp.MinFeeRefScriptCostPerByteRational == nil || pp.MinFeeRefScriptCostPerByteRational.Cmp(big.NewRat(25, 2)) != 0 {
+		t.Fatalf("ref script price = %v", pp.MinFeeRefScriptCostPerByteRational)
+	}
+	if len(pp.CostModels["PlutusV2"]) != 3 || len(pp.CostModels["PlutusV3"]) != 2 || pp.CostModels["PlutusV1"] != nil {
+		t.Fatalf("cost models = %v", pp.CostModels)
+	}
+
+	// The second call is served from the cache.
+	if _, err := ctx.ProtocolParams(); err != nil {
+		t.Fatal(err)
+	}
+	if queries, _ := node.recorded(); len(queries) != 1 {
+		t.Fatalf("node received %d queries, want 1", len(queries))
+	}
+}
+
+func TestProtocolParamsFromBabbage(t *testing.T) {
+	conwayParams := testProtocolParams()
+	pp, err := protocolParamsFromLedger(&babbage.BabbageProtocolParameters{
+		MinFeeA:       conwayParams.MinFeeA,
+		MinFeeB:       conwayParams.MinFeeB,
+		ProtocolMajor: 8,
+		CostModels:    conwayParams.CostModels,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pp.MinFeeCoefficient != 44 || pp.ProtocolMajorVersion != 8 || pp.MinFeeRefScriptCostPerByteRational != nil {
+		t.Fatalf("Babbage parameters = %+v", pp)
+	}
+}
+
+func TestGenesisParams(t *testing.T) {
+	node := newMockNode(t, func(query any) (any, error) {
+		if _, ok := query.(*localstatequery.ShelleyGenesisConfigQuery); !ok {
+			return nil, fmt.Errorf("unexpected query %T", query)
+		}
+		// Mainnet's system start, 2017-09-23T21:44:51Z, is day 266.
+		return []any{[]any{
+			[]any{2017, 266, 78291 * 1_000_000_000_000},
+			764824073, 1,
+			cbor.Tag{Number: cbor.CborTagRational, Content: []any{1, 20}},
+			2160, 432000, 129600, 62, 1, 5, 45000000000000000,
+			[]any{44, 155381, 65536, 16384, 1100, 2000000, 500000000, 18, 150,
+				[]any{3, 10}, []any{3, 1000}, []any{2, 10}, []any{0, 1}, []any{0}, []any{10, 0}, 1000000, 340000000},
+			map[any]any{}, []any{}, []any{[]any{}, map[any]any{}}, []any{},
+		}}, nil
+	})
+
+	gp, err := node.context().GenesisParams()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gp.SystemStart != 1506203091 {
+		t.Fatalf("SystemStart = %d (%s)", gp.SystemStart, time.Unix(gp.SystemStart, 0).UTC())
+	}
+	if gp.NetworkMagic != 764824073 || gp.ActiveSlotsCoefficient != 0.05 || gp.EpochLength != 432000 ||
+		gp.SecurityParam != 2160 || gp.MaxLovelaceSupply != "45000000000000000" || gp.SlotLength != 1 {
+		t.Fatalf("GenesisParams() = %+v", gp)
+	}
+}
+
+func TestTipAndCurrentEpoch(t *testing.T) {
+	node := newMockNode(t, func(query any) (any, error) {
+		switch query.(type) {
+		case *localstatequery.ChainPointQuery:
+			return pcommon.NewPoint(123456789, bytes.Repeat([]byte{0xab}, 32)), nil
+		case *localstatequery.ShelleyEpochNoQuery:
+			return []int{512}, nil
+		}
+		return nil, fmt.Errorf("unexpected query %T", query)
+	})
+	ctx := node.context()
+
+	slot, err := ctx.Tip()
+	if err != nil || slot != 123456789 {
+		t.Fatalf("Tip() = %d, %v", slot, err)
+	}
+	epoch, err := ctx.CurrentEpoch()
+	if err != nil || epoch != 512 {
+		t.Fatalf("CurrentEpoch() = %d, %v", epoch, err)
+	}
+}
+
+func testUtxoResults(t *testing.T) map[localstatequery.UtxoId]babbage.BabbageTransactionOutput {
+	t.Helper()
+	output := func(lovelace uint64) babbage.BabbageTransactionOutput {
+		return babbage.BabbageTransactionOutput{
+			OutputAddress: testAddress(t),
+			OutputAmount:  mary.MaryTransactionOutputValue{Amount: lovelace},
+		}
+	}
+	return map[localstatequery.UtxoId]babbage.BabbageTransactionOutput{
+		{Hash: common.Blake2b256{0x02}, Idx: 0}: output(3_000_000),
+		{Hash: common.Blake2b256{0x01}, Idx: 1}: output(2_000_000),
+		{Hash: common.Blake2b256{0x01}, Idx: 0}: output(1_000_000),
+	}
+}
+
+func TestUtxosByAddress(t *testing.T) {
+	node := newMockNode(t, func(query any) (any, error) {
+		q, ok := query.(*localstatequery.ShelleyUtxoByAddressQuery)
+		if !ok {
+			return nil, fmt.Errorf("unexpected query %T", query)
+		}
+		if len(q.Addrs) != 1 || q.Addrs[0].String() != testAddress(t).String() {
+			return nil, fmt.Errorf("unexpected addresses %v", q.Addrs)
+		}
+		return localstatequery.UTxOsResult{Results: testUtxoResults(t)}, nil
+	})
+
+	utxos, err := node.context().Utxos(testAddress(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(utxos) != 3 {
+		t.Fatalf("Utxos() returned %d UTxOs, want 3", len(utxos))
+	}
+	wantLovelace := []uint64{1_000_000, 2_000_000, 3_000_000}
+	for i, utxo := range utxos {
+		if got := utxo.Output.Amount().Uint64(); got != wantLovelace[i] {
+			t.Fatalf("UTxO %d has %d lovelace, want %d (not sorted by reference?)", i, got, wantLovelace[i])
+		}
+	}
+}
+
+func TestUtxoByRef(t *testing.T) {
+	node := newMockNode(t, func(query any) (any, error) {
+		q, ok := query.(*localstatequery.ShelleyUtxoByTxinQuery)
+		if !ok {
+			return nil, fmt.Errorf("unexpected query %T", query)
+		}
+		results := map[localstatequery.UtxoId]babbage.BabbageTransactionOutput{}
+		for id, output := range testUtxoResults(t) {
+			for _, in := range q.TxIns {
+				if in.TxId == id.Hash && int(in.OutputIndex) == id.Idx {
+					results[id] = output
+				}
+			}
+		}
+		return localstatequery.UTxOsResult{Results: results}, nil
+	})
+	ctx := node.context()
+
+	utxo, err := ctx.UtxoByRef(common.Blake2b256{0x01}, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if utxo.Id.Id() != (common.Blake2b256{0x01}) || utxo.Id.Index() != 1 || utxo.Output.Amount().Uint64() != 2_000_000 {
+		t.Fatalf("UtxoByRef() = %+v", utxo)
+	}
+	if _, err := ctx.UtxoByRef(common.Blake2b256{0x03}, 0); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Fatalf("UtxoByRef() error = %v, want not found", err)
+	}
+}
+
+func TestSubmitTxUsesCurrentEra(t *testing.T) {
+	node := newMockNode(t, nil)
+	body := []byte{0xa0}
+	txCbor := []byte{0x84, 0xa0, 0xa0, 0xf5, 0xf6}
+
+	hash, err := node.context().SubmitTx(txCbor)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hash != common.Blake2b256Hash(body) {
+		t.Fatalf("SubmitTx() = %s, want the body hash", hash.String())
+	}
+	_, txs := node.recorded()
+	if len(txs) != 1 {
+		t.Fatalf("node received %d transactions, want 1", len(txs))
+	}
+	submitted := txs[0]
+	if submitted.EraId != uint16(conway.EraIdConway) || !bytes.Equal(submitted.Raw.Content.([]byte), txCbor) {
+		t.Fatalf("submitted era %d tx %x", submitted.EraId, submitted.Raw.Content)
+	}
+}
+
+func TestSubmitTxReportsRejection(t *testing.T) {
+	node := newMockNode(t, nil)
+	node.rejectTx = errors.New("bad inputs")
+	_, err := node.context().SubmitTx([]byte{0x84, 0xa0, 0xa0, 0xf5, 0xf6})
+	var rejected localtxsubmission.TransactionRejectedError
+	if !errors.As(err, &rejected) {
+		t.Fatalf("SubmitTx() error = %v, want a rejection", err)
+	}
+}
+
+func TestSubmitTxRejectsMalformedCbor(t *testing.T) {
+	if _, err := NewNodeChainContext("/nonexistent", 1, 0).SubmitTx([]byte{0xff}); err == nil {
+		t.Fatal("SubmitTx accepted malformed CBOR")
+	}
+}
+
+func TestUnsupportedOperations(t *testing.T) {
+	ctx := NewNodeChainContext("/nonexistent", 1, 0)
+	if _, err := ctx.EvaluateTx([]byte{0x84}, nil); !errors.Is(err, backend.ErrUnsupported) {
+		t.Fatalf("EvaluateTx() error = %v", err)
+	}
+	if _, err := ctx.ScriptCbor(common.Blake2b224{}); !errors.Is(err, backend.ErrUnsupported) {
+		t.Fatalf("ScriptCbor() error = %v", err)
+	}
+}
+
+func TestConnectionErrors(t *testing.T) {
+	ctx := NewNodeChainContext(filepath.Join(t.TempDir(), "missing.socket"), 1, 0)
+	if _, err := ctx.Tip(); err == nil || !strings.Contains(err.Error(), "node socket") {
+		t.Fatalf("Tip() error = %v, want a socket error", err)
+	}
+
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := ctx.TipContext(canceled); !errors.Is(err, context.Canceled) {
+		t.Fatalf("TipContext() error = %v, want context.Canceled", err)
+	}
+}
+
+func TestQueryHonoursContextDeadline(t *testing.T) {
+	release := make(chan struct{})
+	node := newMockNode(t, func(any) (any, error) {
+		<-release
+		return []int{1}, nil
+	})
+	defer close(release)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if _, err := node.context().CurrentEpochContext(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("CurrentEpochContext() error = %v, want context.DeadlineExceeded", err)
+	}
+}
+
+func TestSystemStartTimeRejectsInvalidValues(t *testing.T) {
+	for _, start := range []localstatequery.SystemStartResult{
+		{Year: *big.NewInt(2017), Day: 0},
+		{Year: *big.NewInt(2017), Day: 1, Picoseconds: *big.NewInt(-1)},
+		{Year: *big.NewInt(2017), Day: 1, Picoseconds: *new(big.Int).Mul(big.NewInt(86400), picosecondsPerSecond)},
+		{Year: *big.NewInt(0), Day: 1},
+	} {
+		if _, err := systemStartTime(start); err == nil {
+			t.Errorf("systemStartTime(%s) succeeded", start)
+		}
+	}
+}
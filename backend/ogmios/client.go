@@ -6,6 +6,8 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"net/http"
 	"net/url"
 	"strings"
 	"time"
@@ -15,6 +17,10 @@ import (
 	"github.com/SundaeSwap-finance/ogmigo/v6/ouroboros/chainsync"
 	"github.com/SundaeSwap-finance/ogmigo/v6/ouroboros/shared"
 	"github.com/blinklabs-io/gouroboros/ledger/common"
+	"github.com/gorilla/websocket"
+
+	"github.com/Salvionied/apollo/v2/backend"
+	"github.com/Salvionied/apollo/v2/internal/backendutil"
 )
 
 // OgmiosClient is the Ogmios query surface OgmiosChainContext depends on.
@@ -78,6 +84,18 @@ type KupoClient interface {
 	) ([]byte, error)
 }
 
+// KupoDatumClient is an optional extension to KupoClient for clients that
+// can resolve a datum by its hash, which Kupo serves from /datums. A context
+// whose Kupo client implements it reports CapabilityDatumByHash. It is
+// separate from KupoClient so existing implementations remain source
+// compatible.
+type KupoDatumClient interface {
+	DatumByHash(
+		ctx context.Context,
+		datumHash common.Blake2b256,
+	) (*common.Datum, error)
+}
+
 // ogmiosWebsocketURL validates an Ogmios endpoint and returns the URL to
 // dial. Ogmios serves JSON-RPC over WebSocket, so an http or https URL is
 // accepted and dialed as ws or wss rather than rejected.
@@ -159,27 +177,66 @@ func newOgmigoClient(endpoint string) (*ogmigoClient, error) {
 	}, nil
 }
 
+// ogmiosError marks the ogmigo failures a later attempt can get past as
+// retryable: a refused websocket handshake, which is how a proxy in front of
+// Ogmios reports a 429 or 503, and a connection the server closed while
+// restarting or overloaded. Network failures need no marking, since
+// backend.IsRetryable classifies them already.
+func ogmiosError(err error) error {
+	if err == nil {
+		return nil
+	}
+	if errors.Is(err, websocket.ErrBadHandshake) {
+		return backend.NewRetryableError(err, 0)
+	}
+	var closeErr *websocket.CloseError
+	if errors.As(err, &closeErr) {
+		switch closeErr.Code {
+		case websocket.CloseGoingAway,
+			websocket.CloseAbnormalClosure,
+			websocket.CloseInternalServerErr,
+			websocket.CloseServiceRestart,
+			websocket.CloseTryAgainLater:
+			return backend.NewRetryableError(err, 0)
+		}
+	}
+	return err
+}
+
+// ogmiosSubmitError marks only a refused handshake retryable. A connection
+// closed after the transaction was sent may have delivered it, and retry
+// treats a retryable submit failure as proof that it did not.
+func ogmiosSubmitError(err error) error {
+	if errors.Is(err, websocket.ErrBadHandshake) {
+		return backend.NewRetryableError(err, 0)
+	}
+	return err
+}
+
 func (c *ogmigoClient) ProtocolParameters(
 	ctx context.Context,
 ) (json.RawMessage, error) {
-	return c.client.CurrentProtocolParameters(ctx)
+	params, err := c.client.CurrentProtocolParameters(ctx)
+	return params, ogmiosError(err)
 }
 
 func (c *ogmigoClient) GenesisConfig(
 	ctx context.Context,
 	era string,
 ) (json.RawMessage, error) {
-	return c.client.GenesisConfig(ctx, era)
+	config, err := c.client.GenesisConfig(ctx, era)
+	return config, ogmiosError(err)
 }
 
 func (c *ogmigoClient) CurrentEpoch(ctx context.Context) (uint64, error) {
-	return c.client.CurrentEpoch(ctx)
+	epoch, err := c.client.CurrentEpoch(ctx)
+	return epoch, ogmiosError(err)
 }
 
 func (c *ogmigoClient) Tip(ctx context.Context) (uint64, error) {
 	point, err := c.client.ChainTip(ctx)
 	if err != nil {
-		return 0, err
+		return 0, ogmiosError(err)
 	}
 	ps, ok := point.PointStruct()
 	if !ok || ps == nil {
@@ -194,7 +251,7 @@ func (c *ogmigoClient) SubmitTx(
 ) (common.Blake2b256, error) {
 	resp, err := c.client.SubmitTx(ctx, hex.EncodeToString(txCbor))
 	if err != nil {
-		return common.Blake2b256{}, err
+		return common.Blake2b256{}, ogmiosSubmitError(err)
 	}
 	if resp == nil {
 		return common.Blake2b256{}, errors.New("empty submit tx response")
@@ -241,7 +298,7 @@ func (c *ogmigoClient) EvaluateTx(
 		resp, err = c.client.EvaluateTx(ctx, txHex)
 	}
 	if err != nil {
-		return nil, err
+		return nil, ogmiosError(err)
 	}
 	return evaluateResponseToExUnits(resp)
 }
@@ -259,10 +316,10 @@ func (c *ogmigoClient) UtxoByRef(
 	}
 	utxos, err := c.client.UtxosByTxIn(ctx, query)
 	if err != nil {
-		return nil, err
+		return nil, ogmiosError(err)
 	}
 	if len(utxos) == 0 {
-		return nil, errors.New("utxo not found")
+		return nil, backend.ErrUtxoNotFound
 	}
 
 	raw := utxos[0]
@@ -277,13 +334,25 @@ func (c *ogmigoClient) UtxoByRef(
 	return &result, nil
 }
 
-// kugoClient adapts the kugo client to KupoClient. As with ogmigoClient,
-// every kugo call Apollo makes goes through this type.
+// kugoClient is the default KupoClient. It makes its own requests and decodes
+// the responses into kugo's types: the kugo client decodes an error response
+// as if it were a result, which would hide a 429 or 503 from retry and
+// failover behind a decode error.
 type kugoClient struct {
-	client *kugo.Client
+	endpoint *url.URL
+	http     *http.Client
 }
 
-var _ KupoClient = (*kugoClient)(nil)
+var (
+	_ KupoClient      = (*kugoClient)(nil)
+	_ KupoDatumClient = (*kugoClient)(nil)
+)
+
+const (
+	// defaultKupoTimeout matches the kugo client's default.
+	defaultKupoTimeout        = 5 * time.Minute
+	maxKupoErrorResponseBytes = 64 * 1024
+)
 
 // newKugoClient builds the default KupoClient for an endpoint. A zero timeout
 // leaves the kugo default in place.
@@ -295,19 +364,89 @@ func newKugoClient(
 	if err != nil {
 		return nil, err
 	}
-	options := []kugo.Option{kugo.WithEndpoint(baseURL)}
-	if timeout > 0 {
-		options = append(options, kugo.WithTimeout(timeout))
+	parsed, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid kupo endpoint %q: %w", endpoint, err)
+	}
+	if timeout <= 0 {
+		timeout = defaultKupoTimeout
+	}
+	return &kugoClient{
+		endpoint: parsed,
+		http:     &http.Client{Timeout: timeout},
+	}, nil
+}
+
+// get requests path from Kupo and decodes the JSON response into v. As with
+// kugo, path replaces any path on the endpoint. A response outside the 2xx
+// range is returned as a *backend.StatusError.
+func (c *kugoClient) get(
+	ctx context.Context,
+	path string,
+	query string,
+	v any,
+) error {
+	target := *c.endpoint
+	target.Path = path
+	target.RawPath = ""
+	target.RawQuery = query
+	req, err := http.NewRequestWithContext(
+		ctx, http.MethodGet, target.String(), nil,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to build kupo request: %w", err)
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("kupo request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < http.StatusOK ||
+		resp.StatusCode >= http.StatusMultipleChoices {
+		msg, _ := io.ReadAll(
+			io.LimitReader(resp.Body, maxKupoErrorResponseBytes),
+		)
+		return &backend.StatusError{
+			Backend:    "kupo",
+			StatusCode: resp.StatusCode,
+			Message:    strings.TrimSpace(string(msg)),
+			RetryAfter: backend.ParseRetryAfter(
+				resp.Header.Get("Retry-After"), time.Now(),
+			),
+		}
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("failed to decode kupo response: %w", err)
+	}
+	return nil
+}
+
+// Datum returns the CBOR hex of the datum with the given hash, or "" when
+// Kupo does not know it. It makes the client the datumFetcher matchToUtxo
+// resolves inline datums with.
+func (c *kugoClient) Datum(
+	ctx context.Context,
+	datumHash string,
+) (string, error) {
+	var response *struct {
+		Datum string `json:"datum"`
+	}
+	if err := c.get(ctx, "/v1/datums/"+datumHash, "", &response); err != nil {
+		return "", err
 	}
-	return &kugoClient{client: kugo.New(options...)}, nil
+	if response == nil {
+		return "", nil
+	}
+	return response.Datum, nil
 }
 
 func (c *kugoClient) UtxosAtAddress(
 	ctx context.Context,
 	address common.Address,
 ) ([]common.Utxo, error) {
-	matches, err := c.client.Matches(
-		ctx, kugo.OnlyUnspent(), kugo.Address(address.String()),
+	var matches []kugo.Match
+	err := c.get(
+		ctx, "/v1/matches/"+address.String(), "unspent", &matches,
 	)
 	if err != nil {
 		return nil, err
@@ -317,7 +456,7 @@ func (c *kugoClient) UtxosAtAddress(
 	for _, match := range matches {
 		// The client doubles as the datum fetcher: kupo reports only the
 		// datum hash in a match, so inline datums are resolved separately.
-		utxo, err := matchToUtxo(ctx, match, address, c.client)
+		utxo, err := matchToUtxo(ctx, match, address, c)
 		if err != nil {
 			return nil, fmt.Errorf("failed to parse UTxO match: %w", err)
 		}
@@ -331,8 +470,8 @@ func (c *kugoClient) ScriptCbor(
 	scriptHash common.Blake2b224,
 ) ([]byte, error) {
 	hashHex := hex.EncodeToString(scriptHash.Bytes())
-	script, err := c.client.Script(ctx, hashHex)
-	if err != nil {
+	var script *kugo.Script
+	if err := c.get(ctx, "/v1/scripts/"+hashHex, "", &script); err != nil {
 		return nil, err
 	}
 	if script == nil {
@@ -340,3 +479,18 @@ func (c *kugoClient) ScriptCbor(
 	}
 	return hex.DecodeString(script.Script)
 }
+
+func (c *kugoClient) DatumByHash(
+	ctx context.Context,
+	datumHash common.Blake2b256,
+) (*common.Datum, error) {
+	hashHex := hex.EncodeToString(datumHash.Bytes())
+	datumCborHex, err := c.Datum(ctx, hashHex)
+	if err != nil {
+		return nil, err
+	}
+	if datumCborHex == "" {
+		return nil, fmt.Errorf("kupo returned no datum for %s", hashHex)
+	}
+	return backendutil.DecodeDatumHex(datumCborHex)
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/hex"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"strconv"
@@ -17,6 +18,7 @@ import (
 	"github.com/blinklabs-io/gouroboros/ledger/common"
 	"github.com/blinklabs-io/gouroboros/ledger/mary"
 	"github.com/blinklabs-io/gouroboros/ledger/shelley"
+	"github.com/gorilla/websocket"
 
 	"github.com/Salvionied/apollo/v2/backend"
 )
@@ -235,6 +237,104 @@ func TestNewOgmiosChainContextQueriesKupo(t *testing.T) {
 	}
 }
 
+// TestErrorResponsesAreClassified checks that a rate-limited or unavailable
+// Kupo or Ogmios reaches retry and failover as a retryable error instead of
+// a decode failure, while a request Kupo rejects stays final.
+func TestErrorResponsesAreClassified(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			switch {
+			case strings.HasPrefix(r.URL.Path, "/v1/matches"):
+				w.Header().Set("Retry-After", "7")
+				w.WriteHeader(http.StatusTooManyRequests)
+				_, _ = w.Write([]byte(`{"hint":"rate limited"}`))
+			case strings.HasPrefix(r.URL.Path, "/v1/scripts/"):
+				w.WriteHeader(http.StatusBadRequest)
+				_, _ = w.Write([]byte(`{"hint":"malformed hash"}`))
+			default:
+				w.WriteHeader(http.StatusServiceUnavailable)
+			}
+		},
+	))
+	t.Cleanup(server.Close)
+
+	ctx := testChainContext(t, Config{
+		OgmiosEndpoint: server.URL,
+		KupoEndpoint:   server.URL,
+	})
+
+	_, err := ctx.UtxosContext(t.Context(), testAddress(t))
+	var statusErr *backend.StatusError
+	if !errors.As(err, &statusErr) ||
+		statusErr.StatusCode != http.StatusTooManyRequests ||
+		statusErr.RetryAfter != 7*time.Second ||
+		!strings.Contains(statusErr.Message, "rate limited") {
+		t.Fatalf("Utxos error = %v, want a 429 StatusError", err)
+	}
+	if !backend.IsRetryable(err) {
+		t.Fatal("a 429 from Kupo should be retryable")
+	}
+
+	var scriptHash common.Blake2b224
+	_, err = ctx.ScriptCborContext(t.Context(), scriptHash)
+	if !errors.As(err, &statusErr) ||
+		statusErr.StatusCode != http.StatusBadRequest {
+		t.Fatalf("ScriptCbor error = %v, want a 400 StatusError", err)
+	}
+	if backend.IsRetryable(err) {
+		t.Fatal("a request Kupo rejects should not be retryable")
+	}
+
+	if _, err := ctx.CurrentEpochContext(t.Context()); !backend.IsRetryable(
+		err,
+	) {
+		t.Fatalf("CurrentEpoch error = %v, want retryable", err)
+	}
+	if _, err := ctx.SubmitTxContext(
+		t.Context(), []byte{0x84},
+	); !backend.IsRetryable(err) {
+		t.Fatalf(
+			"SubmitTx error = %v, want retryable: the handshake failed "+
+				"before the transaction was sent", err,
+		)
+	}
+}
+
+// TestOgmiosErrorMarksTransientCloses pins which websocket closes are worth
+// another attempt, and that a submission is retried only when it was never
+// sent.
+func TestOgmiosErrorMarksTransientCloses(t *testing.T) {
+	wrap := func(code int) error {
+		return fmt.Errorf(
+			"failed to read json response: %w",
+			&websocket.CloseError{Code: code},
+		)
+	}
+	tests := []struct {
+		name      string
+		err       error
+		retryable bool
+		submit    bool
+	}{
+		{"try again later", wrap(websocket.CloseTryAgainLater), true, false},
+		{"service restart", wrap(websocket.CloseServiceRestart), true, false},
+		{"going away", wrap(websocket.CloseGoingAway), true, false},
+		{"policy violation", wrap(websocket.ClosePolicyViolation), false, false},
+		{"bad handshake", websocket.ErrBadHandshake, true, true},
+		{"ledger rejection", errors.New("submit tx error"), false, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := backend.IsRetryable(ogmiosError(test.err)); got != test.retryable {
+				t.Fatalf("query retryable = %v, want %v", got, test.retryable)
+			}
+			if got := backend.IsRetryable(ogmiosSubmitError(test.err)); got != test.submit {
+				t.Fatalf("submit retryable = %v, want %v", got, test.submit)
+			}
+		})
+	}
+}
+
 // stubOgmiosClient is a canned OgmiosClient. Implementing the whole interface
 // in a handful of lines is the point: the injection seam stays usable without
 // a server, and without naming a type Apollo does not own.
@@ -337,6 +437,24 @@ func (s *stubKupoClient) ScriptCbor(
 	return s.scriptCbor, nil
 }
 
+// stubKupoDatumClient is a stubKupoClient that also answers datum lookups.
+type stubKupoDatumClient struct {
+	stubKupoClient
+	datum *common.Datum
+
+	datumHashes []string
+}
+
+var _ KupoDatumClient = (*stubKupoDatumClient)(nil)
+
+func (s *stubKupoDatumClient) DatumByHash(
+	_ context.Context,
+	hash common.Blake2b256,
+) (*common.Datum, error) {
+	s.datumHashes = append(s.datumHashes, hex.EncodeToString(hash.Bytes()))
+	return s.datum, nil
+}
+
 // testStubUtxo is a resolved UTxO the stubs can hand back.
 func testStubUtxo(t *testing.T) common.Utxo {
 	t.Helper()
@@ -546,6 +664,54 @@ func TestNewOgmiosChainContextFromClientsNormalizesTypedNilKupo(t *testing.T) {
 	}
 }
 
+// TestDatumByHashRequiresKupoDatumClient checks that datum lookup is only
+// advertised, and only served, when the injected Kupo client implements
+// KupoDatumClient.
+func TestDatumByHashRequiresKupoDatumClient(t *testing.T) {
+	var datum common.Datum
+	if err := datum.UnmarshalCBOR([]byte{0xd8, 0x79, 0x80}); err != nil {
+		t.Fatal(err)
+	}
+	hash := common.Blake2b256Hash(datum.Cbor())
+
+	plain, err := NewOgmiosChainContextFromClients(
+		&stubOgmiosClient{}, &stubKupoClient{}, 0,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if backend.Supports(plain, backend.CapabilityDatumByHash) {
+		t.Fatal("a Kupo client without datum lookup must not report it")
+	}
+	if _, err := plain.DatumByHash(hash); !errors.Is(
+		err, backend.ErrUnsupported,
+	) {
+		t.Fatalf("DatumByHash() error = %v, want ErrUnsupported", err)
+	}
+
+	kupoClient := &stubKupoDatumClient{datum: &datum}
+	ctx, err := NewOgmiosChainContextFromClients(
+		&stubOgmiosClient{}, kupoClient, 0,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !backend.Supports(ctx, backend.CapabilityDatumByHash) {
+		t.Fatal("a KupoDatumClient must report datum lookup")
+	}
+	got, err := backend.DatumByHashContext(context.Background(), ctx, hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hex.EncodeToString(got.Cbor()) != "d87980" {
+		t.Fatalf("datum CBOR = %x, want d87980", got.Cbor())
+	}
+	if len(kupoClient.datumHashes) != 1 ||
+		kupoClient.datumHashes[0] != hex.EncodeToString(hash.Bytes()) {
+		t.Fatalf("Kupo datum lookups = %v", kupoClient.datumHashes)
+	}
+}
+
 // TestZeroValueChainContextReturnsErrors pins the audit fix: a context no
 // constructor produced has no client, and every method must report that
 // rather than dereference nil.
@@ -155,7 +155,8 @@ func (o *OgmiosChainContext) kupoClient(
 
 // Capabilities reports the operations supported by the configured Ogmios
 // client. Address UTxO queries and script lookup require the optional Kupo
-// client; UTxO-by-reference queries are served directly by Ogmios.
+// client, and datum lookup a Kupo client implementing KupoDatumClient;
+// UTxO-by-reference queries are served directly by Ogmios.
 func (o *OgmiosChainContext) Capabilities() backend.CapabilitySet {
 	if o == nil || o.ogmios == nil {
 		return 0
@@ -166,6 +167,9 @@ func (o *OgmiosChainContext) Capabilities() backend.CapabilitySet {
 			backend.CapabilityUtxos | backend.CapabilityScriptCbor,
 		)
 	}
+	if _, ok := o.kupo.(KupoDatumClient); ok {
+		capabilities |= backend.CapabilitySet(backend.CapabilityDatumByHash)
+	}
 	return capabilities
 }
 
@@ -488,6 +492,25 @@ func (o *OgmiosChainContext) ScriptCborContext(
 	return client.ScriptCbor(ctx, scriptHash)
 }
 
+func (o *OgmiosChainContext) DatumByHash(datumHash common.Blake2b256) (*common.Datum, error) {
+	return o.DatumByHashContext(context.Background(), datumHash)
+}
+
+func (o *OgmiosChainContext) DatumByHashContext(
+	ctx context.Context,
+	datumHash common.Blake2b256,
+) (*common.Datum, error) {
+	client, err := o.kupoClient(backend.CapabilityDatumByHash)
+	if err != nil {
+		return nil, err
+	}
+	datums, ok := client.(KupoDatumClient)
+	if !ok {
+		return nil, backend.NewUnsupportedError("Kupo client", backend.CapabilityDatumByHash)
+	}
+	return datums.DatumByHash(ctx, datumHash)
+}
+
 // --- Ogmios response types and conversion ---
 
 type ogmiosProtocolParams struct {
@@ -0,0 +1,5 @@
+// Package replay records the calls made through a chain backend to a fixture
+// file and serves them back offline, so tests that build transactions against
+// real chain data run deterministically and without a network. Replay fails
+// loudly with ErrNotRecorded on any call the fixture does not cover.
+package replay
@@ -0,0 +1,285 @@
+package replay
+
+import (
+	"cmp"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/blinklabs-io/gouroboros/cbor"
+	"github.com/blinklabs-io/gouroboros/ledger"
+	"github.com/blinklabs-io/gouroboros/ledger/common"
+	"github.com/blinklabs-io/gouroboros/ledger/shelley"
+
+	"github.com/Salvionied/apollo/v2/backend"
+	"github.com/Salvionied/apollo/v2/internal/backendutil"
+)
+
+// FixtureVersion is the fixture format this package writes and reads.
+const FixtureVersion = 1
+
+// Fixture is a recorded session: the wrapped context's identity and every
+// call made through it, in order. It is stored as indented JSON so fixtures
+// review well in diffs.
+type Fixture struct {
+	Version      int                   `json:"version"`
+	NetworkId    uint8                 `json:"network_id"`
+	Capabilities backend.CapabilitySet `json:"capabilities"`
+	Calls        []Call                `json:"calls"`
+}
+
+// Call is one recorded ChainContext call. Key identifies the arguments, such
+// as an address or an output reference; Result holds the method's result as
+// JSON, or Error the error it returned.
+type Call struct {
+	Method string          `json:"method"`
+	Key    string          `json:"key,omitempty"`
+	Result json.RawMessage `json:"result,omitempty"`
+	Error  *CallError      `json:"error,omitempty"`
+}
+
+// Recorded error kinds. Errors callers branch on keep their type on replay;
+// any other error replays as its message, still retryable if it was.
+const (
+	ErrorKindUnsupported  = "unsupported"
+	ErrorKindUtxoNotFound = "utxo_not_found"
+	ErrorKindStatus       = "status"
+	ErrorKindOther        = "other"
+)
+
+// CallError is a recorded error.
+type CallError struct {
+	Kind       string             `json:"kind"`
+	Message    string             `json:"message"`
+	Backend    string             `json:"backend,omitempty"`
+	Capability backend.Capability `json:"capability,omitempty"`
+	StatusCode int                `json:"status_code,omitempty"`
+	// Retryable records backend.IsRetryable for ErrorKindOther, and
+	// RetryAfter the delay the backend asked for.
+	Retryable  bool          `json:"retryable,omitempty"`
+	RetryAfter time.Duration `json:"retry_after,omitempty"`
+}
+
+// LoadFixture reads a fixture file.
+func LoadFixture(path string) (*Fixture, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read fixture: %w", err)
+	}
+	var fixture Fixture
+	if err := json.Unmarshal(data, &fixture); err != nil {
+		return nil, fmt.Errorf("failed to decode fixture %s: %w", path, err)
+	}
+	if fixture.Version != FixtureVersion {
+		return nil, fmt.Errorf("fixture %s has version %d, want %d", path, fixture.Version, FixtureVersion)
+	}
+	return &fixture, nil
+}
+
+// Save writes the fixture to path.
+func (f *Fixture) Save(path string) error {
+	data, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode fixture: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o600); err != nil {
+		return fmt.Errorf("failed to write fixture: %w", err)
+	}
+	return nil
+}
+
+func encodeError(err error) *CallError {
+	if err == nil {
+		return nil
+	}
+	var unsupported *backend.UnsupportedError
+	if errors.As(err, &unsupported) {
+		return &CallError{
+			Kind:       ErrorKindUnsupported,
+			Message:    err.Error(),
+			Backend:    unsupported.Backend,
+			Capability: unsupported.Capability,
+		}
+	}
+	if errors.Is(err, backend.ErrUtxoNotFound) {
+		return &CallError{Kind: ErrorKindUtxoNotFound, Message: err.Error()}
+	}
+	var status *backend.StatusError
+	if errors.As(err, &status) {
+		return &CallError{
+			Kind:       ErrorKindStatus,
+			Message:    status.Message,
+			Backend:    status.Backend,
+			StatusCode: status.StatusCode,
+			RetryAfter: status.RetryAfter,
+		}
+	}
+	callErr := &CallError{Kind: ErrorKindOther, Message: err.Error()}
+	if backend.IsRetryable(err) {
+		callErr.Retryable = true
+		callErr.RetryAfter, _ = backend.RetryAfter(err)
+	}
+	return callErr
+}
+
+func (e *CallError) err() error {
+	switch e.Kind {
+	case ErrorKindUnsupported:
+		return backend.NewUnsupportedError(e.Backend, e.Capability)
+	case ErrorKindUtxoNotFound:
+		if e.Message == backend.ErrUtxoNotFound.Error() {
+			return backend.ErrUtxoNotFound
+		}
+		return fmt.Errorf("%w (recorded: %s)", backend.ErrUtxoNotFound, e.Message)
+	case ErrorKindStatus:
+		return &backend.StatusError{
+			Backend:    e.Backend,
+			StatusCode: e.StatusCode,
+			Message:    e.Message,
+			RetryAfter: e.RetryAfter,
+		}
+	default:
+		if e.Retryable {
+			return backend.NewRetryableError(errors.New(e.Message), e.RetryAfter)
+		}
+		return errors.New(e.Message)
+	}
+}
+
+// Call keys.
+
+func refKey(txHash common.Blake2b256, index uint32) string {
+	return txHash.String() + "#" + strconv.FormatUint(uint64(index), 10)
+}
+
+// txKey identifies a transaction by its id, so re-signing the same body
+// still matches. CBOR that does not decode is keyed by its own hash.
+func txKey(txCbor []byte) string {
+	if txId, err := backendutil.TransactionId(txCbor); err == nil {
+		return txId.String()
+	}
+	return "raw:" + common.Blake2b256Hash(txCbor).String()
+}
+
+func evaluateKey(txCbor []byte, additionalUtxos []common.Utxo) string {
+	refs := make([]string, 0, len(additionalUtxos))
+	for _, utxo := range additionalUtxos {
+		refs = append(refs, refKey(utxo.Id.Id(), utxo.Id.Index()))
+	}
+	slices.Sort(refs)
+	return strings.Join(append([]string{txKey(txCbor)}, refs...), "+")
+}
+
+// Result encodings.
+
+// protocolParamsJSON adds the exact reference-script prices, which
+// ProtocolParameters leaves out of its JSON form.
+type protocolParamsJSON struct {
+	backend.ProtocolParameters
+	RefScriptCostPerByte *string `json:"min_fee_ref_script_cost_per_byte_rational,omitempty"`
+	RefScriptMultiplier  *string `json:"min_fee_reference_scripts_multiplier_rational,omitempty"`
+}
+
+func encodeProtocolParams(pp backend.ProtocolParameters) protocolParamsJSON {
+	out := protocolParamsJSON{ProtocolParameters: pp}
+	if pp.MinFeeRefScriptCostPerByteRational != nil {
+		s := pp.MinFeeRefScriptCostPerByteRational.RatString()
+		out.RefScriptCostPerByte = &s
+	}
+	if pp.MinFeeReferenceScriptsMultiplierRational != nil {
+		s := pp.MinFeeReferenceScriptsMultiplierRational.RatString()
+		out.RefScriptMultiplier = &s
+	}
+	return out
+}
+
+func (p protocolParamsJSON) decode() (backend.ProtocolParameters, error) {
+	pp := p.ProtocolParameters
+	for _, field := range []struct {
+		value *string
+		dst   **big.Rat
+	}{
+		{p.RefScriptCostPerByte, &pp.MinFeeRefScriptCostPerByteRational},
+		{p.RefScriptMultiplier, &pp.MinFeeReferenceScriptsMultiplierRational},
+	} {
+		if field.value == nil {
+			continue
+		}
+		rat, ok := new(big.Rat).SetString(*field.value)
+		if !ok {
+			return pp, fmt.Errorf("invalid rational %q", *field.value)
+		}
+		*field.dst = rat
+	}
+	return pp, nil
+}
+
+type utxoJSON struct {
+	TxHash string `json:"tx_hash"`
+	Index  uint32 `json:"index"`
+	Output string `json:"output"`
+}
+
+func encodeUtxo(utxo common.Utxo) (utxoJSON, error) {
+	output, err := cbor.Encode(utxo.Output)
+	if err != nil {
+		return utxoJSON{}, fmt.Errorf("failed to encode output %s: %w", refKey(utxo.Id.Id(), utxo.Id.Index()), err)
+	}
+	return utxoJSON{
+		TxHash: utxo.Id.Id().String(),
+		Index:  utxo.Id.Index(),
+		Output: hex.EncodeToString(output),
+	}, nil
+}
+
+func (u utxoJSON) decode() (common.Utxo, error) {
+	txHash, err := hex.DecodeString(u.TxHash)
+	if err != nil || len(txHash) != common.Blake2b256Size {
+		return common.Utxo{}, fmt.Errorf("invalid recorded tx hash %q", u.TxHash)
+	}
+	outputCbor, err := hex.DecodeString(u.Output)
+	if err != nil {
+		return common.Utxo{}, fmt.Errorf("invalid recorded output hex: %w", err)
+	}
+	output, err := ledger.NewTransactionOutputFromCbor(outputCbor)
+	if err != nil {
+		return common.Utxo{}, fmt.Errorf("failed to decode recorded output: %w", err)
+	}
+	return common.Utxo{
+		Id:     shelley.ShelleyTransactionInput{TxId: common.NewBlake2b256(txHash), OutputIndex: u.Index},
+		Output: output,
+	}, nil
+}
+
+type exUnitsJSON struct {
+	Tag    common.RedeemerTag `json:"tag"`
+	Index  uint32             `json:"index"`
+	Memory int64              `json:"memory"`
+	Steps  int64              `json:"steps"`
+}
+
+func encodeExUnits(units map[common.RedeemerKey]common.ExUnits) []exUnitsJSON {
+	out := make([]exUnitsJSON, 0, len(units))
+	for key, exUnits := range units {
+		out = append(out, exUnitsJSON{Tag: key.Tag, Index: key.Index, Memory: exUnits.Memory, Steps: exUnits.Steps})
+	}
+	slices.SortFunc(out, func(a, b exUnitsJSON) int {
+		return cmp.Or(cmp.Compare(a.Tag, b.Tag), cmp.Compare(a.Index, b.Index))
+	})
+	return out
+}
+
+func decodeExUnits(recorded []exUnitsJSON) map[common.RedeemerKey]common.ExUnits {
+	units := make(map[common.RedeemerKey]common.ExUnits, len(recorded))
+	for _, r := range recorded {
+		units[common.RedeemerKey{Tag: r.Tag, Index: r.Index}] = common.ExUnits{Memory: r.Memory, Steps: r.Steps}
+	}
+	return units
+}
@@ -0,0 +1,249 @@
+package replay
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/blinklabs-io/gouroboros/ledger/common"
+
+	"github.com/Salvionied/apollo/v2/backend"
+)
+
+// RecordingChainContext wraps another ChainContext and records every call
+// and its result, so a session against a real backend can be replayed
+// offline by a ReplayChainContext.
+type RecordingChainContext struct {
+	inner backend.ChainContext
+
+	mu      sync.Mutex
+	fixture Fixture
+	err     error
+}
+
+var (
+	_ backend.ContextChainContext  = (*RecordingChainContext)(nil)
+	_ backend.ContextDatumResolver = (*RecordingChainContext)(nil)
+)
+
+// NewRecordingChainContext creates a recorder around the given ChainContext.
+func NewRecordingChainContext(inner backend.ChainContext) *RecordingChainContext {
+	return &RecordingChainContext{
+		inner: inner,
+		fixture: Fixture{
+			Version:      FixtureVersion,
+			NetworkId:    inner.NetworkId(),
+			Capabilities: backend.CapabilitiesOf(inner),
+		},
+	}
+}
+
+// Fixture returns the calls recorded so far.
+func (r *RecordingChainContext) Fixture() *Fixture {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	fixture := r.fixture
+	fixture.Calls = append([]Call(nil), r.fixture.Calls...)
+	return &fixture
+}
+
+// Save writes the recorded calls to path. It fails if a result could not be
+// recorded, since the fixture would not replay the session faithfully.
+func (r *RecordingChainContext) Save(path string) error {
+	r.mu.Lock()
+	err := r.err
+	r.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	return r.Fixture().Save(path)
+}
+
+// record appends a call, encoding its result with encode.
+func record[T any](r *RecordingChainContext, method, key string, result T, err error, encode func(T) (any, error)) {
+	call := Call{Method: method, Key: key, Error: encodeError(err)}
+	if err == nil {
+		value, encodeErr := encode(result)
+		if encodeErr == nil {
+			call.Result, encodeErr = json.Marshal(value)
+		}
+		if encodeErr != nil {
+			r.mu.Lock()
+			r.err = errors.Join(r.err, fmt.Errorf("failed to record %s(%s): %w", method, key, encodeErr))
+			r.mu.Unlock()
+			return
+		}
+	}
+	r.mu.Lock()
+	r.fixture.Calls = append(r.fixture.Calls, call)
+	r.mu.Unlock()
+}
+
+func asIs[T any](v T) (any, error) {
+	return v, nil
+}
+
+// Capabilities preserves the feature set of the wrapped context.
+func (r *RecordingChainContext) Capabilities() backend.CapabilitySet {
+	return backend.CapabilitiesOf(r.inner)
+}
+
+func (r *RecordingChainContext) ProtocolParams() (backend.ProtocolParameters, error) {
+	return r.ProtocolParamsContext(context.Background())
+}
+
+func (r *RecordingChainContext) ProtocolParamsContext(ctx context.Context) (backend.ProtocolParameters, error) {
+	pp, err := backend.ProtocolParamsContext(ctx, r.inner)
+	record(r, "ProtocolParams", "", pp, err, func(pp backend.ProtocolParameters) (any, error) {
+		return encodeProtocolParams(pp), nil
+	})
+	return pp, err
+}
+
+func (r *RecordingChainContext) GenesisParams() (backend.GenesisParameters, error) {
+	return r.GenesisParamsContext(context.Background())
+}
+
+func (r *RecordingChainContext) GenesisParamsContext(ctx context.Context) (backend.GenesisParameters, error) {
+	gp, err := backend.GenesisParamsContext(ctx, r.inner)
+	record(r, "GenesisParams", "", gp, err, asIs)
+	return gp, err
+}
+
+func (r *RecordingChainContext) NetworkId() uint8 {
+	return r.inner.NetworkId()
+}
+
+func (r *RecordingChainContext) CurrentEpoch() (uint64, error) {
+	return r.CurrentEpochContext(context.Background())
+}
+
+func (r *RecordingChainContext) CurrentEpochContext(ctx context.Context) (uint64, error) {
+	epoch, err := backend.CurrentEpochContext(ctx, r.inner)
+	record(r, "CurrentEpoch", "", epoch, err, asIs)
+	return epoch, err
+}
+
+func (r *RecordingChainContext) MaxTxFee() (uint64, error) {
+	return r.MaxTxFeeContext(context.Background())
+}
+
+func (r *RecordingChainContext) MaxTxFeeContext(ctx context.Context) (uint64, error) {
+	fee, err := backend.MaxTxFeeContext(ctx, r.inner)
+	record(r, "MaxTxFee", "", fee, err, asIs)
+	return fee, err
+}
+
+func (r *RecordingChainContext) Tip() (uint64, error) {
+	return r.TipContext(context.Background())
+}
+
+func (r *RecordingChainContext) TipContext(ctx context.Context) (uint64, error) {
+	tip, err := backend.TipContext(ctx, r.inner)
+	record(r, "Tip", "", tip, err, asIs)
+	return tip, err
+}
+
+func (r *RecordingChainContext) Utxos(address common.Address) ([]common.Utxo, error) {
+	return r.UtxosContext(context.Background(), address)
+}
+
+func (r *RecordingChainContext) UtxosContext(ctx context.Context, address common.Address) ([]common.Utxo, error) {
+	utxos, err := backend.UtxosContext(ctx, r.inner, address)
+	record(r, "Utxos", address.String(), utxos, err, func(utxos []common.Utxo) (any, error) {
+		encoded := make([]utxoJSON, 0, len(utxos))
+		for _, utxo := range utxos {
+			u, err := encodeUtxo(utxo)
+			if err != nil {
+				return nil, err
+			}
+			encoded = append(encoded, u)
+		}
+		return encoded, nil
+	})
+	return utxos, err
+}
+
+func (r *RecordingChainContext) SubmitTx(txCbor []byte) (common.Blake2b256, error) {
+	return r.SubmitTxContext(context.Background(), txCbor)
+}
+
+func (r *RecordingChainContext) SubmitTxContext(ctx context.Context, txCbor []byte) (common.Blake2b256, error) {
+	txId, err := backend.SubmitTxContext(ctx, r.inner, txCbor)
+	record(r, "SubmitTx", txKey(txCbor), txId, err, func(txId common.Blake2b256) (any, error) {
+		return txId.String(), nil
+	})
+	return txId, err
+}
+
+func (r *RecordingChainContext) EvaluateTx(txCbor []byte, additionalUtxos []common.Utxo) (map[common.RedeemerKey]common.ExUnits, error) {
+	return r.EvaluateTxContext(context.Background(), txCbor, additionalUtxos)
+}
+
+func (r *RecordingChainContext) EvaluateTxContext(
+	ctx context.Context,
+	txCbor []byte,
+	additionalUtxos []common.Utxo,
+) (map[common.RedeemerKey]common.ExUnits, error) {
+	units, err := backend.EvaluateTxContext(ctx, r.inner, txCbor, additionalUtxos)
+	record(r, "EvaluateTx", evaluateKey(txCbor, additionalUtxos), units, err,
+		func(units map[common.RedeemerKey]common.ExUnits) (any, error) {
+			return encodeExUnits(units), nil
+		})
+	return units, err
+}
+
+func (r *RecordingChainContext) UtxoByRef(txHash common.Blake2b256, index uint32) (*common.Utxo, error) {
+	return r.UtxoByRefContext(context.Background(), txHash, index)
+}
+
+func (r *RecordingChainContext) UtxoByRefContext(
+	ctx context.Context,
+	txHash common.Blake2b256,
+	index uint32,
+) (*common.Utxo, error) {
+	utxo, err := backend.UtxoByRefContext(ctx, r.inner, txHash, index)
+	record(r, "UtxoByRef", refKey(txHash, index), utxo, err, func(utxo *common.Utxo) (any, error) {
+		if utxo == nil {
+			return nil, nil
+		}
+		return encodeUtxo(*utxo)
+	})
+	return utxo, err
+}
+
+func (r *RecordingChainContext) ScriptCbor(scriptHash common.Blake2b224) ([]byte, error) {
+	return r.ScriptCborContext(context.Background(), scriptHash)
+}
+
+func (r *RecordingChainContext) ScriptCborContext(
+	ctx context.Context,
+	scriptHash common.Blake2b224,
+) ([]byte, error) {
+	script, err := backend.ScriptCborContext(ctx, r.inner, scriptHash)
+	record(r, "ScriptCbor", scriptHash.String(), script, err, func(script []byte) (any, error) {
+		return hex.EncodeToString(script), nil
+	})
+	return script, err
+}
+
+func (r *RecordingChainContext) DatumByHash(datumHash common.Blake2b256) (*common.Datum, error) {
+	return r.DatumByHashContext(context.Background(), datumHash)
+}
+
+func (r *RecordingChainContext) DatumByHashContext(
+	ctx context.Context,
+	datumHash common.Blake2b256,
+) (*common.Datum, error) {
+	datum, err := backend.DatumByHashContext(ctx, r.inner, datumHash)
+	record(r, "DatumByHash", datumHash.String(), datum, err, func(datum *common.Datum) (any, error) {
+		if datum == nil {
+			return nil, nil
+		}
+		return hex.EncodeToString(datum.Cbor()), nil
+	})
+	return datum, err
+}
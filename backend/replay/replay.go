@@ -0,0 +1,300 @@
+package replay
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/blinklabs-io/gouroboros/ledger/common"
+
+	"github.com/Salvionied/apollo/v2/backend"
+	"github.com/Salvionied/apollo/v2/internal/backendutil"
+)
+
+// ErrNotRecorded is returned for a call the fixture has no recording of.
+var ErrNotRecorded = errors.New("call not recorded")
+
+// ReplayChainContext serves the calls recorded in a fixture, without a
+// network. Calls with the same method and arguments are answered in recorded
+// order, and the last answer repeats once they run out. A call that was never
+// recorded fails with ErrNotRecorded and is listed by Misses.
+type ReplayChainContext struct {
+	fixture *Fixture
+
+	mu     sync.Mutex
+	calls  map[string][]Call
+	served map[string]int
+	misses []string
+}
+
+var (
+	_ backend.ContextChainContext  = (*ReplayChainContext)(nil)
+	_ backend.ContextDatumResolver = (*ReplayChainContext)(nil)
+)
+
+// NewReplayChainContext serves the calls in fixture.
+func NewReplayChainContext(fixture *Fixture) (*ReplayChainContext, error) {
+	if fixture == nil {
+		return nil, errors.New("fixture is nil")
+	}
+	if fixture.Version != FixtureVersion {
+		return nil, fmt.Errorf("fixture has version %d, want %d", fixture.Version, FixtureVersion)
+	}
+	r := &ReplayChainContext{
+		fixture: fixture,
+		calls:   make(map[string][]Call),
+		served:  make(map[string]int),
+	}
+	for _, call := range fixture.Calls {
+		id := callId(call.Method, call.Key)
+		r.calls[id] = append(r.calls[id], call)
+	}
+	return r, nil
+}
+
+// LoadReplayChainContext serves the calls in the fixture file at path.
+func LoadReplayChainContext(path string) (*ReplayChainContext, error) {
+	fixture, err := LoadFixture(path)
+	if err != nil {
+		return nil, err
+	}
+	return NewReplayChainContext(fixture)
+}
+
+func callId(method, key string) string {
+	if key == "" {
+		return method + "()"
+	}
+	return method + "(" + key + ")"
+}
+
+// Misses lists the calls made that the fixture has no recording of.
+func (r *ReplayChainContext) Misses() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.misses...)
+}
+
+// next returns the recorded answer for a call.
+func (r *ReplayChainContext) next(ctx context.Context, method, key string) (Call, error) {
+	if err := ctx.Err(); err != nil {
+		return Call{}, err
+	}
+	id := callId(method, key)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	recorded := r.calls[id]
+	if len(recorded) == 0 {
+		r.misses = append(r.misses, id)
+		return Call{}, fmt.Errorf("%w: %s", ErrNotRecorded, id)
+	}
+	i := min(r.served[id], len(recorded)-1)
+	r.served[id]++
+	return recorded[i], nil
+}
+
+// replay answers a call from the fixture, decoding its result with decode.
+func replay[T any](
+	ctx context.Context,
+	r *ReplayChainContext,
+	method, key string,
+	decode func(json.RawMessage) (T, error),
+) (T, error) {
+	var zero T
+	call, err := r.next(ctx, method, key)
+	if err != nil {
+		return zero, err
+	}
+	if call.Error != nil {
+		return zero, call.Error.err()
+	}
+	result, err := decode(call.Result)
+	if err != nil {
+		return zero, fmt.Errorf("invalid recorded result for %s: %w", callId(method, key), err)
+	}
+	return result, nil
+}
+
+func unmarshal[T any](raw json.RawMessage) (T, error) {
+	var v T
+	err := json.Unmarshal(raw, &v)
+	return v, err
+}
+
+// Capabilities reports the capabilities of the recorded context.
+func (r *ReplayChainContext) Capabilities() backend.CapabilitySet {
+	return r.fixture.Capabilities
+}
+
+func (r *ReplayChainContext) ProtocolParams() (backend.ProtocolParameters, error) {
+	return r.ProtocolParamsContext(context.Background())
+}
+
+func (r *ReplayChainContext) ProtocolParamsContext(ctx context.Context) (backend.ProtocolParameters, error) {
+	return replay(ctx, r, "ProtocolParams", "", func(raw json.RawMessage) (backend.ProtocolParameters, error) {
+		recorded, err := unmarshal[protocolParamsJSON](raw)
+		if err != nil {
+			return backend.ProtocolParameters{}, err
+		}
+		return recorded.decode()
+	})
+}
+
+func (r *ReplayChainContext) GenesisParams() (backend.GenesisParameters, error) {
+	return r.GenesisParamsContext(context.Background())
+}
+
+func (r *ReplayChainContext) GenesisParamsContext(ctx context.Context) (backend.GenesisParameters, error) {
+	return replay(ctx, r, "GenesisParams", "", unmarshal[backend.GenesisParameters])
+}
+
+func (r *ReplayChainContext) NetworkId() uint8 {
+	return r.fixture.NetworkId
+}
+
+func (r *ReplayChainContext) CurrentEpoch() (uint64, error) {
+	return r.CurrentEpochContext(context.Background())
+}
+
+func (r *ReplayChainContext) CurrentEpochContext(ctx context.Context) (uint64, error) {
+	return replay(ctx, r, "CurrentEpoch", "", unmarshal[uint64])
+}
+
+func (r *ReplayChainContext) MaxTxFee() (uint64, error) {
+	return r.MaxTxFeeContext(context.Background())
+}
+
+func (r *ReplayChainContext) MaxTxFeeContext(ctx context.Context) (uint64, error) {
+	return replay(ctx, r, "MaxTxFee", "", unmarshal[uint64])
+}
+
+func (r *ReplayChainContext) Tip() (uint64, error) {
+	return r.TipContext(context.Background())
+}
+
+func (r *ReplayChainContext) TipContext(ctx context.Context) (uint64, error) {
+	return replay(ctx, r, "Tip", "", unmarshal[uint64])
+}
+
+func (r *ReplayChainContext) Utxos(address common.Address) ([]common.Utxo, error) {
+	return r.UtxosContext(context.Background(), address)
+}
+
+func (r *ReplayChainContext) UtxosContext(ctx context.Context, address common.Address) ([]common.Utxo, error) {
+	return replay(ctx, r, "Utxos", address.String(), func(raw json.RawMessage) ([]common.Utxo, error) {
+		recorded, err := unmarshal[[]utxoJSON](raw)
+		if err != nil {
+			return nil, err
+		}
+		utxos := make([]common.Utxo, 0, len(recorded))
+		for _, u := range recorded {
+			utxo, err := u.decode()
+			if err != nil {
+				return nil, err
+			}
+			utxos = append(utxos, utxo)
+		}
+		return utxos, nil
+	})
+}
+
+func (r *ReplayChainContext) SubmitTx(txCbor []byte) (common.Blake2b256, error) {
+	return r.SubmitTxContext(context.Background(), txCbor)
+}
+
+func (r *ReplayChainContext) SubmitTxContext(ctx context.Context, txCbor []byte) (common.Blake2b256, error) {
+	return replay(ctx, r, "SubmitTx", txKey(txCbor), decodeTxId)
+}
+
+func (r *ReplayChainContext) EvaluateTx(txCbor []byte, additionalUtxos []common.Utxo) (map[common.RedeemerKey]common.ExUnits, error) {
+	return r.EvaluateTxContext(context.Background(), txCbor, additionalUtxos)
+}
+
+func (r *ReplayChainContext) EvaluateTxContext(
+	ctx context.Context,
+	txCbor []byte,
+	additionalUtxos []common.Utxo,
+) (map[common.RedeemerKey]common.ExUnits, error) {
+	return replay(ctx, r, "EvaluateTx", evaluateKey(txCbor, additionalUtxos),
+		func(raw json.RawMessage) (map[common.RedeemerKey]common.ExUnits, error) {
+			recorded, err := unmarshal[[]exUnitsJSON](raw)
+			if err != nil {
+				return nil, err
+			}
+			return decodeExUnits(recorded), nil
+		})
+}
+
+func (r *ReplayChainContext) UtxoByRef(txHash common.Blake2b256, index uint32) (*common.Utxo, error) {
+	return r.UtxoByRefContext(context.Background(), txHash, index)
+}
+
+func (r *ReplayChainContext) UtxoByRefContext(
+	ctx context.Context,
+	txHash common.Blake2b256,
+	index uint32,
+) (*common.Utxo, error) {
+	return replay(ctx, r, "UtxoByRef", refKey(txHash, index), func(raw json.RawMessage) (*common.Utxo, error) {
+		recorded, err := unmarshal[*utxoJSON](raw)
+		if err != nil || recorded == nil {
+			return nil, err
+		}
+		utxo, err := recorded.decode()
+		if err != nil {
+			return nil, err
+		}
+		return &utxo, nil
+	})
+}
+
+func (r *ReplayChainContext) ScriptCbor(scriptHash common.Blake2b224) ([]byte, error) {
+	return r.ScriptCborContext(context.Background(), scriptHash)
+}
+
+func (r *ReplayChainContext) ScriptCborContext(
+	ctx context.Context,
+	scriptHash common.Blake2b224,
+) ([]byte, error) {
+	return replay(ctx, r, "ScriptCbor", scriptHash.String(), func(raw json.RawMessage) ([]byte, error) {
+		recorded, err := unmarshal[string](raw)
+		if err != nil {
+			return nil, err
+		}
+		return hex.DecodeString(recorded)
+	})
+}
+
+func (r *ReplayChainContext) DatumByHash(datumHash common.Blake2b256) (*common.Datum, error) {
+	return r.DatumByHashContext(context.Background(), datumHash)
+}
+
+func (r *ReplayChainContext) DatumByHashContext(
+	ctx context.Context,
+	datumHash common.Blake2b256,
+) (*common.Datum, error) {
+	return replay(ctx, r, "DatumByHash", datumHash.String(), func(raw json.RawMessage) (*common.Datum, error) {
+		recorded, err := unmarshal[*string](raw)
+		if err != nil || recorded == nil {
+			return nil, err
+		}
+		return backendutil.DecodeDatumHex(*recorded)
+	})
+}
+
+// decodeTxId decodes a recorded hex transaction id.
+func decodeTxId(raw json.RawMessage) (common.Blake2b256, error) {
+	var h common.Blake2b256
+	recorded, err := unmarshal[string](raw)
+	if err != nil {
+		return h, err
+	}
+	b, err := hex.DecodeString(recorded)
+	if err != nil || len(b) != len(h) {
+		return h, fmt.Errorf("invalid recorded hash %q", recorded)
+	}
+	copy(h[:], b)
+	return h, nil
+}
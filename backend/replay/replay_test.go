@@ -0,0 +1,196 @@
+package replay
+
+import (
+	"bytes"
+	"errors"
+	"math/big"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/blinklabs-io/gouroboros/cbor"
+	"github.com/blinklabs-io/gouroboros/ledger/babbage"
+	"github.com/blinklabs-io/gouroboros/ledger/common"
+	"github.com/blinklabs-io/gouroboros/ledger/mary"
+	"github.com/blinklabs-io/gouroboros/ledger/shelley"
+
+	"github.com/Salvionied/apollo/v2/backend"
+	"github.com/Salvionied/apollo/v2/backend/fixed"
+	"github.com/Salvionied/apollo/v2/internal/backendutil"
+)
+
+// stubContext serves the fixed test context, rejects submissions as
+// overloaded, fails Tip as unavailable and answers CurrentEpoch from a script
+// whose last epoch repeats.
+type stubContext struct {
+	*fixed.FixedChainContext
+	epochs []uint64
+}
+
+func (s *stubContext) SubmitTx(_ []byte) (common.Blake2b256, error) {
+	return common.Blake2b256{}, &backend.StatusError{Backend: "stub", StatusCode: 503, Message: "overloaded"}
+}
+
+func (s *stubContext) Tip() (uint64, error) {
+	return 0, backend.NewRetryableError(errors.New("node unavailable"), 2*time.Second)
+}
+
+func (s *stubContext) CurrentEpoch() (uint64, error) {
+	epoch := s.epochs[0]
+	if len(s.epochs) > 1 {
+		s.epochs = s.epochs[1:]
+	}
+	return epoch, nil
+}
+
+func testUtxo(t *testing.T) (common.Address, common.Utxo) {
+	t.Helper()
+	var raw [57]byte
+	raw[1] = 0xAA
+	raw[29] = 0xBB
+	addr, err := common.NewAddressFromBytes(raw[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	datum, err := backendutil.InlineDatumOption([]byte{0xd8, 0x79, 0x80})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return addr, common.Utxo{
+		Id: shelley.ShelleyTransactionInput{TxId: common.Blake2b256{0x01}, OutputIndex: 2},
+		Output: babbage.BabbageTransactionOutput{
+			OutputAddress: addr,
+			OutputAmount:  mary.MaryTransactionOutputValue{Amount: 2_000_000},
+			DatumOption:   datum,
+		},
+	}
+}
+
+func TestRecordedSessionReplaysOffline(t *testing.T) {
+	addr, utxo := testUtxo(t)
+	pp := fixed.NewEmptyFixedChainContext()
+	params, _ := pp.ProtocolParams()
+	params.MinFeeCoefficient = 44
+	params.MinFeeRefScriptCostPerByteRational = big.NewRat(121, 8)
+	params.MinFeeReferenceScriptsMultiplierRational = big.NewRat(2469, 2000)
+	inner := &stubContext{
+		FixedChainContext: fixed.NewFixedChainContext(params, backend.GenesisParameters{EpochLength: 432000}, 1),
+		epochs:            []uint64{500, 501},
+	}
+	inner.AddUtxo(addr, utxo)
+	inner.AddUtxoByRef(utxo)
+	datum, err := backendutil.DecodeDatumHex("d87980")
+	if err != nil {
+		t.Fatal(err)
+	}
+	datumHash, err := inner.AddDatum(*datum)
+	if err != nil {
+		t.Fatal(err)
+	}
+	txCbor := []byte{0x84, 0xa0, 0xa0, 0xf5, 0xf6}
+
+	recorder := NewRecordingChainContext(inner)
+	// The session under test, run once live and once from the fixture.
+	session := func(t *testing.T, ctx interface {
+		backend.ChainContext
+		backend.DatumResolver
+	}) {
+		t.Helper()
+		gotParams, err := ctx.ProtocolParams()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if gotParams.MinFeeRefScriptCostPerByteRational.Cmp(big.NewRat(121, 8)) != 0 ||
+			gotParams.MinFeeReferenceScriptsMultiplierRational.Cmp(big.NewRat(2469, 2000)) != 0 ||
+			gotParams.MinFeeCoefficient != 44 {
+			t.Fatalf("ProtocolParams() = %+v", gotParams)
+		}
+		if gp, err := ctx.GenesisParams(); err != nil || gp.EpochLength != 432000 {
+			t.Fatalf("GenesisParams() = %+v, %v", gp, err)
+		}
+		for _, want := range []uint64{500, 501, 501} {
+			if epoch, err := ctx.CurrentEpoch(); err != nil || epoch != want {
+				t.Fatalf("CurrentEpoch() = %d, %v; want %d", epoch, err, want)
+			}
+		}
+		utxos, err := ctx.Utxos(addr)
+		if err != nil || len(utxos) != 1 {
+			t.Fatalf("Utxos() = %v, %v", utxos, err)
+		}
+		wantOutput, _ := cbor.Encode(utxo.Output)
+		gotOutput, _ := cbor.Encode(utxos[0].Output)
+		if !bytes.Equal(gotOutput, wantOutput) || utxos[0].Id.Index() != 2 {
+			t.Fatalf("Utxos() output = %x, want %x", gotOutput, wantOutput)
+		}
+		if got, err := ctx.UtxoByRef(utxo.Id.Id(), 2); err != nil || got.Id.Id() != utxo.Id.Id() {
+			t.Fatalf("UtxoByRef() = %v, %v", got, err)
+		}
+		if _, err := ctx.UtxoByRef(utxo.Id.Id(), 3); !errors.Is(err, backend.ErrUtxoNotFound) {
+			t.Fatalf("UtxoByRef() of a missing output error = %v", err)
+		}
+		var unsupported *backend.UnsupportedError
+		if _, err := ctx.ScriptCbor(common.Blake2b224{0x0C}); !errors.As(err, &unsupported) ||
+			unsupported.Capability != backend.CapabilityScriptCbor {
+			t.Fatalf("ScriptCbor() error = %v", err)
+		}
+		if got, err := ctx.DatumByHash(datumHash); err != nil || !bytes.Equal(got.Cbor(), datum.Cbor()) {
+			t.Fatalf("DatumByHash() = %v, %v", got, err)
+		}
+		_, err = ctx.Tip()
+		if retryAfter, _ := backend.RetryAfter(err); !backend.IsRetryable(err) || retryAfter != 2*time.Second {
+			t.Fatalf("Tip() error = %v", err)
+		}
+		_, err = ctx.SubmitTx(txCbor)
+		var status *backend.StatusError
+		if !errors.As(err, &status) || status.StatusCode != 503 || !backend.IsRetryable(err) {
+			t.Fatalf("SubmitTx() error = %v", err)
+		}
+	}
+	session(t, recorder)
+
+	path := filepath.Join(t.TempDir(), "session.json")
+	if err := recorder.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	replay, err := LoadReplayChainContext(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if replay.NetworkId() != 1 || replay.Capabilities() != backend.CapabilitiesOf(inner) {
+		t.Fatalf("replay identity = %d, %v", replay.NetworkId(), replay.Capabilities())
+	}
+	session(t, replay)
+	if misses := replay.Misses(); len(misses) != 0 {
+		t.Fatalf("Misses() = %v", misses)
+	}
+}
+
+func TestUnrecordedCallsFail(t *testing.T) {
+	replay, err := NewReplayChainContext(&Fixture{
+		Version: FixtureVersion,
+		Calls:   []Call{{Method: "Tip", Result: []byte("42")}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tip, err := replay.Tip(); err != nil || tip != 42 {
+		t.Fatalf("Tip() = %d, %v", tip, err)
+	}
+	if _, err := replay.MaxTxFee(); !errors.Is(err, ErrNotRecorded) {
+		t.Fatalf("MaxTxFee() error = %v, want ErrNotRecorded", err)
+	}
+	if _, err := replay.UtxoByRef(common.Blake2b256{0x02}, 0); !errors.Is(err, ErrNotRecorded) {
+		t.Fatalf("UtxoByRef() error = %v, want ErrNotRecorded", err)
+	}
+	want := []string{"MaxTxFee()", "UtxoByRef(" + refKey(common.Blake2b256{0x02}, 0) + ")"}
+	if misses := replay.Misses(); !slices.Equal(misses, want) {
+		t.Fatalf("Misses() = %v, want %v", misses, want)
+	}
+}
+
+func TestReplayRejectsOtherFixtureVersions(t *testing.T) {
+	if _, err := NewReplayChainContext(&Fixture{Version: FixtureVersion + 1}); err == nil {
+		t.Fatal("NewReplayChainContext() accepted a future fixture version")
+	}
+}
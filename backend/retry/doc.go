@@ -0,0 +1,6 @@
+// Package retry wraps any chain backend with retries and client-side rate
+// limiting. Calls that fail with a retryable error are repeated with
+// exponential backoff and jitter, honouring any Retry-After delay the
+// provider asked for, and token buckets keep calls under a provider's quota,
+// either overall or per capability.
+package retry
@@ -0,0 +1,478 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"iter"
+	"math"
+	"math/rand/v2"
+	"net/http"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/blinklabs-io/gouroboros/ledger/common"
+
+	"github.com/Salvionied/apollo/v2/backend"
+)
+
+const (
+	defaultMaxAttempts = 4
+	defaultBaseDelay   = 200 * time.Millisecond
+	defaultMaxDelay    = 10 * time.Second
+)
+
+// Rate is a token bucket: calls are allowed at PerSecond on average, with
+// bursts of up to Burst calls.
+type Rate struct {
+	PerSecond float64
+	// Burst is the bucket size. Zero allows one call at a time.
+	Burst int
+}
+
+// Config describes a RetryChainContext.
+type Config struct {
+	// MaxAttempts bounds the attempts per call, including the first. Zero
+	// uses 4; one disables retries.
+	MaxAttempts int
+	// BaseDelay is the backoff before the first retry; each later retry
+	// doubles it. Zero uses 200 milliseconds.
+	BaseDelay time.Duration
+	// MaxDelay caps the backoff between attempts. A provider asking for a
+	// longer Retry-After ends the call with its error instead. Zero uses 10
+	// seconds.
+	MaxDelay time.Duration
+	// Retryable reports whether an error is worth another attempt. Nil uses
+	// backend.IsRetryable.
+	Retryable func(error) bool
+	// RateLimit applies to every call. The zero value does not limit.
+	RateLimit Rate
+	// CapabilityRateLimits apply to the calls of a single capability, in
+	// addition to RateLimit. Keys must be single method capabilities.
+	CapabilityRateLimits map[backend.Capability]Rate
+}
+
+// RetryChainContext wraps another ChainContext with retries and rate limits.
+type RetryChainContext struct {
+	inner       backend.ChainContext
+	maxAttempts int
+	baseDelay   time.Duration
+	maxDelay    time.Duration
+	retryable   func(error) bool
+	limit       *bucket
+	limits      map[backend.Capability]*bucket
+
+	now    func() time.Time
+	sleep  func(context.Context, time.Duration) error
+	jitter func(time.Duration) time.Duration
+}
+
+var (
+	_ backend.ContextChainContext  = (*RetryChainContext)(nil)
+	_ backend.ContextDatumResolver = (*RetryChainContext)(nil)
+	_ backend.UtxoStreamer         = (*RetryChainContext)(nil)
+)
+
+// NewRetryChainContext wraps inner with the retries and rate limits in cfg.
+// It fails when inner is nil or a rate limit is invalid.
+func NewRetryChainContext(inner backend.ChainContext, cfg Config) (*RetryChainContext, error) {
+	if backend.IsNilChainContext(inner) {
+		return nil, errors.New("chain context is nil")
+	}
+	r := &RetryChainContext{
+		inner:       inner,
+		maxAttempts: cfg.MaxAttempts,
+		baseDelay:   cfg.BaseDelay,
+		maxDelay:    cfg.MaxDelay,
+		retryable:   cfg.Retryable,
+		limits:      make(map[backend.Capability]*bucket, len(cfg.CapabilityRateLimits)),
+		now:         time.Now,
+		sleep:       sleep,
+		jitter:      fullJitter,
+	}
+	if r.maxAttempts <= 0 {
+		r.maxAttempts = defaultMaxAttempts
+	}
+	if r.baseDelay <= 0 {
+		r.baseDelay = defaultBaseDelay
+	}
+	if r.maxDelay <= 0 {
+		r.maxDelay = defaultMaxDelay
+	}
+	if r.retryable == nil {
+		r.retryable = backend.IsRetryable
+	}
+	if cfg.RateLimit != (Rate{}) {
+		limit, err := newBucket(cfg.RateLimit, r.now())
+		if err != nil {
+			return nil, fmt.Errorf("invalid rate limit: %w", err)
+		}
+		r.limit = limit
+	}
+	for capability, rate := range cfg.CapabilityRateLimits {
+		if capability == 0 || capability&(capability-1) != 0 ||
+			capability == backend.CapabilityEvaluateTxAdditionalUtxos {
+			return nil, fmt.Errorf("cannot rate limit %s: use a single method capability", capability)
+		}
+		limit, err := newBucket(rate, r.now())
+		if err != nil {
+			return nil, fmt.Errorf("invalid rate limit for %s: %w", capability, err)
+		}
+		r.limits[capability] = limit
+	}
+	return r, nil
+}
+
+// bucket is a token bucket. Tokens may go negative: a reservation that finds
+// the bucket empty takes a token anyway and waits until it would have been
+// refilled, which keeps waiting callers in order.
+type bucket struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newBucket(rate Rate, now time.Time) (*bucket, error) {
+	if !(rate.PerSecond > 0) || math.IsInf(rate.PerSecond, 0) {
+		return nil, fmt.Errorf("rate must be positive, got %v per second", rate.PerSecond)
+	}
+	if rate.Burst < 0 {
+		return nil, fmt.Errorf("burst must not be negative, got %d", rate.Burst)
+	}
+	burst := float64(max(rate.Burst, 1))
+	return &bucket{rate: rate.PerSecond, burst: burst, tokens: burst, last: now}, nil
+}
+
+// reserve takes a token and returns how long to wait before using it.
+func (b *bucket) reserve(now time.Time) time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if elapsed := now.Sub(b.last); elapsed > 0 {
+		b.tokens = math.Min(b.burst, b.tokens+elapsed.Seconds()*b.rate)
+		b.last = now
+	}
+	b.tokens--
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / b.rate * float64(time.Second))
+}
+
+// release returns a reserved token that was not used.
+func (b *bucket) release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.tokens = math.Min(b.burst, b.tokens+1)
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// fullJitter picks a delay uniformly from [0, d], so clients that failed
+// together do not retry together.
+func fullJitter(d time.Duration) time.Duration {
+	if d <= 0 {
+		return 0
+	}
+	return rand.N(d + 1)
+}
+
+// Capabilities preserves the feature set of the wrapped context.
+func (r *RetryChainContext) Capabilities() backend.CapabilitySet {
+	return backend.CapabilitiesOf(r.inner)
+}
+
+// wait blocks until the rate limits allow one call for capability.
+func (r *RetryChainContext) wait(ctx context.Context, capability backend.Capability) error {
+	for _, limit := range []*bucket{r.limit, r.limits[capability]} {
+		if limit == nil {
+			continue
+		}
+		delay := limit.reserve(r.now())
+		if delay <= 0 {
+			continue
+		}
+		if err := r.sleep(ctx, delay); err != nil {
+			limit.release()
+			return err
+		}
+	}
+	return nil
+}
+
+// backoff returns the delay before the retry that follows attempt, counted
+// from zero. A Retry-After delay from the provider replaces the computed
+// backoff; backoff reports false when that delay exceeds MaxDelay.
+func (r *RetryChainContext) backoff(attempt int, err error) (time.Duration, bool) {
+	if delay, ok := backend.RetryAfter(err); ok {
+		return delay, delay <= r.maxDelay
+	}
+	delay := r.maxDelay
+	if attempt < 62 && r.baseDelay <= r.maxDelay>>attempt {
+		delay = r.baseDelay << attempt
+	}
+	return r.jitter(delay), true
+}
+
+// do runs fn, the call for capability, under the rate limits and retries it
+// while retryable reports its error as transient.
+func do[T any](
+	ctx context.Context,
+	r *RetryChainContext,
+	capability backend.Capability,
+	retryable func(error) bool,
+	fn func() (T, error),
+) (T, error) {
+	var zero T
+	// A call the wrapped context cannot serve never reaches the provider, so
+	// it neither waits for nor spends a token.
+	limited := backend.Supports(r.inner, capability)
+	for attempt := 0; ; attempt++ {
+		if limited {
+			if err := r.wait(ctx, capability); err != nil {
+				return zero, err
+			}
+		}
+		result, err := fn()
+		if err == nil {
+			return result, nil
+		}
+		retry, sleepErr := r.pause(ctx, attempt, err, retryable)
+		if sleepErr != nil {
+			return zero, sleepErr
+		}
+		if !retry {
+			return result, err
+		}
+	}
+}
+
+// pause decides whether a failed attempt is retried and, if so, waits out
+// its backoff. It reports false when the error should be returned, and an
+// error of its own when ctx ends during the wait.
+func (r *RetryChainContext) pause(ctx context.Context, attempt int, err error, retryable func(error) bool) (bool, error) {
+	if attempt+1 >= r.maxAttempts || ctx.Err() != nil || !retryable(err) {
+		return false, nil
+	}
+	delay, ok := r.backoff(attempt, err)
+	if !ok {
+		return false, nil
+	}
+	if deadline, ok := ctx.Deadline(); ok && r.now().Add(delay).After(deadline) {
+		return false, nil
+	}
+	if err := r.sleep(ctx, delay); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// submitRetryable narrows the retry classification for SubmitTx to failures
+// where the provider cannot have accepted the transaction: an explicit
+// rate-limit or unavailable status, a refused connection, or an error the
+// backend marked retryable. A timeout or dropped connection after the request
+// was sent is returned instead, since the transaction may already be in the
+// mempool.
+func (r *RetryChainContext) submitRetryable(err error) bool {
+	if !r.retryable(err) {
+		return false
+	}
+	var status *backend.StatusError
+	if errors.As(err, &status) {
+		return status.StatusCode == http.StatusTooManyRequests ||
+			status.StatusCode == http.StatusServiceUnavailable
+	}
+	var retryable *backend.RetryableError
+	return errors.As(err, &retryable) || errors.Is(err, syscall.ECONNREFUSED)
+}
+
+func (r *RetryChainContext) ProtocolParams() (backend.ProtocolParameters, error) {
+	return r.ProtocolParamsContext(context.Background())
+}
+
+func (r *RetryChainContext) ProtocolParamsContext(ctx context.Context) (backend.ProtocolParameters, error) {
+	return do(ctx, r, backend.CapabilityProtocolParams, r.retryable, func() (backend.ProtocolParameters, error) {
+		return backend.ProtocolParamsContext(ctx, r.inner)
+	})
+}
+
+func (r *RetryChainContext) GenesisParams() (backend.GenesisParameters, error) {
+	return r.GenesisParamsContext(context.Background())
+}
+
+func (r *RetryChainContext) GenesisParamsContext(ctx context.Context) (backend.GenesisParameters, error) {
+	return do(ctx, r, backend.CapabilityGenesisParams, r.retryable, func() (backend.GenesisParameters, error) {
+		return backend.GenesisParamsContext(ctx, r.inner)
+	})
+}
+
+func (r *RetryChainContext) NetworkId() uint8 {
+	return r.inner.NetworkId()
+}
+
+func (r *RetryChainContext) CurrentEpoch() (uint64, error) {
+	return r.CurrentEpochContext(context.Background())
+}
+
+func (r *RetryChainContext) CurrentEpochContext(ctx context.Context) (uint64, error) {
+	return do(ctx, r, backend.CapabilityCurrentEpoch, r.retryable, func() (uint64, error) {
+		return backend.CurrentEpochContext(ctx, r.inner)
+	})
+}
+
+func (r *RetryChainContext) MaxTxFee() (uint64, error) {
+	return r.MaxTxFeeContext(context.Background())
+}
+
+func (r *RetryChainContext) MaxTxFeeContext(ctx context.Context) (uint64, error) {
+	return do(ctx, r, backend.CapabilityMaxTxFee, r.retryable, func() (uint64, error) {
+		return backend.MaxTxFeeContext(ctx, r.inner)
+	})
+}
+
+func (r *RetryChainContext) Tip() (uint64, error) {
+	return r.TipContext(context.Background())
+}
+
+func (r *RetryChainContext) TipContext(ctx context.Context) (uint64, error) {
+	return do(ctx, r, backend.CapabilityTip, r.retryable, func() (uint64, error) {
+		return backend.TipContext(ctx, r.inner)
+	})
+}
+
+func (r *RetryChainContext) Utxos(address common.Address) ([]common.Utxo, error) {
+	return r.UtxosContext(context.Background(), address)
+}
+
+func (r *RetryChainContext) UtxosContext(ctx context.Context, address common.Address) ([]common.Utxo, error) {
+	return do(ctx, r, backend.CapabilityUtxos, r.retryable, func() ([]common.Utxo, error) {
+		return backend.UtxosContext(ctx, r.inner, address)
+	})
+}
+
+// UtxosSeq retries a stream that fails. A retry reopens the stream and skips
+// the UTxOs already yielded, so the caller never sees one twice; the pages
+// before the failure are fetched again. Each attempt takes one rate-limit
+// token.
+func (r *RetryChainContext) UtxosSeq(ctx context.Context, address common.Address) iter.Seq2[common.Utxo, error] {
+	type ref struct {
+		txHash common.Blake2b256
+		index  uint32
+	}
+	return func(yield func(common.Utxo, error) bool) {
+		limited := backend.Supports(r.inner, backend.CapabilityUtxos)
+		yielded := make(map[ref]struct{})
+		for attempt := 0; ; attempt++ {
+			if limited {
+				if err := r.wait(ctx, backend.CapabilityUtxos); err != nil {
+					yield(common.Utxo{}, err)
+					return
+				}
+			}
+			var failure error
+			for utxo, err := range backend.UtxosSeq(ctx, r.inner, address) {
+				if err != nil {
+					failure = err
+					break
+				}
+				key := ref{txHash: utxo.Id.Id(), index: utxo.Id.Index()}
+				if _, ok := yielded[key]; ok {
+					continue
+				}
+				yielded[key] = struct{}{}
+				if !yield(utxo, nil) {
+					return
+				}
+			}
+			if failure == nil {
+				return
+			}
+			retry, sleepErr := r.pause(ctx, attempt, failure, r.retryable)
+			if sleepErr != nil {
+				yield(common.Utxo{}, sleepErr)
+				return
+			}
+			if !retry {
+				yield(common.Utxo{}, failure)
+				return
+			}
+		}
+	}
+}
+
+func (r *RetryChainContext) SubmitTx(txCbor []byte) (common.Blake2b256, error) {
+	return r.SubmitTxContext(context.Background(), txCbor)
+}
+
+// SubmitTxContext retries only failures that show the transaction was not
+// accepted; see submitRetryable.
+func (r *RetryChainContext) SubmitTxContext(ctx context.Context, txCbor []byte) (common.Blake2b256, error) {
+	return do(ctx, r, backend.CapabilitySubmitTx, r.submitRetryable, func() (common.Blake2b256, error) {
+		return backend.SubmitTxContext(ctx, r.inner, txCbor)
+	})
+}
+
+func (r *RetryChainContext) EvaluateTx(txCbor []byte, additionalUtxos []common.Utxo) (map[common.RedeemerKey]common.ExUnits, error) {
+	return r.EvaluateTxContext(context.Background(), txCbor, additionalUtxos)
+}
+
+func (r *RetryChainContext) EvaluateTxContext(
+	ctx context.Context,
+	txCbor []byte,
+	additionalUtxos []common.Utxo,
+) (map[common.RedeemerKey]common.ExUnits, error) {
+	return do(ctx, r, backend.CapabilityEvaluateTx, r.retryable, func() (map[common.RedeemerKey]common.ExUnits, error) {
+		return backend.EvaluateTxContext(ctx, r.inner, txCbor, additionalUtxos)
+	})
+}
+
+func (r *RetryChainContext) UtxoByRef(txHash common.Blake2b256, index uint32) (*common.Utxo, error) {
+	return r.UtxoByRefContext(context.Background(), txHash, index)
+}
+
+func (r *RetryChainContext) UtxoByRefContext(
+	ctx context.Context,
+	txHash common.Blake2b256,
+	index uint32,
+) (*common.Utxo, error) {
+	return do(ctx, r, backend.CapabilityUtxoByRef, r.retryable, func() (*common.Utxo, error) {
+		return backend.UtxoByRefContext(ctx, r.inner, txHash, index)
+	})
+}
+
+func (r *RetryChainContext) ScriptCbor(scriptHash common.Blake2b224) ([]byte, error) {
+	return r.ScriptCborContext(context.Background(), scriptHash)
+}
+
+func (r *RetryChainContext) ScriptCborContext(
+	ctx context.Context,
+	scriptHash common.Blake2b224,
+) ([]byte, error) {
+	return do(ctx, r, backend.CapabilityScriptCbor, r.retryable, func() ([]byte, error) {
+		return backend.ScriptCborContext(ctx, r.inner, scriptHash)
+	})
+}
+
+func (r *RetryChainContext) DatumByHash(datumHash common.Blake2b256) (*common.Datum, error) {
+	return r.DatumByHashContext(context.Background(), datumHash)
+}
+
+func (r *RetryChainContext) DatumByHashContext(
+	ctx context.Context,
+	datumHash common.Blake2b256,
+) (*common.Datum, error) {
+	return do(ctx, r, backend.CapabilityDatumByHash, r.retryable, func() (*common.Datum, error) {
+		return backend.DatumByHashContext(ctx, r.inner, datumHash)
+	})
+}
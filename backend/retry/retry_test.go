@@ -0,0 +1,422 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"io"
+	"iter"
+	"net/http"
+	"slices"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/blinklabs-io/gouroboros/ledger/common"
+	"github.com/blinklabs-io/gouroboros/ledger/shelley"
+
+	"github.com/Salvionied/apollo/v2/backend"
+	"github.com/Salvionied/apollo/v2/backend/cache"
+	"github.com/Salvionied/apollo/v2/backend/fixed"
+)
+
+type ctxKey struct{}
+
+// stubContext serves the fixed test context through the context-aware
+// interface, fails Tip and SubmitTx with
+// scripted errors in turn and records the context each call received.
+type stubContext struct {
+	*cache.CachedChainContext
+	tipErrs    []error
+	submitErrs []error
+	calls      map[string]int
+	ctxValues  []any
+}
+
+func newStub() *stubContext {
+	return &stubContext{
+		CachedChainContext: cache.NewCachedChainContext(fixed.NewEmptyFixedChainContext(), 0),
+		calls:              make(map[string]int),
+	}
+}
+
+func (s *stubContext) Capabilities() backend.CapabilitySet {
+	return backend.CapabilitySet(backend.AllCapabilities &^ (backend.CapabilityEvaluateTx | backend.CapabilityEvaluateTxAdditionalUtxos))
+}
+
+func next(errs *[]error) error {
+	if len(*errs) == 0 {
+		return nil
+	}
+	err := (*errs)[0]
+	*errs = (*errs)[1:]
+	return err
+}
+
+func (s *stubContext) TipContext(ctx context.Context) (uint64, error) {
+	s.calls["Tip"]++
+	s.ctxValues = append(s.ctxValues, ctx.Value(ctxKey{}))
+	if err := next(&s.tipErrs); err != nil {
+		return 0, err
+	}
+	return 42, nil
+}
+
+func (s *stubContext) SubmitTxContext(_ context.Context, _ []byte) (common.Blake2b256, error) {
+	s.calls["SubmitTx"]++
+	if err := next(&s.submitErrs); err != nil {
+		return common.Blake2b256{}, err
+	}
+	return common.Blake2b256{1}, nil
+}
+
+func (s *stubContext) UtxosContext(_ context.Context, _ common.Address) ([]common.Utxo, error) {
+	s.calls["Utxos"]++
+	return nil, nil
+}
+
+func statusError(code int, retryAfter time.Duration) error {
+	return &backend.StatusError{Backend: "test", StatusCode: code, RetryAfter: retryAfter}
+}
+
+// testClock is a manually advanced clock; sleeping advances it and records
+// the delay.
+type testClock struct {
+	now    time.Time
+	sleeps []time.Duration
+}
+
+func (c *testClock) Now() time.Time { return c.now }
+
+func (c *testClock) Sleep(ctx context.Context, d time.Duration) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	c.sleeps = append(c.sleeps, d)
+	c.now = c.now.Add(d)
+	return nil
+}
+
+func newRetry(t *testing.T, inner backend.ChainContext, cfg Config) (*RetryChainContext, *testClock) {
+	t.Helper()
+	r, err := NewRetryChainContext(inner, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clock := &testClock{now: time.Unix(1_700_000_000, 0)}
+	r.now = clock.Now
+	r.sleep = clock.Sleep
+	// No jitter, so the backoff delays are the ceilings.
+	r.jitter = func(d time.Duration) time.Duration { return d }
+	for _, limit := range append([]*bucket{r.limit}, mapValues(r.limits)...) {
+		if limit != nil {
+			limit.last = clock.now
+		}
+	}
+	return r, clock
+}
+
+func mapValues(m map[backend.Capability]*bucket) []*bucket {
+	values := make([]*bucket, 0, len(m))
+	for _, v := range m {
+		values = append(values, v)
+	}
+	return values
+}
+
+func TestRetriesTransientErrorsWithBackoff(t *testing.T) {
+	inner := newStub()
+	inner.tipErrs = []error{statusError(http.StatusServiceUnavailable, 0), io.ErrUnexpectedEOF}
+	r, clock := newRetry(t, inner, Config{})
+
+	ctx := context.WithValue(context.Background(), ctxKey{}, "request")
+	tip, err := r.TipContext(ctx)
+	if err != nil || tip != 42 {
+		t.Fatalf("TipContext() = %d, %v", tip, err)
+	}
+	if inner.calls["Tip"] != 3 {
+		t.Fatalf("Tip calls = %d, want 3", inner.calls["Tip"])
+	}
+	want := []time.Duration{200 * time.Millisecond, 400 * time.Millisecond}
+	if !slices.Equal(clock.sleeps, want) {
+		t.Fatalf("sleeps = %v, want %v", clock.sleeps, want)
+	}
+	for i, value := range inner.ctxValues {
+		if value != "request" {
+			t.Fatalf("attempt %d did not receive the caller context", i)
+		}
+	}
+}
+
+func TestStopsAfterMaxAttempts(t *testing.T) {
+	inner := newStub()
+	for range 5 {
+		inner.tipErrs = append(inner.tipErrs, statusError(http.StatusBadGateway, 0))
+	}
+	r, clock := newRetry(t, inner, Config{MaxAttempts: 3, BaseDelay: time.Second, MaxDelay: 1500 * time.Millisecond})
+
+	_, err := r.Tip()
+	var status *backend.StatusError
+	if !errors.As(err, &status) || status.StatusCode != http.StatusBadGateway {
+		t.Fatalf("Tip() error = %v, want the last status error", err)
+	}
+	if inner.calls["Tip"] != 3 {
+		t.Fatalf("Tip calls = %d, want 3", inner.calls["Tip"])
+	}
+	want := []time.Duration{time.Second, 1500 * time.Millisecond}
+	if !slices.Equal(clock.sleeps, want) {
+		t.Fatalf("sleeps = %v, want %v", clock.sleeps, want)
+	}
+}
+
+func TestHonoursRetryAfter(t *testing.T) {
+	inner := newStub()
+	inner.tipErrs = []error{statusError(http.StatusTooManyRequests, 3*time.Second)}
+	r, clock := newRetry(t, inner, Config{})
+	if _, err := r.Tip(); err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(clock.sleeps, []time.Duration{3 * time.Second}) {
+		t.Fatalf("sleeps = %v, want [3s]", clock.sleeps)
+	}
+
+	// A requested delay beyond MaxDelay ends the call with the provider's error.
+	inner.tipErrs = []error{statusError(http.StatusTooManyRequests, time.Minute)}
+	clock.sleeps = nil
+	if _, err := r.Tip(); !errors.As(err, new(*backend.StatusError)) {
+		t.Fatalf("Tip() error = %v, want the status error", err)
+	}
+	if len(clock.sleeps) != 0 {
+		t.Fatalf("slept %v despite Retry-After exceeding MaxDelay", clock.sleeps)
+	}
+}
+
+func TestPermanentErrorsAreNotRetried(t *testing.T) {
+	for _, err := range []error{
+		statusError(http.StatusBadRequest, 0),
+		backend.ErrUtxoNotFound,
+		backend.NewPermanentError(io.ErrUnexpectedEOF),
+	} {
+		inner := newStub()
+		inner.tipErrs = []error{err}
+		r, _ := newRetry(t, inner, Config{})
+		if _, got := r.Tip(); !errors.Is(got, err) {
+			t.Fatalf("Tip() error = %v, want %v", got, err)
+		}
+		if inner.calls["Tip"] != 1 {
+			t.Fatalf("%v: Tip calls = %d, want 1", err, inner.calls["Tip"])
+		}
+	}
+}
+
+func TestDeadlineShorterThanBackoffReturnsError(t *testing.T) {
+	inner := newStub()
+	inner.tipErrs = []error{statusError(http.StatusServiceUnavailable, 5*time.Second)}
+	r, clock := newRetry(t, inner, Config{})
+	ctx, cancel := context.WithDeadline(context.Background(), clock.now.Add(time.Second))
+	defer cancel()
+	if _, err := r.TipContext(ctx); !errors.As(err, new(*backend.StatusError)) {
+		t.Fatalf("TipContext() error = %v, want the status error", err)
+	}
+	if inner.calls["Tip"] != 1 || len(clock.sleeps) != 0 {
+		t.Fatalf("Tip calls = %d, sleeps = %v", inner.calls["Tip"], clock.sleeps)
+	}
+}
+
+func TestSubmitTxRetriesOnlyUnacceptedSubmissions(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		err   error
+		calls int
+	}{
+		{"rate limited", statusError(http.StatusTooManyRequests, 0), 2},
+		{"refused", syscall.ECONNREFUSED, 2},
+		{"marked retryable", backend.NewRetryableError(errors.New("unavailable"), 0), 2},
+		{"dropped response", io.ErrUnexpectedEOF, 1},
+		{"gateway timeout", statusError(http.StatusGatewayTimeout, 0), 1},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			inner := newStub()
+			inner.submitErrs = []error{tc.err}
+			r, _ := newRetry(t, inner, Config{})
+			_, err := r.SubmitTx(nil)
+			if inner.calls["SubmitTx"] != tc.calls {
+				t.Fatalf("SubmitTx calls = %d, want %d", inner.calls["SubmitTx"], tc.calls)
+			}
+			if (tc.calls == 1) != (err != nil) {
+				t.Fatalf("SubmitTx() error = %v", err)
+			}
+		})
+	}
+}
+
+func TestRateLimitsPerCapability(t *testing.T) {
+	inner := newStub()
+	r, clock := newRetry(t, inner, Config{
+		CapabilityRateLimits: map[backend.Capability]Rate{
+			backend.CapabilityTip: {PerSecond: 2},
+		},
+	})
+	for range 3 {
+		if _, err := r.Tip(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want := []time.Duration{500 * time.Millisecond, 500 * time.Millisecond}
+	if !slices.Equal(clock.sleeps, want) {
+		t.Fatalf("sleeps = %v, want %v", clock.sleeps, want)
+	}
+
+	clock.sleeps = nil
+	for range 3 {
+		if _, err := r.Utxos(common.Address{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(clock.sleeps) != 0 {
+		t.Fatalf("unlimited capability waited %v", clock.sleeps)
+	}
+}
+
+func TestGlobalRateLimitAllowsBursts(t *testing.T) {
+	inner := newStub()
+	r, clock := newRetry(t, inner, Config{RateLimit: Rate{PerSecond: 10, Burst: 3}})
+	for range 4 {
+		if _, err := r.Utxos(common.Address{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if !slices.Equal(clock.sleeps, []time.Duration{100 * time.Millisecond}) {
+		t.Fatalf("sleeps = %v, want [100ms]", clock.sleeps)
+	}
+}
+
+func TestCanceledWaitReleasesToken(t *testing.T) {
+	inner := newStub()
+	r, _ := newRetry(t, inner, Config{RateLimit: Rate{PerSecond: 1}})
+	if _, err := r.Tip(); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := r.TipContext(ctx); !errors.Is(err, context.Canceled) {
+		t.Fatalf("TipContext() error = %v, want context.Canceled", err)
+	}
+	if inner.calls["Tip"] != 1 {
+		t.Fatalf("Tip calls = %d, want 1", inner.calls["Tip"])
+	}
+	if r.limit.tokens != 0 {
+		t.Fatalf("tokens = %v after a canceled wait, want 0", r.limit.tokens)
+	}
+}
+
+func TestCapabilitiesArePreserved(t *testing.T) {
+	inner := newStub()
+	r, _ := newRetry(t, inner, Config{})
+	if got, want := r.Capabilities(), backend.CapabilitiesOf(inner); got != want {
+		t.Fatalf("Capabilities() = %b, want %b", got, want)
+	}
+	if _, err := r.EvaluateTx(nil, nil); !errors.Is(err, backend.ErrUnsupported) {
+		t.Fatalf("EvaluateTx() error = %v, want ErrUnsupported", err)
+	}
+}
+
+func TestNewRetryChainContextRejectsInvalidConfig(t *testing.T) {
+	var nilStub *stubContext
+	for _, tc := range []struct {
+		name  string
+		inner backend.ChainContext
+		cfg   Config
+		want  string
+	}{
+		{"nil context", nilStub, Config{}, "nil"},
+		{"zero rate", newStub(), Config{RateLimit: Rate{Burst: 2}}, "rate must be positive"},
+		{
+			"combined capability",
+			newStub(),
+			Config{CapabilityRateLimits: map[backend.Capability]Rate{
+				backend.CapabilityTip | backend.CapabilityUtxos: {PerSecond: 1},
+			}},
+			"cannot rate limit",
+		},
+		{
+			"negative burst",
+			newStub(),
+			Config{CapabilityRateLimits: map[backend.Capability]Rate{
+				backend.CapabilityTip: {PerSecond: 1, Burst: -1},
+			}},
+			"burst",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := NewRetryChainContext(tc.inner, tc.cfg)
+			if err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Fatalf("NewRetryChainContext() error = %v, want %q", err, tc.want)
+			}
+		})
+	}
+}
+
+// streamingStub streams scripted UTxO pages: each attempt yields the next
+// list of UTxOs and then the next scripted error, if any.
+type streamingStub struct {
+	*stubContext
+	attempts [][]uint32
+	errs     []error
+}
+
+func (s *streamingStub) UtxosSeq(_ context.Context, _ common.Address) iter.Seq2[common.Utxo, error] {
+	return func(yield func(common.Utxo, error) bool) {
+		s.calls["UtxosSeq"]++
+		indexes := s.attempts[0]
+		s.attempts = s.attempts[1:]
+		for _, index := range indexes {
+			utxo := common.Utxo{Id: shelley.ShelleyTransactionInput{TxId: common.Blake2b256{0x01}, OutputIndex: index}}
+			if !yield(utxo, nil) {
+				return
+			}
+		}
+		if err := next(&s.errs); err != nil {
+			yield(common.Utxo{}, err)
+		}
+	}
+}
+
+func TestUtxosSeqResumesWithoutRepeating(t *testing.T) {
+	inner := &streamingStub{
+		stubContext: newStub(),
+		attempts:    [][]uint32{{0, 1}, {0, 1, 2}},
+		errs:        []error{statusError(http.StatusBadGateway, 0)},
+	}
+	r, clock := newRetry(t, inner, Config{})
+
+	var got []uint32
+	for utxo, err := range r.UtxosSeq(t.Context(), common.Address{}) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, utxo.Id.Index())
+	}
+	if !slices.Equal(got, []uint32{0, 1, 2}) {
+		t.Fatalf("UtxosSeq() yielded %v, want [0 1 2]", got)
+	}
+	if inner.calls["UtxosSeq"] != 2 || len(clock.sleeps) != 1 {
+		t.Fatalf("streams = %d, sleeps = %v", inner.calls["UtxosSeq"], clock.sleeps)
+	}
+
+	// A permanent failure ends the stream after what was already yielded.
+	inner.attempts = [][]uint32{{0}}
+	inner.errs = []error{errors.New("forbidden")}
+	got = got[:0]
+	var last error
+	for utxo, err := range r.UtxosSeq(t.Context(), common.Address{}) {
+		if err != nil {
+			last = err
+			continue
+		}
+		got = append(got, utxo.Id.Index())
+	}
+	if !slices.Equal(got, []uint32{0}) || last == nil || last.Error() != "forbidden" {
+		t.Fatalf("UtxosSeq() yielded %v, %v", got, last)
+	}
+}
@@ -0,0 +1,61 @@
+package backend
+
+import (
+	"context"
+	"iter"
+
+	"github.com/blinklabs-io/gouroboros/ledger/common"
+)
+
+// UtxoStreamer is an optional extension to ChainContext for backends that
+// page through an address's UTxOs. UtxosSeq yields them as pages arrive and
+// fetches the next page only once the caller has consumed the previous one,
+// so a caller that stops early never requests the rest. A failure is yielded
+// once, with a zero UTxO, and ends the sequence; UTxOs yielded before it were
+// valid but the sequence is incomplete.
+type UtxoStreamer interface {
+	UtxosSeq(ctx context.Context, address common.Address) iter.Seq2[common.Utxo, error]
+}
+
+// UtxosSeq streams the UTxOs at address through chainContext's UtxoStreamer
+// when it has one, otherwise it loads them all with UtxosContext and yields
+// them in order.
+func UtxosSeq(ctx context.Context, chainContext ChainContext, address common.Address) iter.Seq2[common.Utxo, error] {
+	if isNilInterface(chainContext) {
+		return failedSeq(errNilChainContext)
+	}
+	if streamer, ok := chainContext.(UtxoStreamer); ok {
+		return streamer.UtxosSeq(normalizeContext(ctx), address)
+	}
+	return func(yield func(common.Utxo, error) bool) {
+		utxos, err := UtxosContext(ctx, chainContext, address)
+		if err != nil {
+			yield(common.Utxo{}, err)
+			return
+		}
+		for _, utxo := range utxos {
+			if !yield(utxo, nil) {
+				return
+			}
+		}
+	}
+}
+
+// CollectUtxos drains seq into a slice. It returns the first error the
+// sequence yields and no UTxOs, as UtxosContext would.
+func CollectUtxos(seq iter.Seq2[common.Utxo, error]) ([]common.Utxo, error) {
+	var utxos []common.Utxo
+	for utxo, err := range seq {
+		if err != nil {
+			return nil, err
+		}
+		utxos = append(utxos, utxo)
+	}
+	return utxos, nil
+}
+
+func failedSeq(err error) iter.Seq2[common.Utxo, error] {
+	return func(yield func(common.Utxo, error) bool) {
+		yield(common.Utxo{}, err)
+	}
+}
@@ -0,0 +1,50 @@
+package backend
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/blinklabs-io/gouroboros/ledger/common"
+	"github.com/blinklabs-io/gouroboros/ledger/shelley"
+)
+
+type listingLegacyContext struct {
+	legacyChainContext
+	utxos []common.Utxo
+	err   error
+}
+
+func (c *listingLegacyContext) Utxos(common.Address) ([]common.Utxo, error) {
+	return c.utxos, c.err
+}
+
+func TestUtxosSeqFallsBackToUtxos(t *testing.T) {
+	chainContext := &listingLegacyContext{}
+	for i := range uint32(3) {
+		chainContext.utxos = append(chainContext.utxos, common.Utxo{
+			Id: shelley.ShelleyTransactionInput{OutputIndex: i},
+		})
+	}
+
+	utxos, err := CollectUtxos(UtxosSeq(context.Background(), chainContext, common.Address{}))
+	if err != nil || len(utxos) != 3 {
+		t.Fatalf("CollectUtxos() = %d UTxOs, %v; want 3", len(utxos), err)
+	}
+	seen := 0
+	for range UtxosSeq(context.Background(), chainContext, common.Address{}) {
+		seen++
+		break
+	}
+	if seen != 1 {
+		t.Fatalf("stopped sequence yielded %d UTxOs", seen)
+	}
+
+	chainContext.err = errors.New("backend down")
+	if _, err := CollectUtxos(UtxosSeq(context.Background(), chainContext, common.Address{})); !errors.Is(err, chainContext.err) {
+		t.Fatalf("CollectUtxos() error = %v, want %v", err, chainContext.err)
+	}
+	if _, err := CollectUtxos(UtxosSeq(context.Background(), nil, common.Address{})); err == nil {
+		t.Fatal("UtxosSeq(nil chain context) yielded no error")
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/hex"
 	"errors"
 	"fmt"
+	"iter"
 	"math"
 	"math/big"
 	"sort"
@@ -48,7 +49,10 @@ type UtxoRpcChainContext struct {
 	version   protoVersion
 }
 
-var _ backend.ContextChainContext = (*UtxoRpcChainContext)(nil)
+var (
+	_ backend.ContextChainContext = (*UtxoRpcChainContext)(nil)
+	_ backend.UtxoStreamer        = (*UtxoRpcChainContext)(nil)
+)
 
 // Capabilities reports the UTxO RPC operations supported by this client.
 func (u *UtxoRpcChainContext) Capabilities() backend.CapabilitySet {
@@ -369,39 +373,53 @@ func (u *UtxoRpcChainContext) Utxos(address common.Address) ([]common.Utxo, erro
 }
 
 func (u *UtxoRpcChainContext) UtxosContext(ctx context.Context, address common.Address) ([]common.Utxo, error) {
-	addrBytes, err := address.Bytes()
-	if err != nil {
-		return nil, fmt.Errorf("failed to get address bytes: %w", err)
-	}
+	return backend.CollectUtxos(u.UtxosSeq(ctx, address))
+}
 
-	var utxos []common.Utxo
-	seenTokens := make(map[string]struct{})
-	const maxPages = 10_000
-	var startToken string
-	for page := 0; page < maxPages; page++ {
-		page, err := u.searchUtxosPage(ctx, addrBytes, startToken)
+// UtxosSeq follows SearchUtxos' next tokens, fetching each page only once the
+// previous one has been consumed.
+func (u *UtxoRpcChainContext) UtxosSeq(ctx context.Context, address common.Address) iter.Seq2[common.Utxo, error] {
+	return func(yield func(common.Utxo, error) bool) {
+		addrBytes, err := address.Bytes()
 		if err != nil {
-			if startToken == "" {
-				return nil, err
-			}
-			return nil, fmt.Errorf(
-				"failed to fetch UTxO RPC page after token %q: %w",
-				startToken,
-				err,
-			)
-		}
-		utxos = append(utxos, page.utxos...)
-		next := page.nextToken
-		if next == "" {
-			return utxos, nil
+			yield(common.Utxo{}, fmt.Errorf("failed to get address bytes: %w", err))
+			return
 		}
-		if _, seen := seenTokens[next]; seen {
-			return nil, fmt.Errorf("UTxO RPC pagination repeated token %q", next)
+
+		seenTokens := make(map[string]struct{})
+		const maxPages = 10_000
+		var startToken string
+		for range maxPages {
+			page, err := u.searchUtxosPage(ctx, addrBytes, startToken)
+			if err != nil {
+				if startToken != "" {
+					err = fmt.Errorf(
+						"failed to fetch UTxO RPC page after token %q: %w",
+						startToken,
+						err,
+					)
+				}
+				yield(common.Utxo{}, err)
+				return
+			}
+			for _, utxo := range page.utxos {
+				if !yield(utxo, nil) {
+					return
+				}
+			}
+			next := page.nextToken
+			if next == "" {
+				return
+			}
+			if _, seen := seenTokens[next]; seen {
+				yield(common.Utxo{}, fmt.Errorf("UTxO RPC pagination repeated token %q", next))
+				return
+			}
+			seenTokens[next] = struct{}{}
+			startToken = next
 		}
-		seenTokens[next] = struct{}{}
-		startToken = next
+		yield(common.Utxo{}, fmt.Errorf("UTxO RPC pagination exceeded %d pages", maxPages))
 	}
-	return nil, fmt.Errorf("UTxO RPC pagination exceeded %d pages", maxPages)
 }
 
 // utxoPage is one page of a UTxO search, already converted to Apollo's types.
@@ -797,7 +815,7 @@ func (u *UtxoRpcChainContext) UtxoByRefContext(
 		return nil, err
 	}
 	if len(found) == 0 {
-		return nil, errors.New("utxo not found")
+		return nil, backend.ErrUtxoNotFound
 	}
 	utxo := found[0]
 	return &utxo, nil
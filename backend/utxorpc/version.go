@@ -7,6 +7,8 @@ import (
 
 	"connectrpc.com/connect"
 	"google.golang.org/protobuf/proto"
+
+	"github.com/Salvionied/apollo/v2/backend"
 )
 
 // protoVersion identifies which UTxO RPC proto package a server answers on.
@@ -66,6 +68,18 @@ func isUnimplemented(err error) bool {
 	return connect.CodeOf(err) == connect.CodeUnimplemented
 }
 
+// markTransient wraps errors whose connect code means the server may answer the
+// same request later in a backend.RetryableError, so retry and failover
+// middleware can tell them from rejected requests.
+func markTransient(err error) error {
+	switch connect.CodeOf(err) {
+	case connect.CodeUnavailable, connect.CodeResourceExhausted, connect.CodeAborted:
+		return backend.NewRetryableError(err, 0)
+	default:
+		return err
+	}
+}
+
 // callWithVersionFallback runs a request against v1beta and, only when the
 // server reports that service as unimplemented, retries it against v1alpha.
 //
@@ -77,6 +91,19 @@ func callWithVersionFallback[T any](
 	u *UtxoRpcChainContext,
 	beta func(context.Context) (T, error),
 	alpha func(context.Context) (T, error),
+) (T, error) {
+	result, err := negotiateVersion(ctx, u, beta, alpha)
+	if err != nil {
+		return result, markTransient(err)
+	}
+	return result, nil
+}
+
+func negotiateVersion[T any](
+	ctx context.Context,
+	u *UtxoRpcChainContext,
+	beta func(context.Context) (T, error),
+	alpha func(context.Context) (T, error),
 ) (T, error) {
 	switch u.negotiatedVersion() {
 	case protoVersionV1Beta:
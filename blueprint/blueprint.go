@@ -0,0 +1,329 @@
+package blueprint
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/blinklabs-io/gouroboros/ledger/common"
+	"github.com/blinklabs-io/plutigo/data"
+
+	apollo "github.com/Salvionied/apollo/v2"
+)
+
+// Blueprint is a parsed CIP-57 plutus.json.
+type Blueprint struct {
+	Preamble    Preamble           `json:"preamble"`
+	Validators  []Validator        `json:"validators"`
+	Definitions map[string]*Schema `json:"definitions,omitempty"`
+}
+
+// Preamble is the blueprint's metadata.
+type Preamble struct {
+	Title         string    `json:"title"`
+	Description   string    `json:"description,omitempty"`
+	Version       string    `json:"version"`
+	PlutusVersion string    `json:"plutusVersion,omitempty"`
+	Compiler      *Compiler `json:"compiler,omitempty"`
+	License       string    `json:"license,omitempty"`
+}
+
+// Compiler identifies the tool that produced the blueprint.
+type Compiler struct {
+	Name    string `json:"name"`
+	Version string `json:"version,omitempty"`
+}
+
+// Argument is a validator's datum, redeemer or parameter.
+type Argument struct {
+	Title       string  `json:"title,omitempty"`
+	Description string  `json:"description,omitempty"`
+	Purpose     any     `json:"purpose,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+// Validator is one validator of a blueprint. Compilers that emit one entry
+// per purpose (Aiken's "spend", "mint", "else", ...) repeat the same
+// compiled code, so those entries share a hash and addresses.
+type Validator struct {
+	Title        string     `json:"title"`
+	Description  string     `json:"description,omitempty"`
+	Datum        *Argument  `json:"datum,omitempty"`
+	Redeemer     *Argument  `json:"redeemer,omitempty"`
+	Parameters   []Argument `json:"parameters,omitempty"`
+	CompiledCode string     `json:"compiledCode"`
+	Hash         string     `json:"hash"`
+
+	script    common.Script
+	blueprint *Blueprint
+}
+
+var (
+	_ apollo.DataSchema         = (*Validator)(nil)
+	_ apollo.MintRedeemerSchema = (*Validator)(nil)
+)
+
+// purposes are the handler names Aiken appends to a validator title.
+var purposes = []string{"spend", "mint", "withdraw", "publish", "vote", "propose", "else"}
+
+// Load reads and parses the blueprint at path.
+func Load(path string) (*Blueprint, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read blueprint: %w", err)
+	}
+	return Parse(data)
+}
+
+// Parse decodes a CIP-57 blueprint. Each validator's compiled code is decoded
+// for the preamble's Plutus version and its hash is checked against the one
+// the compiler recorded.
+func Parse(data []byte) (*Blueprint, error) {
+	var b Blueprint
+	if err := json.Unmarshal(data, &b); err != nil {
+		return nil, fmt.Errorf("decode blueprint: %w", err)
+	}
+	for i := range b.Validators {
+		v := &b.Validators[i]
+		if v.Title == "" {
+			return nil, fmt.Errorf("validator %d has no title", i)
+		}
+		script, err := newScript(b.Preamble.PlutusVersion, v.CompiledCode)
+		if err != nil {
+			return nil, fmt.Errorf("validator %s: %w", v.Title, err)
+		}
+		if v.Hash != "" && script.Hash().String() != v.Hash {
+			return nil, fmt.Errorf(
+				"validator %s: compiled code hashes to %s, blueprint records %s",
+				v.Title,
+				script.Hash().String(),
+				v.Hash,
+			)
+		}
+		v.script = script
+		v.blueprint = &b
+	}
+	return &b, nil
+}
+
+func newScript(plutusVersion, compiledCode string) (common.Script, error) {
+	code, err := hex.DecodeString(compiledCode)
+	if err != nil {
+		return nil, fmt.Errorf("decode compiled code: %w", err)
+	}
+	if len(code) == 0 {
+		return nil, errors.New("compiled code is empty")
+	}
+	switch plutusVersion {
+	case "v1":
+		return common.PlutusV1Script(code), nil
+	case "v2":
+		return common.PlutusV2Script(code), nil
+	case "v3", "":
+		// CIP-57 makes plutusVersion optional; current compilers target V3.
+		return common.PlutusV3Script(code), nil
+	default:
+		return nil, fmt.Errorf("unsupported plutus version %q", plutusVersion)
+	}
+}
+
+// Validator returns the validator with the given title.
+func (b *Blueprint) Validator(title string) (*Validator, error) {
+	for i := range b.Validators {
+		if b.Validators[i].Title == title {
+			return &b.Validators[i], nil
+		}
+	}
+	return nil, fmt.Errorf("blueprint has no validator %q", title)
+}
+
+// Script returns the validator's compiled script. For a parameterized
+// validator this is the code before parameters are applied.
+func (v *Validator) Script() common.Script {
+	return v.script
+}
+
+// ScriptHash returns the hash of Script.
+func (v *Validator) ScriptHash() common.ScriptHash {
+	return v.script.Hash()
+}
+
+// PlutusVersion returns the validator's script ref type, one of
+// common.ScriptRefTypePlutusV1 through common.ScriptRefTypePlutusV3.
+func (v *Validator) PlutusVersion() uint {
+	switch v.script.(type) {
+	case common.PlutusV1Script:
+		return common.ScriptRefTypePlutusV1
+	case common.PlutusV2Script:
+		return common.ScriptRefTypePlutusV2
+	default:
+		return common.ScriptRefTypePlutusV3
+	}
+}
+
+// requireApplied rejects parameterized validators, whose unapplied code
+// cannot lock or spend funds.
+func (v *Validator) requireApplied() error {
+	if len(v.Parameters) > 0 {
+		return fmt.Errorf(
+			"validator %s takes %d parameters; apply them with ApplyParams first",
+			v.Title,
+			len(v.Parameters),
+		)
+	}
+	return nil
+}
+
+// Address returns the enterprise address of the validator on networkId.
+func (v *Validator) Address(networkId uint8) (common.Address, error) {
+	if err := v.requireApplied(); err != nil {
+		return common.Address{}, err
+	}
+	return apollo.ScriptAddress(v.script, networkId, nil)
+}
+
+// BaseAddress returns the address of the validator on networkId delegated to
+// the stake credential.
+func (v *Validator) BaseAddress(networkId uint8, stake common.Credential) (common.Address, error) {
+	if err := v.requireApplied(); err != nil {
+		return common.Address{}, err
+	}
+	return apollo.ScriptAddress(v.script, networkId, &stake)
+}
+
+// ApplyParams returns the validator with params applied to its compiled
+// code, one per entry of Parameters and in the same order. Each parameter
+// is checked against its schema first. The result has no parameters left,
+// so it can be attached, deployed and paid to.
+func (v *Validator) ApplyParams(params ...data.PlutusData) (*Validator, error) {
+	if len(v.Parameters) == 0 {
+		return nil, fmt.Errorf("validator %s takes no parameters", v.Title)
+	}
+	if len(params) != len(v.Parameters) {
+		return nil, fmt.Errorf(
+			"validator %s takes %d parameters, got %d",
+			v.Title,
+			len(v.Parameters),
+			len(params),
+		)
+	}
+	for i, param := range v.Parameters {
+		if param.Schema == nil {
+			continue
+		}
+		if err := v.blueprint.Validate(param.Schema, params[i]); err != nil {
+			return nil, fmt.Errorf("validator %s parameter %s: %w", v.Title, parameterName(param, i), err)
+		}
+	}
+	script, err := apollo.ApplyParamsToScript(v.script, params...)
+	if err != nil {
+		return nil, fmt.Errorf("validator %s: %w", v.Title, err)
+	}
+	applied := *v
+	applied.Parameters = nil
+	applied.CompiledCode = hex.EncodeToString(script.RawScriptBytes())
+	applied.Hash = script.Hash().String()
+	applied.script = script
+	return &applied, nil
+}
+
+func parameterName(param Argument, index int) string {
+	if param.Title != "" {
+		return param.Title
+	}
+	return strconv.Itoa(index)
+}
+
+// Attach adds the validator as a witness script of the transaction.
+func (v *Validator) Attach(a *apollo.Apollo) (*apollo.Apollo, error) {
+	if err := v.requireApplied(); err != nil {
+		return a, err
+	}
+	return a.AttachScript(v.script), nil
+}
+
+// DeployReference pays lovelace to addr in an output carrying the validator
+// as a reference script, so later transactions can reference it instead of
+// attaching it.
+func (v *Validator) DeployReference(a *apollo.Apollo, addr common.Address, lovelace int64) (*apollo.Apollo, error) {
+	if err := v.requireApplied(); err != nil {
+		return a, err
+	}
+	return a.PayToAddressWithReferenceScript(addr, lovelace, v.script)
+}
+
+// Purpose returns the handler name ending the validator's title, such as
+// "spend" for "escrow.escrow.spend", or "" for a title without one.
+func (v *Validator) Purpose() string {
+	i := strings.LastIndexByte(v.Title, '.')
+	if i < 0 || !slices.Contains(purposes, v.Title[i+1:]) {
+		return ""
+	}
+	return v.Title[i+1:]
+}
+
+// handler returns the entry of v's validator that handles purpose: v itself
+// when its title names no purpose, otherwise the sibling entry for purpose or
+// the "else" entry. It returns nil when the validator has neither.
+func (v *Validator) handler(purpose string) *Validator {
+	own := v.Purpose()
+	if own == "" || own == purpose {
+		return v
+	}
+	prefix := strings.TrimSuffix(v.Title, own)
+	var fallback *Validator
+	for i := range v.blueprint.Validators {
+		sibling := &v.blueprint.Validators[i]
+		switch sibling.Title {
+		case prefix + purpose:
+			return sibling
+		case prefix + "else":
+			fallback = sibling
+		}
+	}
+	if own == "else" {
+		return v
+	}
+	return fallback
+}
+
+// ValidateDatum checks pd against the datum schema of the validator's spend
+// handler. A validator without one accepts any datum.
+func (v *Validator) ValidateDatum(pd data.PlutusData) error {
+	h := v.handler("spend")
+	if h == nil || h.Datum == nil || h.Datum.Schema == nil {
+		return nil
+	}
+	if err := v.blueprint.Validate(h.Datum.Schema, pd); err != nil {
+		return fmt.Errorf("validator %s datum: %w", h.Title, err)
+	}
+	return nil
+}
+
+// ValidateRedeemer checks pd against the redeemer schema of the validator's
+// spend handler. A validator without one accepts any redeemer.
+func (v *Validator) ValidateRedeemer(pd data.PlutusData) error {
+	return v.validateRedeemer("spend", pd)
+}
+
+// ValidateMintRedeemer checks pd against the redeemer schema of the
+// validator's mint handler. A validator without one accepts any redeemer.
+func (v *Validator) ValidateMintRedeemer(pd data.PlutusData) error {
+	return v.validateRedeemer("mint", pd)
+}
+
+func (v *Validator) validateRedeemer(purpose string, pd data.PlutusData) error {
+	h := v.handler(purpose)
+	if h == nil || h.Redeemer == nil || h.Redeemer.Schema == nil {
+		return nil
+	}
+	if err := v.blueprint.Validate(h.Redeemer.Schema, pd); err != nil {
+		return fmt.Errorf("validator %s redeemer: %w", h.Title, err)
+	}
+	return nil
+}
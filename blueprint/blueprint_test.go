@@ -0,0 +1,197 @@
+package blueprint
+
+import (
+	"bytes"
+	"encoding/json"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/blinklabs-io/gouroboros/ledger/common"
+	"github.com/blinklabs-io/plutigo/data"
+
+	apollo "github.com/Salvionied/apollo/v2"
+	"github.com/Salvionied/apollo/v2/backend/fixed"
+)
+
+func loadTestBlueprint(t *testing.T) *Blueprint {
+	t.Helper()
+	b, err := Load("testdata/plutus.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func TestParseExposesValidators(t *testing.T) {
+	b := loadTestBlueprint(t)
+	if b.Preamble.PlutusVersion != "v3" || b.Preamble.Compiler.Name != "Aiken" {
+		t.Fatalf("preamble = %+v", b.Preamble)
+	}
+	spend, err := b.Validator("escrow.escrow.spend")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if spend.PlutusVersion() != common.ScriptRefTypePlutusV3 {
+		t.Fatalf("plutus version = %d", spend.PlutusVersion())
+	}
+	if spend.ScriptHash().String() != "186e32faa80a26810392fda6d559c7ed4721a65ce1c9d4ef3e1c87b4" {
+		t.Fatalf("hash = %s", spend.ScriptHash().String())
+	}
+
+	addr, err := spend.Address(common.AddressNetworkTestnet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if addr.Type() != common.AddressTypeScriptNone || !strings.HasPrefix(addr.String(), "addr_test1w") {
+		t.Fatalf("enterprise address = %s", addr.String())
+	}
+	stake := common.Credential{CredType: common.CredentialTypeAddrKeyHash, Credential: common.Blake2b224{0x01}}
+	base, err := spend.BaseAddress(common.AddressNetworkMainnet, stake)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if base.Type() != common.AddressTypeScriptKey || base.PaymentKeyHash() != spend.ScriptHash() || base.StakeKeyHash() != stake.Credential {
+		t.Fatalf("base address = %s", base.String())
+	}
+
+	gift, err := b.Validator("gift.gift.mint")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := gift.Address(common.AddressNetworkMainnet); err == nil {
+		t.Fatal("expected an unapplied parameterized validator to have no address")
+	}
+}
+
+func TestParseRejectsHashMismatch(t *testing.T) {
+	data := []byte(`{"preamble":{"title":"x","version":"0","plutusVersion":"v3"},` +
+		`"validators":[{"title":"v","compiledCode":"450101002499","hash":"00"}]}`)
+	if _, err := Parse(data); err == nil || !strings.Contains(err.Error(), "hashes to") {
+		t.Fatalf("expected a hash mismatch, got %v", err)
+	}
+}
+
+func TestApplyParams(t *testing.T) {
+	b := loadTestBlueprint(t)
+	gift, err := b.Validator("gift.gift.mint")
+	if err != nil {
+		t.Fatal(err)
+	}
+	owner := data.NewByteString(bytes.Repeat([]byte{0x0a}, 28))
+	applied, err := gift.ApplyParams(owner)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := apollo.ApplyParamsToScript(gift.Script(), owner)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if applied.ScriptHash() != want.Hash() || applied.Hash != want.Hash().String() {
+		t.Fatalf("applied hash = %s, want %s", applied.Hash, want.Hash().String())
+	}
+	if applied.CompiledCode == gift.CompiledCode || len(applied.Parameters) != 0 {
+		t.Fatalf("applied validator = %+v", applied)
+	}
+	if len(gift.Parameters) != 1 {
+		t.Fatal("ApplyParams modified the original validator")
+	}
+	addr, err := applied.Address(common.AddressNetworkTestnet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if addr.PaymentKeyHash() != want.Hash() {
+		t.Fatalf("address = %s", addr.String())
+	}
+
+	if _, err := gift.ApplyParams(data.NewInteger(big.NewInt(1))); err == nil ||
+		!strings.Contains(err.Error(), "parameter owner: expected bytes got integer") {
+		t.Fatalf("expected a parameter schema error, got %v", err)
+	}
+	if _, err := gift.ApplyParams(owner, owner); err == nil ||
+		!strings.Contains(err.Error(), "takes 1 parameters, got 2") {
+		t.Fatalf("expected a parameter count error, got %v", err)
+	}
+	spend, err := b.Validator("escrow.escrow.spend")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := spend.ApplyParams(owner); err == nil {
+		t.Fatal("expected an unparameterized validator to reject parameters")
+	}
+}
+
+func TestResolveSchemas(t *testing.T) {
+	b := loadTestBlueprint(t)
+	spend, err := b.Validator("escrow.escrow.spend")
+	if err != nil {
+		t.Fatal(err)
+	}
+	datum, err := b.Resolve(spend.Datum.Schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(datum.AnyOf) != 1 || len(datum.AnyOf[0].Fields) != 5 {
+		t.Fatalf("datum schema = %+v", datum)
+	}
+	limit, err := b.Resolve(datum.AnyOf[0].Fields[3])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if limit.Title != "limit" || len(limit.AnyOf) != 2 || limit.AnyOf[1].Index != 1 {
+		t.Fatalf("option field = %+v", limit)
+	}
+	list, err := b.Definition("List$ByteArray")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if list.Items == nil || list.Items.Ref != "#/definitions/ByteArray" {
+		t.Fatalf("list items = %+v", list.Items)
+	}
+
+	redeemer, err := b.Resolve(spend.Redeemer.Schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := json.Marshal(redeemer.AnyOf[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != `{"title":"Cancel","dataType":"constructor","index":0}` {
+		t.Fatalf("constructor re-encodes as %s", out)
+	}
+
+	var tuple Schema
+	if err := json.Unmarshal([]byte(`{"dataType":"list","items":[{"dataType":"integer"},{"dataType":"bytes"}]}`), &tuple); err != nil {
+		t.Fatal(err)
+	}
+	if tuple.Items != nil || len(tuple.TupleItems) != 2 {
+		t.Fatalf("tuple schema = %+v", tuple)
+	}
+}
+
+func TestAttachAndDeployReference(t *testing.T) {
+	b := loadTestBlueprint(t)
+	spend, err := b.Validator("escrow.escrow.spend")
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := apollo.New(fixed.NewEmptyFixedChainContext())
+	if _, err := spend.Attach(a); err != nil {
+		t.Fatal(err)
+	}
+	addr, err := spend.Address(common.AddressNetworkTestnet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := spend.DeployReference(a, addr, 20_000_000); err != nil {
+		t.Fatal(err)
+	}
+	gift, err := b.Validator("gift.gift.mint")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := gift.Attach(a); err == nil {
+		t.Fatal("expected an unapplied parameterized validator to be refused")
+	}
+}
@@ -0,0 +1,568 @@
+package blueprint
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/format"
+	"maps"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// GenerateOptions configures Generate.
+type GenerateOptions struct {
+	// Package is the package clause of the generated file.
+	Package string
+	// Source names the blueprint in the generated header.
+	Source string
+}
+
+// Generate emits Go types for the blueprint's definitions, tagged for
+// plutusencoder.MarshalPlutus and plutusencoder.UnmarshalPlutus:
+//
+//   - a single-constructor type becomes a struct with a plutusConstr tag
+//   - a sum type becomes an interface implemented by one struct per
+//     constructor, registered with plutusencoder.RegisterSumType
+//   - Option becomes plutusencoder.Maybe
+//   - Bool becomes bool, Int becomes *big.Int and ByteArray becomes []byte
+//   - lists become slices and maps become named slices of Key/Value entry
+//     structs, which keeps the pair order of Aiken's Pairs
+//   - opaque Data becomes the generated Data wrapper around data.PlutusData
+//
+// List elements and Option values are encoded without tags, so a Bool, list
+// or map in those positions is wrapped in a generated struct that encodes its
+// Value field on its own. Builtin UPLC types, which only appear in validator
+// parameters, are rejected.
+func Generate(b *Blueprint, opts GenerateOptions) ([]byte, error) {
+	if opts.Package == "" {
+		return nil, errors.New("package name is required")
+	}
+	g := &generator{
+		b:       b,
+		names:   make(map[string]string),
+		emitted: make(map[string]bool),
+		decls:   make(map[string]string),
+	}
+	if err := g.assignNames(); err != nil {
+		return nil, err
+	}
+	for _, key := range slices.Sorted(maps.Keys(b.Definitions)) {
+		if strings.HasPrefix(string(b.Definitions[key].DataType), "#") {
+			// Builtin types are only used by parameters, never in data.
+			continue
+		}
+		kind, err := g.kindOf(b.Definitions[key])
+		if err != nil {
+			return nil, fmt.Errorf("definition %s: %w", key, err)
+		}
+		if kind.named() {
+			if _, err := g.namedType(key); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	var out bytes.Buffer
+	source := opts.Source
+	if source == "" {
+		source = "a CIP-57 blueprint"
+	}
+	fmt.Fprintf(&out, "// Code generated by blueprintgen from %s. DO NOT EDIT.\n\n", source)
+	fmt.Fprintf(&out, "package %s\n\n", opts.Package)
+	out.WriteString("import (\n")
+	for _, imp := range []string{"errors", "fmt", "math/big"} {
+		if g.imports[imp] {
+			fmt.Fprintf(&out, "\t%q\n", imp)
+		}
+	}
+	if g.imports[importData] || g.imports[importEncoder] {
+		out.WriteString("\n")
+		for _, imp := range []string{importEncoder, importData} {
+			if g.imports[imp] {
+				fmt.Fprintf(&out, "\t%q\n", imp)
+			}
+		}
+	}
+	out.WriteString(")\n")
+	for _, name := range slices.Sorted(maps.Keys(g.decls)) {
+		out.WriteString("\n")
+		out.WriteString(g.decls[name])
+	}
+	formatted, err := format.Source(out.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("format generated code: %w", err)
+	}
+	return formatted, nil
+}
+
+const (
+	importData    = "github.com/blinklabs-io/plutigo/data"
+	importEncoder = "github.com/Salvionied/apollo/v2/plutusencoder"
+)
+
+type schemaKind int
+
+const (
+	kindData schemaKind = iota
+	kindInteger
+	kindBytes
+	kindBool
+	kindList
+	kindTuple
+	kindMap
+	kindStruct
+	kindSum
+	kindOption
+)
+
+// named reports whether the kind is emitted as its own Go type.
+func (k schemaKind) named() bool {
+	switch k {
+	case kindData, kindTuple, kindMap, kindStruct, kindSum:
+		return true
+	default:
+		return false
+	}
+}
+
+type generator struct {
+	b       *Blueprint
+	names   map[string]string // definition key -> Go type name
+	emitted map[string]bool   // Go type names already declared
+	decls   map[string]string // Go type name -> declaration source
+	imports map[string]bool
+}
+
+func (g *generator) use(imports ...string) {
+	if g.imports == nil {
+		g.imports = make(map[string]bool)
+	}
+	for _, imp := range imports {
+		g.imports[imp] = true
+	}
+}
+
+// assignNames picks a Go name for every definition: the last path segment
+// of each generic component ("escrow/Datum" is Datum, "Option$Int" is
+// OptionInt), falling back to the full path where short names collide.
+func (g *generator) assignNames() error {
+	short := make(map[string][]string)
+	for key := range g.b.Definitions {
+		name := goTypeName(key, true)
+		short[name] = append(short[name], key)
+	}
+	taken := make(map[string]string)
+	for _, key := range slices.Sorted(maps.Keys(g.b.Definitions)) {
+		name := goTypeName(key, true)
+		if len(short[name]) > 1 {
+			name = goTypeName(key, false)
+		}
+		if other, dup := taken[name]; dup {
+			return fmt.Errorf("definitions %s and %s both map to Go type %s", other, key, name)
+		}
+		taken[name] = key
+		g.names[key] = name
+	}
+	return nil
+}
+
+// goTypeName turns a definition key into an exported identifier.
+func goTypeName(key string, short bool) string {
+	var b strings.Builder
+	for component := range strings.FieldsFuncSeq(key, func(r rune) bool { return r == '$' || r == '_' }) {
+		if short {
+			if i := strings.LastIndexByte(component, '/'); i >= 0 {
+				component = component[i+1:]
+			}
+		}
+		b.WriteString(exportedIdent(component))
+	}
+	if b.Len() == 0 {
+		return "Type"
+	}
+	return b.String()
+}
+
+// exportedIdent capitalizes the alphanumeric runs of s and joins them.
+func exportedIdent(s string) string {
+	var b strings.Builder
+	for word := range strings.FieldsFuncSeq(s, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		runes := []rune(word)
+		runes[0] = unicode.ToUpper(runes[0])
+		b.WriteString(string(runes))
+	}
+	ident := b.String()
+	if ident != "" && unicode.IsDigit([]rune(ident)[0]) {
+		ident = "X" + ident
+	}
+	return ident
+}
+
+func (g *generator) kindOf(s *Schema) (schemaKind, error) {
+	resolved, err := g.b.Resolve(s)
+	if err != nil {
+		return 0, err
+	}
+	switch resolved.DataType {
+	case DataTypeInteger:
+		return kindInteger, nil
+	case DataTypeBytes:
+		return kindBytes, nil
+	case DataTypeList:
+		if resolved.TupleItems != nil {
+			return kindTuple, nil
+		}
+		if resolved.Items == nil {
+			return 0, errors.New("list schema has no items")
+		}
+		return kindList, nil
+	case DataTypeMap:
+		if resolved.Keys == nil || resolved.Values == nil {
+			return 0, errors.New("map schema needs keys and values")
+		}
+		return kindMap, nil
+	case DataTypeConstructor:
+		return kindStruct, nil
+	case "":
+	default:
+		return 0, fmt.Errorf("unsupported dataType %q", resolved.DataType)
+	}
+	switch {
+	case resolved.IsOpaque():
+		return kindData, nil
+	case isBoolSchema(resolved):
+		return kindBool, nil
+	case isOptionSchema(resolved):
+		return kindOption, nil
+	case len(resolved.AnyOf) == 1 && resolved.AnyOf[0].DataType == DataTypeConstructor:
+		return kindStruct, nil
+	case len(resolved.AnyOf) > 1:
+		for _, variant := range resolved.AnyOf {
+			if variant.DataType != DataTypeConstructor {
+				return 0, errors.New("anyOf alternatives must be constructors")
+			}
+		}
+		return kindSum, nil
+	default:
+		return 0, errors.New("unsupported schema")
+	}
+}
+
+func isBoolSchema(s *Schema) bool {
+	return len(s.AnyOf) == 2 &&
+		s.AnyOf[0].Title == "False" && s.AnyOf[0].Index == 0 && len(s.AnyOf[0].Fields) == 0 &&
+		s.AnyOf[1].Title == "True" && s.AnyOf[1].Index == 1 && len(s.AnyOf[1].Fields) == 0
+}
+
+func isOptionSchema(s *Schema) bool {
+	return len(s.AnyOf) == 2 &&
+		s.AnyOf[0].Title == "Some" && s.AnyOf[0].Index == 0 && len(s.AnyOf[0].Fields) == 1 &&
+		s.AnyOf[1].Title == "None" && s.AnyOf[1].Index == 1 && len(s.AnyOf[1].Fields) == 0
+}
+
+// namedType declares the Go type for a definition and returns its name.
+func (g *generator) namedType(key string) (string, error) {
+	name := g.names[key]
+	if g.emitted[name] {
+		return name, nil
+	}
+	g.emitted[name] = true
+	if err := g.declare(name, g.b.Definitions[key], key); err != nil {
+		return "", fmt.Errorf("definition %s: %w", key, err)
+	}
+	return name, nil
+}
+
+// typeFor returns the Go type a schema maps to. Inline schemas that need a
+// declaration are named after context. elem selects the forms plutusencoder
+// accepts as slice elements.
+func (g *generator) typeFor(s *Schema, context string, elem bool) (goType, tag string, err error) {
+	kind, err := g.kindOf(s)
+	if err != nil {
+		return "", "", err
+	}
+	if elem && (kind == kindBool || kind == kindList || kind == kindMap) {
+		name, err := g.elementWrapper(s, context)
+		return name, "", err
+	}
+	name := ""
+	if kind.named() {
+		if key, ok := DefinitionName(s.Ref); ok && s.Ref != "" {
+			if name, err = g.namedType(key); err != nil {
+				return "", "", err
+			}
+		} else {
+			name = context
+			if !g.emitted[name] {
+				g.emitted[name] = true
+				resolved, err := g.b.Resolve(s)
+				if err != nil {
+					return "", "", err
+				}
+				if err := g.declare(name, resolved, context); err != nil {
+					return "", "", err
+				}
+			}
+		}
+	}
+	switch kind {
+	case kindInteger:
+		g.use("math/big")
+		if elem {
+			return "*big.Int", "", nil
+		}
+		return "*big.Int", "BigInt", nil
+	case kindBytes:
+		if elem {
+			return "[]byte", "", nil
+		}
+		return "[]byte", "Bytes", nil
+	case kindBool:
+		return "bool", "Bool", nil
+	case kindList:
+		resolved, err := g.b.Resolve(s)
+		if err != nil {
+			return "", "", err
+		}
+		itemType, _, err := g.typeFor(resolved.Items, context+"Item", true)
+		if err != nil {
+			return "", "", fmt.Errorf("items: %w", err)
+		}
+		return "[]" + itemType, "IndefList", nil
+	case kindMap:
+		return name, "Map", nil
+	case kindOption:
+		resolved, err := g.b.Resolve(s)
+		if err != nil {
+			return "", "", err
+		}
+		valueType, _, err := g.typeFor(resolved.AnyOf[0].Fields[0], context+"Value", true)
+		if err != nil {
+			return "", "", fmt.Errorf("Some: %w", err)
+		}
+		g.use(importEncoder)
+		return "plutusencoder.Maybe[" + valueType + "]", "", nil
+	default:
+		return name, "", nil
+	}
+}
+
+func (g *generator) declare(name string, s *Schema, origin string) error {
+	kind, err := g.kindOf(s)
+	if err != nil {
+		return err
+	}
+	resolved, err := g.b.Resolve(s)
+	if err != nil {
+		return err
+	}
+	var b strings.Builder
+	switch kind {
+	case kindData:
+		g.use(importData, "errors", "fmt")
+		writeDoc(&b, resolved, fmt.Sprintf("%s holds arbitrary Plutus data.", name))
+		fmt.Fprintf(&b, "type %s struct {\n\tdata.PlutusData\n}\n\n", name)
+		fmt.Fprintf(&b, "// ToPlutusData returns the held data.\n")
+		fmt.Fprintf(&b, "func (d %s) ToPlutusData() (data.PlutusData, error) {\n", name)
+		fmt.Fprintf(&b, "\tif d.PlutusData == nil {\n\t\treturn nil, errors.New(%q)\n\t}\n", name+": no data set")
+		fmt.Fprintf(&b, "\treturn d.PlutusData, nil\n}\n\n")
+		fmt.Fprintf(&b, "// FromPlutusData stores pd.\n")
+		fmt.Fprintf(&b, "func (%s) FromPlutusData(pd data.PlutusData, res any) error {\n", name)
+		writeTarget(&b, name)
+		fmt.Fprintf(&b, "\ttarget.PlutusData = pd\n\treturn nil\n}\n")
+	case kindStruct:
+		variant := resolved
+		if len(resolved.AnyOf) == 1 {
+			variant = resolved.AnyOf[0]
+		}
+		writeDoc(&b, resolved, fmt.Sprintf("%s is generated from %s.", name, origin))
+		if err := g.writeStruct(&b, name, variant); err != nil {
+			return err
+		}
+	case kindTuple:
+		writeDoc(&b, resolved, fmt.Sprintf("%s is a tuple generated from %s.", name, origin))
+		fmt.Fprintf(&b, "type %s struct {\n\t_ struct{} `plutusType:\"IndefList\"`\n", name)
+		for i, item := range resolved.TupleItems {
+			fieldType, tag, err := g.typeFor(item, fmt.Sprintf("%sItem%d", name, i), false)
+			if err != nil {
+				return fmt.Errorf("items[%d]: %w", i, err)
+			}
+			writeField(&b, fmt.Sprintf("Item%d", i), fieldType, tag)
+		}
+		b.WriteString("}\n")
+	case kindMap:
+		keyType, keyTag, err := g.typeFor(resolved.Keys, name+"Key", false)
+		if err != nil {
+			return fmt.Errorf("keys: %w", err)
+		}
+		valueType, valueTag, err := g.typeFor(resolved.Values, name+"Value", false)
+		if err != nil {
+			return fmt.Errorf("values: %w", err)
+		}
+		title := resolved.Title
+		if title == "" {
+			title = origin
+		}
+		writeDoc(&b, resolved, fmt.Sprintf("%s is generated from %s.", name, origin))
+		fmt.Fprintf(&b, "type %s []%sEntry\n\n", name, name)
+		fmt.Fprintf(&b, "// %sEntry is one key/value pair of %s.\n", name, title)
+		fmt.Fprintf(&b, "type %sEntry struct {\n", name)
+		writeField(&b, "Key", keyType, keyTag)
+		writeField(&b, "Value", valueType, valueTag)
+		b.WriteString("}\n")
+	case kindSum:
+		if err := g.writeSum(&b, name, resolved); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("%s does not need a declaration", origin)
+	}
+	g.decls[name] = b.String()
+	return nil
+}
+
+// elementWrapper declares a struct named name that holds a Bool, list or map
+// list element or Option value, and encodes it as its Value field alone.
+func (g *generator) elementWrapper(s *Schema, name string) (string, error) {
+	if g.emitted[name] {
+		return name, nil
+	}
+	g.emitted[name] = true
+	resolved, err := g.b.Resolve(s)
+	if err != nil {
+		return "", err
+	}
+	valueType, tag, err := g.typeFor(s, name+"Value", false)
+	if err != nil {
+		return "", err
+	}
+	g.use(importData, importEncoder, "errors", "fmt")
+	var b strings.Builder
+	writeDoc(&b, resolved, fmt.Sprintf("%s wraps a list element or Option value that needs a tag.", name))
+	fmt.Fprintf(&b, "type %s struct {\n\t_ struct{} `plutusType:\"IndefList\"`\n", name)
+	writeField(&b, "Value", valueType, tag)
+	b.WriteString("}\n\n")
+	fmt.Fprintf(&b, "// ToPlutusData encodes Value.\n")
+	fmt.Fprintf(&b, "func (w %s) ToPlutusData() (data.PlutusData, error) {\n", name)
+	fmt.Fprintf(&b, "\ttype fields %s\n", name)
+	b.WriteString("\tpd, err := plutusencoder.MarshalPlutus(fields(w))\n")
+	b.WriteString("\tif err != nil {\n\t\treturn nil, err\n\t}\n")
+	b.WriteString("\tlist, ok := pd.(*data.List)\n")
+	fmt.Fprintf(&b, "\tif !ok || len(list.Items) != 1 {\n\t\treturn nil, errors.New(%q)\n\t}\n", name+": unexpected encoding")
+	b.WriteString("\treturn list.Items[0], nil\n}\n\n")
+	fmt.Fprintf(&b, "// FromPlutusData decodes pd into Value.\n")
+	fmt.Fprintf(&b, "func (%s) FromPlutusData(pd data.PlutusData, res any) error {\n", name)
+	writeTarget(&b, name)
+	fmt.Fprintf(&b, "\ttype fields %s\n", name)
+	b.WriteString("\treturn plutusencoder.UnmarshalPlutus(data.NewList(pd), (*fields)(target))\n}\n")
+	g.decls[name] = b.String()
+	return name, nil
+}
+
+func writeDoc(b *strings.Builder, s *Schema, fallback string) {
+	fmt.Fprintf(b, "// %s\n", fallback)
+	if s.Description == "" {
+		return
+	}
+	b.WriteString("//\n")
+	for line := range strings.Lines(strings.TrimSpace(s.Description)) {
+		fmt.Fprintf(b, "// %s\n", strings.TrimRight(line, "\n"))
+	}
+}
+
+func writeField(b *strings.Builder, name, goType, tag string) {
+	if tag == "" {
+		fmt.Fprintf(b, "\t%s %s\n", name, goType)
+		return
+	}
+	fmt.Fprintf(b, "\t%s %s `plutusType:%q`\n", name, goType, tag)
+}
+
+func writeTarget(b *strings.Builder, name string) {
+	fmt.Fprintf(b, "\ttarget, ok := res.(*%s)\n", name)
+	fmt.Fprintf(b, "\tif !ok {\n\t\treturn fmt.Errorf(\"expected *%s result, got %%T\", res)\n\t}\n", name)
+}
+
+// constructorContainer matches the Haskell encoding of constructor fields:
+// indefinite when there are fields, definite when there are none.
+func constructorContainer(fields int) string {
+	if fields == 0 {
+		return "DefList"
+	}
+	return "IndefList"
+}
+
+// writeStruct writes a struct for a constructor schema.
+func (g *generator) writeStruct(b *strings.Builder, name string, constr *Schema) error {
+	fmt.Fprintf(b, "type %s struct {\n", name)
+	fmt.Fprintf(b, "\t_ struct{} `plutusType:%q plutusConstr:\"%d\"`\n", constructorContainer(len(constr.Fields)), constr.Index)
+	used := make(map[string]int)
+	for i, field := range constr.Fields {
+		resolvedField, err := g.b.Resolve(field)
+		if err != nil {
+			return fmt.Errorf("fields[%d]: %w", i, err)
+		}
+		fieldName := exportedIdent(resolvedField.Title)
+		if field.Title == "" {
+			fieldName = fmt.Sprintf("Field%d", i)
+		}
+		if used[fieldName]++; used[fieldName] > 1 {
+			fieldName = fmt.Sprintf("%s%d", fieldName, used[fieldName])
+		}
+		fieldType, tag, err := g.typeFor(field, name+fieldName, false)
+		if err != nil {
+			return fmt.Errorf("%s.fields[%d]: %w", name, i, err)
+		}
+		writeField(b, fieldName, fieldType, tag)
+	}
+	b.WriteString("}\n")
+	return nil
+}
+
+func (g *generator) writeSum(b *strings.Builder, name string, s *Schema) error {
+	g.use(importEncoder)
+	variants := make([]string, len(s.AnyOf))
+	for i, variant := range s.AnyOf {
+		title := exportedIdent(variant.Title)
+		if title == "" {
+			title = fmt.Sprintf("Constr%d", variant.Index)
+		}
+		variants[i] = name + title
+	}
+	marker := "is" + name
+	writeDoc(b, s, fmt.Sprintf("%s is a sum type implemented by %s.", name, joinNames(variants)))
+	fmt.Fprintf(b, "type %s interface {\n\t%s()\n}\n\n", name, marker)
+	for i, variant := range s.AnyOf {
+		fmt.Fprintf(b, "// %s is the %s constructor of %s.\n", variants[i], variant.Title, name)
+		if err := g.writeStruct(b, variants[i], variant); err != nil {
+			return err
+		}
+		fmt.Fprintf(b, "\nfunc (%s) %s() {}\n\n", variants[i], marker)
+	}
+	fmt.Fprintf(b, "func init() {\n\tplutusencoder.MustRegisterSumType[%s](", name)
+	for i, variant := range variants {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		fmt.Fprintf(b, "%s{}", variant)
+	}
+	b.WriteString(")\n}\n")
+	return nil
+}
+
+func joinNames(names []string) string {
+	switch len(names) {
+	case 0:
+		return ""
+	case 1:
+		return names[0]
+	case 2:
+		return names[0] + " and " + names[1]
+	default:
+		return strings.Join(names[:len(names)-1], ", ") + " and " + names[len(names)-1]
+	}
+}
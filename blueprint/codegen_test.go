@@ -0,0 +1,35 @@
+package blueprint
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestGenerateMatchesGolden(t *testing.T) {
+	b := loadTestBlueprint(t)
+	got, err := Generate(b, GenerateOptions{Package: "escrowgen", Source: "plutus.json"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := os.ReadFile("internal/escrowgen/escrow_gen.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != string(want) {
+		t.Fatalf("generated code differs from internal/escrowgen/escrow_gen.go; run go generate ./blueprint/...\n%s", got)
+	}
+}
+
+func TestGenerateRejectsBuiltinTypesInData(t *testing.T) {
+	b, err := Parse([]byte(`{"preamble":{"title":"x","version":"0"},"validators":[],` +
+		`"definitions":{"Grid":{"anyOf":[{"dataType":"constructor","index":0,"fields":[` +
+		`{"title":"rows","dataType":"list","items":{"dataType":"#integer"}}]}]}}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = Generate(b, GenerateOptions{Package: "grid"})
+	if err == nil || !strings.Contains(err.Error(), "definition Grid: Grid.fields[0]: items: unsupported dataType") {
+		t.Fatalf("expected a builtin list item to be rejected, got %v", err)
+	}
+}
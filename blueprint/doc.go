@@ -0,0 +1,6 @@
+// Package blueprint loads CIP-57 Plutus contract blueprints (plutus.json) as
+// produced by Aiken and other compilers. It exposes each validator's compiled
+// script, hash and addresses along with the datum and redeemer schemas,
+// applies parameters to parameterized validators, and attaches or deploys
+// validators through the apollo builder.
+package blueprint
@@ -0,0 +1,5 @@
+// Package escrowgen holds the types blueprintgen generates from the test
+// blueprint. The generated file doubles as the generator's golden output.
+package escrowgen
+
+//go:generate go run ../../../cmd/blueprintgen -in ../../testdata/plutus.json -out escrow_gen.go -package escrowgen
@@ -0,0 +1,218 @@
+// Code generated by blueprintgen from plutus.json. DO NOT EDIT.
+
+package escrowgen
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/Salvionied/apollo/v2/plutusencoder"
+	"github.com/blinklabs-io/plutigo/data"
+)
+
+// Action is a sum type implemented by ActionCancel, ActionUpdate and ActionClaim.
+type Action interface {
+	isAction()
+}
+
+// ActionCancel is the Cancel constructor of Action.
+type ActionCancel struct {
+	_ struct{} `plutusType:"DefList" plutusConstr:"0"`
+}
+
+func (ActionCancel) isAction() {}
+
+// ActionUpdate is the Update constructor of Action.
+type ActionUpdate struct {
+	_        struct{} `plutusType:"IndefList" plutusConstr:"1"`
+	Deadline *big.Int `plutusType:"BigInt"`
+}
+
+func (ActionUpdate) isAction() {}
+
+// ActionClaim is the Claim constructor of Action.
+type ActionClaim struct {
+	_           struct{} `plutusType:"IndefList" plutusConstr:"2"`
+	Beneficiary []byte   `plutusType:"Bytes"`
+	Amount      *big.Int `plutusType:"BigInt"`
+}
+
+func (ActionClaim) isAction() {}
+
+func init() {
+	plutusencoder.MustRegisterSumType[Action](ActionCancel{}, ActionUpdate{}, ActionClaim{})
+}
+
+// Config is generated from escrow/Config.
+type Config struct {
+	_       struct{}            `plutusType:"IndefList" plutusConstr:"0"`
+	Flags   []ConfigFlagsItem   `plutusType:"IndefList"`
+	Grid    []ConfigGridItem    `plutusType:"IndefList"`
+	Ledgers []ConfigLedgersItem `plutusType:"IndefList"`
+	Paused  plutusencoder.Maybe[ConfigPausedValue]
+}
+
+// ConfigFlagsItem wraps a list element or Option value that needs a tag.
+type ConfigFlagsItem struct {
+	_     struct{} `plutusType:"IndefList"`
+	Value bool     `plutusType:"Bool"`
+}
+
+// ToPlutusData encodes Value.
+func (w ConfigFlagsItem) ToPlutusData() (data.PlutusData, error) {
+	type fields ConfigFlagsItem
+	pd, err := plutusencoder.MarshalPlutus(fields(w))
+	if err != nil {
+		return nil, err
+	}
+	list, ok := pd.(*data.List)
+	if !ok || len(list.Items) != 1 {
+		return nil, errors.New("ConfigFlagsItem: unexpected encoding")
+	}
+	return list.Items[0], nil
+}
+
+// FromPlutusData decodes pd into Value.
+func (ConfigFlagsItem) FromPlutusData(pd data.PlutusData, res any) error {
+	target, ok := res.(*ConfigFlagsItem)
+	if !ok {
+		return fmt.Errorf("expected *ConfigFlagsItem result, got %T", res)
+	}
+	type fields ConfigFlagsItem
+	return plutusencoder.UnmarshalPlutus(data.NewList(pd), (*fields)(target))
+}
+
+// ConfigGridItem wraps a list element or Option value that needs a tag.
+type ConfigGridItem struct {
+	_     struct{}   `plutusType:"IndefList"`
+	Value []*big.Int `plutusType:"IndefList"`
+}
+
+// ToPlutusData encodes Value.
+func (w ConfigGridItem) ToPlutusData() (data.PlutusData, error) {
+	type fields ConfigGridItem
+	pd, err := plutusencoder.MarshalPlutus(fields(w))
+	if err != nil {
+		return nil, err
+	}
+	list, ok := pd.(*data.List)
+	if !ok || len(list.Items) != 1 {
+		return nil, errors.New("ConfigGridItem: unexpected encoding")
+	}
+	return list.Items[0], nil
+}
+
+// FromPlutusData decodes pd into Value.
+func (ConfigGridItem) FromPlutusData(pd data.PlutusData, res any) error {
+	target, ok := res.(*ConfigGridItem)
+	if !ok {
+		return fmt.Errorf("expected *ConfigGridItem result, got %T", res)
+	}
+	type fields ConfigGridItem
+	return plutusencoder.UnmarshalPlutus(data.NewList(pd), (*fields)(target))
+}
+
+// ConfigLedgersItem wraps a list element or Option value that needs a tag.
+type ConfigLedgersItem struct {
+	_     struct{}          `plutusType:"IndefList"`
+	Value PairsByteArrayInt `plutusType:"Map"`
+}
+
+// ToPlutusData encodes Value.
+func (w ConfigLedgersItem) ToPlutusData() (data.PlutusData, error) {
+	type fields ConfigLedgersItem
+	pd, err := plutusencoder.MarshalPlutus(fields(w))
+	if err != nil {
+		return nil, err
+	}
+	list, ok := pd.(*data.List)
+	if !ok || len(list.Items) != 1 {
+		return nil, errors.New("ConfigLedgersItem: unexpected encoding")
+	}
+	return list.Items[0], nil
+}
+
+// FromPlutusData decodes pd into Value.
+func (ConfigLedgersItem) FromPlutusData(pd data.PlutusData, res any) error {
+	target, ok := res.(*ConfigLedgersItem)
+	if !ok {
+		return fmt.Errorf("expected *ConfigLedgersItem result, got %T", res)
+	}
+	type fields ConfigLedgersItem
+	return plutusencoder.UnmarshalPlutus(data.NewList(pd), (*fields)(target))
+}
+
+// ConfigPausedValue wraps a list element or Option value that needs a tag.
+type ConfigPausedValue struct {
+	_     struct{} `plutusType:"IndefList"`
+	Value bool     `plutusType:"Bool"`
+}
+
+// ToPlutusData encodes Value.
+func (w ConfigPausedValue) ToPlutusData() (data.PlutusData, error) {
+	type fields ConfigPausedValue
+	pd, err := plutusencoder.MarshalPlutus(fields(w))
+	if err != nil {
+		return nil, err
+	}
+	list, ok := pd.(*data.List)
+	if !ok || len(list.Items) != 1 {
+		return nil, errors.New("ConfigPausedValue: unexpected encoding")
+	}
+	return list.Items[0], nil
+}
+
+// FromPlutusData decodes pd into Value.
+func (ConfigPausedValue) FromPlutusData(pd data.PlutusData, res any) error {
+	target, ok := res.(*ConfigPausedValue)
+	if !ok {
+		return fmt.Errorf("expected *ConfigPausedValue result, got %T", res)
+	}
+	type fields ConfigPausedValue
+	return plutusencoder.UnmarshalPlutus(data.NewList(pd), (*fields)(target))
+}
+
+// Data holds arbitrary Plutus data.
+//
+// Any Plutus data.
+type Data struct {
+	data.PlutusData
+}
+
+// ToPlutusData returns the held data.
+func (d Data) ToPlutusData() (data.PlutusData, error) {
+	if d.PlutusData == nil {
+		return nil, errors.New("Data: no data set")
+	}
+	return d.PlutusData, nil
+}
+
+// FromPlutusData stores pd.
+func (Data) FromPlutusData(pd data.PlutusData, res any) error {
+	target, ok := res.(*Data)
+	if !ok {
+		return fmt.Errorf("expected *Data result, got %T", res)
+	}
+	target.PlutusData = pd
+	return nil
+}
+
+// Datum is generated from escrow/Datum.
+type Datum struct {
+	_             struct{} `plutusType:"IndefList" plutusConstr:"0"`
+	Owner         []byte   `plutusType:"Bytes"`
+	Deadline      *big.Int `plutusType:"BigInt"`
+	Beneficiaries [][]byte `plutusType:"IndefList"`
+	Limit         plutusencoder.Maybe[*big.Int]
+	Balances      PairsByteArrayInt `plutusType:"Map"`
+}
+
+// PairsByteArrayInt is generated from Pairs$ByteArray_Int.
+type PairsByteArrayInt []PairsByteArrayIntEntry
+
+// PairsByteArrayIntEntry is one key/value pair of Pairs<ByteArray, Int>.
+type PairsByteArrayIntEntry struct {
+	Key   []byte   `plutusType:"Bytes"`
+	Value *big.Int `plutusType:"BigInt"`
+}
@@ -0,0 +1,81 @@
+package escrowgen
+
+import (
+	"encoding/hex"
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/blinklabs-io/plutigo/data"
+
+	"github.com/Salvionied/apollo/v2/plutusencoder"
+)
+
+func roundTrip(t *testing.T, in any, out any, wantHex string) {
+	t.Helper()
+	pd, err := plutusencoder.MarshalPlutus(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	encoded, err := data.Encode(pd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := hex.EncodeToString(encoded); got != wantHex {
+		t.Fatalf("cbor = %s, want %s", got, wantHex)
+	}
+	if err := plutusencoder.UnmarshalPlutus(pd, out); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDatumRoundTrip(t *testing.T) {
+	datum := Datum{
+		Owner:         []byte{0xaa},
+		Deadline:      big.NewInt(5),
+		Beneficiaries: [][]byte{{0xbb}},
+		Limit:         plutusencoder.Just(big.NewInt(7)),
+		Balances:      []PairsByteArrayIntEntry{{Key: []byte{0xaa}, Value: big.NewInt(1)}},
+	}
+	var decoded Datum
+	roundTrip(t, datum, &decoded, "d8799f41aa059f41bbffd8799f07ffa141aa01ff")
+	if !reflect.DeepEqual(decoded, datum) {
+		t.Fatalf("decoded = %+v, want %+v", decoded, datum)
+	}
+
+	datum.Limit = plutusencoder.Maybe[*big.Int]{}
+	roundTrip(t, datum, &decoded, "d8799f41aa059f41bbffd87a80a141aa01ff")
+	if decoded.Limit.Valid {
+		t.Fatalf("expected None, got %+v", decoded.Limit)
+	}
+}
+
+func TestActionRoundTrip(t *testing.T) {
+	var decoded Action
+	roundTrip(t, ActionCancel{}, &decoded, "d87980")
+	if _, ok := decoded.(ActionCancel); !ok {
+		t.Fatalf("decoded %T, want ActionCancel", decoded)
+	}
+
+	claim := ActionClaim{Beneficiary: []byte{0xbb}, Amount: big.NewInt(10)}
+	roundTrip(t, claim, &decoded, "d87b9f41bb0aff")
+	if !reflect.DeepEqual(decoded, claim) {
+		t.Fatalf("decoded = %+v, want %+v", decoded, claim)
+	}
+}
+
+func TestConfigRoundTrip(t *testing.T) {
+	config := Config{
+		Flags: []ConfigFlagsItem{{Value: true}, {Value: false}},
+		Grid:  []ConfigGridItem{{Value: []*big.Int{big.NewInt(1), big.NewInt(2)}}, {Value: []*big.Int{}}},
+		Ledgers: []ConfigLedgersItem{{Value: PairsByteArrayInt{
+			{Key: []byte{0xaa}, Value: big.NewInt(1)},
+		}}},
+		Paused: plutusencoder.Just(ConfigPausedValue{Value: true}),
+	}
+	var decoded Config
+	roundTrip(t, config, &decoded, "d8799f9fd87a80d87980ff9f9f0102ff80ff9fa141aa01ffd8799fd87a80ffff")
+	if !reflect.DeepEqual(decoded, config) {
+		t.Fatalf("decoded = %+v, want %+v", decoded, config)
+	}
+}
@@ -0,0 +1,185 @@
+package blueprint
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// DataType is the dataType keyword of a CIP-57 schema. The plain types
+// describe Plutus data; the "#"-prefixed ones describe builtin UPLC values
+// and only appear in validator parameters.
+type DataType string
+
+const (
+	DataTypeInteger     DataType = "integer"
+	DataTypeBytes       DataType = "bytes"
+	DataTypeList        DataType = "list"
+	DataTypeMap         DataType = "map"
+	DataTypeConstructor DataType = "constructor"
+
+	DataTypeBuiltinUnit    DataType = "#unit"
+	DataTypeBuiltinBoolean DataType = "#boolean"
+	DataTypeBuiltinInteger DataType = "#integer"
+	DataTypeBuiltinBytes   DataType = "#bytes"
+	DataTypeBuiltinString  DataType = "#string"
+	DataTypeBuiltinPair    DataType = "#pair"
+	DataTypeBuiltinList    DataType = "#list"
+)
+
+// definitionsPrefix is the only JSON pointer prefix CIP-57 references use.
+const definitionsPrefix = "#/definitions/"
+
+// Schema is a CIP-57 data schema. A schema with neither DataType, AnyOf nor
+// Ref is "any Plutus data", which is how compilers describe opaque Data.
+type Schema struct {
+	Title       string   `json:"title,omitempty"`
+	Description string   `json:"description,omitempty"`
+	Comment     string   `json:"$comment,omitempty"`
+	Ref         string   `json:"$ref,omitempty"`
+	DataType    DataType `json:"dataType,omitempty"`
+	// AnyOf lists the alternatives of a sum type, usually constructors.
+	AnyOf []*Schema `json:"anyOf,omitempty"`
+	// Index and Fields describe a constructor.
+	Index  int       `json:"index,omitempty"`
+	Fields []*Schema `json:"fields,omitempty"`
+	// Items is the element schema of a list. A list given as a tuple
+	// ("items" holding an array) sets TupleItems instead.
+	Items      *Schema   `json:"-"`
+	TupleItems []*Schema `json:"-"`
+	// Keys and Values describe a map.
+	Keys   *Schema `json:"keys,omitempty"`
+	Values *Schema `json:"values,omitempty"`
+	// Left and Right describe a builtin pair.
+	Left  *Schema `json:"left,omitempty"`
+	Right *Schema `json:"right,omitempty"`
+}
+
+// schemaJSON is Schema without its methods, so the custom codecs below can
+// reuse the default field handling.
+type schemaJSON Schema
+
+// UnmarshalJSON accepts "items" as either a single schema or a tuple.
+func (s *Schema) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		*schemaJSON
+		Items json.RawMessage `json:"items,omitempty"`
+	}
+	raw.schemaJSON = (*schemaJSON)(s)
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	items := bytes.TrimSpace(raw.Items)
+	switch {
+	case len(items) == 0:
+	case items[0] == '[':
+		if err := json.Unmarshal(items, &s.TupleItems); err != nil {
+			return fmt.Errorf("items: %w", err)
+		}
+	default:
+		s.Items = &Schema{}
+		if err := json.Unmarshal(items, s.Items); err != nil {
+			return fmt.Errorf("items: %w", err)
+		}
+	}
+	return nil
+}
+
+// MarshalJSON writes Items or TupleItems back under "items".
+func (s *Schema) MarshalJSON() ([]byte, error) {
+	var items any
+	if s.Items != nil {
+		items = s.Items
+	} else if s.TupleItems != nil {
+		items = s.TupleItems
+	}
+	return json.Marshal(struct {
+		*schemaJSON
+		Index *int `json:"index,omitempty"`
+		Items any  `json:"items,omitempty"`
+	}{
+		schemaJSON: (*schemaJSON)(s),
+		Index:      s.constructorIndex(),
+		Items:      items,
+	})
+}
+
+// constructorIndex keeps index 0 in the output for constructors, which
+// omitempty would otherwise drop.
+func (s *Schema) constructorIndex() *int {
+	if s.DataType != DataTypeConstructor {
+		return nil
+	}
+	index := s.Index
+	return &index
+}
+
+// IsOpaque reports whether the schema accepts any Plutus data.
+func (s *Schema) IsOpaque() bool {
+	return s.Ref == "" && s.DataType == "" && len(s.AnyOf) == 0
+}
+
+// Resolve follows $ref links until it reaches a schema that is not a bare
+// reference. Titles and descriptions on the referring schema are kept, as
+// compilers put field names there.
+func (b *Blueprint) Resolve(s *Schema) (*Schema, error) {
+	if s == nil {
+		return nil, errors.New("nil schema")
+	}
+	seen := make(map[string]struct{})
+	resolved := s
+	for resolved.Ref != "" {
+		if _, loop := seen[resolved.Ref]; loop {
+			return nil, fmt.Errorf("schema reference cycle at %s", resolved.Ref)
+		}
+		seen[resolved.Ref] = struct{}{}
+		target, err := b.Definition(resolved.Ref)
+		if err != nil {
+			return nil, err
+		}
+		resolved = target
+	}
+	if resolved == s || (s.Title == "" && s.Description == "") {
+		return resolved, nil
+	}
+	merged := *resolved
+	if s.Title != "" {
+		merged.Title = s.Title
+	}
+	if s.Description != "" {
+		merged.Description = s.Description
+	}
+	return &merged, nil
+}
+
+// Definition returns a named definition. The name may be given as a $ref
+// ("#/definitions/escrow~1Datum") or as the plain key ("escrow/Datum").
+func (b *Blueprint) Definition(name string) (*Schema, error) {
+	key := name
+	if rest, ok := strings.CutPrefix(name, definitionsPrefix); ok {
+		key = unescapePointer(rest)
+	} else if strings.HasPrefix(name, "#") {
+		return nil, fmt.Errorf("unsupported schema reference %q", name)
+	}
+	def, ok := b.Definitions[key]
+	if !ok || def == nil {
+		return nil, fmt.Errorf("unknown schema definition %q", key)
+	}
+	return def, nil
+}
+
+// DefinitionName returns the definitions key a $ref points to.
+func DefinitionName(ref string) (string, bool) {
+	rest, ok := strings.CutPrefix(ref, definitionsPrefix)
+	if !ok {
+		return "", false
+	}
+	return unescapePointer(rest), true
+}
+
+// unescapePointer decodes a JSON pointer token (RFC 6901).
+func unescapePointer(token string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+}
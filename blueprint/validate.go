@@ -0,0 +1,285 @@
+package blueprint
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/blinklabs-io/plutigo/data"
+
+	"github.com/Salvionied/apollo/v2/plutusencoder"
+)
+
+// ValidationError reports where Plutus data departs from its schema. Path
+// locates the offending value from the root, as in "fields[2].constructor".
+type ValidationError struct {
+	Path   string
+	Reason string
+}
+
+func (e *ValidationError) Error() string {
+	if e.Path == "" {
+		return e.Reason
+	}
+	return e.Path + " " + e.Reason
+}
+
+// Validate checks pd against s, resolving references in b's definitions.
+// Builtin UPLC schemas are rejected, as they never describe Plutus data.
+func (b *Blueprint) Validate(s *Schema, pd data.PlutusData) error {
+	return b.validate(s, pd, "")
+}
+
+// ValidateValue marshals v with plutusencoder.MarshalPlutus and validates
+// the result against s.
+func (b *Blueprint) ValidateValue(s *Schema, v any) error {
+	pd, err := plutusencoder.MarshalPlutus(v)
+	if err != nil {
+		return fmt.Errorf("marshal value: %w", err)
+	}
+	return b.Validate(s, pd)
+}
+
+func (b *Blueprint) validate(s *Schema, pd data.PlutusData, path string) error {
+	resolved, err := b.Resolve(s)
+	if err != nil {
+		return &ValidationError{Path: path, Reason: err.Error()}
+	}
+	if pd == nil {
+		return &ValidationError{Path: path, Reason: "is missing"}
+	}
+	switch resolved.DataType {
+	case "":
+		if resolved.IsOpaque() {
+			return nil
+		}
+		return b.validateAnyOf(resolved, pd, path)
+	case DataTypeInteger:
+		if _, ok := asInteger(pd); !ok {
+			return mismatch(path, "integer", pd)
+		}
+	case DataTypeBytes:
+		if _, ok := asBytes(pd); !ok {
+			return mismatch(path, "bytes", pd)
+		}
+	case DataTypeList:
+		items, ok := asList(pd)
+		if !ok {
+			return mismatch(path, "list", pd)
+		}
+		if resolved.TupleItems != nil {
+			if len(items) != len(resolved.TupleItems) {
+				return &ValidationError{
+					Path:   path,
+					Reason: fmt.Sprintf("expected %d items got %d", len(resolved.TupleItems), len(items)),
+				}
+			}
+			for i, item := range items {
+				if err := b.validate(resolved.TupleItems[i], item, join(path, "items", i)); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+		if resolved.Items == nil {
+			return nil
+		}
+		for i, item := range items {
+			if err := b.validate(resolved.Items, item, join(path, "items", i)); err != nil {
+				return err
+			}
+		}
+	case DataTypeMap:
+		pairs, ok := asMap(pd)
+		if !ok {
+			return mismatch(path, "map", pd)
+		}
+		for i, pair := range pairs {
+			if resolved.Keys != nil {
+				if err := b.validate(resolved.Keys, pair[0], join(path, "keys", i)); err != nil {
+					return err
+				}
+			}
+			if resolved.Values != nil {
+				if err := b.validate(resolved.Values, pair[1], join(path, "values", i)); err != nil {
+					return err
+				}
+			}
+		}
+	case DataTypeConstructor:
+		return b.validateConstructor(resolved, pd, path)
+	default:
+		return &ValidationError{
+			Path:   path,
+			Reason: fmt.Sprintf("schema type %q does not describe Plutus data", resolved.DataType),
+		}
+	}
+	return nil
+}
+
+// validateAnyOf picks the constructor alternative matching the data's tag.
+// Alternatives that are not constructors are tried in order.
+func (b *Blueprint) validateAnyOf(s *Schema, pd data.PlutusData, path string) error {
+	constr, isConstr := asConstr(pd)
+	var indexes []string
+	var errs []error
+	for _, alt := range s.AnyOf {
+		resolved, err := b.Resolve(alt)
+		if err != nil {
+			return &ValidationError{Path: path, Reason: err.Error()}
+		}
+		if resolved.DataType == DataTypeConstructor {
+			indexes = append(indexes, strconv.Itoa(resolved.Index))
+			if isConstr && uint(resolved.Index) == constr.Tag {
+				return b.validateConstructor(resolved, pd, path)
+			}
+			continue
+		}
+		err = b.validate(resolved, pd, path)
+		if err == nil {
+			return nil
+		}
+		errs = append(errs, err)
+	}
+	if len(errs) == 0 {
+		if !isConstr {
+			return mismatch(path, "constructor", pd)
+		}
+		expected := strings.Join(indexes, ", ")
+		if len(indexes) > 1 {
+			expected = "one of " + expected
+		}
+		return &ValidationError{
+			Path:   join(path, "constructor", -1),
+			Reason: fmt.Sprintf("expected %s got %d", expected, constr.Tag),
+		}
+	}
+	if len(errs) == 1 && len(indexes) == 0 {
+		return errs[0]
+	}
+	return &ValidationError{Path: path, Reason: "matches no alternative: " + errors.Join(errs...).Error()}
+}
+
+func (b *Blueprint) validateConstructor(s *Schema, pd data.PlutusData, path string) error {
+	constr, ok := asConstr(pd)
+	if !ok {
+		return mismatch(path, "constructor", pd)
+	}
+	if constr.Tag != uint(s.Index) {
+		return &ValidationError{
+			Path:   join(path, "constructor", -1),
+			Reason: fmt.Sprintf("expected %d got %d", s.Index, constr.Tag),
+		}
+	}
+	if len(constr.Fields) != len(s.Fields) {
+		return &ValidationError{
+			Path:   join(path, "fields", -1),
+			Reason: fmt.Sprintf("expected %d fields got %d", len(s.Fields), len(constr.Fields)),
+		}
+	}
+	for i, field := range s.Fields {
+		if err := b.validate(field, constr.Fields[i], join(path, "fields", i)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// join appends a path segment, indexed unless index is negative.
+func join(path, segment string, index int) string {
+	if index >= 0 {
+		segment = fmt.Sprintf("%s[%d]", segment, index)
+	}
+	if path == "" {
+		return segment
+	}
+	return path + "." + segment
+}
+
+func mismatch(path, expected string, pd data.PlutusData) error {
+	return &ValidationError{
+		Path:   path,
+		Reason: fmt.Sprintf("expected %s got %s", expected, kindName(pd)),
+	}
+}
+
+func kindName(pd data.PlutusData) string {
+	switch {
+	case isKind(pd, asConstr):
+		return "constructor"
+	case isKind(pd, asMap):
+		return "map"
+	case isKind(pd, asList):
+		return "list"
+	case isKind(pd, asInteger):
+		return "integer"
+	case isKind(pd, asBytes):
+		return "bytes"
+	default:
+		return fmt.Sprintf("%T", pd)
+	}
+}
+
+func isKind[T any](pd data.PlutusData, as func(data.PlutusData) (T, bool)) bool {
+	_, ok := as(pd)
+	return ok
+}
+
+// The as* helpers accept both the pointer and value forms plutigo uses.
+
+func asConstr(pd data.PlutusData) (*data.Constr, bool) {
+	switch v := pd.(type) {
+	case *data.Constr:
+		return v, v != nil
+	case data.Constr:
+		return &v, true
+	}
+	return nil, false
+}
+
+func asMap(pd data.PlutusData) ([][2]data.PlutusData, bool) {
+	switch v := pd.(type) {
+	case *data.Map:
+		if v != nil {
+			return v.Pairs, true
+		}
+	case data.Map:
+		return v.Pairs, true
+	}
+	return nil, false
+}
+
+func asList(pd data.PlutusData) ([]data.PlutusData, bool) {
+	switch v := pd.(type) {
+	case *data.List:
+		if v != nil {
+			return v.Items, true
+		}
+	case data.List:
+		return v.Items, true
+	}
+	return nil, false
+}
+
+func asInteger(pd data.PlutusData) (*data.Integer, bool) {
+	switch v := pd.(type) {
+	case *data.Integer:
+		return v, v != nil
+	case data.Integer:
+		return &v, true
+	}
+	return nil, false
+}
+
+func asBytes(pd data.PlutusData) ([]byte, bool) {
+	switch v := pd.(type) {
+	case *data.ByteString:
+		if v != nil {
+			return v.Inner, true
+		}
+	case data.ByteString:
+		return v.Inner, true
+	}
+	return nil, false
+}
@@ -0,0 +1,116 @@
+package blueprint
+
+import (
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/blinklabs-io/plutigo/data"
+
+	"github.com/Salvionied/apollo/v2/blueprint/internal/escrowgen"
+)
+
+func escrowDatum(limit data.PlutusData) data.PlutusData {
+	return data.NewConstr(0,
+		data.NewByteString([]byte{0xaa}),
+		data.NewInteger(big.NewInt(5)),
+		data.NewList(data.NewByteString([]byte{0xbb})),
+		limit,
+		data.NewMap([][2]data.PlutusData{{data.NewByteString([]byte{0xaa}), data.NewInteger(big.NewInt(1))}}),
+	)
+}
+
+func TestValidateReportsPath(t *testing.T) {
+	b := loadTestBlueprint(t)
+	spend, err := b.Validator("escrow.escrow.spend")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := spend.ValidateDatum(escrowDatum(data.NewConstr(1))); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		datum data.PlutusData
+		path  string
+		want  string
+	}{
+		{escrowDatum(data.NewConstr(2)), "fields[3].constructor", "expected one of 0, 1 got 2"},
+		{escrowDatum(data.NewConstr(0, data.NewByteString(nil))), "fields[3].fields[0]", "expected integer got bytes"},
+		{data.NewConstr(1), "constructor", "expected 0 got 1"},
+		{data.NewConstr(0, data.NewInteger(big.NewInt(1))), "fields", "expected 5 fields got 1"},
+	}
+	for _, tc := range cases {
+		err := spend.ValidateDatum(tc.datum)
+		var validationErr *ValidationError
+		if !errors.As(err, &validationErr) || validationErr.Path != tc.path || validationErr.Reason != tc.want {
+			t.Errorf("want %s %s, got %v", tc.path, tc.want, err)
+		}
+	}
+
+	list, err := b.Definition("List$ByteArray")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = b.Validate(list, data.NewList(data.NewByteString(nil), data.NewInteger(big.NewInt(1))))
+	if err == nil || err.Error() != "items[1] expected bytes got integer" {
+		t.Fatalf("unexpected list error: %v", err)
+	}
+}
+
+func TestValidateValueMarshalsGoTypes(t *testing.T) {
+	b := loadTestBlueprint(t)
+	spend, err := b.Validator("escrow.escrow.spend")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := b.ValidateValue(spend.Redeemer.Schema, escrowgen.ActionClaim{
+		Beneficiary: []byte{0x01},
+		Amount:      big.NewInt(3),
+	}); err != nil {
+		t.Fatal(err)
+	}
+	// A hand-written type with the wrong field count for Update.
+	type update struct {
+		_ struct{} `plutusType:"IndefList" plutusConstr:"1"`
+	}
+	err = b.ValidateValue(spend.Redeemer.Schema, update{})
+	if err == nil || !strings.HasSuffix(err.Error(), "fields expected 1 fields got 0") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestValidatorsCheckTheirPurposeHandler(t *testing.T) {
+	b := loadTestBlueprint(t)
+	spend, err := b.Validator("escrow.escrow.spend")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fallback, err := b.Validator("escrow.escrow.else")
+	if err != nil {
+		t.Fatal(err)
+	}
+	gift, err := b.Validator("gift.gift.mint")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if spend.Purpose() != "spend" || gift.Purpose() != "mint" {
+		t.Fatalf("purposes = %q, %q", spend.Purpose(), gift.Purpose())
+	}
+
+	notAnAction := data.NewConstr(9)
+	for _, v := range []*Validator{spend, fallback} {
+		if err := v.ValidateRedeemer(notAnAction); err == nil {
+			t.Errorf("%s: expected the spend handler to reject the redeemer", v.Title)
+		}
+		// escrow has no mint handler, so minting falls to "else", which
+		// accepts any data.
+		if err := v.ValidateMintRedeemer(notAnAction); err != nil {
+			t.Errorf("%s: mint redeemer checked against the spend schema: %v", v.Title, err)
+		}
+	}
+	if err := gift.ValidateRedeemer(notAnAction); err != nil {
+		t.Errorf("gift has no spend handler, got %v", err)
+	}
+}
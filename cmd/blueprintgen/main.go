@@ -0,0 +1,46 @@
+// Command blueprintgen generates plutusencoder-tagged Go types from the
+// definitions of a CIP-57 blueprint. It is meant to be run from a
+// go:generate directive:
+//
+//	//go:generate go run github.com/Salvionied/apollo/v2/cmd/blueprintgen -in plutus.json -out types_gen.go -package contracts
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/Salvionied/apollo/v2/blueprint"
+)
+
+func main() {
+	in := flag.String("in", "plutus.json", "blueprint to read")
+	out := flag.String("out", "", "file to write (default stdout)")
+	pkg := flag.String("package", os.Getenv("GOPACKAGE"), "package name of the generated file")
+	flag.Parse()
+
+	if err := run(*in, *out, *pkg); err != nil {
+		fmt.Fprintln(os.Stderr, "blueprintgen:", err)
+		os.Exit(1)
+	}
+}
+
+func run(in, out, pkg string) error {
+	b, err := blueprint.Load(in)
+	if err != nil {
+		return err
+	}
+	src, err := blueprint.Generate(b, blueprint.GenerateOptions{
+		Package: pkg,
+		Source:  filepath.Base(in),
+	})
+	if err != nil {
+		return err
+	}
+	if out == "" {
+		_, err = os.Stdout.Write(src)
+		return err
+	}
+	return os.WriteFile(out, src, 0o644)
+}
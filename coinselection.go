@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"iter"
 
 	"github.com/blinklabs-io/gouroboros/ledger/common"
 )
@@ -28,6 +29,56 @@ type CoinSelector interface {
 	) ([]common.Utxo, error)
 }
 
+// StreamingCoinSelector is an optional extension to CoinSelector for
+// selectors that can choose from a pool as it arrives, such as one streamed
+// from backend.UtxosSeq. SelectSeq stops reading utxos once the target is
+// covered, so the rest of a large address is never fetched, and returns the
+// first error the sequence yields.
+//
+// When the chain context is a backend.UtxoStreamer, Complete streams the
+// input addresses into SelectSeq instead of loading them, and looks for
+// collateral in the prefix it has read, reading a bounded number of UTxOs
+// further if that holds no candidate.
+type StreamingCoinSelector interface {
+	CoinSelector
+	SelectSeq(
+		ctx context.Context,
+		utxos iter.Seq2[common.Utxo, error],
+		target Value,
+	) ([]common.Utxo, error)
+}
+
+// SelectSeq chooses UTxOs from utxos to cover target. A
+// StreamingCoinSelector consumes the sequence lazily; any other selector is
+// given the whole pool once the sequence is drained. A nil selector uses the
+// default.
+func SelectSeq(
+	ctx context.Context,
+	selector CoinSelector,
+	utxos iter.Seq2[common.Utxo, error],
+	target Value,
+) ([]common.Utxo, error) {
+	if selector == nil {
+		selector = defaultCoinSelector
+	}
+	if streaming, ok := selector.(StreamingCoinSelector); ok {
+		return streaming.SelectSeq(ctx, utxos, target)
+	}
+	var available []common.Utxo
+	for utxo, err := range utxos {
+		if err != nil {
+			return nil, err
+		}
+		available = append(available, utxo)
+		if len(available)%selectionCancelStride == 0 {
+			if err := selectionInterrupted(ctx); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return selector.Select(ctx, available, target)
+}
+
 // selectionCancelStride is how many pool entries a selector may process
 // between context checks. Small enough that cancellation is observed
 // promptly even on a pool of hundreds of thousands of UTxOs, large enough
@@ -3,6 +3,7 @@ package apollo
 import (
 	"context"
 	"errors"
+	"iter"
 	"math/big"
 	"strings"
 	"sync"
@@ -11,6 +12,8 @@ import (
 
 	"github.com/blinklabs-io/gouroboros/cbor"
 	"github.com/blinklabs-io/gouroboros/ledger/common"
+
+	"github.com/Salvionied/apollo/v2/backend/fixed"
 )
 
 // makeSelectorUtxo builds a UTxO for selector tests with a deterministic ref.
@@ -185,10 +188,98 @@ func TestLargestFirstSelectorName(t *testing.T) {
 	}
 }
 
+func TestFirstFitSelectorConformance(t *testing.T) {
+	runSelectorConformance(t, func() CoinSelector { return &FirstFitSelector{} })
+}
+
+// TestFirstFitSelectorStopsReading verifies a streamed pool is read only
+// until the target is covered, and that UTxOs adding nothing the target
+// still lacks are passed over.
+func TestFirstFitSelectorStopsReading(t *testing.T) {
+	pool := []common.Utxo{
+		makeSelectorUtxo(t, 0x01, 0, 4_000_000, nil),
+		makeSelectorUtxo(t, 0x02, 0, 2_000_000, makeTestAssets(0xBB, "tokenB", 5)),
+		makeSelectorUtxo(t, 0x03, 0, 3_000_000, nil),
+		makeSelectorUtxo(t, 0x04, 0, 2_000_000, makeTestAssets(0xAA, "tokenA", 10)),
+		makeSelectorUtxo(t, 0x05, 0, 50_000_000, nil),
+	}
+	read := 0
+	seq := func(yield func(common.Utxo, error) bool) {
+		for _, utxo := range pool {
+			read++
+			if !yield(utxo, nil) {
+				return
+			}
+		}
+	}
+	target := NewValue(8_000_000, makeTestAssets(0xAA, "tokenA", 10))
+	selected, err := SelectSeq(t.Context(), &FirstFitSelector{}, seq, target)
+	if err != nil {
+		t.Fatalf("SelectSeq failed: %v", err)
+	}
+	if read != 4 {
+		t.Errorf("read %d UTxOs, want 4", read)
+	}
+	var refs []string
+	for _, utxo := range selected {
+		refs = append(refs, utxoRef(utxo))
+	}
+	want := []string{utxoRef(pool[0]), utxoRef(pool[1]), utxoRef(pool[2]), utxoRef(pool[3])}
+	if strings.Join(refs, ",") != strings.Join(want, ",") {
+		t.Errorf("selected %v, want %v", refs, want)
+	}
+
+	// Once the lovelace is covered only the missing asset is worth taking.
+	target = NewValue(1_000_000, makeTestAssets(0xAA, "tokenA", 10))
+	selected, err = SelectSeq(t.Context(), &FirstFitSelector{}, seq, target)
+	if err != nil {
+		t.Fatalf("SelectSeq failed: %v", err)
+	}
+	if len(selected) != 2 || utxoRef(selected[1]) != utxoRef(pool[3]) {
+		t.Errorf("selected %d UTxOs, want the first and the tokenA holder", len(selected))
+	}
+}
+
+// TestSelectSeqDrainsForOtherSelectors verifies a selector without streaming
+// support sees the whole pool, and that a failing sequence fails selection.
+func TestSelectSeqDrainsForOtherSelectors(t *testing.T) {
+	pool := []common.Utxo{
+		makeSelectorUtxo(t, 0x01, 0, 1_000_000, nil),
+		makeSelectorUtxo(t, 0x02, 0, 9_000_000, nil),
+	}
+	seq := func(yield func(common.Utxo, error) bool) {
+		for _, utxo := range pool {
+			if !yield(utxo, nil) {
+				return
+			}
+		}
+	}
+	selected, err := SelectSeq(t.Context(), &LargestFirstSelector{}, seq, NewSimpleValue(5_000_000))
+	if err != nil {
+		t.Fatalf("SelectSeq failed: %v", err)
+	}
+	if len(selected) != 1 || utxoRef(selected[0]) != utxoRef(pool[1]) {
+		t.Errorf("largest-first did not see the whole pool")
+	}
+
+	pageErr := errors.New("page 2 failed")
+	failing := func(yield func(common.Utxo, error) bool) {
+		if yield(pool[0], nil) {
+			yield(common.Utxo{}, pageErr)
+		}
+	}
+	for _, selector := range []CoinSelector{&LargestFirstSelector{}, &FirstFitSelector{}} {
+		if _, err := SelectSeq(t.Context(), selector, failing, NewSimpleValue(5_000_000)); !errors.Is(err, pageErr) {
+			t.Errorf("%s: SelectSeq() error = %v, want %v", selector.Name(), err, pageErr)
+		}
+	}
+}
+
 func TestCoinSelectorsRejectMalformedUTxOs(t *testing.T) {
 	selectors := []CoinSelector{
 		&LargestFirstSelector{},
 		NewMACSSelector(),
+		&FirstFitSelector{},
 	}
 	for _, selector := range selectors {
 		t.Run(selector.Name(), func(t *testing.T) {
@@ -273,6 +364,74 @@ func TestSetCoinSelectorUsedByComplete(t *testing.T) {
 	}
 }
 
+// limitedStream serves its pool as a stream that fails if it is read past
+// limit UTxOs, and refuses to load the address in full.
+type limitedStream struct {
+	*fixed.FixedChainContext
+	pool  []common.Utxo
+	limit int
+}
+
+func (s *limitedStream) Utxos(common.Address) ([]common.Utxo, error) {
+	return nil, errors.New("address loaded in full")
+}
+
+func (s *limitedStream) UtxosSeq(
+	context.Context,
+	common.Address,
+) iter.Seq2[common.Utxo, error] {
+	return func(yield func(common.Utxo, error) bool) {
+		for i, utxo := range s.pool {
+			if i == s.limit {
+				yield(common.Utxo{}, errors.New("stream read past the target"))
+				return
+			}
+			if !yield(utxo, nil) {
+				return
+			}
+		}
+	}
+}
+
+// TestCompleteStreamsInputAddresses verifies Complete selects from a stream
+// with a StreamingCoinSelector, reading no further than the target needs.
+func TestCompleteStreamsInputAddresses(t *testing.T) {
+	addr := testAddress(t)
+	cc := &limitedStream{FixedChainContext: setupFixedContext(), limit: 2}
+	for i := range 5 {
+		utxo := makeSelectorUtxo(t, byte(i+1), 0, 3_000_000, nil)
+		cc.pool = append(cc.pool, utxo)
+	}
+	p, err := NewPayment(validTestAddrBech32, 4_000_000, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a, err := New(cc).
+		SetWallet(NewExternalWallet(addr)).
+		AddInputAddress(addr).
+		AddPayment(p).
+		SetTtl(50000000).
+		SetCoinSelector(&FirstFitSelector{}).
+		Complete()
+	if err != nil {
+		t.Fatalf("Complete failed: %v", err)
+	}
+	inputs := a.GetTx().Body.TxInputs.Items()
+	if len(inputs) != 2 {
+		t.Fatalf("spent %d inputs, want the 2 streamed ones", len(inputs))
+	}
+
+	// Without a streaming selector the address is loaded in full.
+	if _, err := New(cc).
+		SetWallet(NewExternalWallet(addr)).
+		AddInputAddress(addr).
+		AddPayment(p).
+		SetCoinSelector(&LargestFirstSelector{}).
+		Complete(); err == nil || !strings.Contains(err.Error(), "loaded in full") {
+		t.Fatalf("Complete() error = %v, want a full load", err)
+	}
+}
+
 // contextSelectors returns one instance of every in-tree selector, for the
 // context tests below.
 func contextSelectors() []CoinSelector {
@@ -280,6 +439,7 @@ func contextSelectors() []CoinSelector {
 		&LargestFirstSelector{},
 		&MACSSelector{},
 		NewMACSSelector(),
+		&FirstFitSelector{},
 	}
 }
 
@@ -0,0 +1,128 @@
+package apollo
+
+import (
+	"fmt"
+
+	"github.com/blinklabs-io/gouroboros/ledger/common"
+	"github.com/blinklabs-io/plutigo/data"
+)
+
+// DataSchema checks the datums and redeemers of one script before they reach
+// the chain, where a wrong constructor index or field count would only show
+// up as a failed validation. blueprint.Validator implements it.
+type DataSchema interface {
+	// ScriptHash identifies the script the schema belongs to.
+	ScriptHash() common.ScriptHash
+	// ValidateDatum checks a datum locked at the script.
+	ValidateDatum(pd data.PlutusData) error
+	// ValidateRedeemer checks a redeemer spending from the script.
+	ValidateRedeemer(pd data.PlutusData) error
+}
+
+// MintRedeemerSchema is an optional extension to DataSchema for scripts that
+// are also minting policies, whose mint redeemers follow a different schema
+// from their spend redeemers. Complete checks redeemers minting under the
+// script only against schemas that implement it.
+type MintRedeemerSchema interface {
+	ValidateMintRedeemer(pd data.PlutusData) error
+}
+
+// AddDataSchema registers a schema that Complete checks against every datum
+// paid to the script, inline or by hash, every redeemer spending one of its
+// outputs through CollectFrom and, through MintRedeemerSchema, every redeemer
+// minting under it. A payment that carries only a datum hash, without the
+// datum, cannot be checked.
+func (a *Apollo) AddDataSchema(schema DataSchema) *Apollo {
+	if schema != nil {
+		a.dataSchemas = append(a.dataSchemas, schema)
+	}
+	return a
+}
+
+// validateDataSchemas checks datums and redeemers against the registered
+// schemas.
+func (a *Apollo) validateDataSchemas() error {
+	if len(a.dataSchemas) == 0 {
+		return nil
+	}
+	schemas := make(map[common.ScriptHash][]DataSchema, len(a.dataSchemas))
+	for _, schema := range a.dataSchemas {
+		hash := schema.ScriptHash()
+		schemas[hash] = append(schemas[hash], schema)
+	}
+
+	// Datums paid by hash travel in the witness set.
+	hashedDatums := make(map[common.Blake2b256]*common.Datum, len(a.datums))
+	for i := range a.datums {
+		if hash, err := DatumHash(&a.datums[i]); err == nil {
+			hashedDatums[hash] = &a.datums[i]
+		}
+	}
+	for i, p := range a.payments {
+		payment, ok := p.(*Payment)
+		if !ok {
+			continue
+		}
+		datum := payment.Datum
+		if datum == nil && len(payment.DatumHash) == common.Blake2b256Size {
+			datum = hashedDatums[common.NewBlake2b256(payment.DatumHash)]
+		}
+		if datum == nil {
+			continue
+		}
+		hash, ok := scriptPaymentHash(payment.Receiver)
+		if !ok {
+			continue
+		}
+		for _, schema := range schemas[hash] {
+			if err := schema.ValidateDatum(datum.Data); err != nil {
+				return fmt.Errorf("payment %d to script %s: %w", i, hash.String(), err)
+			}
+		}
+	}
+
+	for _, utxo := range a.preselectedUtxos {
+		entry, ok := a.redeemers[utxoRef(utxo)]
+		if !ok || utxo.Output == nil {
+			continue
+		}
+		hash, ok := scriptPaymentHash(utxo.Output.Address())
+		if !ok {
+			continue
+		}
+		for _, schema := range schemas[hash] {
+			if err := schema.ValidateRedeemer(entry.Data.Data); err != nil {
+				return fmt.Errorf("spending %s from script %s: %w", utxoRef(utxo), hash.String(), err)
+			}
+		}
+	}
+
+	for _, schema := range a.dataSchemas {
+		mintSchema, ok := schema.(MintRedeemerSchema)
+		if !ok {
+			continue
+		}
+		policy := schema.ScriptHash().String()
+		entry, ok := a.mintRedeemers[policy]
+		if !ok {
+			continue
+		}
+		if err := mintSchema.ValidateMintRedeemer(entry.Data.Data); err != nil {
+			return fmt.Errorf("minting under policy %s: %w", policy, err)
+		}
+	}
+	return nil
+}
+
+// scriptPaymentHash returns the script hash of addr's payment credential.
+func scriptPaymentHash(addr common.Address) (common.ScriptHash, bool) {
+	switch addr.Type() {
+	case common.AddressTypeScriptKey,
+		common.AddressTypeScriptScript,
+		common.AddressTypeScriptPointer,
+		common.AddressTypeScriptNone:
+		return addr.PaymentKeyHash(), true
+	default:
+		return common.ScriptHash{}, false
+	}
+}
@@ -0,0 +1,142 @@
+package apollo
+
+import (
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/blinklabs-io/gouroboros/ledger/babbage"
+	"github.com/blinklabs-io/gouroboros/ledger/common"
+	"github.com/blinklabs-io/gouroboros/ledger/mary"
+	"github.com/blinklabs-io/gouroboros/ledger/shelley"
+	"github.com/blinklabs-io/plutigo/data"
+)
+
+// constrZeroSchema accepts only constructor 0 datums and redeemers.
+type constrZeroSchema struct {
+	hash  common.ScriptHash
+	calls int
+}
+
+func (s *constrZeroSchema) ScriptHash() common.ScriptHash { return s.hash }
+
+func (s *constrZeroSchema) ValidateDatum(pd data.PlutusData) error {
+	return s.check("datum", pd)
+}
+
+func (s *constrZeroSchema) ValidateRedeemer(pd data.PlutusData) error {
+	return s.check("redeemer", pd)
+}
+
+func (s *constrZeroSchema) check(role string, pd data.PlutusData) error {
+	s.calls++
+	if constr, ok := pd.(*data.Constr); !ok || constr.Tag != 0 {
+		return errors.New(role + " constructor expected 0")
+	}
+	return nil
+}
+
+func TestCompleteValidatesInlineDatumsAgainstSchema(t *testing.T) {
+	script := syntheticAddress(t, common.AddressTypeScriptNone, common.AddressNetworkMainnet)
+	schema := &constrZeroSchema{hash: script.PaymentKeyHash()}
+
+	good := common.Datum{Data: data.NewConstr(0, data.NewInteger(big.NewInt(1)))}
+	_, err := networkTestBuilder(t, common.AddressNetworkMainnet, common.AddressNetworkMainnet).
+		AddDataSchema(schema).
+		PayToContract(script, &good, 2_000_000).
+		Complete()
+	if err != nil {
+		t.Fatalf("Complete failed: %v", err)
+	}
+	if schema.calls != 1 {
+		t.Fatalf("schema checked %d times, want 1", schema.calls)
+	}
+
+	bad := common.Datum{Data: data.NewConstr(1)}
+	_, err = networkTestBuilder(t, common.AddressNetworkMainnet, common.AddressNetworkMainnet).
+		AddDataSchema(schema).
+		PayToContract(script, &bad, 2_000_000).
+		Complete()
+	if err == nil || !strings.Contains(err.Error(), "payment 0 to script") ||
+		!strings.Contains(err.Error(), "datum constructor expected 0") {
+		t.Fatalf("expected the datum to be rejected, got %v", err)
+	}
+}
+
+func TestCompleteValidatesSpendRedeemersAgainstSchema(t *testing.T) {
+	script := syntheticAddress(t, common.AddressTypeScriptNone, common.AddressNetworkMainnet)
+	schema := &constrZeroSchema{hash: script.PaymentKeyHash()}
+	utxo := common.Utxo{
+		Id: shelley.ShelleyTransactionInput{TxId: common.Blake2b256{0x02}},
+		Output: &babbage.BabbageTransactionOutput{
+			OutputAddress: script,
+			OutputAmount:  mary.MaryTransactionOutputValue{Amount: 5_000_000},
+		},
+	}
+
+	_, err := networkTestBuilder(t, common.AddressNetworkMainnet, common.AddressNetworkMainnet).
+		AddDataSchema(schema).
+		CollectFrom(utxo, common.Datum{Data: data.NewConstr(2)}, common.ExUnits{}).
+		Complete()
+	if err == nil || !strings.Contains(err.Error(), "spending") ||
+		!strings.Contains(err.Error(), "redeemer constructor expected 0") {
+		t.Fatalf("expected the redeemer to be rejected, got %v", err)
+	}
+}
+
+// mintOnlySchema accepts any spend data but only constructor 0 mint
+// redeemers.
+type mintOnlySchema struct {
+	constrZeroSchema
+}
+
+func (s *mintOnlySchema) ValidateDatum(data.PlutusData) error    { return nil }
+func (s *mintOnlySchema) ValidateRedeemer(data.PlutusData) error { return nil }
+
+func (s *mintOnlySchema) ValidateMintRedeemer(pd data.PlutusData) error {
+	return s.check("mint redeemer", pd)
+}
+
+func TestCompleteValidatesMintRedeemersByPurpose(t *testing.T) {
+	script := syntheticAddress(t, common.AddressTypeScriptNone, common.AddressNetworkMainnet)
+	policy := script.PaymentKeyHash()
+	unit := NewUnit(policy.String(), "746f6b656e", 1)
+	redeemer := common.Datum{Data: data.NewConstr(3)}
+
+	// A schema without mint handling is not consulted for minting.
+	spendOnly := &constrZeroSchema{hash: policy}
+	_, err := networkTestBuilder(t, common.AddressNetworkMainnet, common.AddressNetworkMainnet).
+		AddDataSchema(spendOnly).
+		Mint(unit, &redeemer, &common.ExUnits{}).
+		Complete()
+	if spendOnly.calls != 0 || (err != nil && strings.Contains(err.Error(), "minting under policy")) {
+		t.Fatalf("spend schema checked a mint redeemer: %v", err)
+	}
+
+	mint := &mintOnlySchema{constrZeroSchema{hash: policy}}
+	_, err = networkTestBuilder(t, common.AddressNetworkMainnet, common.AddressNetworkMainnet).
+		AddDataSchema(mint).
+		Mint(unit, &redeemer, &common.ExUnits{}).
+		Complete()
+	if err == nil || !strings.Contains(err.Error(), "minting under policy") ||
+		!strings.Contains(err.Error(), "mint redeemer constructor expected 0") {
+		t.Fatalf("expected the mint redeemer to be rejected, got %v", err)
+	}
+}
+
+func TestCompleteValidatesHashedDatumsAgainstSchema(t *testing.T) {
+	script := syntheticAddress(t, common.AddressTypeScriptNone, common.AddressNetworkMainnet)
+	schema := &constrZeroSchema{hash: script.PaymentKeyHash()}
+	bad := common.Datum{Data: data.NewConstr(1)}
+	a, err := networkTestBuilder(t, common.AddressNetworkMainnet, common.AddressNetworkMainnet).
+		AddDataSchema(schema).
+		PayToContractWithDatumHash(script, &bad, 2_000_000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = a.Complete()
+	if err == nil || !strings.Contains(err.Error(), "datum constructor expected 0") {
+		t.Fatalf("expected the hashed datum to be rejected, got %v", err)
+	}
+}
@@ -109,12 +109,12 @@ func (a *Apollo) evaluationWitnesses(body *conway.ConwayTransactionBody) ([]comm
 			}
 		}
 
-		if provider, ok := a.wallet.(*BursaWallet); ok {
+		if provider, ok := a.wallet.(EvaluationWitnessProvider); ok {
 			missing := missingSignerHashes(required, found)
 			if len(missing) > 0 {
 				witnesses, err := provider.EvaluationWitnesses(bodyHash, missing)
 				if err != nil {
-					return nil, fmt.Errorf("get evaluation witnesses from Bursa wallet: %w", err)
+					return nil, fmt.Errorf("get evaluation witnesses from wallet: %w", err)
 				}
 				if err := addWitnesses(witnesses); err != nil {
 					return nil, err
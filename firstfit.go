@@ -0,0 +1,140 @@
+package apollo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"iter"
+
+	"github.com/blinklabs-io/gouroboros/ledger/common"
+)
+
+// FirstFitSelector takes UTxOs in order, keeping each one that reduces what
+// is still missing, until the target is covered. It spends more inputs than
+// MACS or largest-first, but over a streamed pool it stops reading as soon as
+// the target is met, so it suits addresses too large to load whole.
+//
+// Select walks the pool in canonical input order, so a pool yields the same
+// selection however it was ordered; SelectSeq keeps the order the sequence
+// yields.
+type FirstFitSelector struct{}
+
+var _ StreamingCoinSelector = (*FirstFitSelector)(nil)
+
+// Name returns the algorithm's identifier.
+func (s *FirstFitSelector) Name() string { return "first-fit" }
+
+// Select returns a subset of available whose summed value covers target.
+func (s *FirstFitSelector) Select(
+	ctx context.Context,
+	available []common.Utxo,
+	target Value,
+) ([]common.Utxo, error) {
+	if target.Coin == 0 && !target.HasAssets() {
+		return nil, nil
+	}
+	if err := selectionInterrupted(ctx); err != nil {
+		return nil, err
+	}
+	if err := validateUtxos(available); err != nil {
+		return nil, fmt.Errorf("invalid coin-selection input: %w", err)
+	}
+	return s.SelectSeq(ctx, seqOf(SortInputs(available)), target)
+}
+
+// SelectSeq returns UTxOs from utxos whose summed value covers target,
+// reading no further than it needs to.
+func (s *FirstFitSelector) SelectSeq(
+	ctx context.Context,
+	utxos iter.Seq2[common.Utxo, error],
+	target Value,
+) ([]common.Utxo, error) {
+	remaining := target.Clone()
+	if remaining.Coin == 0 && !remaining.HasAssets() {
+		return nil, nil
+	}
+	if err := selectionInterrupted(ctx); err != nil {
+		return nil, err
+	}
+	var selected []common.Utxo
+	seen := make(map[string]struct{})
+	i := 0
+	for utxo, err := range utxos {
+		if err != nil {
+			return nil, err
+		}
+		if i%selectionCancelStride == 0 {
+			if err := selectionInterrupted(ctx); err != nil {
+				return nil, err
+			}
+		}
+		i++
+		if err := validateUtxo(utxo); err != nil {
+			return nil, fmt.Errorf("invalid coin-selection input: %w", err)
+		}
+		amt := utxo.Output.Amount()
+		// Amounts come from a remote backend; reject anything outside the
+		// uint64 lovelace range (big.Int.Uint64 is undefined out of range).
+		if amt == nil || !amt.IsUint64() {
+			return nil, fmt.Errorf(
+				"UTxO %s has an invalid lovelace amount",
+				utxoRef(utxo),
+			)
+		}
+		ref := utxoRef(utxo)
+		if _, ok := seen[ref]; ok {
+			continue
+		}
+		seen[ref] = struct{}{}
+		if !reducesRemaining(remaining, utxo) {
+			continue
+		}
+
+		selected = append(selected, utxo)
+		if remaining.Coin <= amt.Uint64() {
+			remaining.Coin = 0
+		} else {
+			remaining.Coin -= amt.Uint64()
+		}
+		if remaining.Assets != nil && utxo.Output.Assets() != nil {
+			subtractAssetsSaturating(remaining.Assets, utxo.Output.Assets())
+		}
+
+		if remaining.Coin == 0 && !remaining.HasAssets() {
+			return selected, nil
+		}
+	}
+	return nil, errors.New("insufficient UTxOs to cover required value")
+}
+
+// reducesRemaining reports whether utxo contributes lovelace or an asset that
+// remaining still lacks.
+func reducesRemaining(remaining Value, utxo common.Utxo) bool {
+	if remaining.Coin > 0 && utxo.Output.Amount().Sign() > 0 {
+		return true
+	}
+	assets := utxo.Output.Assets()
+	if assets == nil || remaining.Assets == nil {
+		return false
+	}
+	for _, policyId := range assets.Policies() {
+		for _, assetName := range assets.Assets(policyId) {
+			need := remaining.Assets.Asset(policyId, assetName)
+			if need != nil && need.Sign() > 0 && assets.Asset(policyId, assetName).Sign() > 0 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// seqOf yields utxos in order.
+func seqOf(utxos []common.Utxo) iter.Seq2[common.Utxo, error] {
+	return func(yield func(common.Utxo, error) bool) {
+		for _, utxo := range utxos {
+			if !yield(utxo, nil) {
+				return
+			}
+		}
+	}
+}
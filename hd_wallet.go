@@ -0,0 +1,348 @@
+package apollo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/blinklabs-io/bursa"
+	"github.com/blinklabs-io/bursa/bip32"
+	"github.com/blinklabs-io/gouroboros/ledger/common"
+
+	"github.com/Salvionied/apollo/v2/backend"
+)
+
+// DefaultGapLimit is the number of consecutive unused addresses HDWallet
+// derives on each chain before it stops scanning, as recommended by BIP-44.
+const DefaultGapLimit = 20
+
+// CIP-1852 derivation roles below the account key.
+const (
+	hdRoleExternal uint32 = 0
+	hdRoleInternal uint32 = 1
+)
+
+// HDWalletConfig configures NewHDWallet.
+type HDWalletConfig struct {
+	// NetworkId is the address network id: 1 for mainnet, 0 for testnets.
+	NetworkId uint8
+	// AccountID is the hardened CIP-1852 account index.
+	AccountID uint32
+	// Passphrase is the optional BIP-39 passphrase.
+	Passphrase string
+	// GapLimit is the number of consecutive unused addresses scanned past the
+	// last used one on each chain. Zero selects DefaultGapLimit.
+	GapLimit int
+}
+
+// HDWallet is a CIP-1852 multi-address wallet. It derives base addresses on
+// the external (receive) and internal (change) chains of one account, all
+// sharing the account's first stake key, and signs with whichever derived
+// payment keys own the inputs a transaction spends.
+//
+// Addresses are only known to the wallet once derived, so call Discover
+// before Complete to find funds beyond the first receive address.
+type HDWallet struct {
+	networkId  uint8
+	gapLimit   int
+	accountKey bip32.XPrv
+	stakeKey   bip32.XPrv
+
+	mu sync.Mutex
+	// chains holds the derived keys per role, indexed by address index.
+	chains map[uint32][]hdKey
+	// used marks address indexes that held UTxOs when last scanned.
+	used map[uint32]map[uint32]bool
+	// issued marks address indexes handed out by NextReceiveAddress or
+	// NextChangeAddress.
+	issued map[uint32]map[uint32]bool
+	// next is the lowest address index per role not yet handed out by
+	// NextReceiveAddress or NextChangeAddress.
+	next map[uint32]uint32
+}
+
+type hdKey struct {
+	key     bip32.XPrv
+	hash    common.Blake2b224
+	address common.Address
+}
+
+var (
+	_ Wallet                    = (*HDWallet)(nil)
+	_ InputAddressProvider      = (*HDWallet)(nil)
+	_ MultiKeySigner            = (*HDWallet)(nil)
+	_ EvaluationWitnessProvider = (*HDWallet)(nil)
+)
+
+// NewHDWallet derives an HD wallet from a mnemonic. Only the first receive
+// address is derived up front; Discover scans for the rest.
+func NewHDWallet(mnemonic string, cfg HDWalletConfig) (*HDWallet, error) {
+	if cfg.NetworkId != common.AddressNetworkTestnet && cfg.NetworkId != common.AddressNetworkMainnet {
+		return nil, fmt.Errorf("invalid network id %d: expected 0 or 1", cfg.NetworkId)
+	}
+	if cfg.GapLimit < 0 {
+		return nil, fmt.Errorf("gap limit must not be negative, got %d", cfg.GapLimit)
+	}
+	if cfg.GapLimit == 0 {
+		cfg.GapLimit = DefaultGapLimit
+	}
+	rootKey, err := bursa.GetRootKeyFromMnemonic(mnemonic, cfg.Passphrase)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive root key: %w", err)
+	}
+	accountKey, err := bursa.GetAccountKey(rootKey, cfg.AccountID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive account key: %w", err)
+	}
+	stakeKey, err := bursa.GetStakeKey(accountKey, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive stake key: %w", err)
+	}
+	w := &HDWallet{
+		networkId:  cfg.NetworkId,
+		gapLimit:   cfg.GapLimit,
+		accountKey: accountKey,
+		stakeKey:   stakeKey,
+		chains:     map[uint32][]hdKey{hdRoleExternal: nil, hdRoleInternal: nil},
+		used:       map[uint32]map[uint32]bool{hdRoleExternal: {}, hdRoleInternal: {}},
+		issued:     map[uint32]map[uint32]bool{hdRoleExternal: {}, hdRoleInternal: {}},
+		next:       map[uint32]uint32{hdRoleExternal: 0, hdRoleInternal: 0},
+	}
+	if _, err := w.deriveLocked(hdRoleExternal, 0); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// deriveLocked returns the key at role/index, deriving every missing index
+// up to it. The caller must hold w.mu, except during construction.
+func (w *HDWallet) deriveLocked(role, index uint32) (hdKey, error) {
+	for uint32(len(w.chains[role])) <= index {
+		next := uint32(len(w.chains[role])) //nolint:gosec // bounded by index
+		var key bip32.XPrv
+		var err error
+		if role == hdRoleExternal {
+			key, err = bursa.GetPaymentKey(w.accountKey, next)
+		} else {
+			key = w.accountKey.Derive(role).Derive(next)
+		}
+		if err != nil {
+			return hdKey{}, fmt.Errorf("failed to derive key %d/%d: %w", role, next, err)
+		}
+		hash := common.Blake2b224Hash(key.Public().PublicKey())
+		addr, err := common.NewAddressFromParts(
+			common.AddressTypeKeyKey,
+			w.networkId,
+			hash.Bytes(),
+			common.Blake2b224Hash(w.stakeKey.Public().PublicKey()).Bytes(),
+		)
+		if err != nil {
+			return hdKey{}, fmt.Errorf("failed to build address %d/%d: %w", role, next, err)
+		}
+		w.chains[role] = append(w.chains[role], hdKey{key: key, hash: hash, address: addr})
+	}
+	return w.chains[role][index], nil
+}
+
+// Discover scans both chains against cc, deriving addresses until GapLimit
+// consecutive addresses on a chain hold no UTxOs. ChainContext reports only
+// current UTxOs, not history, so an address that was used and then emptied
+// counts as unused; raise GapLimit for wallets with long emptied stretches.
+func (w *HDWallet) Discover(ctx context.Context, cc backend.ChainContext) error {
+	if cc == nil {
+		return errors.New("chain context must not be nil")
+	}
+	// Scan without holding w.mu, so signing is not blocked on the network,
+	// and merge what was found once every chain has been scanned.
+	found := map[uint32]map[uint32]bool{hdRoleExternal: {}, hdRoleInternal: {}}
+	for _, role := range []uint32{hdRoleExternal, hdRoleInternal} {
+		gap := 0
+		for index := uint32(0); gap < w.gapLimit; index++ {
+			key, err := w.derive(role, index)
+			if err != nil {
+				return err
+			}
+			utxos, err := backend.UtxosContext(ctx, cc, key.address)
+			if err != nil {
+				return fmt.Errorf("failed to scan %s: %w", key.address.String(), err)
+			}
+			found[role][index] = len(utxos) > 0
+			if len(utxos) == 0 {
+				gap++
+			} else {
+				gap = 0
+			}
+		}
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for role, indexes := range found {
+		for index, used := range indexes {
+			if !used {
+				delete(w.used[role], index)
+				continue
+			}
+			w.used[role][index] = true
+			if w.next[role] <= index {
+				w.next[role] = index + 1
+			}
+		}
+	}
+	return nil
+}
+
+// derive returns the key at role/index, deriving it if needed.
+func (w *HDWallet) derive(role, index uint32) (hdKey, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.deriveLocked(role, index)
+}
+
+// NextReceiveAddress returns a fresh external address and advances past it.
+func (w *HDWallet) NextReceiveAddress() (common.Address, error) {
+	return w.nextAddress(hdRoleExternal)
+}
+
+// NextChangeAddress returns a fresh internal address and advances past it.
+// Pass it to Apollo.SetChangeAddress to keep change off the receive chain.
+func (w *HDWallet) NextChangeAddress() (common.Address, error) {
+	return w.nextAddress(hdRoleInternal)
+}
+
+// nextAddress hands out the next address on role. It refuses to hand out
+// more than GapLimit unused addresses past the last used one, since a wallet
+// restored from the mnemonic would stop scanning before reaching them.
+func (w *HDWallet) nextAddress(role uint32) (common.Address, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	index := w.next[role]
+	lastUsed := -1
+	for used := range w.used[role] {
+		if int(used) > lastUsed {
+			lastUsed = int(used)
+		}
+	}
+	if int(index)-lastUsed > w.gapLimit {
+		return common.Address{}, fmt.Errorf("gap limit of %d unused addresses reached", w.gapLimit)
+	}
+	key, err := w.deriveLocked(role, index)
+	if err != nil {
+		return common.Address{}, err
+	}
+	w.next[role] = index + 1
+	w.issued[role][index] = true
+	return key.address, nil
+}
+
+// InputAddresses returns the first receive address, every address found
+// holding UTxOs by Discover and every address handed out by
+// NextReceiveAddress or NextChangeAddress, external chain first. Handed-out
+// addresses are included so change sent to one is spendable before the next
+// Discover.
+func (w *HDWallet) InputAddresses() []common.Address {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	addresses := []common.Address{w.chains[hdRoleExternal][0].address}
+	for _, role := range []uint32{hdRoleExternal, hdRoleInternal} {
+		for index, key := range w.chains[role] {
+			if role == hdRoleExternal && index == 0 {
+				continue
+			}
+			i := uint32(index) //nolint:gosec // bounded by derived indexes
+			if w.used[role][i] || w.issued[role][i] {
+				addresses = append(addresses, key.address)
+			}
+		}
+	}
+	return addresses
+}
+
+// Address returns the first receive address.
+func (w *HDWallet) Address() common.Address {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.chains[hdRoleExternal][0].address
+}
+
+// SignTxBody signs with the payment key of the first receive address.
+func (w *HDWallet) SignTxBody(txBodyHash common.Blake2b256) (common.VkeyWitness, error) {
+	w.mu.Lock()
+	key := w.chains[hdRoleExternal][0].key
+	w.mu.Unlock()
+	return common.VkeyWitness{
+		Vkey:      key.Public().PublicKey(),
+		Signature: key.Sign(txBodyHash.Bytes()),
+	}, nil
+}
+
+// SignTxBodyFor signs with every derived payment key, and the stake key,
+// whose hash is listed. Hashes the wallet has not derived are skipped.
+func (w *HDWallet) SignTxBodyFor(
+	txBodyHash common.Blake2b256,
+	keyHashes []common.Blake2b224,
+) ([]common.VkeyWitness, error) {
+	keys := w.keysByHash()
+	witnesses := make([]common.VkeyWitness, 0, len(keyHashes))
+	seen := make(map[common.Blake2b224]struct{}, len(keyHashes))
+	for _, hash := range keyHashes {
+		key, ok := keys[hash]
+		if !ok {
+			continue
+		}
+		if _, dup := seen[hash]; dup {
+			continue
+		}
+		seen[hash] = struct{}{}
+		witnesses = append(witnesses, common.VkeyWitness{
+			Vkey:      key.Public().PublicKey(),
+			Signature: key.Sign(txBodyHash.Bytes()),
+		})
+	}
+	return witnesses, nil
+}
+
+// EvaluationWitnesses provides witnesses from any derived key required by a
+// preliminary transaction evaluation.
+func (w *HDWallet) EvaluationWitnesses(
+	txBodyHash common.Blake2b256,
+	requiredSigners []common.Blake2b224,
+) ([]common.VkeyWitness, error) {
+	return w.SignTxBodyFor(txBodyHash, requiredSigners)
+}
+
+func (w *HDWallet) keysByHash() map[common.Blake2b224]bip32.XPrv {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	keys := make(map[common.Blake2b224]bip32.XPrv, len(w.chains[hdRoleExternal])+len(w.chains[hdRoleInternal])+1)
+	for _, role := range []uint32{hdRoleExternal, hdRoleInternal} {
+		for _, key := range w.chains[role] {
+			keys[key.hash] = key.key
+		}
+	}
+	keys[common.Blake2b224Hash(w.stakeKey.Public().PublicKey())] = w.stakeKey
+	return keys
+}
+
+// PubKeyHash returns the payment key hash of the first receive address.
+func (w *HDWallet) PubKeyHash() common.Blake2b224 {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.chains[hdRoleExternal][0].hash
+}
+
+// StakePubKeyHash returns the account's stake key hash.
+func (w *HDWallet) StakePubKeyHash() common.Blake2b224 {
+	return common.Blake2b224Hash(w.stakeKey.Public().PublicKey())
+}
+
+// String returns a safe string representation that does not expose key material.
+func (w *HDWallet) String() string {
+	return fmt.Sprintf("HDWallet{address: %s}", w.Address().String())
+}
+
+// GoString implements fmt.GoStringer to prevent key material from leaking via %#v.
+func (w *HDWallet) GoString() string {
+	return w.String()
+}
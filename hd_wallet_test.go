@@ -0,0 +1,230 @@
+package apollo
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/hex"
+	"testing"
+
+	"github.com/blinklabs-io/gouroboros/ledger/common"
+)
+
+func newTestHDWallet(t *testing.T, gapLimit int) *HDWallet {
+	t.Helper()
+	w, err := NewHDWallet(signingTestMnemonic, HDWalletConfig{
+		NetworkId: common.AddressNetworkMainnet,
+		GapLimit:  gapLimit,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return w
+}
+
+// derivedAddress derives role/index on a scratch wallet so the fixture can
+// fund addresses before the wallet under test has discovered them.
+func derivedAddress(t *testing.T, role, index uint32) common.Address {
+	t.Helper()
+	w := newTestHDWallet(t, 1)
+	key, err := w.deriveLocked(role, index)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key.address
+}
+
+func TestHDWalletFirstAddressMatchesBursaWallet(t *testing.T) {
+	hd := newTestHDWallet(t, 0)
+	bw, err := NewBursaWallet(signingTestMnemonic)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hd.Address().String() != bw.Address().String() {
+		t.Fatalf("first receive address = %s, want %s", hd.Address().String(), bw.Address().String())
+	}
+	if hd.PubKeyHash() != bw.PubKeyHash() || hd.StakePubKeyHash() != bw.StakePubKeyHash() {
+		t.Fatal("HD wallet keys differ from the equivalent BursaWallet")
+	}
+}
+
+func TestHDWalletDiscoverRespectsGapLimit(t *testing.T) {
+	cc := networkTestContext(t, common.AddressNetworkMainnet)
+	external3 := derivedAddress(t, hdRoleExternal, 3)
+	internal1 := derivedAddress(t, hdRoleInternal, 1)
+	beyondGap := derivedAddress(t, hdRoleExternal, 9)
+	addTestUtxo(cc, external3, 5_000_000, 0x01, 0)
+	addTestUtxo(cc, internal1, 5_000_000, 0x02, 0)
+	addTestUtxo(cc, beyondGap, 5_000_000, 0x03, 0)
+
+	w := newTestHDWallet(t, 5)
+	if err := w.Discover(context.Background(), cc); err != nil {
+		t.Fatal(err)
+	}
+	got := make(map[string]bool)
+	for _, addr := range w.InputAddresses() {
+		got[addr.String()] = true
+	}
+	if !got[w.Address().String()] || !got[external3.String()] || !got[internal1.String()] {
+		t.Fatalf("discovered addresses %v missing a funded address", got)
+	}
+	if got[beyondGap.String()] {
+		t.Fatal("address beyond the gap limit was discovered")
+	}
+	if len(got) != 3 {
+		t.Fatalf("discovered %d addresses, want 3", len(got))
+	}
+
+	receive, err := w.NextReceiveAddress()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if receive.String() != derivedAddress(t, hdRoleExternal, 4).String() {
+		t.Fatal("next receive address is not the first index after the last used one")
+	}
+	change, err := w.NextChangeAddress()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if change.String() != derivedAddress(t, hdRoleInternal, 2).String() {
+		t.Fatal("next change address is not the first index after the last used one")
+	}
+}
+
+func TestHDWalletNextAddressStopsAtGapLimit(t *testing.T) {
+	w := newTestHDWallet(t, 2)
+	for i := range 2 {
+		if _, err := w.NextChangeAddress(); err != nil {
+			t.Fatalf("address %d: %v", i, err)
+		}
+	}
+	if _, err := w.NextChangeAddress(); err == nil {
+		t.Fatal("expected an error once the gap limit of unused addresses is reached")
+	}
+}
+
+func TestHDWalletSignsEverySpentAddress(t *testing.T) {
+	cc := &submitCaptureContext{
+		FixedChainContext: networkTestContext(t, common.AddressNetworkMainnet),
+	}
+	w := newTestHDWallet(t, 3)
+	external2 := derivedAddress(t, hdRoleExternal, 2)
+	internal0 := derivedAddress(t, hdRoleInternal, 0)
+	addTestUtxo(cc.FixedChainContext, external2, 3_000_000, 0x01, 0)
+	addTestUtxo(cc.FixedChainContext, internal0, 3_000_000, 0x02, 0)
+	if err := w.Discover(context.Background(), cc); err != nil {
+		t.Fatal(err)
+	}
+
+	change, err := w.NextChangeAddress()
+	if err != nil {
+		t.Fatal(err)
+	}
+	payee := syntheticAddress(t, common.AddressTypeKeyKey, common.AddressNetworkMainnet)
+	a, err := New(cc).
+		SetWallet(w).
+		SetChangeAddress(change).
+		SetTtl(50_000_000).
+		PayToAddress(payee, 5_000_000).
+		Complete()
+	if err != nil {
+		t.Fatalf("Complete failed: %v", err)
+	}
+	if a, err = a.Sign(); err != nil {
+		t.Fatalf("Sign failed: %v", err)
+	}
+	if _, err := a.Submit(); err != nil {
+		t.Fatal(err)
+	}
+
+	elements := txTopLevelElements(t, cc.submitted)
+	bodyHash := common.Blake2b256Hash(elements[0])
+	signers := make(map[common.Blake2b224]bool)
+	for _, witness := range submittedVkeyWitnesses(t, elements[1]) {
+		if !ed25519.Verify(ed25519.PublicKey(witness.Vkey), bodyHash.Bytes(), witness.Signature) {
+			t.Fatal("witness does not verify against the submitted body hash")
+		}
+		signers[common.Blake2b224Hash(witness.Vkey)] = true
+	}
+	for _, addr := range []common.Address{external2, internal0} {
+		if !signers[addr.PaymentKeyHash()] {
+			t.Fatalf("no witness for spent address %s", addr.String())
+		}
+	}
+	if signers[w.PubKeyHash()] || len(signers) != 2 {
+		t.Fatalf("got %d witnesses, want only the two spent addresses", len(signers))
+	}
+}
+
+func TestHDWalletInputAddressesIncludeHandedOutAddresses(t *testing.T) {
+	cc := networkTestContext(t, common.AddressNetworkMainnet)
+	w := newTestHDWallet(t, 3)
+	if err := w.Discover(context.Background(), cc); err != nil {
+		t.Fatal(err)
+	}
+	change, err := w.NextChangeAddress()
+	if err != nil {
+		t.Fatal(err)
+	}
+	addTestUtxo(cc, change, 5_000_000, 0x01, 0)
+
+	found := false
+	for _, addr := range w.InputAddresses() {
+		found = found || addr.String() == change.String()
+	}
+	if !found {
+		t.Fatal("handed-out change address is not an input address before the next Discover")
+	}
+}
+
+func TestHDWalletSignsLoadedTransaction(t *testing.T) {
+	cc := networkTestContext(t, common.AddressNetworkMainnet)
+	w := newTestHDWallet(t, 3)
+	external1 := derivedAddress(t, hdRoleExternal, 1)
+	addTestUtxo(cc, external1, 8_000_000, 0x01, 0)
+	utxos, err := cc.Utxos(external1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, utxo := range utxos {
+		cc.AddUtxoByRef(utxo)
+	}
+	if err := w.Discover(context.Background(), cc); err != nil {
+		t.Fatal(err)
+	}
+
+	payee := syntheticAddress(t, common.AddressTypeKeyKey, common.AddressNetworkMainnet)
+	built, err := New(cc).
+		SetWallet(NewExternalWallet(external1)).
+		SetTtl(50_000_000).
+		PayToAddress(payee, 2_000_000).
+		Complete()
+	if err != nil {
+		t.Fatalf("Complete failed: %v", err)
+	}
+	txCbor, err := built.GetTxCbor()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A co-signer that only has the CBOR resolves the inputs itself.
+	a, err := New(cc).SetWallet(w).LoadTxCbor(hex.EncodeToString(txCbor))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a, err = a.Sign(); err != nil {
+		t.Fatalf("Sign failed: %v", err)
+	}
+	witnesses := a.GetTx().WitnessSet.VkeyWitnesses.Items()
+	if len(witnesses) != 1 || common.Blake2b224Hash(witnesses[0].Vkey) != external1.PaymentKeyHash() {
+		t.Fatalf("got %d witnesses, want one for the spent address", len(witnesses))
+	}
+
+	// A wallet that holds none of the needed keys reports it.
+	other, err := New(cc).SetWallet(newTestHDWallet(t, 0)).LoadTxCbor(hex.EncodeToString(txCbor))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := other.Sign(); err == nil {
+		t.Fatal("Sign succeeded without producing a witness")
+	}
+}
@@ -6,14 +6,20 @@ package backendutil
 
 import (
 	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"math"
 	"math/big"
+	"sort"
 	"strconv"
 	"strings"
 
 	"github.com/blinklabs-io/gouroboros/cbor"
+	"github.com/blinklabs-io/gouroboros/ledger/babbage"
 	"github.com/blinklabs-io/gouroboros/ledger/common"
+
+	"github.com/Salvionied/apollo/v2/backend"
 )
 
 // ParseRational parses a provider-supplied rational number without passing it
@@ -125,6 +131,36 @@ func ParseFraction(s string) (float64, error) {
 	return val, nil
 }
 
+// ParseCostModels parses provider cost models keyed by language ("PlutusV1"
+// through "PlutusV4"). Each language is either the canonical flat integer
+// array or an object keyed by parameter name. The ledger orders the flat
+// list by sorted parameter name (see IntersectMBO/cardano-ledger#2902), so
+// keyed models are flattened in that order.
+func ParseCostModels(raw json.RawMessage) (map[string][]int64, error) {
+	var arrayModels map[string][]int64
+	if err := json.Unmarshal(raw, &arrayModels); err == nil {
+		return arrayModels, nil
+	}
+	var keyedModels map[string]map[string]int64
+	if err := json.Unmarshal(raw, &keyedModels); err != nil {
+		return nil, fmt.Errorf("failed to parse cost models: %w", err)
+	}
+	models := make(map[string][]int64, len(keyedModels))
+	for lang, costs := range keyedModels {
+		names := make([]string, 0, len(costs))
+		for name := range costs {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		values := make([]int64, 0, len(costs))
+		for _, name := range names {
+			values = append(values, costs[name])
+		}
+		models[lang] = values
+	}
+	return models, nil
+}
+
 // ScriptRefFromBytes builds a common.ScriptRef of the given script ref type
 // from raw script bytes. Native scripts are decoded from their CBOR
 // representation. When expectedHashHex is non-empty, the script hash is
@@ -169,3 +205,145 @@ func ScriptRefFromBytes(scriptType uint, scriptBytes []byte, expectedHashHex str
 	}
 	return &common.ScriptRef{Type: scriptType, Script: script}, nil
 }
+
+// ScriptRefFromDbSync builds a reference script from a script row as
+// cardano-db-sync stores it, which indexers built on db-sync such as Koios
+// pass through unchanged. scriptType is the db-sync script type name. Plutus
+// script bytes are tried as stored and wrapped in one CBOR byte-string layer,
+// since only one of the two forms matches the hash.
+func ScriptRefFromDbSync(scriptType string, scriptBytes []byte, expectedHashHex string) (*common.ScriptRef, error) {
+	var refType uint
+	switch scriptType {
+	case "plutusV1":
+		refType = common.ScriptRefTypePlutusV1
+	case "plutusV2":
+		refType = common.ScriptRefTypePlutusV2
+	case "plutusV3":
+		refType = common.ScriptRefTypePlutusV3
+	case "timelock", "multisig":
+		refType = common.ScriptRefTypeNativeScript
+	default:
+		return nil, fmt.Errorf("unsupported reference script type %q", scriptType)
+	}
+	scriptRef, err := ScriptRefFromBytes(refType, scriptBytes, expectedHashHex)
+	if err == nil || refType == common.ScriptRefTypeNativeScript {
+		return scriptRef, err
+	}
+	wrapped, wrapErr := cbor.Encode(scriptBytes)
+	if wrapErr != nil {
+		return nil, err
+	}
+	if scriptRef, wrappedErr := ScriptRefFromBytes(refType, wrapped, expectedHashHex); wrappedErr == nil {
+		return scriptRef, nil
+	}
+	return nil, err
+}
+
+// InlineDatumOption builds an inline datum option from datum CBOR, keeping
+// the original bytes so the datum hash is unchanged.
+func InlineDatumOption(datumBytes []byte) (*babbage.BabbageTransactionOutputDatumOption, error) {
+	// Inline datum option: [1, #6.24(datum_cbor)]
+	cborBytes, err := cbor.Encode([]any{1, cbor.Tag{Number: 24, Content: datumBytes}})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode inline datum option: %w", err)
+	}
+	var opt babbage.BabbageTransactionOutputDatumOption
+	if err := opt.UnmarshalCBOR(cborBytes); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal inline datum option: %w", err)
+	}
+	return &opt, nil
+}
+
+// DatumHashOption builds a datum option that references a datum by hash.
+func DatumHashOption(hash common.Blake2b256) (*babbage.BabbageTransactionOutputDatumOption, error) {
+	cborBytes, err := cbor.Encode([]any{0, hash})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode datum option hash: %w", err)
+	}
+	var opt babbage.BabbageTransactionOutputDatumOption
+	if err := opt.UnmarshalCBOR(cborBytes); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal datum option: %w", err)
+	}
+	return &opt, nil
+}
+
+// TransactionId hashes the transaction body exactly as it was serialised,
+// which is how the ledger derives the transaction ID.
+func TransactionId(txCbor []byte) (common.Blake2b256, error) {
+	var parts []cbor.RawMessage
+	if _, err := cbor.Decode(txCbor, &parts); err != nil {
+		return common.Blake2b256{}, fmt.Errorf("failed to decode transaction: %w", err)
+	}
+	if len(parts) == 0 {
+		return common.Blake2b256{}, errors.New("failed to decode transaction: missing body")
+	}
+	return common.Blake2b256Hash(parts[0]), nil
+}
+
+// DecodeDatumHex decodes a provider-supplied datum from its CBOR hex. The
+// original bytes are kept on the datum, so it hashes and serializes exactly
+// as it does on chain even when the encoding is not canonical.
+func DecodeDatumHex(datumCborHex string) (*common.Datum, error) {
+	if datumCborHex == "" {
+		return nil, errors.New("provider returned an empty datum")
+	}
+	datumBytes, err := hex.DecodeString(datumCborHex)
+	if err != nil {
+		return nil, fmt.Errorf("invalid datum CBOR hex: %w", err)
+	}
+	var datum common.Datum
+	if err := datum.UnmarshalCBOR(datumBytes); err != nil {
+		return nil, fmt.Errorf("failed to decode datum: %w", err)
+	}
+	return &datum, nil
+}
+
+// CloneProtocolParams returns a deep copy of pp, so a cached copy cannot be
+// mutated through the value handed to a caller.
+func CloneProtocolParams(pp backend.ProtocolParameters) backend.ProtocolParameters {
+	if pp.CostModels != nil {
+		cm := make(map[string][]int64, len(pp.CostModels))
+		for lang, costs := range pp.CostModels {
+			cm[lang] = append([]int64(nil), costs...)
+		}
+		pp.CostModels = cm
+	}
+	if pp.MinFeeRefScriptCostPerByteRational != nil {
+		pp.MinFeeRefScriptCostPerByteRational = new(big.Rat).Set(pp.MinFeeRefScriptCostPerByteRational)
+	}
+	if pp.MinFeeReferenceScriptsMultiplierRational != nil {
+		pp.MinFeeReferenceScriptsMultiplierRational = new(big.Rat).Set(pp.MinFeeReferenceScriptsMultiplierRational)
+	}
+	return pp
+}
+
+// ScriptFromCbor turns the bytes a backend returns from ScriptCbor into a
+// script. Backends disagree on whether Plutus scripts come wrapped in a CBOR
+// byte string and none say which language they are, so every candidate is
+// checked against the hash that was asked for.
+func ScriptFromCbor(hash common.ScriptHash, scriptCbor []byte) (common.Script, error) {
+	candidates := [][]byte{scriptCbor}
+	var inner []byte
+	if _, err := cbor.Decode(scriptCbor, &inner); err == nil {
+		candidates = append(candidates, inner)
+	}
+	if wrapped, err := cbor.Encode(scriptCbor); err == nil {
+		candidates = append(candidates, wrapped)
+	}
+	for _, candidate := range candidates {
+		for _, script := range []common.Script{
+			common.PlutusV1Script(candidate),
+			common.PlutusV2Script(candidate),
+			common.PlutusV3Script(candidate),
+		} {
+			if script.Hash() == hash {
+				return script, nil
+			}
+		}
+	}
+	var native common.NativeScript
+	if err := native.UnmarshalCBOR(scriptCbor); err == nil && native.Hash() == hash {
+		return native, nil
+	}
+	return nil, fmt.Errorf("script CBOR returned for %s does not match its hash", hash.String())
+}
@@ -2,10 +2,13 @@ package backendutil
 
 import (
 	"encoding/hex"
+	"math/big"
 	"testing"
 
 	"github.com/blinklabs-io/gouroboros/cbor"
 	"github.com/blinklabs-io/gouroboros/ledger/common"
+
+	"github.com/Salvionied/apollo/v2/backend"
 )
 
 func TestParseFractionValid(t *testing.T) {
@@ -243,3 +246,46 @@ func TestScriptRefFromBytesNativeScript(t *testing.T) {
 		t.Fatal("expected native script hash mismatch error")
 	}
 }
+
+func TestParseCostModels(t *testing.T) {
+	models, err := ParseCostModels([]byte(`{"PlutusV2": [3, 1, 2]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := models["PlutusV2"]; len(got) != 3 || got[0] != 3 || got[2] != 2 {
+		t.Fatalf("array cost model = %v", got)
+	}
+
+	models, err = ParseCostModels([]byte(`{"PlutusV1": {"b-cpu": 2, "a-cpu": 1, "c-mem": 3}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := models["PlutusV1"]; len(got) != 3 || got[0] != 1 || got[1] != 2 || got[2] != 3 {
+		t.Fatalf("keyed cost model = %v, want values in parameter name order", got)
+	}
+
+	if _, err := ParseCostModels([]byte(`{"PlutusV1": "x"}`)); err == nil {
+		t.Fatal("ParseCostModels accepted a malformed cost model")
+	}
+}
+
+func TestCloneProtocolParamsIsDeep(t *testing.T) {
+	original := backend.ProtocolParameters{
+		CostModels:                               map[string][]int64{"PlutusV3": {1, 2}},
+		MinFeeRefScriptCostPerByteRational:       big.NewRat(15, 1),
+		MinFeeReferenceScriptsMultiplierRational: big.NewRat(6, 5),
+	}
+	clone := CloneProtocolParams(original)
+	clone.CostModels["PlutusV3"][0] = 99
+	clone.CostModels["PlutusV2"] = nil
+	clone.MinFeeRefScriptCostPerByteRational.SetInt64(1)
+	clone.MinFeeReferenceScriptsMultiplierRational.SetInt64(1)
+
+	if original.CostModels["PlutusV3"][0] != 1 || len(original.CostModels) != 1 {
+		t.Fatalf("cost models shared with the clone: %v", original.CostModels)
+	}
+	if original.MinFeeRefScriptCostPerByteRational.Cmp(big.NewRat(15, 1)) != 0 ||
+		original.MinFeeReferenceScriptsMultiplierRational.Cmp(big.NewRat(6, 5)) != 0 {
+		t.Fatal("rationals shared with the clone")
+	}
+}
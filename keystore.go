@@ -0,0 +1,575 @@
+package apollo
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/blinklabs-io/bursa"
+	"github.com/blinklabs-io/bursa/bip32"
+	"github.com/blinklabs-io/gouroboros/ledger/common"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/chacha20poly1305"
+	"golang.org/x/crypto/scrypt"
+)
+
+// KeystoreVersion is the keystore format version written by this package.
+const KeystoreVersion = 1
+
+// Keystore secret kinds.
+const (
+	KeystoreKindMnemonic = "mnemonic"
+	KeystoreKindRootKey  = "root_key"
+)
+
+// Keystore key-derivation functions.
+const (
+	KeystoreKDFScrypt   = "scrypt"
+	KeystoreKDFArgon2id = "argon2id"
+)
+
+// Keystore ciphers.
+const (
+	KeystoreCipherAESGCM           = "aes-256-gcm"
+	KeystoreCipherChaCha20Poly1305 = "chacha20-poly1305"
+)
+
+// Default and maximum key-derivation costs. The defaults follow the scrypt
+// "interactive login" and RFC 9106 second-choice argon2id recommendations.
+// The maxima bound the memory and time a crafted keystore file can make
+// Unlock spend: scrypt allocates 128·N·r bytes, capped at the argon2id memory
+// limit, and its work grows with N·r·p.
+const (
+	defaultScryptN        = 1 << 17
+	defaultScryptR        = 8
+	defaultScryptP        = 1
+	defaultArgon2Time     = 3
+	defaultArgon2MemoryKB = 64 * 1024
+	defaultArgon2Threads  = 4
+	maxScryptN            = 1 << 20
+	maxScryptR            = 32
+	maxScryptP            = 16
+	maxScryptMemory       = maxArgon2MemoryKB * 1024
+	maxScryptWork         = 1 << 24
+	maxArgon2MemoryKB     = 1024 * 1024
+	maxArgon2Time         = 16
+	keystoreKeySize       = 32
+	keystoreSaltSize      = 32
+)
+
+// ErrWalletLocked is returned when a locked KeystoreWallet is asked to sign.
+var ErrWalletLocked = errors.New("wallet is locked")
+
+// ErrKeystorePassword is returned when a keystore cannot be decrypted, which
+// is almost always a wrong password. AEAD ciphers cannot tell a wrong key
+// from a tampered file.
+var ErrKeystorePassword = errors.New("keystore decryption failed: wrong password or corrupt file")
+
+// KeystoreKDFParams records the key-derivation function and its parameters.
+type KeystoreKDFParams struct {
+	Name string `json:"name"`
+	Salt string `json:"salt"`
+	// scrypt parameters.
+	N int `json:"n,omitempty"`
+	R int `json:"r,omitempty"`
+	P int `json:"p,omitempty"`
+	// argon2id parameters; Memory is in KiB.
+	Time    uint32 `json:"time,omitempty"`
+	Memory  uint32 `json:"memory,omitempty"`
+	Threads uint8  `json:"threads,omitempty"`
+}
+
+// KeystoreCipherParams records the AEAD cipher and its nonce.
+type KeystoreCipherParams struct {
+	Name  string `json:"name"`
+	Nonce string `json:"nonce"`
+}
+
+// Keystore is a password-encrypted mnemonic or extended root key. Every field
+// other than Ciphertext is authenticated as associated data, so the address
+// and derivation indices cannot be swapped without failing decryption.
+type Keystore struct {
+	Version    int                  `json:"version"`
+	Kind       string               `json:"kind"`
+	Address    string               `json:"address"`
+	AccountID  uint32               `json:"account_id"`
+	AddressID  uint32               `json:"address_id"`
+	KDF        KeystoreKDFParams    `json:"kdf"`
+	Cipher     KeystoreCipherParams `json:"cipher"`
+	Ciphertext string               `json:"ciphertext"`
+}
+
+// KeystoreOptions configures a new keystore. Zero values select defaults:
+// scrypt, AES-256-GCM, mainnet, account 0 and address 0.
+type KeystoreOptions struct {
+	KDF    string
+	Cipher string
+	// NetworkId is the wallet address network id: 1 for mainnet, 0 for
+	// testnets. It is a pointer so the zero value can mean mainnet.
+	NetworkId *uint8
+	AccountID uint32
+	AddressID uint32
+	// ScryptN, ScryptR and ScryptP override the scrypt cost.
+	ScryptN, ScryptR, ScryptP int
+	// Argon2Time, Argon2MemoryKB and Argon2Threads override the argon2id cost.
+	Argon2Time     uint32
+	Argon2MemoryKB uint32
+	Argon2Threads  uint8
+}
+
+// NewMnemonicKeystore encrypts a BIP-39 mnemonic under password. The wallet
+// keys are derived with an empty BIP-39 passphrase; for a passphrase-protected
+// mnemonic, derive the root key and use NewRootKeyKeystore.
+func NewMnemonicKeystore(mnemonic string, password string, opts KeystoreOptions) (*Keystore, error) {
+	// Store the mnemonic normalized, so Unlock derives from exactly the
+	// words the address was derived from here.
+	normalized := strings.Join(strings.Fields(mnemonic), " ")
+	rootKey, err := bursa.GetRootKeyFromMnemonic(normalized, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive root key: %w", err)
+	}
+	defer clear(rootKey)
+	secret := []byte(normalized)
+	defer clear(secret)
+	return newKeystore(KeystoreKindMnemonic, secret, rootKey, password, opts)
+}
+
+// NewRootKeyKeystore encrypts a 96-byte extended root key under password.
+func NewRootKeyKeystore(rootKey bip32.XPrv, password string, opts KeystoreOptions) (*Keystore, error) {
+	if len(rootKey) != bip32XPrvSize {
+		return nil, fmt.Errorf("invalid root key length: expected %d bytes, got %d", bip32XPrvSize, len(rootKey))
+	}
+	return newKeystore(KeystoreKindRootKey, rootKey, rootKey, password, opts)
+}
+
+func newKeystore(kind string, secret []byte, rootKey bip32.XPrv, password string, opts KeystoreOptions) (*Keystore, error) {
+	if password == "" {
+		return nil, errors.New("keystore password must not be empty")
+	}
+	networkId := uint8(common.AddressNetworkMainnet)
+	if opts.NetworkId != nil {
+		networkId = *opts.NetworkId
+	}
+	paymentKey, stakeKey, err := deriveKeystoreKeys(rootKey, opts.AccountID, opts.AddressID)
+	if err != nil {
+		return nil, err
+	}
+	defer clear(paymentKey)
+	defer clear(stakeKey)
+	addr, err := common.NewAddressFromParts(
+		common.AddressTypeKeyKey,
+		networkId,
+		common.Blake2b224Hash(paymentKey.Public().PublicKey()).Bytes(),
+		common.Blake2b224Hash(stakeKey.Public().PublicKey()).Bytes(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build wallet address: %w", err)
+	}
+
+	salt := make([]byte, keystoreSaltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, fmt.Errorf("failed to generate salt: %w", err)
+	}
+	ks := &Keystore{
+		Version:   KeystoreVersion,
+		Kind:      kind,
+		Address:   addr.String(),
+		AccountID: opts.AccountID,
+		AddressID: opts.AddressID,
+		KDF:       KeystoreKDFParams{Name: opts.KDF, Salt: hex.EncodeToString(salt)},
+		Cipher:    KeystoreCipherParams{Name: opts.Cipher},
+	}
+	switch ks.KDF.Name {
+	case "", KeystoreKDFScrypt:
+		ks.KDF.Name = KeystoreKDFScrypt
+		ks.KDF.N, ks.KDF.R, ks.KDF.P = defaultScryptN, defaultScryptR, defaultScryptP
+		if opts.ScryptN > 0 {
+			ks.KDF.N = opts.ScryptN
+		}
+		if opts.ScryptR > 0 {
+			ks.KDF.R = opts.ScryptR
+		}
+		if opts.ScryptP > 0 {
+			ks.KDF.P = opts.ScryptP
+		}
+	case KeystoreKDFArgon2id:
+		ks.KDF.Time, ks.KDF.Memory, ks.KDF.Threads = defaultArgon2Time, defaultArgon2MemoryKB, defaultArgon2Threads
+		if opts.Argon2Time > 0 {
+			ks.KDF.Time = opts.Argon2Time
+		}
+		if opts.Argon2MemoryKB > 0 {
+			ks.KDF.Memory = opts.Argon2MemoryKB
+		}
+		if opts.Argon2Threads > 0 {
+			ks.KDF.Threads = opts.Argon2Threads
+		}
+	}
+	if ks.Cipher.Name == "" {
+		ks.Cipher.Name = KeystoreCipherAESGCM
+	}
+	if err := ks.validate(); err != nil {
+		return nil, err
+	}
+
+	aead, err := ks.aead(password)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	ks.Cipher.Nonce = hex.EncodeToString(nonce)
+	aad, err := ks.associatedData()
+	if err != nil {
+		return nil, err
+	}
+	ks.Ciphertext = hex.EncodeToString(aead.Seal(nil, nonce, secret, aad))
+	return ks, nil
+}
+
+// ParseKeystore decodes and validates keystore JSON.
+func ParseKeystore(jsonData []byte) (*Keystore, error) {
+	var ks Keystore
+	dec := json.NewDecoder(bytes.NewReader(jsonData))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&ks); err != nil {
+		return nil, fmt.Errorf("decode keystore JSON: %w", err)
+	}
+	var extra any
+	if err := dec.Decode(&extra); err != io.EOF {
+		if err == nil {
+			return nil, errors.New("decode keystore JSON: multiple JSON values")
+		}
+		return nil, fmt.Errorf("decode keystore JSON: %w", err)
+	}
+	if err := ks.validate(); err != nil {
+		return nil, err
+	}
+	return &ks, nil
+}
+
+// JSON encodes the keystore for storage.
+func (k *Keystore) JSON() ([]byte, error) {
+	return json.MarshalIndent(k, "", "  ")
+}
+
+// validate checks the header before any expensive key derivation, bounding
+// the cost parameters a crafted file can request.
+func (k *Keystore) validate() error {
+	if k.Version != KeystoreVersion {
+		return fmt.Errorf("unsupported keystore version %d", k.Version)
+	}
+	if k.Kind != KeystoreKindMnemonic && k.Kind != KeystoreKindRootKey {
+		return fmt.Errorf("unsupported keystore kind %q", k.Kind)
+	}
+	if _, err := common.NewAddress(k.Address); err != nil {
+		return fmt.Errorf("invalid keystore address: %w", err)
+	}
+	if salt, err := hex.DecodeString(k.KDF.Salt); err != nil || len(salt) < 16 {
+		return errors.New("keystore salt must be at least 16 hex-encoded bytes")
+	}
+	switch k.KDF.Name {
+	case KeystoreKDFScrypt:
+		if k.KDF.N < 2 || k.KDF.N&(k.KDF.N-1) != 0 || k.KDF.N > maxScryptN {
+			return fmt.Errorf("scrypt N must be a power of two between 2 and %d, got %d", maxScryptN, k.KDF.N)
+		}
+		if k.KDF.R < 1 || k.KDF.R > maxScryptR {
+			return fmt.Errorf("scrypt r must be between 1 and %d, got %d", maxScryptR, k.KDF.R)
+		}
+		if k.KDF.P < 1 || k.KDF.P > maxScryptP {
+			return fmt.Errorf("scrypt p must be between 1 and %d, got %d", maxScryptP, k.KDF.P)
+		}
+		if memory := 128 * int64(k.KDF.N) * int64(k.KDF.R); memory > maxScryptMemory {
+			return fmt.Errorf("scrypt N and r need %d bytes, over the %d byte limit", memory, maxScryptMemory)
+		}
+		if work := int64(k.KDF.N) * int64(k.KDF.R) * int64(k.KDF.P); work > maxScryptWork {
+			return fmt.Errorf("scrypt N·r·p must be at most %d, got %d", maxScryptWork, work)
+		}
+	case KeystoreKDFArgon2id:
+		if k.KDF.Time < 1 || k.KDF.Time > maxArgon2Time {
+			return fmt.Errorf("argon2id time must be between 1 and %d, got %d", maxArgon2Time, k.KDF.Time)
+		}
+		if k.KDF.Memory < 8*uint32(max(k.KDF.Threads, 1)) || k.KDF.Memory > maxArgon2MemoryKB {
+			return fmt.Errorf("argon2id memory must be between 8 KiB per thread and %d KiB, got %d", maxArgon2MemoryKB, k.KDF.Memory)
+		}
+		if k.KDF.Threads < 1 {
+			return errors.New("argon2id threads must be at least 1")
+		}
+	default:
+		return fmt.Errorf("unsupported keystore KDF %q", k.KDF.Name)
+	}
+	switch k.Cipher.Name {
+	case KeystoreCipherAESGCM, KeystoreCipherChaCha20Poly1305:
+	default:
+		return fmt.Errorf("unsupported keystore cipher %q", k.Cipher.Name)
+	}
+	return nil
+}
+
+// associatedData is the header the ciphertext is bound to: the keystore
+// encoded without its ciphertext.
+func (k *Keystore) associatedData() ([]byte, error) {
+	header := *k
+	header.Ciphertext = ""
+	return json.Marshal(header)
+}
+
+func (k *Keystore) aead(password string) (cipher.AEAD, error) {
+	salt, err := hex.DecodeString(k.KDF.Salt)
+	if err != nil {
+		return nil, fmt.Errorf("invalid keystore salt: %w", err)
+	}
+	var key []byte
+	switch k.KDF.Name {
+	case KeystoreKDFScrypt:
+		key, err = scrypt.Key([]byte(password), salt, k.KDF.N, k.KDF.R, k.KDF.P, keystoreKeySize)
+		if err != nil {
+			return nil, fmt.Errorf("scrypt: %w", err)
+		}
+	case KeystoreKDFArgon2id:
+		key = argon2.IDKey([]byte(password), salt, k.KDF.Time, k.KDF.Memory, k.KDF.Threads, keystoreKeySize)
+	default:
+		return nil, fmt.Errorf("unsupported keystore KDF %q", k.KDF.Name)
+	}
+	defer clear(key)
+	switch k.Cipher.Name {
+	case KeystoreCipherAESGCM:
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, err
+		}
+		return cipher.NewGCM(block)
+	case KeystoreCipherChaCha20Poly1305:
+		return chacha20poly1305.New(key)
+	default:
+		return nil, fmt.Errorf("unsupported keystore cipher %q", k.Cipher.Name)
+	}
+}
+
+// decrypt returns the stored secret. The caller owns the result and should
+// clear it when done.
+func (k *Keystore) decrypt(password string) ([]byte, error) {
+	if err := k.validate(); err != nil {
+		return nil, err
+	}
+	aead, err := k.aead(password)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := hex.DecodeString(k.Cipher.Nonce)
+	if err != nil || len(nonce) != aead.NonceSize() {
+		return nil, errors.New("invalid keystore nonce")
+	}
+	ciphertext, err := hex.DecodeString(k.Ciphertext)
+	if err != nil {
+		return nil, fmt.Errorf("invalid keystore ciphertext: %w", err)
+	}
+	aad, err := k.associatedData()
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := aead.Open(nil, nonce, ciphertext, aad)
+	if err != nil {
+		return nil, ErrKeystorePassword
+	}
+	return plaintext, nil
+}
+
+func deriveKeystoreKeys(rootKey bip32.XPrv, accountID, addressID uint32) (bip32.XPrv, bip32.XPrv, error) {
+	accountKey, err := bursa.GetAccountKey(rootKey, accountID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to derive account key: %w", err)
+	}
+	defer clear(accountKey)
+	paymentKey, err := bursa.GetPaymentKey(accountKey, addressID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to derive payment key: %w", err)
+	}
+	stakeKey, err := bursa.GetStakeKey(accountKey, addressID)
+	if err != nil {
+		clear(paymentKey)
+		return nil, nil, fmt.Errorf("failed to derive stake key: %w", err)
+	}
+	return paymentKey, stakeKey, nil
+}
+
+// KeystoreWallet is a Wallet backed by a Keystore. It starts locked: the
+// address and key hashes are available, but signing fails with
+// ErrWalletLocked until Unlock succeeds. Lock zeroizes the derived keys.
+//
+// Go strings cannot be wiped, so a mnemonic keystore briefly holds the
+// mnemonic as a string inside bursa while Unlock derives the root key.
+type KeystoreWallet struct {
+	keystore Keystore
+	address  common.Address
+
+	mu         sync.Mutex
+	paymentKey bip32.XPrv
+	stakeKey   bip32.XPrv
+}
+
+var (
+	_ Wallet                    = (*KeystoreWallet)(nil)
+	_ EvaluationWitnessProvider = (*KeystoreWallet)(nil)
+)
+
+// NewKeystoreWallet returns a locked wallet for ks.
+func NewKeystoreWallet(ks *Keystore) (*KeystoreWallet, error) {
+	if ks == nil {
+		return nil, errors.New("keystore must not be nil")
+	}
+	if err := ks.validate(); err != nil {
+		return nil, err
+	}
+	addr, err := common.NewAddress(ks.Address)
+	if err != nil {
+		return nil, fmt.Errorf("invalid keystore address: %w", err)
+	}
+	return &KeystoreWallet{keystore: *ks, address: addr}, nil
+}
+
+// OpenKeystoreWallet parses keystore JSON and unlocks it with password.
+func OpenKeystoreWallet(jsonData []byte, password string) (*KeystoreWallet, error) {
+	ks, err := ParseKeystore(jsonData)
+	if err != nil {
+		return nil, err
+	}
+	w, err := NewKeystoreWallet(ks)
+	if err != nil {
+		return nil, err
+	}
+	if err := w.Unlock(password); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// Unlock decrypts the keystore and derives the signing keys. It fails
+// closed if the derived keys do not control the stored address.
+func (w *KeystoreWallet) Unlock(password string) error {
+	secret, err := w.keystore.decrypt(password)
+	if err != nil {
+		return err
+	}
+	defer clear(secret)
+	var rootKey bip32.XPrv
+	switch w.keystore.Kind {
+	case KeystoreKindMnemonic:
+		rootKey, err = bursa.GetRootKeyFromMnemonic(string(secret), "")
+		if err != nil {
+			return fmt.Errorf("failed to derive root key: %w", err)
+		}
+	case KeystoreKindRootKey:
+		if len(secret) != bip32XPrvSize {
+			return fmt.Errorf("invalid root key length: expected %d bytes, got %d", bip32XPrvSize, len(secret))
+		}
+		rootKey = append(bip32.XPrv(nil), secret...)
+	}
+	defer clear(rootKey)
+	paymentKey, stakeKey, err := deriveKeystoreKeys(rootKey, w.keystore.AccountID, w.keystore.AddressID)
+	if err != nil {
+		return err
+	}
+	if common.Blake2b224Hash(paymentKey.Public().PublicKey()) != w.address.PaymentKeyHash() ||
+		common.Blake2b224Hash(stakeKey.Public().PublicKey()) != w.address.StakeKeyHash() {
+		clear(paymentKey)
+		clear(stakeKey)
+		return errors.New("keystore keys do not match the stored address")
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	clear(w.paymentKey)
+	clear(w.stakeKey)
+	w.paymentKey, w.stakeKey = paymentKey, stakeKey
+	return nil
+}
+
+// Lock zeroizes the derived signing keys. The wallet can be unlocked again.
+func (w *KeystoreWallet) Lock() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	clear(w.paymentKey)
+	clear(w.stakeKey)
+	w.paymentKey, w.stakeKey = nil, nil
+}
+
+// Locked reports whether the wallet currently holds no signing keys.
+func (w *KeystoreWallet) Locked() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.paymentKey == nil
+}
+
+func (w *KeystoreWallet) Address() common.Address {
+	return w.address
+}
+
+func (w *KeystoreWallet) SignTxBody(txBodyHash common.Blake2b256) (common.VkeyWitness, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.paymentKey == nil {
+		return common.VkeyWitness{}, ErrWalletLocked
+	}
+	return common.VkeyWitness{
+		Vkey:      w.paymentKey.Public().PublicKey(),
+		Signature: w.paymentKey.Sign(txBodyHash.Bytes()),
+	}, nil
+}
+
+// EvaluationWitnesses provides payment and stake witnesses required by a
+// preliminary transaction evaluation.
+func (w *KeystoreWallet) EvaluationWitnesses(
+	txBodyHash common.Blake2b256,
+	requiredSigners []common.Blake2b224,
+) ([]common.VkeyWitness, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.paymentKey == nil {
+		return nil, ErrWalletLocked
+	}
+	witnesses := make([]common.VkeyWitness, 0, 2)
+	for _, required := range requiredSigners {
+		var key bip32.XPrv
+		switch required {
+		case w.address.PaymentKeyHash():
+			key = w.paymentKey
+		case w.address.StakeKeyHash():
+			key = w.stakeKey
+		default:
+			continue
+		}
+		witnesses = append(witnesses, common.VkeyWitness{
+			Vkey:      key.Public().PublicKey(),
+			Signature: key.Sign(txBodyHash.Bytes()),
+		})
+	}
+	return witnesses, nil
+}
+
+func (w *KeystoreWallet) PubKeyHash() common.Blake2b224 {
+	return w.address.PaymentKeyHash()
+}
+
+func (w *KeystoreWallet) StakePubKeyHash() common.Blake2b224 {
+	return w.address.StakeKeyHash()
+}
+
+// String returns a safe string representation that does not expose key material.
+func (w *KeystoreWallet) String() string {
+	return fmt.Sprintf("KeystoreWallet{address: %s}", w.address.String())
+}
+
+// GoString implements fmt.GoStringer to prevent key material from leaking via %#v.
+func (w *KeystoreWallet) GoString() string {
+	return w.String()
+}
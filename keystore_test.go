@@ -0,0 +1,203 @@
+package apollo
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/blinklabs-io/bursa"
+	"github.com/blinklabs-io/gouroboros/ledger/common"
+)
+
+// testKeystoreOptions keeps key derivation cheap; production defaults take
+// hundreds of milliseconds by design.
+func testKeystoreOptions(kdf, cipherName string) KeystoreOptions {
+	return KeystoreOptions{
+		KDF:            kdf,
+		Cipher:         cipherName,
+		ScryptN:        1 << 10,
+		Argon2Time:     1,
+		Argon2MemoryKB: 64,
+		Argon2Threads:  1,
+	}
+}
+
+func TestKeystoreRoundTrip(t *testing.T) {
+	expected, err := NewBursaWallet(signingTestMnemonic)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, kdf := range []string{KeystoreKDFScrypt, KeystoreKDFArgon2id} {
+		for _, cipherName := range []string{KeystoreCipherAESGCM, KeystoreCipherChaCha20Poly1305} {
+			t.Run(kdf+"/"+cipherName, func(t *testing.T) {
+				ks, err := NewMnemonicKeystore(signingTestMnemonic, "correct horse", testKeystoreOptions(kdf, cipherName))
+				if err != nil {
+					t.Fatal(err)
+				}
+				data, err := ks.JSON()
+				if err != nil {
+					t.Fatal(err)
+				}
+				if strings.Contains(string(data), "abandon") {
+					t.Fatal("keystore JSON contains the plaintext mnemonic")
+				}
+				w, err := OpenKeystoreWallet(data, "correct horse")
+				if err != nil {
+					t.Fatal(err)
+				}
+				if w.Address().String() != expected.Address().String() {
+					t.Fatalf("address = %s, want %s", w.Address().String(), expected.Address().String())
+				}
+				got, err := w.SignTxBody(common.Blake2b256{0x01})
+				if err != nil {
+					t.Fatal(err)
+				}
+				want, err := expected.SignTxBody(common.Blake2b256{0x01})
+				if err != nil {
+					t.Fatal(err)
+				}
+				if string(got.Signature) != string(want.Signature) {
+					t.Fatal("keystore wallet signs differently from the equivalent BursaWallet")
+				}
+			})
+		}
+	}
+}
+
+func TestKeystoreRootKey(t *testing.T) {
+	rootKey, err := bursa.GetRootKeyFromMnemonic(signingTestMnemonic, "bip39 passphrase")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ks, err := NewRootKeyKeystore(rootKey, "pw", testKeystoreOptions("", ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	w, err := NewKeystoreWallet(ks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Unlock("pw"); err != nil {
+		t.Fatal(err)
+	}
+	expected, err := NewBursaWalletWithPassphrase(signingTestMnemonic, "bip39 passphrase")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w.PubKeyHash() != expected.PubKeyHash() {
+		t.Fatal("root key keystore derived a different payment key")
+	}
+}
+
+func TestKeystoreWrongPasswordAndTampering(t *testing.T) {
+	ks, err := NewMnemonicKeystore(signingTestMnemonic, "pw", testKeystoreOptions("", ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := ks.JSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := OpenKeystoreWallet(data, "wrong"); !errors.Is(err, ErrKeystorePassword) {
+		t.Fatalf("wrong password error = %v, want ErrKeystorePassword", err)
+	}
+
+	// The address is authenticated data: pointing it elsewhere must not
+	// yield a wallet that advertises one address and signs for another.
+	other := syntheticAddress(t, common.AddressTypeKeyKey, common.AddressNetworkMainnet)
+	tampered := *ks
+	tampered.Address = other.String()
+	w, err := NewKeystoreWallet(&tampered)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Unlock("pw"); !errors.Is(err, ErrKeystorePassword) {
+		t.Fatalf("tampered address error = %v, want ErrKeystorePassword", err)
+	}
+
+	expensive := *ks
+	expensive.KDF.N = 1 << 30
+	if _, err := NewKeystoreWallet(&expensive); err == nil {
+		t.Fatal("expected an unbounded scrypt cost to be rejected")
+	}
+}
+
+func TestKeystoreRejectsExcessiveScryptCost(t *testing.T) {
+	ks, err := NewMnemonicKeystore(signingTestMnemonic, "pw", testKeystoreOptions("", ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Each of these passes the per-parameter bounds or the old r·p < 2^30
+	// bound but would make Unlock allocate gigabytes or run for minutes.
+	for _, tc := range []struct {
+		name string
+		n    int
+		r, p int
+	}{
+		{"large r", 1 << 20, 1 << 20, 1},
+		{"large p", 1 << 20, 8, 1 << 20},
+		{"memory over budget", 1 << 20, 32, 1},
+		{"work over budget", 1 << 20, 8, 16},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			crafted := *ks
+			crafted.KDF.Name = KeystoreKDFScrypt
+			crafted.KDF.N, crafted.KDF.R, crafted.KDF.P = tc.n, tc.r, tc.p
+			data, err := crafted.JSON()
+			if err != nil {
+				t.Fatal(err)
+			}
+			// Rejection must come from the header check: reaching scrypt
+			// would fail on the password or exhaust memory instead.
+			_, err = OpenKeystoreWallet(data, "pw")
+			if err == nil || !strings.Contains(err.Error(), "scrypt") {
+				t.Fatalf("error = %v, want the scrypt cost to be rejected", err)
+			}
+		})
+	}
+}
+
+func TestKeystoreWalletLockZeroizes(t *testing.T) {
+	ks, err := NewMnemonicKeystore(signingTestMnemonic, "pw", testKeystoreOptions("", ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	w, err := NewKeystoreWallet(ks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !w.Locked() {
+		t.Fatal("a new keystore wallet must start locked")
+	}
+	if _, err := w.SignTxBody(common.Blake2b256{}); !errors.Is(err, ErrWalletLocked) {
+		t.Fatalf("locked sign error = %v, want ErrWalletLocked", err)
+	}
+	if err := w.Unlock("pw"); err != nil {
+		t.Fatal(err)
+	}
+	paymentKey := w.paymentKey
+	w.Lock()
+	for _, b := range paymentKey {
+		if b != 0 {
+			t.Fatal("Lock left payment key material in memory")
+		}
+	}
+	if !w.Locked() {
+		t.Fatal("wallet reports unlocked after Lock")
+	}
+}
+
+func TestKeystoreWalletBuildsAndSigns(t *testing.T) {
+	ks, err := NewMnemonicKeystore(signingTestMnemonic, "pw", testKeystoreOptions("", ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	w, err := NewKeystoreWallet(ks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Unlock("pw"); err != nil {
+		t.Fatal(err)
+	}
+	assertSignsSubmittedBodyHash(t, signAndSubmit(t, w), w.PubKeyHash())
+}
@@ -0,0 +1,309 @@
+package apollo
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+
+	"github.com/blinklabs-io/gouroboros/ledger/common"
+)
+
+// ScriptSigner holds one of the keys a native script names. Every Wallet
+// satisfies it, so local wallets can be used directly; a remote co-signer only
+// needs to report its key hash and return a witness for a body hash.
+type ScriptSigner interface {
+	PubKeyHash() common.Blake2b224
+	SignTxBody(txBodyHash common.Blake2b256) (common.VkeyWitness, error)
+}
+
+// MultisigWalletConfig configures a MultisigWallet.
+type MultisigWalletConfig struct {
+	NetworkId uint8
+	// StakeKeyHash delegates the script address to a stake key. The zero
+	// hash gives an enterprise script address.
+	StakeKeyHash common.Blake2b224
+}
+
+// MultisigWallet spends from the address of a native script by collecting
+// vkey witnesses from several signers until the script is satisfied.
+// Complete attaches the script whenever an input at the wallet address is
+// spent, so callers only set the wallet. Scripts deployed as reference
+// scripts should be spent with a plain signer instead, as the ledger rejects
+// a witness script that is also supplied by reference.
+type MultisigWallet struct {
+	script  common.NativeScript
+	address common.Address
+	stake   common.Blake2b224
+	signers []ScriptSigner
+	planned []common.Blake2b224
+}
+
+var (
+	_ Wallet                    = (*MultisigWallet)(nil)
+	_ MultiKeySigner            = (*MultisigWallet)(nil)
+	_ NativeScriptWallet        = (*MultisigWallet)(nil)
+	_ EvaluationWitnessProvider = (*MultisigWallet)(nil)
+)
+
+// NewMultisigWallet returns a wallet for script. Signers are asked in the
+// order given, and the key hashes they report must be able to satisfy the
+// script's key conditions.
+func NewMultisigWallet(
+	script common.NativeScript,
+	cfg MultisigWalletConfig,
+	signers ...ScriptSigner,
+) (*MultisigWallet, error) {
+	if script.Item() == nil {
+		return nil, errors.New("native script is empty")
+	}
+	seen := make(map[common.Blake2b224]struct{}, len(signers))
+	unique := make([]ScriptSigner, 0, len(signers))
+	for i, signer := range signers {
+		if signer == nil {
+			return nil, fmt.Errorf("signer %d is nil", i)
+		}
+		hash := signer.PubKeyHash()
+		if _, dup := seen[hash]; dup {
+			continue
+		}
+		seen[hash] = struct{}{}
+		unique = append(unique, signer)
+	}
+	planned, ok := planNativeScriptSigners(script, seen)
+	if !ok {
+		return nil, errors.New("signers cannot satisfy the native script")
+	}
+
+	scriptHash := script.Hash()
+	var addrType uint8 = common.AddressTypeScriptNone
+	var stakePayload []byte
+	if cfg.StakeKeyHash != (common.Blake2b224{}) {
+		addrType, stakePayload = common.AddressTypeScriptKey, cfg.StakeKeyHash.Bytes()
+	}
+	addr, err := common.NewAddressFromParts(addrType, cfg.NetworkId, scriptHash.Bytes(), stakePayload)
+	if err != nil {
+		return nil, fmt.Errorf("build script address: %w", err)
+	}
+	return &MultisigWallet{
+		script:  script,
+		address: addr,
+		stake:   cfg.StakeKeyHash,
+		signers: unique,
+		planned: planned,
+	}, nil
+}
+
+// Address returns the script address.
+func (w *MultisigWallet) Address() common.Address {
+	return w.address
+}
+
+// NativeScript returns the script locking the wallet address.
+func (w *MultisigWallet) NativeScript() common.NativeScript {
+	return w.script
+}
+
+// ScriptHash returns the hash of the wallet's script.
+func (w *MultisigWallet) ScriptHash() common.ScriptHash {
+	return w.script.Hash()
+}
+
+// ScriptSigners returns the smallest set of signer key hashes, preferring
+// earlier signers, that satisfies the script. Fees are estimated for one
+// witness per returned hash.
+func (w *MultisigWallet) ScriptSigners() []common.Blake2b224 {
+	return slices.Clone(w.planned)
+}
+
+// PubKeyHash returns the zero hash: a script address has no payment key.
+func (w *MultisigWallet) PubKeyHash() common.Blake2b224 {
+	return common.Blake2b224{}
+}
+
+// StakePubKeyHash returns the configured stake key hash.
+func (w *MultisigWallet) StakePubKeyHash() common.Blake2b224 {
+	return w.stake
+}
+
+// SignTxBody always fails: a multisig witness needs several signatures, which
+// Sign collects through SignTxBodyFor.
+func (w *MultisigWallet) SignTxBody(common.Blake2b256) (common.VkeyWitness, error) {
+	return common.VkeyWitness{}, errors.New("multisig wallet signs through SignTxBodyFor")
+}
+
+// SignTxBodyFor signs with every signer whose key hash is listed. If one of
+// them fails, it re-plans the smallest set of the remaining signers that
+// satisfies the script and asks those. A signer that fails is skipped; an
+// error is returned if the script cannot be satisfied by the signers that
+// succeeded, or if doing so needs more witnesses than were listed, since fees
+// were estimated for one witness per listed key.
+func (w *MultisigWallet) SignTxBodyFor(
+	txBodyHash common.Blake2b256,
+	keyHashes []common.Blake2b224,
+) ([]common.VkeyWitness, error) {
+	requested := make(map[common.Blake2b224]struct{}, len(keyHashes))
+	for _, hash := range keyHashes {
+		requested[hash] = struct{}{}
+	}
+	signed := make(map[common.Blake2b224]bool, len(w.signers))
+	attempted := make(map[int]struct{}, len(w.signers))
+	var witnesses []common.VkeyWitness
+	var signErrs []error
+	sign := func(i int) {
+		attempted[i] = struct{}{}
+		signer := w.signers[i]
+		witness, err := signer.SignTxBody(txBodyHash)
+		if err != nil {
+			signErrs = append(signErrs, fmt.Errorf("signer %s: %w", signer.PubKeyHash().String(), err))
+			return
+		}
+		witnesses = append(witnesses, witness)
+		signed[signer.PubKeyHash()] = true
+	}
+
+	budget := 0
+	for i, signer := range w.signers {
+		if _, ok := requested[signer.PubKeyHash()]; ok {
+			budget++
+			sign(i)
+		}
+	}
+	if !nativeScriptKeysSatisfied(w.script, signed) {
+		available := make(map[common.Blake2b224]struct{}, len(w.signers))
+		for i, signer := range w.signers {
+			if _, done := attempted[i]; !done || signed[signer.PubKeyHash()] {
+				available[signer.PubKeyHash()] = struct{}{}
+			}
+		}
+		if plan, ok := planNativeScriptSigners(w.script, available); ok {
+			for i, signer := range w.signers {
+				if _, done := attempted[i]; !done && slices.Contains(plan, signer.PubKeyHash()) {
+					sign(i)
+				}
+			}
+		}
+	}
+	if !nativeScriptKeysSatisfied(w.script, signed) {
+		return nil, fmt.Errorf(
+			"collected %d of the signatures the native script needs: %w",
+			len(witnesses),
+			errors.Join(signErrs...),
+		)
+	}
+	if len(witnesses) > budget {
+		return nil, fmt.Errorf(
+			"replacement signers need %d witnesses but fees were estimated for %d: %w",
+			len(witnesses),
+			budget,
+			errors.Join(signErrs...),
+		)
+	}
+	return witnesses, nil
+}
+
+// EvaluationWitnesses signs with the listed signers only. Native scripts are
+// not run during Plutus evaluation, so no threshold applies.
+func (w *MultisigWallet) EvaluationWitnesses(
+	txBodyHash common.Blake2b256,
+	requiredSigners []common.Blake2b224,
+) ([]common.VkeyWitness, error) {
+	var witnesses []common.VkeyWitness
+	for _, signer := range w.signers {
+		if !slices.Contains(requiredSigners, signer.PubKeyHash()) {
+			continue
+		}
+		witness, err := signer.SignTxBody(txBodyHash)
+		if err != nil {
+			return nil, fmt.Errorf("signer %s: %w", signer.PubKeyHash().String(), err)
+		}
+		witnesses = append(witnesses, witness)
+	}
+	return witnesses, nil
+}
+
+// String returns a safe string representation that does not expose signers.
+func (w *MultisigWallet) String() string {
+	return fmt.Sprintf("MultisigWallet{address: %s}", w.address.String())
+}
+
+// GoString returns a safe Go-syntax representation that does not expose signers.
+func (w *MultisigWallet) GoString() string {
+	return w.String()
+}
+
+// nativeScriptKeysSatisfied reports whether keys satisfy the script's key
+// conditions. Timelocks are judged by the ledger against the validity
+// interval, so they are evaluated against an inverted interval that passes
+// both InvalidBefore and InvalidHereafter.
+func nativeScriptKeysSatisfied(script common.NativeScript, keys map[common.Blake2b224]bool) bool {
+	return script.Evaluate(0, math.MaxUint64, 0, keys)
+}
+
+// planNativeScriptSigners returns a small set of available key hashes that
+// satisfies the script's key conditions, preferring earlier sub-scripts when
+// sizes tie. It reports false when available keys cannot satisfy it.
+func planNativeScriptSigners(
+	script common.NativeScript,
+	available map[common.Blake2b224]struct{},
+) ([]common.Blake2b224, bool) {
+	switch s := script.Item().(type) {
+	case *common.NativeScriptPubkey:
+		hash := common.NewBlake2b224(s.Hash)
+		if _, ok := available[hash]; !ok {
+			return nil, false
+		}
+		return []common.Blake2b224{hash}, true
+	case *common.NativeScriptAll:
+		var keys []common.Blake2b224
+		for _, sub := range s.Scripts {
+			subKeys, ok := planNativeScriptSigners(sub, available)
+			if !ok {
+				return nil, false
+			}
+			keys = appendUniqueHashes(keys, subKeys)
+		}
+		return keys, true
+	case *common.NativeScriptAny:
+		return planNofK(1, s.Scripts, available)
+	case *common.NativeScriptNofK:
+		return planNofK(s.N, s.Scripts, available)
+	case *common.NativeScriptInvalidBefore, *common.NativeScriptInvalidHereafter:
+		return nil, true
+	default:
+		return nil, false
+	}
+}
+
+func planNofK(
+	n uint,
+	scripts []common.NativeScript,
+	available map[common.Blake2b224]struct{},
+) ([]common.Blake2b224, bool) {
+	var options [][]common.Blake2b224
+	for _, sub := range scripts {
+		if subKeys, ok := planNativeScriptSigners(sub, available); ok {
+			options = append(options, subKeys)
+		}
+	}
+	if uint(len(options)) < n {
+		return nil, false
+	}
+	slices.SortStableFunc(options, func(a, b []common.Blake2b224) int {
+		return len(a) - len(b)
+	})
+	var keys []common.Blake2b224
+	for _, subKeys := range options[:n] {
+		keys = appendUniqueHashes(keys, subKeys)
+	}
+	return keys, true
+}
+
+func appendUniqueHashes(dst, src []common.Blake2b224) []common.Blake2b224 {
+	for _, hash := range src {
+		if !slices.Contains(dst, hash) {
+			dst = append(dst, hash)
+		}
+	}
+	return dst
+}
@@ -0,0 +1,199 @@
+package apollo
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/blinklabs-io/gouroboros/cbor"
+	"github.com/blinklabs-io/gouroboros/ledger/common"
+)
+
+// offlineSigner is a co-signer that cannot be reached.
+type offlineSigner struct {
+	hash common.Blake2b224
+}
+
+func (s offlineSigner) PubKeyHash() common.Blake2b224 {
+	return s.hash
+}
+
+func (offlineSigner) SignTxBody(common.Blake2b256) (common.VkeyWitness, error) {
+	return common.VkeyWitness{}, errors.New("signer offline")
+}
+
+func multisigTestSigners(t *testing.T, n int) []*SigningKeyWallet {
+	t.Helper()
+	signers := make([]*SigningKeyWallet, n)
+	for i := range signers {
+		addr := syntheticAddress(t, common.AddressTypeKeyNone, common.AddressNetworkMainnet)
+		w, err := NewSigningKeyWallet(addr, bytes.Repeat([]byte{byte(0x10 + i)}, ed25519.SeedSize))
+		if err != nil {
+			t.Fatal(err)
+		}
+		signers[i] = w
+	}
+	return signers
+}
+
+func twoOfThreeScript(t *testing.T, signers []*SigningKeyWallet) common.NativeScript {
+	t.Helper()
+	keys := make([]common.NativeScript, len(signers))
+	for i, signer := range signers {
+		key, err := NewNativeScriptPubkey(signer.PubKeyHash())
+		if err != nil {
+			t.Fatal(err)
+		}
+		keys[i] = key
+	}
+	script, err := NewNativeScriptNofK(2, keys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return script
+}
+
+// spendFromMultisig funds w, spends from it and returns the submitted CBOR.
+func spendFromMultisig(t *testing.T, w *MultisigWallet) ([]byte, *Apollo) {
+	t.Helper()
+	cc := &submitCaptureContext{
+		FixedChainContext: networkTestContext(t, common.AddressNetworkMainnet),
+	}
+	addTestUtxo(cc.FixedChainContext, w.Address(), 10_000_000, 0x01, 0)
+	payee := syntheticAddress(t, common.AddressTypeKeyKey, common.AddressNetworkMainnet)
+	a, err := New(cc).
+		SetWallet(w).
+		SetTtl(50_000_000).
+		PayToAddress(payee, 2_000_000).
+		Complete()
+	if err != nil {
+		t.Fatalf("Complete failed: %v", err)
+	}
+	if a, err = a.Sign(); err != nil {
+		t.Fatalf("Sign failed: %v", err)
+	}
+	if _, err := a.Submit(); err != nil {
+		t.Fatal(err)
+	}
+	return cc.submitted, a
+}
+
+func TestMultisigWalletSpendsWithThreshold(t *testing.T) {
+	signers := multisigTestSigners(t, 3)
+	script := twoOfThreeScript(t, signers)
+	w, err := NewMultisigWallet(script, MultisigWalletConfig{NetworkId: common.AddressNetworkMainnet},
+		signers[0], signers[1], signers[2])
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := w.Address()
+	if addr.Type() != common.AddressTypeScriptNone || addr.PaymentKeyHash() != script.Hash() {
+		t.Fatal("wallet address is not the enterprise address of the script")
+	}
+
+	submitted, a := spendFromMultisig(t, w)
+	elements := txTopLevelElements(t, submitted)
+	bodyHash := common.Blake2b256Hash(elements[0])
+	witnesses := submittedVkeyWitnesses(t, elements[1])
+	if len(witnesses) != 2 {
+		t.Fatalf("got %d vkey witnesses, want 2", len(witnesses))
+	}
+	for i, witness := range witnesses {
+		if !ed25519.Verify(witness.Vkey, bodyHash.Bytes(), witness.Signature) {
+			t.Fatalf("witness %d does not verify against the submitted body hash", i)
+		}
+	}
+
+	var witnessSet map[uint64]cbor.RawMessage
+	if _, err := cbor.Decode(elements[1], &witnessSet); err != nil {
+		t.Fatal(err)
+	}
+	var nativeScripts []common.NativeScript
+	if _, err := cbor.Decode(witnessSet[1], &nativeScripts); err != nil {
+		t.Fatalf("witness set has no native scripts: %v", err)
+	}
+	if len(nativeScripts) != 1 || nativeScripts[0].Hash() != script.Hash() {
+		t.Fatal("wallet script was not attached to the witness set")
+	}
+
+	pp, err := a.Context.ProtocolParams()
+	if err != nil {
+		t.Fatal(err)
+	}
+	minFee := big.NewInt(int64(len(submitted))*pp.MinFeeCoefficient + pp.MinFeeConstant)
+	if fee := a.GetTx().Body.Fee(); fee.Cmp(minFee) < 0 {
+		t.Fatalf("fee %d below the minimum %d for the signed transaction", fee, minFee)
+	}
+}
+
+func TestMultisigWalletSkipsUnavailableSigner(t *testing.T) {
+	signers := multisigTestSigners(t, 3)
+	script := twoOfThreeScript(t, signers)
+	w, err := NewMultisigWallet(script, MultisigWalletConfig{NetworkId: common.AddressNetworkMainnet},
+		offlineSigner{hash: signers[0].PubKeyHash()}, signers[1], signers[2])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	submitted, _ := spendFromMultisig(t, w)
+	elements := txTopLevelElements(t, submitted)
+	got := make(map[common.Blake2b224]bool)
+	for _, witness := range submittedVkeyWitnesses(t, elements[1]) {
+		got[common.Blake2b224Hash(witness.Vkey)] = true
+	}
+	if len(got) != 2 || !got[signers[1].PubKeyHash()] || !got[signers[2].PubKeyHash()] {
+		t.Fatal("expected the reachable signers to cover the offline one")
+	}
+}
+
+func TestMultisigWalletReportsUnmetThreshold(t *testing.T) {
+	signers := multisigTestSigners(t, 3)
+	script := twoOfThreeScript(t, signers)
+	if _, err := NewMultisigWallet(script, MultisigWalletConfig{}, signers[0]); err == nil {
+		t.Fatal("expected a single signer to be rejected for a 2-of-3 script")
+	}
+
+	w, err := NewMultisigWallet(script, MultisigWalletConfig{},
+		signers[0], offlineSigner{hash: signers[1].PubKeyHash()}, offlineSigner{hash: signers[2].PubKeyHash()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.SignTxBodyFor(common.Blake2b256{}, w.ScriptSigners()); err == nil {
+		t.Fatal("expected an error when too few signers respond")
+	}
+}
+
+func TestMultisigWalletFallbackStaysWithinFeeEstimate(t *testing.T) {
+	signers := multisigTestSigners(t, 3)
+	keys := make([]common.NativeScript, len(signers))
+	for i, signer := range signers {
+		key, err := NewNativeScriptPubkey(signer.PubKeyHash())
+		if err != nil {
+			t.Fatal(err)
+		}
+		keys[i] = key
+	}
+	both, err := NewNativeScriptAll(keys[1:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	script, err := NewNativeScriptAny([]common.NativeScript{keys[0], both})
+	if err != nil {
+		t.Fatal(err)
+	}
+	w, err := NewMultisigWallet(script, MultisigWalletConfig{},
+		offlineSigner{hash: signers[0].PubKeyHash()}, signers[1], signers[2])
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Fees are estimated for the one planned witness; covering the offline
+	// signer with the other branch needs two.
+	if planned := w.ScriptSigners(); len(planned) != 1 {
+		t.Fatalf("planned %d signers, want 1", len(planned))
+	}
+	if _, err := w.SignTxBodyFor(common.Blake2b256{}, w.ScriptSigners()); err == nil {
+		t.Fatal("expected an error when the fallback needs more witnesses than were estimated")
+	}
+}
@@ -0,0 +1,285 @@
+package apollo
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/Salvionied/apollo/v2/backend"
+	"github.com/blinklabs-io/gouroboros/ledger/common"
+)
+
+// NativeScriptEnv is the transaction state a native script is evaluated
+// against. Zero slots mean the bound is unset, matching SetValidityStart and
+// SetTtl.
+type NativeScriptEnv struct {
+	ValidityStart uint64
+	Ttl           uint64
+	// Signers are the key hashes that witness, or will witness, the
+	// transaction.
+	Signers []common.Blake2b224
+}
+
+// NativeScriptMatch describes how a native script is satisfied.
+type NativeScriptMatch struct {
+	// Paths lists the leaves the satisfying branch rests on, such as
+	// "atLeast[2].sig" or "all[0].after".
+	Paths []string
+	// Signers are the key hashes the satisfying branch uses.
+	Signers []common.Blake2b224
+}
+
+// NativeScriptError explains why a native script is not satisfied. Path
+// names the failing sub-script using the same syntax as NativeScriptMatch;
+// for "any" and "atLeast" the failing branches are listed as causes.
+type NativeScriptError struct {
+	Path   string
+	Reason string
+	Causes []error
+}
+
+func (e *NativeScriptError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "native script %s: %s", e.Path, e.Reason)
+	for _, cause := range e.Causes {
+		b.WriteString("; ")
+		b.WriteString(cause.Error())
+	}
+	return b.String()
+}
+
+func (e *NativeScriptError) Unwrap() []error {
+	return e.Causes
+}
+
+// EvaluateNativeScript checks script against the validity interval and
+// signers in env using the ledger's rules: "after" (InvalidBefore) needs a
+// validity start at or past its slot and "before" (InvalidHereafter) needs a
+// TTL at or before its slot. "any" and "atLeast" take their branches in
+// order, so the match reports the first satisfying combination.
+func EvaluateNativeScript(script common.NativeScript, env NativeScriptEnv) (NativeScriptMatch, error) {
+	e := newNativeScriptEvaluator(env.Signers)
+	e.start, e.ttl = env.ValidityStart, env.Ttl
+	result, err := e.eval(script, "")
+	if err != nil {
+		return NativeScriptMatch{}, err
+	}
+	return result.NativeScriptMatch, nil
+}
+
+type nativeScriptEvaluator struct {
+	signers    map[common.Blake2b224]struct{}
+	start, ttl uint64
+	// plan judges timelocks whose bound is unset against tip and records the
+	// bound they need instead of failing.
+	plan bool
+	tip  uint64
+}
+
+type nativeScriptResult struct {
+	NativeScriptMatch
+	// lower and upper are the validity start and TTL a planned branch needs.
+	lower, upper       uint64
+	hasLower, hasUpper bool
+}
+
+func newNativeScriptEvaluator(signers []common.Blake2b224) *nativeScriptEvaluator {
+	set := make(map[common.Blake2b224]struct{}, len(signers))
+	for _, hash := range signers {
+		set[hash] = struct{}{}
+	}
+	return &nativeScriptEvaluator{signers: set}
+}
+
+func (r *nativeScriptResult) merge(other nativeScriptResult) {
+	r.Paths = append(r.Paths, other.Paths...)
+	r.Signers = appendUniqueHashes(r.Signers, other.Signers)
+	if other.hasLower && (!r.hasLower || other.lower > r.lower) {
+		r.lower, r.hasLower = other.lower, true
+	}
+	if other.hasUpper && (!r.hasUpper || other.upper < r.upper) {
+		r.upper, r.hasUpper = other.upper, true
+	}
+}
+
+func joinScriptPath(prefix, name string) string {
+	if prefix == "" {
+		return name
+	}
+	return prefix + "." + name
+}
+
+func (e *nativeScriptEvaluator) eval(script common.NativeScript, prefix string) (nativeScriptResult, error) {
+	fail := func(path, reason string, args ...any) (nativeScriptResult, error) {
+		return nativeScriptResult{}, &NativeScriptError{Path: path, Reason: fmt.Sprintf(reason, args...)}
+	}
+	switch s := script.Item().(type) {
+	case *common.NativeScriptPubkey:
+		path := joinScriptPath(prefix, "sig")
+		hash := common.NewBlake2b224(s.Hash)
+		if _, ok := e.signers[hash]; !ok {
+			return fail(path, "key %s has not signed", hash.String())
+		}
+		return nativeScriptResult{NativeScriptMatch: NativeScriptMatch{
+			Paths:   []string{path},
+			Signers: []common.Blake2b224{hash},
+		}}, nil
+	case *common.NativeScriptAll:
+		var result nativeScriptResult
+		for i, sub := range s.Scripts {
+			subResult, err := e.eval(sub, fmt.Sprintf("%s[%d]", joinScriptPath(prefix, "all"), i))
+			if err != nil {
+				return nativeScriptResult{}, err
+			}
+			result.merge(subResult)
+		}
+		if result.hasLower && result.hasUpper && result.lower >= result.upper {
+			return fail(joinScriptPath(prefix, "all"), "needs validity start %d before ttl %d", result.lower, result.upper)
+		}
+		return result, nil
+	case *common.NativeScriptAny:
+		return e.evalAtLeast(1, s.Scripts, joinScriptPath(prefix, "any"))
+	case *common.NativeScriptNofK:
+		return e.evalAtLeast(s.N, s.Scripts, joinScriptPath(prefix, "atLeast"))
+	case *common.NativeScriptInvalidBefore:
+		path := joinScriptPath(prefix, "after")
+		result := nativeScriptResult{NativeScriptMatch: NativeScriptMatch{Paths: []string{path}}}
+		switch {
+		case e.start != 0:
+			if e.start < s.Slot {
+				return fail(path, "validity start %d is before slot %d", e.start, s.Slot)
+			}
+		case e.plan:
+			if s.Slot > e.tip {
+				return fail(path, "slot %d is after the current slot %d", s.Slot, e.tip)
+			}
+			result.lower, result.hasLower = s.Slot, true
+		default:
+			return fail(path, "validity start is unset, need at least slot %d", s.Slot)
+		}
+		return result, nil
+	case *common.NativeScriptInvalidHereafter:
+		path := joinScriptPath(prefix, "before")
+		result := nativeScriptResult{NativeScriptMatch: NativeScriptMatch{Paths: []string{path}}}
+		switch {
+		case e.ttl != 0:
+			if e.ttl > s.Slot {
+				return fail(path, "ttl %d is after slot %d", e.ttl, s.Slot)
+			}
+		case e.plan:
+			if s.Slot <= e.tip {
+				return fail(path, "slot %d has passed, the current slot is %d", s.Slot, e.tip)
+			}
+			result.upper, result.hasUpper = s.Slot, true
+		default:
+			return fail(path, "ttl is unset, need at most slot %d", s.Slot)
+		}
+		return result, nil
+	default:
+		return fail(joinScriptPath(prefix, "script"), "unsupported native script %T", s)
+	}
+}
+
+// evalAtLeast satisfies n of scripts, taking branches in order and skipping
+// any whose validity bounds conflict with the branches already taken.
+func (e *nativeScriptEvaluator) evalAtLeast(n uint, scripts []common.NativeScript, path string) (nativeScriptResult, error) {
+	var result nativeScriptResult
+	var causes []error
+	satisfied := uint(0)
+	for i := range scripts {
+		if satisfied >= n {
+			break
+		}
+		subResult, err := e.eval(scripts[i], fmt.Sprintf("%s[%d]", path, i))
+		if err != nil {
+			causes = append(causes, err)
+			continue
+		}
+		candidate := result
+		candidate.merge(subResult)
+		if candidate.hasLower && candidate.hasUpper && candidate.lower >= candidate.upper {
+			causes = append(causes, &NativeScriptError{
+				Path:   fmt.Sprintf("%s[%d]", path, i),
+				Reason: "validity bounds conflict with earlier branches",
+			})
+			continue
+		}
+		result = candidate
+		satisfied++
+	}
+	if satisfied < n {
+		reason := fmt.Sprintf("%d of %d branches satisfied, need %d", satisfied, len(scripts), n)
+		if n == 1 {
+			reason = "no branch is satisfied"
+		}
+		return nativeScriptResult{}, &NativeScriptError{Path: path, Reason: reason, Causes: causes}
+	}
+	return result, nil
+}
+
+// SetAutoValidityInterval makes Complete check every native script the
+// transaction carries against its validity interval and planned signers.
+// When the validity start or TTL is unset and a timelock needs it, Complete
+// sets it to the bound the first satisfiable branch requires, judged against
+// the chain tip. Co-signers outside the wallet are only known to Complete if
+// declared with AddRequiredSigner.
+func (a *Apollo) SetAutoValidityInterval(enabled bool) *Apollo {
+	a.autoValidityInterval = enabled
+	return a
+}
+
+// applyNativeScriptValidity fills in an unset validity start and TTL that the
+// transaction's native scripts need, then verifies every script.
+func (a *Apollo) applyNativeScriptValidity(inputs []common.Utxo) error {
+	scripts := a.witnessNativeScripts(inputs)
+	if len(scripts) == 0 {
+		return nil
+	}
+	var signers []common.Blake2b224
+	for hash := range a.vkeySignerHashes(inputs) {
+		signers = append(signers, hash)
+	}
+	signers = sortedSignerHashes(signers)
+	tip, err := backend.TipContext(a.requestContext, a.Context)
+	if err != nil {
+		return fmt.Errorf("get tip for native script validity: %w", err)
+	}
+
+	e := newNativeScriptEvaluator(signers)
+	e.start, e.ttl = uint64(a.ValidityStart), uint64(a.Ttl) //nolint:gosec // setters reject negative slots
+	e.plan, e.tip = true, tip
+	var bounds nativeScriptResult
+	for _, script := range scripts {
+		result, err := e.eval(script, "")
+		if err != nil {
+			return fmt.Errorf("script %s cannot be satisfied: %w", script.Hash().String(), err)
+		}
+		bounds.merge(result)
+	}
+	if bounds.hasLower && bounds.hasUpper && bounds.lower >= bounds.upper {
+		return fmt.Errorf(
+			"native scripts need validity start %d before ttl %d",
+			bounds.lower,
+			bounds.upper,
+		)
+	}
+	if a.ValidityStart == 0 && bounds.hasLower {
+		a.ValidityStart = int64(bounds.lower) //nolint:gosec // bounded by the chain tip
+	}
+	if a.Ttl == 0 && bounds.hasUpper {
+		a.Ttl = int64(bounds.upper) //nolint:gosec // slot numbers fit in int64
+	}
+
+	env := NativeScriptEnv{
+		ValidityStart: uint64(a.ValidityStart), //nolint:gosec // setters reject negative slots
+		Ttl:           uint64(a.Ttl),           //nolint:gosec // setters reject negative slots
+		Signers:       signers,
+	}
+	var errs []error
+	for _, script := range scripts {
+		if _, err := EvaluateNativeScript(script, env); err != nil {
+			errs = append(errs, fmt.Errorf("script %s: %w", script.Hash().String(), err))
+		}
+	}
+	return errors.Join(errs...)
+}
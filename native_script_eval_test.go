@@ -0,0 +1,128 @@
+package apollo
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/Salvionied/apollo/v2/backend/fixed"
+	"github.com/blinklabs-io/gouroboros/ledger/common"
+)
+
+// tipContext reports a fixed chain tip.
+type tipContext struct {
+	*fixed.FixedChainContext
+	slot uint64
+}
+
+func (c *tipContext) Tip() (uint64, error) {
+	return c.slot, nil
+}
+
+func (c *tipContext) TipContext(context.Context) (uint64, error) {
+	return c.slot, nil
+}
+
+func mustNativeScript(t *testing.T) func(common.NativeScript, error) common.NativeScript {
+	return func(script common.NativeScript, err error) common.NativeScript {
+		t.Helper()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return script
+	}
+}
+
+func TestEvaluateNativeScriptReportsBranch(t *testing.T) {
+	must := mustNativeScript(t)
+	signers := multisigTestSigners(t, 2)
+	a, b := signers[0].PubKeyHash(), signers[1].PubKeyHash()
+	script := must(NewNativeScriptAny([]common.NativeScript{
+		must(NewNativeScriptAll([]common.NativeScript{
+			must(NewNativeScriptPubkey(a)),
+			must(NewNativeScriptInvalidBefore(100)),
+		})),
+		must(NewNativeScriptPubkey(b)),
+	}))
+
+	match, err := EvaluateNativeScript(script, NativeScriptEnv{ValidityStart: 150, Signers: []common.Blake2b224{a}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(match.Paths, []string{"any[0].all[0].sig", "any[0].all[1].after"}) {
+		t.Fatalf("paths = %v", match.Paths)
+	}
+	if !slices.Equal(match.Signers, []common.Blake2b224{a}) {
+		t.Fatalf("signers = %v", match.Signers)
+	}
+
+	_, err = EvaluateNativeScript(script, NativeScriptEnv{ValidityStart: 50, Signers: []common.Blake2b224{a}})
+	var scriptErr *NativeScriptError
+	if !errors.As(err, &scriptErr) || scriptErr.Path != "any" {
+		t.Fatalf("expected a failure at the any branch, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "any[0].all[1].after: validity start 50 is before slot 100") {
+		t.Fatalf("error does not explain the timelock failure: %v", err)
+	}
+}
+
+func TestEvaluateNativeScriptCountsThreshold(t *testing.T) {
+	signers := multisigTestSigners(t, 3)
+	script := twoOfThreeScript(t, signers)
+	_, err := EvaluateNativeScript(script, NativeScriptEnv{Signers: []common.Blake2b224{signers[2].PubKeyHash()}})
+	if err == nil || !strings.HasPrefix(err.Error(), "native script atLeast: 1 of 3 branches satisfied, need 2") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	match, err := EvaluateNativeScript(script, NativeScriptEnv{Signers: []common.Blake2b224{
+		signers[0].PubKeyHash(), signers[2].PubKeyHash(),
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(match.Paths, []string{"atLeast[0].sig", "atLeast[2].sig"}) {
+		t.Fatalf("paths = %v", match.Paths)
+	}
+}
+
+func TestCompleteSetsValidityIntervalForNativeScript(t *testing.T) {
+	must := mustNativeScript(t)
+	signers := multisigTestSigners(t, 3)
+	script := must(NewNativeScriptAll([]common.NativeScript{
+		twoOfThreeScript(t, signers),
+		must(NewNativeScriptInvalidBefore(1_000)),
+		must(NewNativeScriptInvalidHereafter(5_000)),
+	}))
+	w, err := NewMultisigWallet(script, MultisigWalletConfig{NetworkId: common.AddressNetworkMainnet},
+		signers[0], signers[1], signers[2])
+	if err != nil {
+		t.Fatal(err)
+	}
+	cc := &tipContext{FixedChainContext: networkTestContext(t, common.AddressNetworkMainnet), slot: 2_000}
+	addTestUtxo(cc.FixedChainContext, w.Address(), 10_000_000, 0x01, 0)
+	payee := syntheticAddress(t, common.AddressTypeKeyKey, common.AddressNetworkMainnet)
+
+	a, err := New(cc).
+		SetWallet(w).
+		SetAutoValidityInterval(true).
+		PayToAddress(payee, 2_000_000).
+		Complete()
+	if err != nil {
+		t.Fatalf("Complete failed: %v", err)
+	}
+	body := a.GetTx().Body
+	if body.TxValidityIntervalStart != 1_000 || body.Ttl != 5_000 {
+		t.Fatalf("validity interval = [%d, %d), want [1000, 5000)", body.TxValidityIntervalStart, body.Ttl)
+	}
+
+	cc.slot = 6_000
+	_, err = New(cc).
+		SetWallet(w).
+		SetAutoValidityInterval(true).
+		PayToAddress(payee, 2_000_000).
+		Complete()
+	if err == nil || !strings.Contains(err.Error(), "all[2].before: slot 5000 has passed") {
+		t.Fatalf("expected an expired script to be reported, got %v", err)
+	}
+}
@@ -0,0 +1,27 @@
+package apollo
+
+import (
+	"errors"
+
+	"github.com/blinklabs-io/gouroboros/ledger/common"
+
+	"github.com/Salvionied/apollo/v2/plutusencoder"
+)
+
+// DatumFromJSON parses a datum in cardano-cli's detailed schema, the format
+// read by --tx-out-inline-datum-file.
+func DatumFromJSON(jsonData []byte) (*common.Datum, error) {
+	pd, err := plutusencoder.DataFromJSON(jsonData)
+	if err != nil {
+		return nil, err
+	}
+	return &common.Datum{Data: pd}, nil
+}
+
+// DatumToJSON renders a datum in cardano-cli's detailed schema.
+func DatumToJSON(datum *common.Datum) ([]byte, error) {
+	if datum == nil {
+		return nil, errors.New("datum is nil")
+	}
+	return plutusencoder.DataToJSON(datum.Data)
+}
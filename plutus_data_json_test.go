@@ -0,0 +1,74 @@
+package apollo
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+
+	"github.com/blinklabs-io/gouroboros/ledger/common"
+	"github.com/blinklabs-io/plutigo/data"
+)
+
+func TestDatumJSONRoundTrip(t *testing.T) {
+	input := `{"constructor":0,"fields":[{"bytes":"deadbeef"},{"int":1000000}]}`
+	datum, err := DatumFromJSON([]byte(input))
+	if err != nil {
+		t.Fatalf("DatumFromJSON: %v", err)
+	}
+	constr, ok := datum.Data.(*data.Constr)
+	if !ok || constr.Tag != 0 || len(constr.Fields) != 2 {
+		t.Fatalf("DatumFromJSON = %#v", datum.Data)
+	}
+
+	got, err := DatumToJSON(datum)
+	if err != nil {
+		t.Fatalf("DatumToJSON: %v", err)
+	}
+	if string(got) != input {
+		t.Fatalf("DatumToJSON = %s, want %s", got, input)
+	}
+}
+
+func TestDatumToJSONMatchesCBOR(t *testing.T) {
+	datum := &common.Datum{Data: data.NewConstr(1,
+		data.NewInteger(big.NewInt(42)),
+		data.NewByteString([]byte{0xde, 0xad}),
+		data.NewList(data.NewInteger(big.NewInt(-1))),
+		data.NewMap([][2]data.PlutusData{{data.NewByteString(nil), data.NewInteger(big.NewInt(7))}}),
+	)}
+	got, err := DatumToJSON(datum)
+	if err != nil {
+		t.Fatalf("DatumToJSON: %v", err)
+	}
+	want := `{"constructor":1,"fields":[{"int":42},{"bytes":"dead"},{"list":[{"int":-1}]},{"map":[{"k":{"bytes":""},"v":{"int":7}}]}]}`
+	if string(got) != want {
+		t.Fatalf("DatumToJSON = %s, want %s", got, want)
+	}
+
+	// The JSON must describe the same datum the chain sees.
+	decoded, err := DatumFromJSON(got)
+	if err != nil {
+		t.Fatalf("DatumFromJSON: %v", err)
+	}
+	wantCbor, err := DatumWireCbor(datum)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gotCbor, err := DatumWireCbor(decoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(gotCbor, wantCbor) {
+		t.Fatalf("datum CBOR after a JSON round trip = %x, want %x", gotCbor, wantCbor)
+	}
+
+	if _, err := DatumToJSON(nil); err == nil {
+		t.Fatal("DatumToJSON(nil) succeeded, want error")
+	}
+}
+
+func TestDatumFromJSONRejectsMalformed(t *testing.T) {
+	if _, err := DatumFromJSON([]byte(`{"int": "one"}`)); err == nil {
+		t.Fatal("DatumFromJSON succeeded, want error")
+	}
+}
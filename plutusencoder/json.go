@@ -0,0 +1,54 @@
+package plutusencoder
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/blinklabs-io/plutigo/data"
+)
+
+// DataToJSON renders pd in cardano-cli's detailed schema, the format taken
+// by --tx-out-inline-datum-file and friends:
+//
+//	{"constructor": 0, "fields": [{"int": 42}, {"bytes": "cafe"}]}
+//
+// Lists and maps render as {"list": [...]} and {"map": [{"k": ..., "v": ...}]}.
+func DataToJSON(pd data.PlutusData) ([]byte, error) {
+	if pd == nil {
+		return nil, errors.New("plutus data is nil")
+	}
+	b, err := data.EncodeJSON(pd)
+	if err != nil {
+		return nil, fmt.Errorf("encode plutus data JSON: %w", err)
+	}
+	return b, nil
+}
+
+// DataFromJSON parses Plutus data in cardano-cli's detailed schema.
+func DataFromJSON(b []byte) (data.PlutusData, error) {
+	pd, err := data.DecodeJSON(b)
+	if err != nil {
+		return nil, fmt.Errorf("decode plutus data JSON: %w", err)
+	}
+	return pd, nil
+}
+
+// MarshalJSON encodes v with MarshalPlutus and renders the result with
+// DataToJSON.
+func MarshalJSON(v any) ([]byte, error) {
+	pd, err := MarshalPlutus(v)
+	if err != nil {
+		return nil, err
+	}
+	return DataToJSON(pd)
+}
+
+// UnmarshalJSON parses detailed schema JSON with DataFromJSON and decodes the
+// result into v with UnmarshalPlutus.
+func UnmarshalJSON(b []byte, v any) error {
+	pd, err := DataFromJSON(b)
+	if err != nil {
+		return err
+	}
+	return UnmarshalPlutus(pd, v)
+}
@@ -0,0 +1,120 @@
+package plutusencoder
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/blinklabs-io/plutigo/data"
+)
+
+type jsonDatum struct {
+	_      struct{}         `plutusType:"IndefList" plutusConstr:"1"`
+	Amount int64            `plutusType:"Int"`
+	Owner  []byte           `plutusType:"Bytes"`
+	Tags   []int64          `plutusType:"DefList"`
+	Prices map[string]int64 `plutusType:"Map"`
+}
+
+func TestDataJSONRoundTrip(t *testing.T) {
+	pd := data.NewConstr(0,
+		data.NewInteger(big.NewInt(-7)),
+		data.NewByteString([]byte{0xca, 0xfe}),
+		data.NewList(data.NewInteger(big.NewInt(1))),
+		data.NewMap([][2]data.PlutusData{
+			{data.NewByteString([]byte{0x01}), data.NewInteger(big.NewInt(2))},
+		}),
+	)
+	want := `{"constructor":0,"fields":[{"int":-7},{"bytes":"cafe"},{"list":[{"int":1}]},{"map":[{"k":{"bytes":"01"},"v":{"int":2}}]}]}`
+
+	got, err := DataToJSON(pd)
+	if err != nil {
+		t.Fatalf("DataToJSON: %v", err)
+	}
+	if string(got) != want {
+		t.Fatalf("DataToJSON = %s, want %s", got, want)
+	}
+
+	decoded, err := DataFromJSON(got)
+	if err != nil {
+		t.Fatalf("DataFromJSON: %v", err)
+	}
+	if encodeHex(t, decoded) != encodeHex(t, pd) {
+		t.Fatalf("round trip CBOR = %s, want %s", encodeHex(t, decoded), encodeHex(t, pd))
+	}
+}
+
+func TestDataFromJSONBigInteger(t *testing.T) {
+	pd, err := DataFromJSON([]byte(`{"int": 340282366920938463463374607431768211456}`))
+	if err != nil {
+		t.Fatalf("DataFromJSON: %v", err)
+	}
+	integer, ok := pd.(*data.Integer)
+	if !ok {
+		t.Fatalf("DataFromJSON type = %T, want *data.Integer", pd)
+	}
+	if integer.Inner.String() != "340282366920938463463374607431768211456" {
+		t.Fatalf("integer = %s", integer.Inner)
+	}
+}
+
+func TestDataFromJSONRejectsInvalid(t *testing.T) {
+	for name, input := range map[string]string{
+		"unknown key":   `{"string": "x"}`,
+		"ambiguous":     `{"int": 1, "bytes": "00"}`,
+		"bad hex":       `{"bytes": "zz"}`,
+		"no fields":     `{"constructor": 0}`,
+		"trailing data": `{"int": 1} {"int": 2}`,
+		"not object":    `[1]`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := DataFromJSON([]byte(input))
+			if err == nil {
+				t.Fatal("DataFromJSON succeeded, want error")
+			}
+			if !strings.HasPrefix(err.Error(), "decode plutus data JSON: ") {
+				t.Fatalf("error = %v", err)
+			}
+		})
+	}
+}
+
+func TestDataToJSONRejectsNil(t *testing.T) {
+	if _, err := DataToJSON(nil); err == nil {
+		t.Fatal("DataToJSON(nil) succeeded, want error")
+	}
+}
+
+func TestStructJSONRoundTrip(t *testing.T) {
+	in := jsonDatum{
+		Amount: 100,
+		Owner:  []byte{0xab},
+		Tags:   []int64{3, 4},
+		Prices: map[string]int64{"ada": 1},
+	}
+	got, err := MarshalJSON(in)
+	if err != nil {
+		t.Fatalf("MarshalJSON: %v", err)
+	}
+	want := `{"constructor":1,"fields":[{"int":100},{"bytes":"ab"},{"list":[{"int":3},{"int":4}]},{"map":[{"k":{"bytes":"616461"},"v":{"int":1}}]}]}`
+	if string(got) != want {
+		t.Fatalf("MarshalJSON = %s, want %s", got, want)
+	}
+
+	var out jsonDatum
+	if err := UnmarshalJSON(got, &out); err != nil {
+		t.Fatalf("UnmarshalJSON: %v", err)
+	}
+	if out.Amount != in.Amount || string(out.Owner) != string(in.Owner) ||
+		len(out.Tags) != 2 || out.Tags[1] != 4 || out.Prices["ada"] != 1 {
+		t.Fatalf("UnmarshalJSON = %+v, want %+v", out, in)
+	}
+}
+
+func TestUnmarshalJSONWrongConstructor(t *testing.T) {
+	var out jsonDatum
+	err := UnmarshalJSON([]byte(`{"constructor":0,"fields":[]}`), &out)
+	if err == nil {
+		t.Fatal("UnmarshalJSON succeeded, want constructor mismatch")
+	}
+}
@@ -0,0 +1,552 @@
+package plutusencoder
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"reflect"
+	"time"
+
+	"github.com/blinklabs-io/gouroboros/cbor"
+	"github.com/blinklabs-io/gouroboros/ledger/common"
+	"github.com/blinklabs-io/gouroboros/ledger/shelley"
+	"github.com/blinklabs-io/plutigo/data"
+)
+
+// This file holds the Plutus ledger API encodings of Cardano types. Types
+// whose Plutus form carries everything needed to rebuild them are field
+// kinds:
+//
+//	Owner    common.Credential               `plutusType:"Credential"`
+//	Ref      shelley.ShelleyTransactionInput `plutusType:"OutputReference"`
+//	Deadline time.Time                       `plutusType:"POSIXTime"`
+//	Price    *big.Rat                        `plutusType:"Rational"`
+//
+// OutputReference is the Plutus V3 shape; OutputReferenceV2 wraps the
+// transaction id in a TxId constructor as Plutus V1 and V2 do. Address,
+// Value and Maybe are helper types implementing PlutusMarshaler.
+
+// Address is the Plutus form of a Shelley address,
+// Address{Credential, Maybe StakingCredential}. It carries no network, so
+// ToAddress takes one.
+type Address struct {
+	Payment common.Credential
+	// Stake is nil for an enterprise address.
+	Stake *StakingCredential
+}
+
+// StakingCredential is a StakingHash of Credential, or a StakingPtr when
+// Pointer is set.
+type StakingCredential struct {
+	Credential common.Credential
+	Pointer    *common.AddressPayloadPointer
+}
+
+// NewAddress converts a Shelley address. Byron and reward addresses have no
+// Plutus Address form.
+func NewAddress(addr common.Address) (Address, error) {
+	var ret Address
+	switch addr.Type() {
+	case common.AddressTypeKeyKey, common.AddressTypeKeyScript,
+		common.AddressTypeKeyPointer, common.AddressTypeKeyNone:
+		ret.Payment.CredType = common.CredentialTypeAddrKeyHash
+	case common.AddressTypeScriptKey, common.AddressTypeScriptScript,
+		common.AddressTypeScriptPointer, common.AddressTypeScriptNone:
+		ret.Payment.CredType = common.CredentialTypeScriptHash
+	default:
+		return Address{}, fmt.Errorf("address type %d has no Plutus form", addr.Type())
+	}
+	ret.Payment.Credential = addr.PaymentKeyHash()
+	switch payload := addr.StakingPayload().(type) {
+	case nil:
+	case common.AddressPayloadPointer:
+		ret.Stake = &StakingCredential{Pointer: &payload}
+	default:
+		cred, ok := addr.StakeCredential()
+		if !ok {
+			return Address{}, fmt.Errorf("unsupported staking payload %T", payload)
+		}
+		ret.Stake = &StakingCredential{Credential: cred}
+	}
+	return ret, nil
+}
+
+// ToAddress builds the Shelley address on networkId.
+func (a Address) ToAddress(networkId uint8) (common.Address, error) {
+	script := a.Payment.CredType == common.CredentialTypeScriptHash
+	var addrType uint8
+	var stake []byte
+	switch {
+	case a.Stake == nil:
+		addrType = common.AddressTypeKeyNone
+	case a.Stake.Pointer != nil:
+		addrType = common.AddressTypeKeyPointer
+		stake = encodePointer(*a.Stake.Pointer)
+	case a.Stake.Credential.CredType == common.CredentialTypeScriptHash:
+		addrType = common.AddressTypeKeyScript
+		stake = a.Stake.Credential.Credential.Bytes()
+	default:
+		addrType = common.AddressTypeKeyKey
+		stake = a.Stake.Credential.Credential.Bytes()
+	}
+	if script {
+		// Script payment types are the key types with the low bit set.
+		addrType |= 0b0001
+	}
+	return common.NewAddressFromParts(addrType, networkId, a.Payment.Credential.Bytes(), stake)
+}
+
+// encodePointer writes a stake pointer as three variable-length naturals.
+func encodePointer(p common.AddressPayloadPointer) []byte {
+	var out []byte
+	for _, n := range []uint64{p.Slot, p.TxIndex, p.CertIndex} {
+		chunk := []byte{byte(n & 0x7f)}
+		for n >>= 7; n > 0; n >>= 7 {
+			chunk = append([]byte{byte(n&0x7f) | 0x80}, chunk...)
+		}
+		out = append(out, chunk...)
+	}
+	return out
+}
+
+// ToPlutusData encodes the address.
+func (a Address) ToPlutusData() (data.PlutusData, error) {
+	payment, err := credentialToData(a.Payment)
+	if err != nil {
+		return nil, err
+	}
+	if a.Stake == nil {
+		return data.NewConstr(0, payment, data.NewConstr(1)), nil
+	}
+	var stake data.PlutusData
+	if p := a.Stake.Pointer; p != nil {
+		stake = data.NewConstr(1,
+			data.NewInteger(new(big.Int).SetUint64(p.Slot)),
+			data.NewInteger(new(big.Int).SetUint64(p.TxIndex)),
+			data.NewInteger(new(big.Int).SetUint64(p.CertIndex)),
+		)
+	} else {
+		cred, err := credentialToData(a.Stake.Credential)
+		if err != nil {
+			return nil, err
+		}
+		stake = data.NewConstr(0, cred)
+	}
+	return data.NewConstr(0, payment, data.NewConstr(0, stake)), nil
+}
+
+// FromPlutusData decodes an address.
+func (Address) FromPlutusData(pd data.PlutusData, res any) error {
+	target, ok := res.(*Address)
+	if !ok {
+		return fmt.Errorf("expected *Address result, got %T", res)
+	}
+	fields, err := constrFields(pd, 0, 2)
+	if err != nil {
+		return fmt.Errorf("Address: %w", err)
+	}
+	var ret Address
+	if ret.Payment, err = credentialFromData(fields[0]); err != nil {
+		return fmt.Errorf("Address payment: %w", err)
+	}
+	var stake Maybe[data.PlutusData]
+	if err := stake.FromPlutusData(fields[1], &stake); err != nil {
+		return fmt.Errorf("Address stake: %w", err)
+	}
+	if stake.Valid {
+		staking, ok := stake.Value.(*data.Constr)
+		if !ok {
+			return fmt.Errorf("StakingCredential: expected Constr, got %T", stake.Value)
+		}
+		switch staking.Tag {
+		case 0:
+			fields, err := constrFields(staking, 0, 1)
+			if err != nil {
+				return fmt.Errorf("StakingHash: %w", err)
+			}
+			cred, err := credentialFromData(fields[0])
+			if err != nil {
+				return fmt.Errorf("StakingHash: %w", err)
+			}
+			ret.Stake = &StakingCredential{Credential: cred}
+		case 1:
+			fields, err := constrFields(staking, 1, 3)
+			if err != nil {
+				return fmt.Errorf("StakingPtr: %w", err)
+			}
+			var parts [3]uint64
+			for i, field := range fields {
+				if parts[i], err = uint64FromData(field); err != nil {
+					return fmt.Errorf("StakingPtr: %w", err)
+				}
+			}
+			ret.Stake = &StakingCredential{Pointer: &common.AddressPayloadPointer{
+				Slot: parts[0], TxIndex: parts[1], CertIndex: parts[2],
+			}}
+		default:
+			return fmt.Errorf("StakingCredential: unknown constructor %d", staking.Tag)
+		}
+	}
+	*target = ret
+	return nil
+}
+
+// Value is the Plutus form of a value: a map from policy id to token name to
+// quantity, with lovelace under the empty policy id and token name. Lovelace
+// is omitted when Coin is zero, as in minted values. Its fields match
+// apollo.Value, so the two convert directly.
+type Value struct {
+	Coin   uint64
+	Assets *common.MultiAsset[common.MultiAssetTypeOutput]
+}
+
+// ToPlutusData encodes the value with policies and token names sorted.
+func (v Value) ToPlutusData() (data.PlutusData, error) {
+	var pairs [][2]data.PlutusData
+	if v.Coin > 0 {
+		pairs = append(pairs, [2]data.PlutusData{
+			data.NewByteString(nil),
+			data.NewMap([][2]data.PlutusData{{
+				data.NewByteString(nil),
+				data.NewInteger(new(big.Int).SetUint64(v.Coin)),
+			}}),
+		})
+	}
+	if v.Assets != nil {
+		assets, ok := v.Assets.ToPlutusData().(*data.Map)
+		if !ok {
+			return nil, errors.New("Value: unexpected multi-asset encoding")
+		}
+		pairs = append(pairs, assets.Pairs...)
+	}
+	return data.NewMap(pairs), nil
+}
+
+// FromPlutusData decodes a value. Quantities must be non-negative.
+func (Value) FromPlutusData(pd data.PlutusData, res any) error {
+	target, ok := res.(*Value)
+	if !ok {
+		return fmt.Errorf("expected *Value result, got %T", res)
+	}
+	policies, ok := pd.(*data.Map)
+	if !ok {
+		return fmt.Errorf("Value: expected Map, got %T", pd)
+	}
+	var ret Value
+	assets := make(map[common.Blake2b224]map[cbor.ByteString]common.MultiAssetTypeOutput)
+	for _, policyPair := range policies.Pairs {
+		policy, ok := policyPair[0].(*data.ByteString)
+		if !ok {
+			return fmt.Errorf("Value policy: expected ByteString, got %T", policyPair[0])
+		}
+		tokens, ok := policyPair[1].(*data.Map)
+		if !ok {
+			return fmt.Errorf("Value policy %x: expected Map, got %T", policy.Inner, policyPair[1])
+		}
+		if len(policy.Inner) != 0 && len(policy.Inner) != common.Blake2b224Size {
+			return fmt.Errorf("Value policy %x: expected %d bytes", policy.Inner, common.Blake2b224Size)
+		}
+		for _, tokenPair := range tokens.Pairs {
+			name, ok := tokenPair[0].(*data.ByteString)
+			if !ok {
+				return fmt.Errorf("Value policy %x: expected token name ByteString, got %T", policy.Inner, tokenPair[0])
+			}
+			if len(policy.Inner) == 0 {
+				if len(name.Inner) != 0 {
+					return fmt.Errorf("Value: lovelace has token name %x", name.Inner)
+				}
+				coin, err := uint64FromData(tokenPair[1])
+				if err != nil {
+					return fmt.Errorf("Value lovelace: %w", err)
+				}
+				ret.Coin = coin
+				continue
+			}
+			quantity, ok := tokenPair[1].(*data.Integer)
+			if !ok {
+				return fmt.Errorf("Value %x.%x: expected Integer, got %T", policy.Inner, name.Inner, tokenPair[1])
+			}
+			if quantity.Inner.Sign() < 0 {
+				return fmt.Errorf("Value %x.%x: negative quantity %s", policy.Inner, name.Inner, quantity.Inner.String())
+			}
+			id := common.NewBlake2b224(policy.Inner)
+			if assets[id] == nil {
+				assets[id] = make(map[cbor.ByteString]common.MultiAssetTypeOutput)
+			}
+			assets[id][cbor.NewByteString(name.Inner)] = new(big.Int).Set(quantity.Inner)
+		}
+	}
+	if len(assets) > 0 {
+		multiAsset := common.NewMultiAsset(assets)
+		ret.Assets = &multiAsset
+	}
+	*target = ret
+	return nil
+}
+
+// Maybe is the Plutus Maybe, Aiken's Option: Just holds Value under
+// constructor 0 and Nothing is constructor 1. Value is encoded like a slice
+// element, so T may be a struct, integer, *big.Int, string, []byte, a
+// registered sum type or a PlutusMarshaler.
+type Maybe[T any] struct {
+	Valid bool
+	Value T
+}
+
+// Just returns a Maybe holding v.
+func Just[T any](v T) Maybe[T] {
+	return Maybe[T]{Valid: true, Value: v}
+}
+
+// ToPlutusData encodes Just or Nothing.
+func (m Maybe[T]) ToPlutusData() (data.PlutusData, error) {
+	if !m.Valid {
+		return data.NewConstr(1), nil
+	}
+	if pd, ok := any(m.Value).(data.PlutusData); ok {
+		return data.NewConstr(0, pd), nil
+	}
+	pd, err := marshalSliceElement(reflect.ValueOf(&m.Value).Elem())
+	if err != nil {
+		return nil, fmt.Errorf("Just: %w", err)
+	}
+	return data.NewConstr(0, pd), nil
+}
+
+// FromPlutusData decodes Just or Nothing.
+func (Maybe[T]) FromPlutusData(pd data.PlutusData, res any) error {
+	target, ok := res.(*Maybe[T])
+	if !ok {
+		return fmt.Errorf("expected *Maybe result, got %T", res)
+	}
+	constr, ok := pd.(*data.Constr)
+	if !ok {
+		return fmt.Errorf("Maybe: expected Constr, got %T", pd)
+	}
+	switch constr.Tag {
+	case 0:
+		if len(constr.Fields) != 1 {
+			return fmt.Errorf("Just: expected 1 field, got %d", len(constr.Fields))
+		}
+		var v T
+		if raw, ok := any(&v).(*data.PlutusData); ok {
+			*raw = constr.Fields[0]
+		} else if err := unmarshalSliceElement(constr.Fields[0], reflect.ValueOf(&v).Elem()); err != nil {
+			return fmt.Errorf("Just: %w", err)
+		}
+		*target = Maybe[T]{Valid: true, Value: v}
+	case 1:
+		*target = Maybe[T]{}
+	default:
+		return fmt.Errorf("Maybe: unknown constructor %d", constr.Tag)
+	}
+	return nil
+}
+
+func credentialToData(c common.Credential) (data.PlutusData, error) {
+	switch c.CredType {
+	case common.CredentialTypeAddrKeyHash:
+		return data.NewConstr(0, data.NewByteString(c.Credential.Bytes())), nil
+	case common.CredentialTypeScriptHash:
+		return data.NewConstr(1, data.NewByteString(c.Credential.Bytes())), nil
+	default:
+		return nil, fmt.Errorf("unsupported credential type %d", c.CredType)
+	}
+}
+
+func credentialFromData(pd data.PlutusData) (common.Credential, error) {
+	constr, ok := pd.(*data.Constr)
+	if !ok {
+		return common.Credential{}, fmt.Errorf("Credential: expected Constr, got %T", pd)
+	}
+	var credType uint
+	switch constr.Tag {
+	case 0:
+		credType = common.CredentialTypeAddrKeyHash
+	case 1:
+		credType = common.CredentialTypeScriptHash
+	default:
+		return common.Credential{}, fmt.Errorf("Credential: unknown constructor %d", constr.Tag)
+	}
+	hash, err := hashFromFields(constr.Fields)
+	if err != nil {
+		return common.Credential{}, fmt.Errorf("Credential: %w", err)
+	}
+	return common.Credential{CredType: credType, Credential: hash}, nil
+}
+
+func hashFromFields(fields []data.PlutusData) (common.Blake2b224, error) {
+	if len(fields) != 1 {
+		return common.Blake2b224{}, fmt.Errorf("expected 1 field, got %d", len(fields))
+	}
+	bs, ok := fields[0].(*data.ByteString)
+	if !ok {
+		return common.Blake2b224{}, fmt.Errorf("expected ByteString, got %T", fields[0])
+	}
+	if len(bs.Inner) != common.Blake2b224Size {
+		return common.Blake2b224{}, fmt.Errorf("expected a %d-byte hash, got %d bytes", common.Blake2b224Size, len(bs.Inner))
+	}
+	return common.NewBlake2b224(bs.Inner), nil
+}
+
+// constrFields checks pd is constructor tag with n fields and returns them.
+func constrFields(pd data.PlutusData, tag uint, n int) ([]data.PlutusData, error) {
+	constr, ok := pd.(*data.Constr)
+	if !ok {
+		return nil, fmt.Errorf("expected Constr, got %T", pd)
+	}
+	if constr.Tag != tag {
+		return nil, fmt.Errorf("expected Constr tag %d, got %d", tag, constr.Tag)
+	}
+	if len(constr.Fields) != n {
+		return nil, fmt.Errorf("expected %d fields, got %d", n, len(constr.Fields))
+	}
+	return constr.Fields, nil
+}
+
+func uint64FromData(pd data.PlutusData) (uint64, error) {
+	integer, ok := pd.(*data.Integer)
+	if !ok {
+		return 0, fmt.Errorf("expected Integer, got %T", pd)
+	}
+	if integer.Inner.Sign() < 0 || !integer.Inner.IsUint64() {
+		return 0, fmt.Errorf("integer value %s does not fit in uint64", integer.Inner.String())
+	}
+	return integer.Inner.Uint64(), nil
+}
+
+func marshalCredential(val reflect.Value) (data.PlutusData, error) {
+	cred, ok := val.Interface().(common.Credential)
+	if !ok {
+		return nil, fmt.Errorf("Credential tag requires common.Credential, got %s", val.Type())
+	}
+	return credentialToData(cred)
+}
+
+func unmarshalCredential(pd data.PlutusData, val reflect.Value) error {
+	if val.Type() != reflect.TypeFor[common.Credential]() {
+		return fmt.Errorf("Credential tag requires common.Credential, got %s", val.Type())
+	}
+	cred, err := credentialFromData(pd)
+	if err != nil {
+		return err
+	}
+	val.Set(reflect.ValueOf(cred))
+	return nil
+}
+
+func marshalOutputReference(val reflect.Value, v2 bool) (data.PlutusData, error) {
+	input, ok := val.Interface().(shelley.ShelleyTransactionInput)
+	if !ok {
+		return nil, fmt.Errorf("OutputReference tag requires shelley.ShelleyTransactionInput, got %s", val.Type())
+	}
+	var txId data.PlutusData = data.NewByteString(input.TxId.Bytes())
+	if v2 {
+		txId = data.NewConstr(0, txId)
+	}
+	return data.NewConstr(0, txId, data.NewInteger(big.NewInt(int64(input.OutputIndex)))), nil
+}
+
+func unmarshalOutputReference(pd data.PlutusData, val reflect.Value, v2 bool) error {
+	if val.Type() != reflect.TypeFor[shelley.ShelleyTransactionInput]() {
+		return fmt.Errorf("OutputReference tag requires shelley.ShelleyTransactionInput, got %s", val.Type())
+	}
+	fields, err := constrFields(pd, 0, 2)
+	if err != nil {
+		return fmt.Errorf("OutputReference: %w", err)
+	}
+	txId := fields[0]
+	if v2 {
+		inner, err := constrFields(txId, 0, 1)
+		if err != nil {
+			return fmt.Errorf("TxId: %w", err)
+		}
+		txId = inner[0]
+	}
+	bs, ok := txId.(*data.ByteString)
+	if !ok {
+		return fmt.Errorf("OutputReference transaction id: expected ByteString, got %T", txId)
+	}
+	if len(bs.Inner) != common.Blake2b256Size {
+		return fmt.Errorf("OutputReference transaction id: expected %d bytes, got %d", common.Blake2b256Size, len(bs.Inner))
+	}
+	index, err := uint64FromData(fields[1])
+	if err != nil {
+		return fmt.Errorf("OutputReference index: %w", err)
+	}
+	if index > math.MaxUint32 {
+		return fmt.Errorf("OutputReference index %d does not fit in uint32", index)
+	}
+	val.Set(reflect.ValueOf(shelley.ShelleyTransactionInput{
+		TxId:        common.NewBlake2b256(bs.Inner),
+		OutputIndex: uint32(index),
+	}))
+	return nil
+}
+
+func marshalPOSIXTime(val reflect.Value) (data.PlutusData, error) {
+	t, ok := val.Interface().(time.Time)
+	if !ok {
+		return nil, fmt.Errorf("POSIXTime tag requires time.Time, got %s", val.Type())
+	}
+	return data.NewInteger(big.NewInt(t.UnixMilli())), nil
+}
+
+func unmarshalPOSIXTime(pd data.PlutusData, val reflect.Value) error {
+	if val.Type() != reflect.TypeFor[time.Time]() {
+		return fmt.Errorf("POSIXTime tag requires time.Time, got %s", val.Type())
+	}
+	integer, ok := pd.(*data.Integer)
+	if !ok {
+		return fmt.Errorf("expected Integer, got %T", pd)
+	}
+	if !integer.Inner.IsInt64() {
+		return fmt.Errorf("POSIX time %s is out of range", integer.Inner.String())
+	}
+	val.Set(reflect.ValueOf(time.UnixMilli(integer.Inner.Int64()).UTC()))
+	return nil
+}
+
+// marshalRational encodes a rational as Constr 0 [numerator, denominator],
+// the encoding of PlutusTx's Rational and Aiken's rational module.
+func marshalRational(val reflect.Value) (data.PlutusData, error) {
+	if val.Type() != reflect.TypeFor[big.Rat]() {
+		return nil, fmt.Errorf("Rational tag requires *big.Rat or big.Rat, got %s", val.Type())
+	}
+	rat := new(big.Rat).Set(valueAddr(val).Interface().(*big.Rat))
+	return data.NewConstr(0, data.NewInteger(rat.Num()), data.NewInteger(rat.Denom())), nil
+}
+
+func unmarshalRational(pd data.PlutusData, val reflect.Value) error {
+	if val.Type() != reflect.TypeFor[big.Rat]() {
+		return fmt.Errorf("Rational tag requires *big.Rat or big.Rat, got %s", val.Type())
+	}
+	fields, err := constrFields(pd, 0, 2)
+	if err != nil {
+		return fmt.Errorf("Rational: %w", err)
+	}
+	num, ok := fields[0].(*data.Integer)
+	if !ok {
+		return fmt.Errorf("Rational numerator: expected Integer, got %T", fields[0])
+	}
+	den, ok := fields[1].(*data.Integer)
+	if !ok {
+		return fmt.Errorf("Rational denominator: expected Integer, got %T", fields[1])
+	}
+	if den.Inner.Sign() <= 0 {
+		return fmt.Errorf("Rational denominator %s is not positive", den.Inner.String())
+	}
+	val.Addr().Interface().(*big.Rat).SetFrac(num.Inner, den.Inner)
+	return nil
+}
+
+// valueAddr returns a pointer to val, copying it if it is not addressable.
+func valueAddr(val reflect.Value) reflect.Value {
+	if val.CanAddr() {
+		return val.Addr()
+	}
+	ptr := reflect.New(val.Type())
+	ptr.Elem().Set(val)
+	return ptr
+}
@@ -0,0 +1,148 @@
+package plutusencoder
+
+import (
+	"bytes"
+	"encoding/hex"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/blinklabs-io/gouroboros/cbor"
+	"github.com/blinklabs-io/gouroboros/ledger/common"
+	"github.com/blinklabs-io/gouroboros/ledger/shelley"
+	"github.com/blinklabs-io/plutigo/data"
+)
+
+type ledgerDatum struct {
+	_        struct{}                        `plutusType:"IndefList" plutusConstr:"0"`
+	Owner    common.Credential               `plutusType:"Credential"`
+	Ref      shelley.ShelleyTransactionInput `plutusType:"OutputReference"`
+	RefV2    shelley.ShelleyTransactionInput `plutusType:"OutputReferenceV2"`
+	Deadline time.Time                       `plutusType:"POSIXTime"`
+	Price    *big.Rat                        `plutusType:"Rational"`
+	Limit    Maybe[*big.Int]
+	Payee    Address
+	Locked   Value
+}
+
+func encodeHex(t *testing.T, pd data.PlutusData) string {
+	t.Helper()
+	encoded, err := data.Encode(pd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return hex.EncodeToString(encoded)
+}
+
+func TestLedgerTypesRoundTrip(t *testing.T) {
+	hash := common.NewBlake2b224(bytes.Repeat([]byte{0x11}, 28))
+	addr, err := common.NewAddressFromParts(common.AddressTypeScriptKey, common.AddressNetworkTestnet,
+		hash.Bytes(), bytes.Repeat([]byte{0x22}, 28))
+	if err != nil {
+		t.Fatal(err)
+	}
+	payee, err := NewAddress(addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	policy := common.NewBlake2b224(bytes.Repeat([]byte{0x33}, 28))
+	assets := common.NewMultiAsset(map[common.Blake2b224]map[cbor.ByteString]common.MultiAssetTypeOutput{
+		policy: {cbor.NewByteString([]byte("tok")): big.NewInt(5)},
+	})
+	input := shelley.ShelleyTransactionInput{TxId: common.NewBlake2b256(bytes.Repeat([]byte{0x44}, 32)), OutputIndex: 3}
+	datum := ledgerDatum{
+		Owner:    common.Credential{CredType: common.CredentialTypeAddrKeyHash, Credential: hash},
+		Ref:      input,
+		RefV2:    input,
+		Deadline: time.UnixMilli(1_700_000_000_123).UTC(),
+		Price:    big.NewRat(3, 4),
+		Limit:    Just(big.NewInt(9)),
+		Payee:    payee,
+		Locked:   Value{Coin: 2_000_000, Assets: &assets},
+	}
+
+	pd, err := MarshalPlutus(datum)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fields := pd.(*data.Constr).Fields
+	// The address encoding must agree with gouroboros' own ScriptContext form.
+	if got, want := encodeHex(t, fields[6]), encodeHex(t, addr.ToPlutusData()); got != want {
+		t.Fatalf("address = %s, want %s", got, want)
+	}
+	txId := "58204444444444444444444444444444444444444444444444444444444444444444"
+	if got := encodeHex(t, fields[1]); got != "d8799f"+txId+"03ff" {
+		t.Fatalf("V3 output reference = %s", got)
+	}
+	if got := encodeHex(t, fields[2]); got != "d8799fd8799f"+txId+"ff03ff" {
+		t.Fatalf("V2 output reference = %s", got)
+	}
+	if got := encodeHex(t, fields[4]); got != "d8799f0304ff" {
+		t.Fatalf("rational = %s", got)
+	}
+	if got := encodeHex(t, fields[5]); got != "d8799f09ff" {
+		t.Fatalf("maybe = %s", got)
+	}
+	if got := encodeHex(t, fields[7]); got != "a240a1401a001e8480581c"+hex.EncodeToString(policy.Bytes())+"a143746f6b05" {
+		t.Fatalf("value = %s", got)
+	}
+
+	var decoded ledgerDatum
+	if err := UnmarshalPlutus(pd, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Owner.Credential != hash || decoded.Ref != input || decoded.RefV2 != input {
+		t.Fatalf("decoded = %+v", decoded)
+	}
+	if !decoded.Deadline.Equal(datum.Deadline) || decoded.Price.Cmp(datum.Price) != 0 {
+		t.Fatalf("decoded deadline/price = %v %v", decoded.Deadline, decoded.Price)
+	}
+	if !decoded.Limit.Valid || decoded.Limit.Value.Int64() != 9 {
+		t.Fatalf("decoded limit = %+v", decoded.Limit)
+	}
+	back, err := decoded.Payee.ToAddress(common.AddressNetworkTestnet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if back.String() != addr.String() {
+		t.Fatalf("address round trip = %s, want %s", back.String(), addr.String())
+	}
+	if decoded.Locked.Coin != 2_000_000 || !decoded.Locked.Assets.Compare(&assets) {
+		t.Fatalf("decoded value = %+v", decoded.Locked)
+	}
+}
+
+func TestAddressStakePointerAndEnterprise(t *testing.T) {
+	hash := bytes.Repeat([]byte{0x11}, 28)
+	pointer := Address{
+		Payment: common.Credential{CredType: common.CredentialTypeAddrKeyHash, Credential: common.NewBlake2b224(hash)},
+		Stake:   &StakingCredential{Pointer: &common.AddressPayloadPointer{Slot: 2498243, TxIndex: 27, CertIndex: 3}},
+	}
+	addr, err := pointer.ToAddress(common.AddressNetworkMainnet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, err := NewAddress(addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again.Stake == nil || again.Stake.Pointer == nil || *again.Stake.Pointer != *pointer.Stake.Pointer {
+		t.Fatalf("pointer round trip = %+v", again.Stake)
+	}
+
+	enterprise := Address{Payment: common.Credential{CredType: common.CredentialTypeScriptHash, Credential: common.NewBlake2b224(hash)}}
+	pd, err := MarshalPlutus(struct {
+		_    struct{} `plutusType:"DefList"`
+		Addr Address
+	}{Addr: enterprise})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := encodeHex(t, pd); got != "81d8799fd87a9f581c"+hex.EncodeToString(hash)+"ffd87a80ff" {
+		t.Fatalf("enterprise address = %s", got)
+	}
+	var nothing Maybe[int64]
+	if err := nothing.FromPlutusData(data.NewConstr(1), &nothing); err != nil || nothing.Valid {
+		t.Fatalf("Nothing decoded as %+v, %v", nothing, err)
+	}
+}
@@ -72,12 +72,18 @@ func marshalSliceOrNested(val reflect.Value, field reflect.StructField, useIndef
 
 // marshalSliceElement marshals a single slice element, handling both struct and primitive types.
 func marshalSliceElement(elem reflect.Value) (data.PlutusData, error) {
+	if isSumType(elem.Type()) {
+		return marshalSum(elem)
+	}
 	for elem.Kind() == reflect.Pointer {
 		if elem.IsNil() {
 			return nil, errors.New("nil pointer in slice")
 		}
 		elem = elem.Elem()
 	}
+	if elem.Type() == reflect.TypeFor[big.Int]() {
+		return marshalBigInt(elem)
+	}
 
 	if elem.CanAddr() {
 		if m, ok := elem.Addr().Interface().(PlutusMarshaler); ok {
@@ -212,6 +218,18 @@ func unmarshalSliceOrNested(pd data.PlutusData, fieldVal reflect.Value, field re
 
 // unmarshalSliceElement unmarshals a single slice element, handling both struct and primitive types.
 func unmarshalSliceElement(pd data.PlutusData, elem reflect.Value) error {
+	if isSumType(elem.Type()) {
+		return unmarshalSum(pd, elem)
+	}
+	if elem.Kind() == reflect.Pointer {
+		if elem.IsNil() {
+			elem.Set(reflect.New(elem.Type().Elem()))
+		}
+		elem = elem.Elem()
+	}
+	if elem.Type() == reflect.TypeFor[big.Int]() {
+		return unmarshalBigInt(pd, elem)
+	}
 	if elem.CanAddr() {
 		if m, ok := elem.Addr().Interface().(PlutusMarshaler); ok {
 			return m.FromPlutusData(pd, elem.Addr().Interface())
@@ -72,6 +72,9 @@ func marshalField(fieldVal reflect.Value, field reflect.StructField) (data.Plutu
 	if plutusType == "BigInt" {
 		return marshalBigInt(fieldVal)
 	}
+	if isSumType(fieldVal.Type()) {
+		return marshalSum(fieldVal)
+	}
 
 	// Dereference pointers
 	for fieldVal.Kind() == reflect.Pointer {
@@ -110,6 +113,16 @@ func marshalField(fieldVal reflect.Value, field reflect.StructField) (data.Plutu
 		return marshalSliceOrNested(fieldVal, field, false)
 	case "Map":
 		return marshalSliceAsMap(fieldVal, field)
+	case "Credential":
+		return marshalCredential(fieldVal)
+	case "OutputReference":
+		return marshalOutputReference(fieldVal, false)
+	case "OutputReferenceV2":
+		return marshalOutputReference(fieldVal, true)
+	case "POSIXTime":
+		return marshalPOSIXTime(fieldVal)
+	case "Rational":
+		return marshalRational(fieldVal)
 	case "Custom":
 		return nil, fmt.Errorf("field %s tagged Custom but doesn't implement PlutusMarshaler", field.Name)
 	default:
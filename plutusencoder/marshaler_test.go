@@ -108,3 +108,51 @@ func TestCustomMarshalerFieldStillWorks(t *testing.T) {
 		t.Fatalf("unexpected decoded datum: %+v", decoded)
 	}
 }
+
+type interfaceMarshalerDatum struct {
+	_     struct{}        `plutusType:"DefList" plutusConstr:"0"`
+	Token PlutusMarshaler `plutusType:"Custom"`
+}
+
+type interfaceMarshalerSlice struct {
+	_          struct{}          `plutusType:"DefList" plutusConstr:"0"`
+	Quantities []PlutusMarshaler `plutusType:"DefList"`
+}
+
+// Interfaces not registered with RegisterSumType are encoded through the
+// value they hold rather than rejected as unregistered sum types.
+func TestInterfaceFieldHoldingMarshaler(t *testing.T) {
+	token := customTokenID("alpha")
+	pd, err := MarshalPlutus(&interfaceMarshalerDatum{Token: &token})
+	if err != nil {
+		t.Fatal(err)
+	}
+	constr, ok := pd.(*data.Constr)
+	if !ok || len(constr.Fields) != 1 {
+		t.Fatalf("expected Constr with one field, got %v", pd)
+	}
+	if bs, ok := constr.Fields[0].(*data.ByteString); !ok || string(bs.Inner) != "alpha" {
+		t.Fatalf("unexpected token encoding: %v", constr.Fields[0])
+	}
+
+	var decodedToken customTokenID
+	decoded := interfaceMarshalerDatum{Token: &decodedToken}
+	if err := UnmarshalPlutus(pd, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decodedToken != "alpha" {
+		t.Fatalf("decoded token = %q, want alpha", decodedToken)
+	}
+
+	pd, err = MarshalPlutus(&interfaceMarshalerSlice{Quantities: []PlutusMarshaler{customQuantity(1)}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	list, ok := pd.(*data.Constr).Fields[0].(*data.List)
+	if !ok || len(list.Items) != 1 {
+		t.Fatalf("unexpected quantity list: %v", pd)
+	}
+	if quantity, ok := list.Items[0].(*data.Integer); !ok || quantity.Inner.Int64() != 101 {
+		t.Fatalf("unexpected quantity encoding: %v", list.Items[0])
+	}
+}
@@ -0,0 +1,167 @@
+package plutusencoder
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+
+	"github.com/blinklabs-io/plutigo/data"
+)
+
+// sumType is a registered interface and the variant structs implementing it,
+// indexed both ways by constructor tag.
+type sumType struct {
+	iface    reflect.Type
+	variants map[uint]reflect.Type
+	tags     map[reflect.Type]uint
+}
+
+var (
+	sumTypesMu sync.RWMutex
+	sumTypes   = make(map[reflect.Type]*sumType)
+)
+
+// RegisterSumType declares the variants of the interface type I, so fields,
+// slice elements and UnmarshalPlutus targets of type I decode to the variant
+// whose plutusConstr tag matches the data. Each variant is a struct, or a
+// pointer to one, with a plutusConstr tag unique within I. Values of type I
+// marshal as whichever registered variant they hold.
+//
+//	type Action interface{ isAction() }
+//	type Cancel struct {
+//		_ struct{} `plutusType:"DefList" plutusConstr:"0"`
+//	}
+//	type Update struct {
+//		_        struct{} `plutusType:"IndefList" plutusConstr:"1"`
+//		Deadline int64    `plutusType:"Int"`
+//	}
+//
+//	err := plutusencoder.RegisterSumType[Action](Cancel{}, Update{})
+func RegisterSumType[I any](variants ...I) error {
+	iface := reflect.TypeFor[I]()
+	if iface.Kind() != reflect.Interface {
+		return fmt.Errorf("sum type %s must be an interface", iface)
+	}
+	if len(variants) == 0 {
+		return fmt.Errorf("sum type %s needs at least one variant", iface)
+	}
+	st := &sumType{
+		iface:    iface,
+		variants: make(map[uint]reflect.Type, len(variants)),
+		tags:     make(map[reflect.Type]uint, len(variants)),
+	}
+	for i, variant := range variants {
+		val := reflect.ValueOf(variant)
+		if !val.IsValid() {
+			return fmt.Errorf("sum type %s: variant %d is nil", iface, i)
+		}
+		typ := val.Type()
+		structType := typ
+		if structType.Kind() == reflect.Pointer {
+			structType = structType.Elem()
+		}
+		if structType.Kind() != reflect.Struct {
+			return fmt.Errorf("sum type %s: variant %s must be a struct", iface, typ)
+		}
+		_, tag, hasConstr, err := readContainerMetadata(structType)
+		if err != nil {
+			return fmt.Errorf("sum type %s: variant %s: %w", iface, typ, err)
+		}
+		if !hasConstr {
+			return fmt.Errorf("sum type %s: variant %s has no plutusConstr tag", iface, typ)
+		}
+		if other, dup := st.variants[tag]; dup {
+			return fmt.Errorf("sum type %s: variants %s and %s share constructor %d", iface, other, typ, tag)
+		}
+		if _, dup := st.tags[typ]; dup {
+			return fmt.Errorf("sum type %s: variant %s registered twice", iface, typ)
+		}
+		st.variants[tag] = typ
+		st.tags[typ] = tag
+	}
+
+	sumTypesMu.Lock()
+	defer sumTypesMu.Unlock()
+	if _, dup := sumTypes[iface]; dup {
+		return fmt.Errorf("sum type %s is already registered", iface)
+	}
+	sumTypes[iface] = st
+	return nil
+}
+
+// MustRegisterSumType is RegisterSumType for package initialization; it
+// panics on error.
+func MustRegisterSumType[I any](variants ...I) {
+	if err := RegisterSumType(variants...); err != nil {
+		panic(err)
+	}
+}
+
+// isSumType reports whether typ is an interface registered with
+// RegisterSumType. Other interfaces are encoded through the value they hold,
+// such as a PlutusMarshaler.
+func isSumType(typ reflect.Type) bool {
+	if typ.Kind() != reflect.Interface {
+		return false
+	}
+	sumTypesMu.RLock()
+	defer sumTypesMu.RUnlock()
+	_, ok := sumTypes[typ]
+	return ok
+}
+
+func lookupSumType(iface reflect.Type) (*sumType, error) {
+	sumTypesMu.RLock()
+	defer sumTypesMu.RUnlock()
+	st, ok := sumTypes[iface]
+	if !ok {
+		return nil, fmt.Errorf("interface type %s has no variants registered with RegisterSumType", iface)
+	}
+	return st, nil
+}
+
+// marshalSum encodes the variant held by an interface value.
+func marshalSum(val reflect.Value) (data.PlutusData, error) {
+	st, err := lookupSumType(val.Type())
+	if err != nil {
+		return nil, err
+	}
+	if val.IsNil() {
+		return nil, fmt.Errorf("sum type %s holds no variant", st.iface)
+	}
+	held := val.Elem()
+	if _, ok := st.tags[held.Type()]; !ok {
+		return nil, fmt.Errorf("%s is not a registered variant of %s", held.Type(), st.iface)
+	}
+	return marshalValue(held)
+}
+
+// unmarshalSum decodes pd into the variant its constructor tag selects and
+// stores it in the interface value val.
+func unmarshalSum(pd data.PlutusData, val reflect.Value) error {
+	st, err := lookupSumType(val.Type())
+	if err != nil {
+		return err
+	}
+	constr, ok := pd.(*data.Constr)
+	if !ok {
+		return fmt.Errorf("sum type %s: expected Constr, got %T", st.iface, pd)
+	}
+	typ, ok := st.variants[constr.Tag]
+	if !ok {
+		return fmt.Errorf("sum type %s has no variant for constructor %d", st.iface, constr.Tag)
+	}
+	var variant reflect.Value
+	if typ.Kind() == reflect.Pointer {
+		variant = reflect.New(typ.Elem())
+		err = unmarshalValue(pd, variant.Elem())
+	} else {
+		variant = reflect.New(typ).Elem()
+		err = unmarshalValue(pd, variant)
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", typ, err)
+	}
+	val.Set(variant)
+	return nil
+}
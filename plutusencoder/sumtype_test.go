@@ -0,0 +1,118 @@
+package plutusencoder
+
+import (
+	"encoding/hex"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/blinklabs-io/plutigo/data"
+)
+
+type testAction interface {
+	isTestAction()
+}
+
+type testCancel struct {
+	_ struct{} `plutusType:"DefList" plutusConstr:"0"`
+}
+
+type testUpdate struct {
+	_        struct{} `plutusType:"IndefList" plutusConstr:"1"`
+	Deadline int64    `plutusType:"Int"`
+}
+
+type testClaim struct {
+	_           struct{} `plutusType:"IndefList" plutusConstr:"2"`
+	Beneficiary []byte   `plutusType:"Bytes"`
+}
+
+func (testCancel) isTestAction()   {}
+func (testUpdate) isTestAction()   {}
+func (*testClaim) isTestAction()   {}
+func (testUnlisted) isTestAction() {}
+
+type testUnlisted struct {
+	_ struct{} `plutusType:"DefList" plutusConstr:"3"`
+}
+
+type actionBatch struct {
+	_       struct{} `plutusType:"IndefList" plutusConstr:"0"`
+	Primary testAction
+	Rest    []testAction `plutusType:"DefList"`
+}
+
+func init() {
+	MustRegisterSumType[testAction](testCancel{}, testUpdate{}, &testClaim{})
+}
+
+func TestSumTypeRoundTrip(t *testing.T) {
+	batch := actionBatch{
+		Primary: testUpdate{Deadline: 9},
+		Rest:    []testAction{testCancel{}, &testClaim{Beneficiary: []byte{0xbb}}},
+	}
+	pd, err := MarshalPlutus(batch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	encoded, err := data.Encode(pd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := hex.EncodeToString(encoded); got != "d8799fd87a9f09ff82d87980d87b9f41bbffff" {
+		t.Fatalf("cbor = %s", got)
+	}
+
+	var decoded actionBatch
+	if err := UnmarshalPlutus(pd, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if update, ok := decoded.Primary.(testUpdate); !ok || update.Deadline != 9 {
+		t.Fatalf("primary = %#v", decoded.Primary)
+	}
+	if len(decoded.Rest) != 2 {
+		t.Fatalf("rest = %#v", decoded.Rest)
+	}
+	if _, ok := decoded.Rest[0].(testCancel); !ok {
+		t.Fatalf("rest[0] = %#v", decoded.Rest[0])
+	}
+	if claim, ok := decoded.Rest[1].(*testClaim); !ok || string(claim.Beneficiary) != "\xbb" {
+		t.Fatalf("rest[1] = %#v", decoded.Rest[1])
+	}
+
+	var action testAction
+	if err := UnmarshalPlutus(data.NewConstr(1, data.NewInteger(big.NewInt(4))), &action); err != nil {
+		t.Fatal(err)
+	}
+	if update, ok := action.(testUpdate); !ok || update.Deadline != 4 {
+		t.Fatalf("top-level action = %#v", action)
+	}
+}
+
+func TestSumTypeErrors(t *testing.T) {
+	var action testAction
+	err := UnmarshalPlutus(data.NewConstr(7), &action)
+	if err == nil || !strings.Contains(err.Error(), "no variant for constructor 7") {
+		t.Fatalf("expected an unknown constructor error, got %v", err)
+	}
+
+	_, err = MarshalPlutus(actionBatch{Primary: testUnlisted{}})
+	if err == nil || !strings.Contains(err.Error(), "is not a registered variant") {
+		t.Fatalf("expected an unregistered variant error, got %v", err)
+	}
+	_, err = MarshalPlutus(actionBatch{})
+	if err == nil || !strings.Contains(err.Error(), "holds no variant") {
+		t.Fatalf("expected a nil variant error, got %v", err)
+	}
+
+	type other interface{ isOther() }
+	if err := RegisterSumType[other](); err == nil {
+		t.Fatal("expected a sum type without variants to be rejected")
+	}
+	if err := RegisterSumType[testAction](testCancel{}); err == nil {
+		t.Fatal("expected a second registration to be rejected")
+	}
+	if err := RegisterSumType[testCancel](testCancel{}); err == nil {
+		t.Fatal("expected a non-interface sum type to be rejected")
+	}
+}
@@ -18,6 +18,9 @@ func UnmarshalPlutus(pd data.PlutusData, v any) error {
 }
 
 func unmarshalValue(pd data.PlutusData, val reflect.Value) error {
+	if isSumType(val.Type()) {
+		return unmarshalSum(pd, val)
+	}
 	// Check for PlutusMarshaler (pointer or value receiver)
 	if val.CanAddr() {
 		if m, ok := val.Addr().Interface().(PlutusMarshaler); ok {
@@ -59,6 +62,9 @@ func unmarshalField(pd data.PlutusData, fieldVal reflect.Value, field reflect.St
 	if plutusType == "BigInt" {
 		return unmarshalBigInt(pd, fieldVal)
 	}
+	if isSumType(fieldVal.Type()) {
+		return unmarshalSum(pd, fieldVal)
+	}
 
 	// Dereference / allocate pointers
 	for fieldVal.Kind() == reflect.Pointer {
@@ -93,6 +99,16 @@ func unmarshalField(pd data.PlutusData, fieldVal reflect.Value, field reflect.St
 		return unmarshalSliceOrNested(pd, fieldVal, field)
 	case "Map":
 		return unmarshalSliceAsMap(pd, fieldVal, field)
+	case "Credential":
+		return unmarshalCredential(pd, fieldVal)
+	case "OutputReference":
+		return unmarshalOutputReference(pd, fieldVal, false)
+	case "OutputReferenceV2":
+		return unmarshalOutputReference(pd, fieldVal, true)
+	case "POSIXTime":
+		return unmarshalPOSIXTime(pd, fieldVal)
+	case "Rational":
+		return unmarshalRational(pd, fieldVal)
 	case "Custom":
 		return fmt.Errorf("field %s tagged Custom but doesn't implement PlutusMarshaler", field.Name)
 	default:
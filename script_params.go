@@ -0,0 +1,110 @@
+package apollo
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/blinklabs-io/gouroboros/cbor"
+	"github.com/blinklabs-io/gouroboros/ledger/common"
+	"github.com/blinklabs-io/plutigo/data"
+	"github.com/blinklabs-io/plutigo/syn"
+)
+
+// ApplyParamsToScript applies params, in order, to a parameterized Plutus
+// script and returns the resulting script of the same version. The script
+// bytes are the CBOR-wrapped flat program a compiler emits as compiledCode,
+// and the result is wrapped the same way, so its Hash is the policy ID or
+// validator hash the chain sees:
+//
+//	script, err := apollo.ApplyParamsToScript(oneShot, outRef, ownerPkh)
+//	policy := script.Hash()
+func ApplyParamsToScript(script common.Script, params ...data.PlutusData) (common.Script, error) {
+	switch s := script.(type) {
+	case common.PlutusV1Script:
+		applied, err := applyParams(s, params)
+		return common.PlutusV1Script(applied), err
+	case *common.PlutusV1Script:
+		if s == nil {
+			return nil, errors.New("script must not be nil")
+		}
+		applied, err := applyParams(*s, params)
+		return common.PlutusV1Script(applied), err
+	case common.PlutusV2Script:
+		applied, err := applyParams(s, params)
+		return common.PlutusV2Script(applied), err
+	case *common.PlutusV2Script:
+		if s == nil {
+			return nil, errors.New("script must not be nil")
+		}
+		applied, err := applyParams(*s, params)
+		return common.PlutusV2Script(applied), err
+	case common.PlutusV3Script:
+		applied, err := applyParams(s, params)
+		return common.PlutusV3Script(applied), err
+	case *common.PlutusV3Script:
+		if s == nil {
+			return nil, errors.New("script must not be nil")
+		}
+		applied, err := applyParams(*s, params)
+		return common.PlutusV3Script(applied), err
+	default:
+		return nil, fmt.Errorf("cannot apply parameters to script type %T", script)
+	}
+}
+
+func applyParams(script []byte, params []data.PlutusData) ([]byte, error) {
+	if len(params) == 0 {
+		return nil, errors.New("no parameters to apply")
+	}
+	var flat []byte
+	if _, err := cbor.Decode(script, &flat); err != nil {
+		return nil, fmt.Errorf("decode script CBOR: %w", err)
+	}
+	program, err := syn.Decode[syn.DeBruijn](flat)
+	if err != nil {
+		return nil, fmt.Errorf("decode script program: %w", err)
+	}
+	term := program.Term
+	for i, param := range params {
+		if param == nil {
+			return nil, fmt.Errorf("parameter %d is nil", i)
+		}
+		term = &syn.Apply[syn.DeBruijn]{
+			Function: term,
+			Argument: &syn.Constant{Con: &syn.Data{Inner: param}},
+		}
+	}
+	program.Term = term
+	encoded, err := syn.Encode(program)
+	if err != nil {
+		return nil, fmt.Errorf("encode script program: %w", err)
+	}
+	applied, err := cbor.Encode(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("encode script CBOR: %w", err)
+	}
+	return applied, nil
+}
+
+// ScriptAddress returns the address locking funds at script on networkId.
+// A nil stake credential yields an enterprise address; otherwise the address
+// delegates to stake.
+func ScriptAddress(script common.Script, networkId uint8, stake *common.Credential) (common.Address, error) {
+	if script == nil {
+		return common.Address{}, errors.New("script must not be nil")
+	}
+	hash := script.Hash()
+	if stake == nil {
+		return common.NewAddressFromParts(common.AddressTypeScriptNone, networkId, hash.Bytes(), nil)
+	}
+	var addrType uint8
+	switch stake.CredType {
+	case common.CredentialTypeAddrKeyHash:
+		addrType = common.AddressTypeScriptKey
+	case common.CredentialTypeScriptHash:
+		addrType = common.AddressTypeScriptScript
+	default:
+		return common.Address{}, fmt.Errorf("unsupported stake credential type %d", stake.CredType)
+	}
+	return common.NewAddressFromParts(addrType, networkId, hash.Bytes(), stake.Credential.Bytes())
+}
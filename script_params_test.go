@@ -0,0 +1,153 @@
+package apollo
+
+import (
+	"bytes"
+	"encoding/hex"
+	"math/big"
+	"testing"
+
+	"github.com/blinklabs-io/gouroboros/cbor"
+	"github.com/blinklabs-io/gouroboros/ledger/common"
+	"github.com/blinklabs-io/plutigo/cek"
+	"github.com/blinklabs-io/plutigo/data"
+	"github.com/blinklabs-io/plutigo/lang"
+	"github.com/blinklabs-io/plutigo/syn"
+)
+
+// compileScript builds the CBOR-wrapped flat encoding of a textual UPLC
+// program, as a compiler would emit it.
+func compileScript(t *testing.T, source string) []byte {
+	t.Helper()
+	program, err := syn.Parse(source)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	debruijn, err := syn.NameToDeBruijn(program)
+	if err != nil {
+		t.Fatalf("debruijn: %v", err)
+	}
+	flat, err := syn.Encode(debruijn)
+	if err != nil {
+		t.Fatalf("flat encode: %v", err)
+	}
+	script, err := cbor.Encode(flat)
+	if err != nil {
+		t.Fatalf("cbor encode: %v", err)
+	}
+	return script
+}
+
+func TestApplyParamsToScriptAppliesInOrder(t *testing.T) {
+	script := common.PlutusV3Script(compileScript(t, "(program 1.1.0 (lam first (lam second first)))"))
+	first := data.NewByteString([]byte{0xca, 0xfe})
+	applied, err := ApplyParamsToScript(script, first, data.NewInteger(big.NewInt(7)))
+	if err != nil {
+		t.Fatalf("ApplyParamsToScript: %v", err)
+	}
+	v3, ok := applied.(common.PlutusV3Script)
+	if !ok {
+		t.Fatalf("applied script type = %T, want common.PlutusV3Script", applied)
+	}
+	if v3.Hash() == script.Hash() {
+		t.Fatal("applied script kept the unapplied hash")
+	}
+
+	var flat []byte
+	if _, err := cbor.Decode(v3, &flat); err != nil {
+		t.Fatalf("decode applied script: %v", err)
+	}
+	program, err := syn.Decode[syn.DeBruijn](flat)
+	if err != nil {
+		t.Fatalf("decode applied program: %v", err)
+	}
+	if program.Version != (lang.LanguageVersion{1, 1, 0}) {
+		t.Fatalf("program version = %v", program.Version)
+	}
+	machine := cek.NewMachine[syn.DeBruijn](lang.LanguageVersionV3, 200, nil)
+	result, err := machine.Run(program.Term)
+	if err != nil {
+		t.Fatalf("run applied program: %v", err)
+	}
+	constant, ok := result.(*syn.Constant)
+	if !ok {
+		t.Fatalf("result = %T, want *syn.Constant", result)
+	}
+	got, ok := constant.Con.(*syn.Data)
+	if !ok {
+		t.Fatalf("result constant = %T, want *syn.Data", constant.Con)
+	}
+	bs, ok := got.Inner.(*data.ByteString)
+	if !ok || !bytes.Equal(bs.Inner, first.(*data.ByteString).Inner) {
+		t.Fatalf("result = %#v, want the first parameter", got.Inner)
+	}
+}
+
+func TestApplyParamsToScriptKeepsVersion(t *testing.T) {
+	code := compileScript(t, "(program 1.0.0 (lam x x))")
+	param := data.NewInteger(big.NewInt(1))
+	v2 := common.PlutusV2Script(code)
+	applied, err := ApplyParamsToScript(&v2, param)
+	if err != nil {
+		t.Fatalf("ApplyParamsToScript: %v", err)
+	}
+	if _, ok := applied.(common.PlutusV2Script); !ok {
+		t.Fatalf("applied script type = %T, want common.PlutusV2Script", applied)
+	}
+	v1, err := ApplyParamsToScript(common.PlutusV1Script(code), param)
+	if err != nil {
+		t.Fatalf("ApplyParamsToScript: %v", err)
+	}
+	if !bytes.Equal(v1.RawScriptBytes(), applied.RawScriptBytes()) || v1.Hash() == applied.Hash() {
+		t.Fatal("V1 and V2 applications should share code but not hashes")
+	}
+}
+
+func TestApplyParamsToScriptErrors(t *testing.T) {
+	code := compileScript(t, "(program 1.1.0 (lam x x))")
+	param := data.NewInteger(big.NewInt(1))
+	native, err := NewNativeScriptInvalidBefore(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	notCBOR, _ := hex.DecodeString("ff")
+	for name, tc := range map[string]struct {
+		script common.Script
+		params []data.PlutusData
+	}{
+		"no params":     {common.PlutusV3Script(code), nil},
+		"nil param":     {common.PlutusV3Script(code), []data.PlutusData{nil}},
+		"native script": {native, []data.PlutusData{param}},
+		"not cbor":      {common.PlutusV3Script(notCBOR), []data.PlutusData{param}},
+		"not flat":      {common.PlutusV3Script([]byte{0x41, 0x00}), []data.PlutusData{param}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			if _, err := ApplyParamsToScript(tc.script, tc.params...); err == nil {
+				t.Fatal("ApplyParamsToScript succeeded, want error")
+			}
+		})
+	}
+}
+
+func TestScriptAddress(t *testing.T) {
+	script := common.PlutusV3Script(compileScript(t, "(program 1.1.0 (lam x x))"))
+	enterprise, err := ScriptAddress(script, common.AddressNetworkTestnet, nil)
+	if err != nil {
+		t.Fatalf("ScriptAddress: %v", err)
+	}
+	if enterprise.Type() != common.AddressTypeScriptNone || enterprise.PaymentKeyHash() != script.Hash() {
+		t.Fatalf("enterprise address = %s", enterprise.String())
+	}
+
+	stake := common.Credential{CredType: common.CredentialTypeScriptHash, Credential: common.Blake2b224{0x02}}
+	base, err := ScriptAddress(script, common.AddressNetworkMainnet, &stake)
+	if err != nil {
+		t.Fatalf("ScriptAddress: %v", err)
+	}
+	if base.Type() != common.AddressTypeScriptScript || base.StakeKeyHash() != stake.Credential {
+		t.Fatalf("base address = %s", base.String())
+	}
+
+	if _, err := ScriptAddress(script, common.AddressNetworkMainnet, &common.Credential{CredType: 7}); err == nil {
+		t.Fatal("ScriptAddress accepted an unknown stake credential type")
+	}
+}
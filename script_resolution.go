@@ -0,0 +1,221 @@
+package apollo
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	"github.com/blinklabs-io/gouroboros/ledger/common"
+	"github.com/blinklabs-io/gouroboros/ledger/shelley"
+
+	"github.com/Salvionied/apollo/v2/backend"
+	"github.com/Salvionied/apollo/v2/internal/backendutil"
+)
+
+// ScriptSource says where Complete found a script it resolved on its own.
+type ScriptSource int
+
+const (
+	// ScriptSourceReferenceInput means the script is provided by a UTxO
+	// registered with AddReferenceScriptUtxo, which Complete added as a
+	// reference input.
+	ScriptSourceReferenceInput ScriptSource = iota
+	// ScriptSourceChainContext means the script was fetched with
+	// ScriptCbor and attached to the witness set.
+	ScriptSourceChainContext
+)
+
+// String returns a short description of the source.
+func (s ScriptSource) String() string {
+	switch s {
+	case ScriptSourceReferenceInput:
+		return "reference input"
+	case ScriptSourceChainContext:
+		return "chain context"
+	default:
+		return fmt.Sprintf("ScriptSource(%d)", int(s))
+	}
+}
+
+// ResolvedScript records a script Complete supplied for a script input or
+// a minting policy that had no script attached.
+type ResolvedScript struct {
+	Hash   common.ScriptHash
+	Source ScriptSource
+	// ReferenceInput is the "txhash#index" reference of the UTxO carrying
+	// the script. It is empty unless Source is ScriptSourceReferenceInput.
+	ReferenceInput string
+}
+
+// AddReferenceScriptUtxo registers UTxOs that carry reference scripts. When
+// a script input or minting policy has no script attached, Complete adds the
+// registered UTxO with the matching script as a reference input. Registered
+// UTxOs are only referenced when needed, and must be resolvable through the
+// chain context like any other reference input.
+func (a *Apollo) AddReferenceScriptUtxo(utxos ...common.Utxo) *Apollo {
+	for _, utxo := range utxos {
+		if err := validateUtxo(utxo); err != nil {
+			a.setErrOnce(fmt.Errorf("reference script UTxO is invalid: %w", err))
+			return a
+		}
+		if utxo.Output.ScriptRef() == nil {
+			a.setErrOnce(fmt.Errorf("reference script UTxO %s carries no script", utxoRef(utxo)))
+			return a
+		}
+		a.referenceScripts = append(a.referenceScripts, utxo)
+	}
+	return a
+}
+
+// ResolvedScripts reports the scripts Complete resolved automatically, in
+// the order they were resolved.
+func (a *Apollo) ResolvedScripts() []ResolvedScript {
+	return append([]ResolvedScript(nil), a.resolvedScripts...)
+}
+
+// resolveScripts supplies the script for every script input and minting
+// policy, Plutus or native, that has no script attached and none in the
+// transaction's inputs or reference inputs. Registered reference
+// script UTxOs are preferred, since a reference input is smaller than the
+// script itself; otherwise the script is fetched with ScriptCbor when the
+// chain context supports it. Anything still missing is left for the ledger
+// or the evaluator to report, as before.
+func (a *Apollo) resolveScripts() error {
+	missing := a.unresolvedScriptHashes()
+	if len(missing) == 0 {
+		return nil
+	}
+	available, err := a.availableScriptHashes()
+	if err != nil {
+		return err
+	}
+	canFetch := backend.Supports(a.Context, backend.CapabilityScriptCbor)
+	for _, hash := range missing {
+		if available[hash] {
+			continue
+		}
+		if utxo, ok := a.referenceScriptUtxo(hash); ok {
+			a.referenceInputs = append(a.referenceInputs, shelley.ShelleyTransactionInput{
+				TxId:        utxo.Id.Id(),
+				OutputIndex: utxo.Id.Index(),
+			})
+			a.resolvedScripts = append(a.resolvedScripts, ResolvedScript{
+				Hash:           hash,
+				Source:         ScriptSourceReferenceInput,
+				ReferenceInput: utxoRef(utxo),
+			})
+			available[hash] = true
+			continue
+		}
+		if !canFetch {
+			continue
+		}
+		scriptCbor, err := backend.ScriptCborContext(a.requestContext, a.Context, hash)
+		if errors.Is(err, backend.ErrUnsupported) {
+			// Contexts that do not report capabilities claim them all.
+			canFetch = false
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("resolve script %s: %w", hash.String(), err)
+		}
+		script, err := scriptFromCbor(hash, scriptCbor)
+		if err != nil {
+			return err
+		}
+		a.AttachScript(script)
+		if a.err != nil {
+			return a.err
+		}
+		a.resolvedScripts = append(a.resolvedScripts, ResolvedScript{
+			Hash:   hash,
+			Source: ScriptSourceChainContext,
+		})
+		available[hash] = true
+	}
+	return nil
+}
+
+// unresolvedScriptHashes lists, without duplicates, the script hashes of
+// preselected script inputs and minting policies that have no attached
+// script. Native scripts take no redeemer, so inputs and policies count
+// whether or not one was given.
+func (a *Apollo) unresolvedScriptHashes() []common.ScriptHash {
+	var missing []common.ScriptHash
+	seen := make(map[common.ScriptHash]bool)
+	add := func(hash common.ScriptHash) {
+		if seen[hash] || a.hasScriptHash(hash.String()) {
+			return
+		}
+		seen[hash] = true
+		missing = append(missing, hash)
+	}
+	for _, utxo := range a.preselectedUtxos {
+		if hash, ok := scriptPaymentHash(utxo.Output.Address()); ok {
+			add(hash)
+		}
+	}
+	for _, policyId := range a.sortedMintPolicyIds() {
+		policyBytes, err := hex.DecodeString(policyId)
+		if err != nil || len(policyBytes) != common.Blake2b224Size {
+			// buildMintAsset reports malformed policy IDs.
+			continue
+		}
+		add(common.NewBlake2b224(policyBytes))
+	}
+	return missing
+}
+
+// availableScriptHashes returns the hashes of the scripts carried by the
+// preselected inputs and the reference inputs added so far.
+func (a *Apollo) availableScriptHashes() (map[common.ScriptHash]bool, error) {
+	available := make(map[common.ScriptHash]bool)
+	for _, utxo := range a.preselectedUtxos {
+		if script := utxo.Output.ScriptRef(); script != nil {
+			available[script.Hash()] = true
+		}
+	}
+	for _, refInput := range a.referenceInputs {
+		utxo, err := backend.UtxoByRefContext(a.requestContext, a.Context, refInput.TxId, refInput.OutputIndex)
+		if err != nil {
+			return nil, fmt.Errorf(
+				"failed to resolve reference input %s#%d for script resolution: %w",
+				refInput.TxId.String(),
+				refInput.OutputIndex,
+				err,
+			)
+		}
+		if utxo != nil && utxo.Output.ScriptRef() != nil {
+			available[utxo.Output.ScriptRef().Hash()] = true
+		}
+	}
+	return available, nil
+}
+
+// referenceScriptUtxo returns the first registered reference script UTxO
+// carrying the script with the given hash.
+func (a *Apollo) referenceScriptUtxo(hash common.ScriptHash) (common.Utxo, bool) {
+	for _, utxo := range a.referenceScripts {
+		if utxo.Output.ScriptRef().Hash() == hash {
+			return utxo, true
+		}
+	}
+	return common.Utxo{}, false
+}
+
+// isResolvedReferenceInput reports whether ref was added as a reference
+// input by resolveScripts. Such UTxOs must not also be spent.
+func (a *Apollo) isResolvedReferenceInput(ref string) bool {
+	for _, resolved := range a.resolvedScripts {
+		if resolved.ReferenceInput == ref {
+			return true
+		}
+	}
+	return false
+}
+
+// scriptFromCbor turns the bytes a backend returns from ScriptCbor into a
+// script checked against the hash that was asked for.
+func scriptFromCbor(hash common.ScriptHash, scriptCbor []byte) (common.Script, error) {
+	return backendutil.ScriptFromCbor(hash, scriptCbor)
+}
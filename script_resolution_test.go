@@ -0,0 +1,227 @@
+package apollo
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/blinklabs-io/gouroboros/cbor"
+	"github.com/blinklabs-io/gouroboros/ledger/babbage"
+	"github.com/blinklabs-io/gouroboros/ledger/common"
+	"github.com/blinklabs-io/gouroboros/ledger/mary"
+	"github.com/blinklabs-io/gouroboros/ledger/shelley"
+	"github.com/blinklabs-io/plutigo/data"
+
+	"github.com/Salvionied/apollo/v2/backend"
+	"github.com/Salvionied/apollo/v2/backend/fixed"
+)
+
+// scriptCborContext serves ScriptCbor from a map on top of a fixed context.
+type scriptCborContext struct {
+	*fixed.FixedChainContext
+	scripts map[common.Blake2b224][]byte
+	lookups int
+}
+
+func (c *scriptCborContext) Capabilities() backend.CapabilitySet {
+	return c.FixedChainContext.Capabilities() | backend.CapabilitySet(backend.CapabilityScriptCbor)
+}
+
+func (c *scriptCborContext) ScriptCbor(hash common.Blake2b224) ([]byte, error) {
+	c.lookups++
+	if script, ok := c.scripts[hash]; ok {
+		return script, nil
+	}
+	return nil, backend.ErrUnsupported
+}
+
+var resolutionScript = common.PlutusV2Script{0x42, 0x01, 0x02}
+
+func newScriptCborContext(t *testing.T) *scriptCborContext {
+	t.Helper()
+	cc := setupFixedContext()
+	addTestUtxo(cc, testAddress(t), 20_000_000, 0x01, 0)
+	return &scriptCborContext{
+		FixedChainContext: cc,
+		scripts: map[common.Blake2b224][]byte{
+			// Unwrapped, as some providers return it.
+			resolutionScript.Hash(): {0x01, 0x02},
+		},
+	}
+}
+
+func resolutionScriptUtxo(t *testing.T) common.Utxo {
+	t.Helper()
+	addr, err := ScriptAddress(resolutionScript, 0, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	opt, err := NewDatumOptionInline(&common.Datum{Data: data.NewInteger(big.NewInt(1))})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return common.Utxo{
+		Id: shelley.ShelleyTransactionInput{TxId: common.Blake2b256{0x6a}},
+		Output: &babbage.BabbageTransactionOutput{
+			OutputAddress: addr,
+			OutputAmount:  mary.MaryTransactionOutputValue{Amount: 5_000_000},
+			DatumOption:   opt,
+		},
+	}
+}
+
+func referenceScriptHolder(t *testing.T, cc *fixed.FixedChainContext) common.Utxo {
+	t.Helper()
+	scriptRef, err := NewScriptRef(resolutionScript)
+	if err != nil {
+		t.Fatal(err)
+	}
+	utxo := common.Utxo{
+		Id: shelley.ShelleyTransactionInput{TxId: common.Blake2b256{0x7b}, OutputIndex: 2},
+		Output: &babbage.BabbageTransactionOutput{
+			OutputAddress:  testAddress(t),
+			OutputAmount:   mary.MaryTransactionOutputValue{Amount: 10_000_000},
+			TxOutScriptRef: scriptRef,
+		},
+	}
+	cc.AddUtxo(testAddress(t), utxo)
+	return utxo
+}
+
+func spendResolutionScript(t *testing.T, cc backend.ChainContext, register ...common.Utxo) *Apollo {
+	t.Helper()
+	redeemer := common.Datum{Data: data.NewInteger(big.NewInt(0))}
+	a := New(cc).
+		SetWallet(NewExternalWallet(testAddress(t))).
+		DisableExecutionUnitsEstimation().
+		AddReferenceScriptUtxo(register...).
+		CollectFrom(resolutionScriptUtxo(t), redeemer, common.ExUnits{Memory: 1, Steps: 1})
+	if _, err := a.Complete(); err != nil {
+		t.Fatalf("Complete: %v", err)
+	}
+	return a
+}
+
+func TestCompletePrefersRegisteredReferenceScript(t *testing.T) {
+	cc := newScriptCborContext(t)
+	holder := referenceScriptHolder(t, cc.FixedChainContext)
+
+	a := spendResolutionScript(t, cc, holder)
+
+	resolved := a.ResolvedScripts()
+	if len(resolved) != 1 || resolved[0].Hash != resolutionScript.Hash() ||
+		resolved[0].Source != ScriptSourceReferenceInput ||
+		resolved[0].ReferenceInput != utxoRef(holder) {
+		t.Fatalf("ResolvedScripts() = %+v", resolved)
+	}
+	if cc.lookups != 0 {
+		t.Fatalf("ScriptCbor called %d times, want 0", cc.lookups)
+	}
+	if len(a.v2scripts) != 0 {
+		t.Fatal("a script provided by a reference input was also attached")
+	}
+	refs := a.tx.Body.TxReferenceInputs.Items()
+	if len(refs) != 1 || refs[0].TxId != holder.Id.Id() || refs[0].OutputIndex != holder.Id.Index() {
+		t.Fatalf("reference inputs = %+v", refs)
+	}
+	for _, ref := range bodyInputRefs(t, a) {
+		if ref == utxoRef(holder) {
+			t.Fatal("the reference script UTxO was also spent")
+		}
+	}
+}
+
+func TestCompleteFetchesMissingScript(t *testing.T) {
+	cc := newScriptCborContext(t)
+
+	a := spendResolutionScript(t, cc)
+
+	resolved := a.ResolvedScripts()
+	if len(resolved) != 1 || resolved[0].Source != ScriptSourceChainContext ||
+		resolved[0].ReferenceInput != "" {
+		t.Fatalf("ResolvedScripts() = %+v", resolved)
+	}
+	if len(a.v2scripts) != 1 || a.v2scripts[0].Hash() != resolutionScript.Hash() {
+		t.Fatalf("attached V2 scripts = %x", a.v2scripts)
+	}
+}
+
+func TestCompleteFetchesMissingNativeScript(t *testing.T) {
+	cc := newScriptCborContext(t)
+	owner := testAddress(t)
+	script, err := NewNativeScriptPubkey(owner.PaymentKeyHash())
+	if err != nil {
+		t.Fatal(err)
+	}
+	scriptCbor, err := cbor.Encode(&script)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cc.scripts[script.Hash()] = scriptCbor
+	addr, err := ScriptAddress(script, 0, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	locked := common.Utxo{
+		Id: shelley.ShelleyTransactionInput{TxId: common.Blake2b256{0x6b}},
+		Output: &babbage.BabbageTransactionOutput{
+			OutputAddress: addr,
+			OutputAmount:  mary.MaryTransactionOutputValue{Amount: 5_000_000},
+		},
+	}
+
+	a := New(cc).
+		SetWallet(NewExternalWallet(testAddress(t))).
+		AddInput(locked)
+	if _, err := a.Complete(); err != nil {
+		t.Fatalf("Complete: %v", err)
+	}
+	resolved := a.ResolvedScripts()
+	if len(resolved) != 1 || resolved[0].Hash != script.Hash() || resolved[0].Source != ScriptSourceChainContext {
+		t.Fatalf("ResolvedScripts() = %+v", resolved)
+	}
+	if len(a.nativescripts) != 1 || a.nativescripts[0].Hash() != script.Hash() {
+		t.Fatal("native script was not attached")
+	}
+}
+
+func TestCompleteSkipsResolutionWhenScriptAttached(t *testing.T) {
+	cc := newScriptCborContext(t)
+	redeemer := common.Datum{Data: data.NewInteger(big.NewInt(0))}
+	a := New(cc).
+		SetWallet(NewExternalWallet(testAddress(t))).
+		DisableExecutionUnitsEstimation().
+		AttachScript(resolutionScript).
+		CollectFrom(resolutionScriptUtxo(t), redeemer, common.ExUnits{Memory: 1, Steps: 1})
+	if _, err := a.Complete(); err != nil {
+		t.Fatalf("Complete: %v", err)
+	}
+	if len(a.ResolvedScripts()) != 0 || cc.lookups != 0 {
+		t.Fatalf("resolved %+v with %d lookups", a.ResolvedScripts(), cc.lookups)
+	}
+}
+
+func TestScriptFromCbor(t *testing.T) {
+	hash := resolutionScript.Hash()
+	for name, scriptCbor := range map[string][]byte{
+		"wrapped":   resolutionScript,
+		"unwrapped": {0x01, 0x02},
+	} {
+		script, err := scriptFromCbor(hash, scriptCbor)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if _, ok := script.(common.PlutusV2Script); !ok || script.Hash() != hash {
+			t.Fatalf("%s: scriptFromCbor = %T %x", name, script, script.RawScriptBytes())
+		}
+	}
+	if _, err := scriptFromCbor(hash, []byte{0x01, 0x03}); err == nil {
+		t.Fatal("scriptFromCbor accepted bytes that do not match the hash")
+	}
+}
+
+func TestAddReferenceScriptUtxoRejectsPlainUtxo(t *testing.T) {
+	a := New(setupFixedContext()).AddReferenceScriptUtxo(resolutionScriptUtxo(t))
+	if a.err == nil {
+		t.Fatal("AddReferenceScriptUtxo accepted a UTxO without a script")
+	}
+}
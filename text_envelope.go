@@ -0,0 +1,408 @@
+package apollo
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/blinklabs-io/bursa/bip32"
+	"github.com/blinklabs-io/gouroboros/cbor"
+	"github.com/blinklabs-io/gouroboros/ledger/common"
+	"github.com/blinklabs-io/gouroboros/ledger/conway"
+)
+
+// cardano-cli TextEnvelope types understood by Apollo.
+const (
+	TextEnvelopePaymentSigningKey              = "PaymentSigningKeyShelley_ed25519"
+	TextEnvelopePaymentVerificationKey         = "PaymentVerificationKeyShelley_ed25519"
+	TextEnvelopePaymentExtendedSigningKey      = "PaymentExtendedSigningKeyShelley_ed25519_bip32"
+	TextEnvelopePaymentExtendedVerificationKey = "PaymentExtendedVerificationKeyShelley_ed25519_bip32"
+	TextEnvelopeStakeSigningKey                = "StakeSigningKeyShelley_ed25519"
+	TextEnvelopeStakeVerificationKey           = "StakeVerificationKeyShelley_ed25519"
+	TextEnvelopeStakeExtendedSigningKey        = "StakeExtendedSigningKeyShelley_ed25519_bip32"
+	TextEnvelopeStakeExtendedVerificationKey   = "StakeExtendedVerificationKeyShelley_ed25519_bip32"
+	TextEnvelopeTxWitness                      = "TxWitness ConwayEra"
+	TextEnvelopeTx                             = "Tx ConwayEra"
+	// TextEnvelopeUnwitnessedTx is written by older cardano-cli releases for
+	// unsigned transactions.
+	TextEnvelopeUnwitnessedTx = "Unwitnessed Tx ConwayEra"
+)
+
+// keyWitnessTag is the cardano-api KeyWitness constructor tag for a Shelley
+// vkey witness; 1 is a Byron bootstrap witness.
+const keyWitnessTag = 0
+
+// cardano-cli stores an extended signing key as the 64-byte extended private
+// key, the 32-byte public key, then the 32-byte chain code. Apollo uses the
+// 96-byte bursa layout without the public key.
+const (
+	extendedSigningKeyEnvelopeSize      = 128
+	extendedVerificationKeyEnvelopeSize = 64
+	bip32XPrvSize                       = 96
+)
+
+var textEnvelopeDescriptions = map[string]string{
+	TextEnvelopePaymentSigningKey:              "Payment Signing Key",
+	TextEnvelopePaymentVerificationKey:         "Payment Verification Key",
+	TextEnvelopePaymentExtendedSigningKey:      "Payment Signing Key",
+	TextEnvelopePaymentExtendedVerificationKey: "Payment Verification Key",
+	TextEnvelopeStakeSigningKey:                "Stake Signing Key",
+	TextEnvelopeStakeVerificationKey:           "Stake Verification Key",
+	TextEnvelopeStakeExtendedSigningKey:        "Stake Signing Key",
+	TextEnvelopeStakeExtendedVerificationKey:   "Stake Verification Key",
+	TextEnvelopeTxWitness:                      "Key Witness ShelleyEra",
+	TextEnvelopeTx:                             "Ledger Cddl Format",
+	TextEnvelopeUnwitnessedTx:                  "Ledger Cddl Format",
+}
+
+// TextEnvelope is the cardano-cli JSON wrapper around a CBOR artifact.
+type TextEnvelope struct {
+	Type        string `json:"type"`
+	Description string `json:"description"`
+	CborHex     string `json:"cborHex"`
+}
+
+// ParseTextEnvelope decodes a cardano-cli TextEnvelope JSON document. The
+// type is not restricted, so callers can inspect envelopes Apollo does not
+// otherwise interpret; the accessor methods check it.
+func ParseTextEnvelope(jsonData []byte) (TextEnvelope, error) {
+	var env TextEnvelope
+	dec := json.NewDecoder(bytes.NewReader(jsonData))
+	if err := dec.Decode(&env); err != nil {
+		return TextEnvelope{}, fmt.Errorf("decode text envelope: %w", err)
+	}
+	var extra any
+	if err := dec.Decode(&extra); err != io.EOF {
+		if err == nil {
+			return TextEnvelope{}, errors.New("decode text envelope: multiple JSON values")
+		}
+		return TextEnvelope{}, fmt.Errorf("decode text envelope: %w", err)
+	}
+	if env.Type == "" {
+		return TextEnvelope{}, errors.New("text envelope has no type")
+	}
+	if _, err := hex.DecodeString(env.CborHex); err != nil {
+		return TextEnvelope{}, fmt.Errorf("text envelope cborHex: %w", err)
+	}
+	return env, nil
+}
+
+// JSON encodes the envelope the way cardano-cli writes it to disk.
+func (e TextEnvelope) JSON() ([]byte, error) {
+	data, err := json.MarshalIndent(e, "", "    ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+// Cbor returns the decoded cborHex payload.
+func (e TextEnvelope) Cbor() ([]byte, error) {
+	data, err := hex.DecodeString(e.CborHex)
+	if err != nil {
+		return nil, fmt.Errorf("text envelope cborHex: %w", err)
+	}
+	return data, nil
+}
+
+func newTextEnvelope(envelopeType string, payload []byte) TextEnvelope {
+	return TextEnvelope{
+		Type:        envelopeType,
+		Description: textEnvelopeDescriptions[envelopeType],
+		CborHex:     hex.EncodeToString(payload),
+	}
+}
+
+func (e TextEnvelope) requireType(allowed ...string) error {
+	for _, t := range allowed {
+		if e.Type == t {
+			return nil
+		}
+	}
+	return fmt.Errorf("unexpected text envelope type %q", e.Type)
+}
+
+func (e TextEnvelope) keyBytes() ([]byte, error) {
+	payload, err := e.Cbor()
+	if err != nil {
+		return nil, err
+	}
+	var key []byte
+	if _, err := cbor.Decode(payload, &key); err != nil {
+		return nil, fmt.Errorf("decode %s key bytes: %w", e.Type, err)
+	}
+	return key, nil
+}
+
+// NewSigningKeyTextEnvelope wraps a signing key. Plain key types take a
+// 32-byte Ed25519 seed or a 64-byte Go ed25519.PrivateKey; extended key types
+// take a 96-byte bursa bip32.XPrv.
+func NewSigningKeyTextEnvelope(envelopeType string, skey []byte) (TextEnvelope, error) {
+	var key []byte
+	switch envelopeType {
+	case TextEnvelopePaymentSigningKey, TextEnvelopeStakeSigningKey:
+		switch len(skey) {
+		case ed25519.SeedSize:
+			key = skey
+		case ed25519.PrivateKeySize:
+			key = skey[:ed25519.SeedSize]
+		default:
+			return TextEnvelope{}, fmt.Errorf("invalid signing key length %d: expected 32 or 64 bytes", len(skey))
+		}
+	case TextEnvelopePaymentExtendedSigningKey, TextEnvelopeStakeExtendedSigningKey:
+		if len(skey) != bip32XPrvSize {
+			return TextEnvelope{}, fmt.Errorf("invalid extended signing key length %d: expected %d bytes", len(skey), bip32XPrvSize)
+		}
+		xprv := bip32.XPrv(skey)
+		key = make([]byte, 0, extendedSigningKeyEnvelopeSize)
+		key = append(key, skey[:64]...)
+		key = append(key, xprv.Public().PublicKey()...)
+		key = append(key, skey[64:]...)
+	default:
+		return TextEnvelope{}, fmt.Errorf("%q is not a signing key text envelope type", envelopeType)
+	}
+	payload, err := cbor.Encode(key)
+	if err != nil {
+		return TextEnvelope{}, fmt.Errorf("encode signing key: %w", err)
+	}
+	return newTextEnvelope(envelopeType, payload), nil
+}
+
+// SigningKey returns the key in the form NewVkeyWitnessFromSkey and
+// SignWithSkey accept: a 32-byte Ed25519 seed, or a 96-byte bip32.XPrv for
+// extended keys.
+func (e TextEnvelope) SigningKey() ([]byte, error) {
+	if err := e.requireType(
+		TextEnvelopePaymentSigningKey,
+		TextEnvelopeStakeSigningKey,
+		TextEnvelopePaymentExtendedSigningKey,
+		TextEnvelopeStakeExtendedSigningKey,
+	); err != nil {
+		return nil, err
+	}
+	key, err := e.keyBytes()
+	if err != nil {
+		return nil, err
+	}
+	switch e.Type {
+	case TextEnvelopePaymentSigningKey, TextEnvelopeStakeSigningKey:
+		if len(key) != ed25519.SeedSize {
+			return nil, fmt.Errorf("invalid %s length %d: expected %d bytes", e.Type, len(key), ed25519.SeedSize)
+		}
+		return key, nil
+	default:
+		if len(key) != extendedSigningKeyEnvelopeSize {
+			return nil, fmt.Errorf("invalid %s length %d: expected %d bytes", e.Type, len(key), extendedSigningKeyEnvelopeSize)
+		}
+		xprv := make(bip32.XPrv, 0, bip32XPrvSize)
+		xprv = append(xprv, key[:64]...)
+		xprv = append(xprv, key[96:]...)
+		// Signing with a public key that does not belong to the private key
+		// leaks the private scalar, so refuse a tampered or corrupt file.
+		if !bytes.Equal(xprv.Public().PublicKey(), key[64:96]) {
+			return nil, errors.New("extended signing key public-key half does not match its private key")
+		}
+		return xprv, nil
+	}
+}
+
+// NewVerificationKeyTextEnvelope wraps a verification key: 32 bytes for plain
+// key types, 64 bytes (public key then chain code) for extended ones.
+func NewVerificationKeyTextEnvelope(envelopeType string, vkey []byte) (TextEnvelope, error) {
+	want := ed25519.PublicKeySize
+	switch envelopeType {
+	case TextEnvelopePaymentVerificationKey, TextEnvelopeStakeVerificationKey:
+	case TextEnvelopePaymentExtendedVerificationKey, TextEnvelopeStakeExtendedVerificationKey:
+		want = extendedVerificationKeyEnvelopeSize
+	default:
+		return TextEnvelope{}, fmt.Errorf("%q is not a verification key text envelope type", envelopeType)
+	}
+	if len(vkey) != want {
+		return TextEnvelope{}, fmt.Errorf("invalid verification key length %d: expected %d bytes", len(vkey), want)
+	}
+	payload, err := cbor.Encode(vkey)
+	if err != nil {
+		return TextEnvelope{}, fmt.Errorf("encode verification key: %w", err)
+	}
+	return newTextEnvelope(envelopeType, payload), nil
+}
+
+// VerificationKey returns the 32-byte Ed25519 public key of a verification or
+// signing key envelope. The chain code of an extended key is dropped.
+func (e TextEnvelope) VerificationKey() ([]byte, error) {
+	switch e.Type {
+	case TextEnvelopePaymentVerificationKey, TextEnvelopeStakeVerificationKey,
+		TextEnvelopePaymentExtendedVerificationKey, TextEnvelopeStakeExtendedVerificationKey:
+		key, err := e.keyBytes()
+		if err != nil {
+			return nil, err
+		}
+		if len(key) != ed25519.PublicKeySize && len(key) != extendedVerificationKeyEnvelopeSize {
+			return nil, fmt.Errorf("invalid %s length %d", e.Type, len(key))
+		}
+		return key[:ed25519.PublicKeySize], nil
+	}
+	skey, err := e.SigningKey()
+	if err != nil {
+		return nil, err
+	}
+	if len(skey) == bip32XPrvSize {
+		return bip32.XPrv(skey).Public().PublicKey(), nil
+	}
+	return ed25519.NewKeyFromSeed(skey).Public().(ed25519.PublicKey), nil
+}
+
+// KeyHash returns the Blake2b-224 hash of the envelope's verification key.
+func (e TextEnvelope) KeyHash() (common.Blake2b224, error) {
+	vkey, err := e.VerificationKey()
+	if err != nil {
+		return common.Blake2b224{}, err
+	}
+	return common.Blake2b224Hash(vkey), nil
+}
+
+// NewVkeyWitnessTextEnvelope wraps a witness the way
+// "cardano-cli conway transaction witness" writes it.
+func NewVkeyWitnessTextEnvelope(witness common.VkeyWitness) (TextEnvelope, error) {
+	payload, err := cbor.Encode([]any{keyWitnessTag, witness})
+	if err != nil {
+		return TextEnvelope{}, fmt.Errorf("encode key witness: %w", err)
+	}
+	return newTextEnvelope(TextEnvelopeTxWitness, payload), nil
+}
+
+// VkeyWitness decodes a "TxWitness ConwayEra" envelope. Byron bootstrap
+// witnesses are rejected.
+func (e TextEnvelope) VkeyWitness() (common.VkeyWitness, error) {
+	if err := e.requireType(TextEnvelopeTxWitness); err != nil {
+		return common.VkeyWitness{}, err
+	}
+	payload, err := e.Cbor()
+	if err != nil {
+		return common.VkeyWitness{}, err
+	}
+	var parts []cbor.RawMessage
+	if _, err := cbor.Decode(payload, &parts); err != nil {
+		return common.VkeyWitness{}, fmt.Errorf("decode key witness: %w", err)
+	}
+	if len(parts) != 2 {
+		return common.VkeyWitness{}, fmt.Errorf("key witness has %d elements, want 2", len(parts))
+	}
+	var tag uint64
+	if _, err := cbor.Decode(parts[0], &tag); err != nil {
+		return common.VkeyWitness{}, fmt.Errorf("decode key witness tag: %w", err)
+	}
+	if tag != keyWitnessTag {
+		return common.VkeyWitness{}, fmt.Errorf("unsupported key witness tag %d: only Shelley vkey witnesses are supported", tag)
+	}
+	var witness common.VkeyWitness
+	if _, err := cbor.Decode(parts[1], &witness); err != nil {
+		return common.VkeyWitness{}, fmt.Errorf("decode vkey witness: %w", err)
+	}
+	if len(witness.Vkey) != ed25519.PublicKeySize || len(witness.Signature) != ed25519.SignatureSize {
+		return common.VkeyWitness{}, errors.New("vkey witness has malformed key or signature")
+	}
+	return witness, nil
+}
+
+// NewTxTextEnvelope wraps a serialized Conway transaction, signed or not, as
+// "cardano-cli conway transaction sign" writes a .signed file.
+func NewTxTextEnvelope(txCbor []byte) TextEnvelope {
+	return newTextEnvelope(TextEnvelopeTx, txCbor)
+}
+
+// NewUnwitnessedTxTextEnvelope wraps an unsigned Conway transaction with the
+// "Unwitnessed Tx" type older cardano-cli releases expect from "transaction
+// build".
+func NewUnwitnessedTxTextEnvelope(txCbor []byte) TextEnvelope {
+	return newTextEnvelope(TextEnvelopeUnwitnessedTx, txCbor)
+}
+
+// TxCbor returns the transaction bytes of a transaction envelope after
+// checking they decode as a Conway transaction.
+func (e TextEnvelope) TxCbor() ([]byte, error) {
+	if err := e.requireType(TextEnvelopeTx, TextEnvelopeUnwitnessedTx); err != nil {
+		return nil, err
+	}
+	payload, err := e.Cbor()
+	if err != nil {
+		return nil, err
+	}
+	var tx conway.ConwayTransaction
+	if _, err := cbor.Decode(payload, &tx); err != nil {
+		return nil, fmt.Errorf("failed to decode transaction: %w", err)
+	}
+	return payload, nil
+}
+
+// NewWalletFromTextEnvelope loads a payment signing key envelope into a
+// Wallet whose address is the key's enterprise address on networkId. Use
+// NewKeyPairWallet or NewSigningKeyWallet with SigningKey for a different
+// address, such as a base address with a separate stake key.
+func NewWalletFromTextEnvelope(jsonData []byte, networkId uint8) (Wallet, error) {
+	env, err := ParseTextEnvelope(jsonData)
+	if err != nil {
+		return nil, err
+	}
+	if err := env.requireType(TextEnvelopePaymentSigningKey, TextEnvelopePaymentExtendedSigningKey); err != nil {
+		return nil, err
+	}
+	skey, err := env.SigningKey()
+	if err != nil {
+		return nil, err
+	}
+	keyHash, err := env.KeyHash()
+	if err != nil {
+		return nil, err
+	}
+	addr, err := common.NewAddressFromParts(common.AddressTypeKeyNone, networkId, keyHash.Bytes(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build enterprise address: %w", err)
+	}
+	if len(skey) == bip32XPrvSize {
+		return NewKeyPairWallet(addr, bip32.XPrv(skey))
+	}
+	return NewSigningKeyWallet(addr, skey)
+}
+
+// GetTxTextEnvelope returns the built transaction as cardano-cli TextEnvelope
+// JSON, ready to write as a .signed file for "cardano-cli conway transaction
+// submit".
+func (a *Apollo) GetTxTextEnvelope() ([]byte, error) {
+	txCbor, err := a.GetTxCbor()
+	if err != nil {
+		return nil, err
+	}
+	return NewTxTextEnvelope(txCbor).JSON()
+}
+
+// LoadTxTextEnvelope loads a transaction from cardano-cli TextEnvelope JSON.
+func (a *Apollo) LoadTxTextEnvelope(jsonData []byte) (*Apollo, error) {
+	env, err := ParseTextEnvelope(jsonData)
+	if err != nil {
+		return a, err
+	}
+	txCbor, err := env.TxCbor()
+	if err != nil {
+		return a, err
+	}
+	return a.LoadTxCbor(hex.EncodeToString(txCbor))
+}
+
+// AddTextEnvelopeWitness adds a witness produced by "cardano-cli conway
+// transaction witness" to the built transaction.
+func (a *Apollo) AddTextEnvelopeWitness(jsonData []byte) (*Apollo, error) {
+	env, err := ParseTextEnvelope(jsonData)
+	if err != nil {
+		return a, err
+	}
+	witness, err := env.VkeyWitness()
+	if err != nil {
+		return a, err
+	}
+	return a.AddVerificationKeyWitness(witness)
+}
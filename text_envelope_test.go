@@ -0,0 +1,174 @@
+package apollo
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/blinklabs-io/gouroboros/cbor"
+	"github.com/blinklabs-io/gouroboros/ledger/common"
+)
+
+// cardanoCliPaymentSkey is the all-0x01 seed laid out byte for byte as
+// "cardano-cli address key-gen" writes a signing key file; it holds no funds.
+const cardanoCliPaymentSkey = `{
+    "type": "PaymentSigningKeyShelley_ed25519",
+    "description": "Payment Signing Key",
+    "cborHex": "58200101010101010101010101010101010101010101010101010101010101010101"
+}
+`
+
+func TestTextEnvelopeSigningKeyRoundTrip(t *testing.T) {
+	env, err := ParseTextEnvelope([]byte(cardanoCliPaymentSkey))
+	if err != nil {
+		t.Fatal(err)
+	}
+	skey, err := env.SigningKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(skey, bytes.Repeat([]byte{0x01}, ed25519.SeedSize)) {
+		t.Fatalf("signing key = %x", skey)
+	}
+	rebuilt, err := NewSigningKeyTextEnvelope(TextEnvelopePaymentSigningKey, skey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := rebuilt.JSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != cardanoCliPaymentSkey {
+		t.Fatalf("re-encoded envelope differs from cardano-cli:\n%s", out)
+	}
+}
+
+func TestTextEnvelopeExtendedSigningKey(t *testing.T) {
+	xprv := testPaymentKey(t, 0)
+	env, err := NewSigningKeyTextEnvelope(TextEnvelopePaymentExtendedSigningKey, xprv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 128-byte key behind a two-byte CBOR bytestring header.
+	if len(env.CborHex) != 2*(2+extendedSigningKeyEnvelopeSize) {
+		t.Fatalf("cborHex length = %d", len(env.CborHex))
+	}
+	skey, err := env.SigningKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(skey, xprv) {
+		t.Fatal("extended signing key did not round-trip")
+	}
+
+	payload, err := env.Cbor()
+	if err != nil {
+		t.Fatal(err)
+	}
+	payload[2+64] ^= 0xff
+	env.CborHex = hex.EncodeToString(payload)
+	if _, err := env.SigningKey(); err == nil {
+		t.Fatal("expected a tampered public-key half to be rejected")
+	}
+}
+
+func TestTextEnvelopeRejectsWrongType(t *testing.T) {
+	env, err := ParseTextEnvelope([]byte(cardanoCliPaymentSkey))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := env.VkeyWitness(); err == nil {
+		t.Fatal("expected a signing key envelope to be rejected as a witness")
+	}
+	if _, err := env.TxCbor(); err == nil {
+		t.Fatal("expected a signing key envelope to be rejected as a transaction")
+	}
+	if _, err := ParseTextEnvelope([]byte(`{"type":"","cborHex":""}`)); err == nil {
+		t.Fatal("expected an envelope without a type to be rejected")
+	}
+}
+
+func TestTextEnvelopeWalletSignsAndExports(t *testing.T) {
+	w, err := NewWalletFromTextEnvelope([]byte(cardanoCliPaymentSkey), common.AddressNetworkMainnet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w.Address().Type() != common.AddressTypeKeyNone {
+		t.Fatalf("address type = %d, want enterprise", w.Address().Type())
+	}
+
+	cc := networkTestContext(t, common.AddressNetworkMainnet)
+	addTestUtxo(cc, w.Address(), 10_000_000, 0x01, 0)
+	payee := syntheticAddress(t, common.AddressTypeKeyKey, common.AddressNetworkMainnet)
+	a, err := New(cc).
+		SetWallet(w).
+		SetTtl(50_000_000).
+		PayToAddress(payee, 2_000_000).
+		Complete()
+	if err != nil {
+		t.Fatal(err)
+	}
+	txCbor, err := a.GetTxCbor()
+	if err != nil {
+		t.Fatal(err)
+	}
+	unsigned, err := NewUnwitnessedTxTextEnvelope(txCbor).JSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if env, err := ParseTextEnvelope(unsigned); err != nil || env.Type != TextEnvelopeUnwitnessedTx {
+		t.Fatalf("unsigned envelope %s is not an Unwitnessed Tx: %v", unsigned, err)
+	}
+
+	// Sign out of band, as "cardano-cli transaction witness" would. A built
+	// body has no stored CBOR, so hash its encoding rather than calling Id.
+	bodyCbor, err := cbor.Encode(&a.GetTx().Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bodyHash := common.Blake2b256Hash(bodyCbor)
+	witness, err := w.SignTxBody(bodyHash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	witnessEnv, err := NewVkeyWitnessTextEnvelope(witness)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(witnessEnv.CborHex, "8200825820") {
+		t.Fatalf("witness cborHex %s is not a tagged Shelley key witness", witnessEnv.CborHex)
+	}
+	witnessJSON, err := witnessEnv.JSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := New(cc).LoadTxTextEnvelope(unsigned)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loaded.AddTextEnvelopeWitness(witnessJSON); err != nil {
+		t.Fatal(err)
+	}
+	signed, err := loaded.GetTxTextEnvelope()
+	if err != nil {
+		t.Fatal(err)
+	}
+	env, err := ParseTextEnvelope(signed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if env.Type != TextEnvelopeTx || env.Description != "Ledger Cddl Format" {
+		t.Fatalf("signed envelope header = %q / %q", env.Type, env.Description)
+	}
+	loadedHash := common.Blake2b256Hash(loaded.GetTx().Body.Cbor())
+	if loadedHash != bodyHash {
+		t.Fatal("loaded transaction body differs from the exported one")
+	}
+	witnesses := loaded.GetTx().WitnessSet.VkeyWitnesses.Items()
+	if len(witnesses) != 1 || !ed25519.Verify(witnesses[0].Vkey, loadedHash.Bytes(), witnesses[0].Signature) {
+		t.Fatal("imported witness does not verify against the transaction body")
+	}
+}
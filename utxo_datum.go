@@ -0,0 +1,170 @@
+package apollo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/blinklabs-io/gouroboros/ledger/common"
+
+	"github.com/Salvionied/apollo/v2/backend"
+	"github.com/Salvionied/apollo/v2/plutusencoder"
+)
+
+// ErrNoDatum is returned for a UTxO that carries neither an inline datum nor
+// a datum hash.
+var ErrNoDatum = errors.New("utxo carries no datum")
+
+// UtxoDatum returns the datum of utxo. An inline datum is returned as is. A
+// datum hash is resolved through chainContext, which must implement
+// backend.DatumResolver; chainContext may be nil when only inline datums are
+// expected.
+func UtxoDatum(ctx context.Context, chainContext backend.ChainContext, utxo common.Utxo) (*common.Datum, error) {
+	if utxo.Output == nil {
+		return nil, errors.New("utxo has no output")
+	}
+	if datum := utxo.Output.Datum(); datum != nil {
+		return datum, nil
+	}
+	hash := utxo.Output.DatumHash()
+	if hash == nil {
+		return nil, ErrNoDatum
+	}
+	datum, err := backend.DatumByHashContext(ctx, chainContext, *hash)
+	if err != nil {
+		return nil, fmt.Errorf("resolve datum hash %s: %w", hash.String(), err)
+	}
+	return datum, nil
+}
+
+// DecodeUtxoDatum returns the datum of utxo decoded into T with
+// plutusencoder.UnmarshalPlutus. Datum hashes are resolved as in UtxoDatum.
+//
+//	state, err := apollo.DecodeUtxoDatum[EscrowDatum](ctx, chainContext, utxo)
+func DecodeUtxoDatum[T any](ctx context.Context, chainContext backend.ChainContext, utxo common.Utxo) (T, error) {
+	var value T
+	datum, err := UtxoDatum(ctx, chainContext, utxo)
+	if err != nil {
+		return value, err
+	}
+	if err := plutusencoder.UnmarshalPlutus(datum.Data, &value); err != nil {
+		return value, fmt.Errorf("decode datum of %s: %w", utxoRef(utxo), err)
+	}
+	return value, nil
+}
+
+// DatumUtxo is a UTxO together with its decoded datum.
+type DatumUtxo[T any] struct {
+	Utxo  common.Utxo
+	Datum T
+}
+
+// FilterUtxosByDatum decodes the datum of each UTxO into T and keeps those
+// for which keep returns true; a nil keep keeps every UTxO whose datum
+// decodes. UTxOs without a datum, or whose datum does not decode to T, are
+// skipped, since anyone can lock arbitrary datums at a script address.
+// Failing to resolve a datum hash is an error.
+func FilterUtxosByDatum[T any](
+	ctx context.Context,
+	chainContext backend.ChainContext,
+	utxos []common.Utxo,
+	keep func(T) bool,
+) ([]DatumUtxo[T], error) {
+	var result []DatumUtxo[T]
+	for _, utxo := range utxos {
+		datum, err := UtxoDatum(ctx, chainContext, utxo)
+		if errors.Is(err, ErrNoDatum) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", utxoRef(utxo), err)
+		}
+		var value T
+		if err := plutusencoder.UnmarshalPlutus(datum.Data, &value); err != nil {
+			continue
+		}
+		if keep != nil && !keep(value) {
+			continue
+		}
+		result = append(result, DatumUtxo[T]{Utxo: utxo, Datum: value})
+	}
+	return result, nil
+}
+
+// UtxosWithDatum fetches the UTxOs at addr and filters them with
+// FilterUtxosByDatum.
+func UtxosWithDatum[T any](
+	ctx context.Context,
+	chainContext backend.ChainContext,
+	addr common.Address,
+	keep func(T) bool,
+) ([]DatumUtxo[T], error) {
+	utxos, err := backend.UtxosContext(ctx, chainContext, addr)
+	if err != nil {
+		return nil, err
+	}
+	return FilterUtxosByDatum(ctx, chainContext, utxos, keep)
+}
+
+// resolveWitnessDatums adds the datum of every script input locked with a
+// datum hash to the witness set, unless AddDatum already supplied it. The
+// datums are looked up through the chain context, which must report
+// backend.CapabilityDatumByHash.
+func (a *Apollo) resolveWitnessDatums() error {
+	var have map[common.Blake2b256]bool
+	for _, utxo := range a.preselectedUtxos {
+		if utxo.Output == nil || utxo.Output.Datum() != nil {
+			continue
+		}
+		hash := utxo.Output.DatumHash()
+		if hash == nil {
+			continue
+		}
+		if _, ok := scriptPaymentHash(utxo.Output.Address()); !ok {
+			continue
+		}
+		if have == nil {
+			have = make(map[common.Blake2b256]bool, len(a.datums))
+			for i := range a.datums {
+				datumHash, err := witnessDatumHash(&a.datums[i])
+				if err != nil {
+					return err
+				}
+				have[datumHash] = true
+			}
+		}
+		if have[*hash] {
+			continue
+		}
+		if !backend.Supports(a.Context, backend.CapabilityDatumByHash) {
+			return fmt.Errorf(
+				"input %s is locked with datum hash %s: add its datum with AddDatum or use a chain context that supports %s",
+				utxoRef(utxo),
+				hash.String(),
+				backend.CapabilityDatumByHash,
+			)
+		}
+		datum, err := backend.DatumByHashContext(a.requestContext, a.Context, *hash)
+		if err != nil {
+			return fmt.Errorf("resolve datum of input %s: %w", utxoRef(utxo), err)
+		}
+		a.datums = append(a.datums, *datum)
+		have[*hash] = true
+	}
+	return nil
+}
+
+// witnessDatumHash hashes the bytes datum occupies in the witness set. Unlike
+// DatumHash it accepts datums whose original CBOR is not canonical, which is
+// how fetched datums keep the hash their output commits to.
+func witnessDatumHash(datum *common.Datum) (common.Blake2b256, error) {
+	datumCbor := datum.Cbor()
+	if len(datumCbor) == 0 {
+		var err error
+		datumCbor, err = datum.MarshalCBOR()
+		if err != nil {
+			return common.Blake2b256{}, fmt.Errorf("encode witness datum: %w", err)
+		}
+	}
+	return common.Blake2b256Hash(datumCbor), nil
+}
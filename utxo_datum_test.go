@@ -0,0 +1,235 @@
+package apollo
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/blinklabs-io/gouroboros/ledger/babbage"
+	"github.com/blinklabs-io/gouroboros/ledger/common"
+	"github.com/blinklabs-io/gouroboros/ledger/mary"
+	"github.com/blinklabs-io/gouroboros/ledger/shelley"
+	"github.com/blinklabs-io/plutigo/data"
+
+	"github.com/Salvionied/apollo/v2/backend"
+	"github.com/Salvionied/apollo/v2/backend/fixed"
+)
+
+type lockDatum struct {
+	_      struct{} `plutusType:"IndefList" plutusConstr:"0"`
+	Owner  []byte   `plutusType:"Bytes"`
+	Amount int64    `plutusType:"Int"`
+}
+
+func lockDatumData(owner byte, amount int64) *common.Datum {
+	return &common.Datum{Data: data.NewConstr(0,
+		data.NewByteString([]byte{owner}),
+		data.NewInteger(big.NewInt(amount)),
+	)}
+}
+
+func makeDatumUtxo(t *testing.T, index uint32, datumOption *babbage.BabbageTransactionOutputDatumOption) common.Utxo {
+	t.Helper()
+	output := babbage.BabbageTransactionOutput{
+		OutputAddress: testAddress(t),
+		OutputAmount:  mary.MaryTransactionOutputValue{Amount: 2_000_000},
+		DatumOption:   datumOption,
+	}
+	return common.Utxo{
+		Id:     shelley.ShelleyTransactionInput{TxId: common.Blake2b256{0x0d}, OutputIndex: index},
+		Output: &output,
+	}
+}
+
+func inlineDatumUtxo(t *testing.T, index uint32, datum *common.Datum) common.Utxo {
+	t.Helper()
+	opt, err := NewDatumOptionInline(datum)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return makeDatumUtxo(t, index, opt)
+}
+
+func hashDatumUtxo(t *testing.T, index uint32, hash common.Blake2b256) common.Utxo {
+	t.Helper()
+	opt, err := NewDatumOptionHash(hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return makeDatumUtxo(t, index, opt)
+}
+
+func TestDecodeUtxoDatumInline(t *testing.T) {
+	utxo := inlineDatumUtxo(t, 0, lockDatumData(0xaa, 5))
+	got, err := DecodeUtxoDatum[lockDatum](context.Background(), nil, utxo)
+	if err != nil {
+		t.Fatalf("DecodeUtxoDatum: %v", err)
+	}
+	if len(got.Owner) != 1 || got.Owner[0] != 0xaa || got.Amount != 5 {
+		t.Fatalf("DecodeUtxoDatum = %+v", got)
+	}
+}
+
+func TestDecodeUtxoDatumByHash(t *testing.T) {
+	cc := fixed.NewEmptyFixedChainContext()
+	hash, err := cc.AddDatum(*lockDatumData(0xbb, 9))
+	if err != nil {
+		t.Fatal(err)
+	}
+	utxo := hashDatumUtxo(t, 0, hash)
+	got, err := DecodeUtxoDatum[lockDatum](context.Background(), cc, utxo)
+	if err != nil {
+		t.Fatalf("DecodeUtxoDatum: %v", err)
+	}
+	if got.Owner[0] != 0xbb || got.Amount != 9 {
+		t.Fatalf("DecodeUtxoDatum = %+v", got)
+	}
+
+	missing := hashDatumUtxo(t, 1, common.Blake2b256{0x01})
+	if _, err := DecodeUtxoDatum[lockDatum](context.Background(), cc, missing); err == nil {
+		t.Fatal("DecodeUtxoDatum resolved an unknown datum hash")
+	}
+}
+
+func TestUtxoDatumErrors(t *testing.T) {
+	plain := makeDatumUtxo(t, 0, nil)
+	if _, err := UtxoDatum(context.Background(), nil, plain); !errors.Is(err, ErrNoDatum) {
+		t.Fatalf("UtxoDatum without datum = %v, want ErrNoDatum", err)
+	}
+
+	hashOnly := hashDatumUtxo(t, 1, common.Blake2b256{0x02})
+	_, err := UtxoDatum(context.Background(), unsupportedDatumContext{fixed.NewEmptyFixedChainContext()}, hashOnly)
+	if !errors.Is(err, backend.ErrUnsupported) {
+		t.Fatalf("UtxoDatum without resolver = %v, want ErrUnsupported", err)
+	}
+
+	utxo := inlineDatumUtxo(t, 2, &common.Datum{Data: data.NewInteger(big.NewInt(1))})
+	if _, err := DecodeUtxoDatum[lockDatum](context.Background(), nil, utxo); err == nil {
+		t.Fatal("DecodeUtxoDatum decoded an integer into a struct")
+	}
+}
+
+// unsupportedDatumContext hides the fixed context's DatumResolver.
+type unsupportedDatumContext struct {
+	backend.ChainContext
+}
+
+func TestUtxosWithDatum(t *testing.T) {
+	cc := fixed.NewEmptyFixedChainContext()
+	addr := testAddress(t)
+	hash, err := cc.AddDatum(*lockDatumData(0x02, 20))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, utxo := range []common.Utxo{
+		inlineDatumUtxo(t, 0, lockDatumData(0x01, 10)),
+		hashDatumUtxo(t, 1, hash),
+		inlineDatumUtxo(t, 2, lockDatumData(0x03, 1)),
+		inlineDatumUtxo(t, 3, &common.Datum{Data: data.NewByteString([]byte("junk"))}),
+		makeDatumUtxo(t, 4, nil),
+	} {
+		cc.AddUtxo(addr, utxo)
+	}
+
+	matches, err := UtxosWithDatum(context.Background(), cc, addr, func(d lockDatum) bool {
+		return d.Amount >= 10
+	})
+	if err != nil {
+		t.Fatalf("UtxosWithDatum: %v", err)
+	}
+	if len(matches) != 2 {
+		t.Fatalf("UtxosWithDatum returned %d UTxOs, want 2", len(matches))
+	}
+	if matches[0].Utxo.Id.Index() != 0 || matches[0].Datum.Amount != 10 ||
+		matches[1].Utxo.Id.Index() != 1 || matches[1].Datum.Amount != 20 {
+		t.Fatalf("UtxosWithDatum = %+v", matches)
+	}
+
+	all, err := UtxosWithDatum[lockDatum](context.Background(), cc, addr, nil)
+	if err != nil {
+		t.Fatalf("UtxosWithDatum: %v", err)
+	}
+	if len(all) != 3 {
+		t.Fatalf("UtxosWithDatum(nil) returned %d UTxOs, want 3", len(all))
+	}
+}
+
+func TestFilterUtxosByDatumFailsOnUnresolvableHash(t *testing.T) {
+	utxos := []common.Utxo{hashDatumUtxo(t, 0, common.Blake2b256{0x03})}
+	if _, err := FilterUtxosByDatum[lockDatum](context.Background(), nil, utxos, nil); err == nil {
+		t.Fatal("FilterUtxosByDatum skipped an unresolvable datum hash")
+	}
+}
+
+// hashLockedScriptUtxo is a script-address UTxO locked with a datum hash.
+func hashLockedScriptUtxo(t *testing.T, hash common.Blake2b256) common.Utxo {
+	t.Helper()
+	utxo := scriptAddressUtxo(t, 0x5a, 5_000_000)
+	opt, err := NewDatumOptionHash(hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	utxo.Output.(*babbage.BabbageTransactionOutput).DatumOption = opt
+	return utxo
+}
+
+func spendHashLockedInput(t *testing.T, cc backend.ChainContext, utxo common.Utxo, extra ...common.Datum) (*Apollo, error) {
+	t.Helper()
+	redeemer := common.Datum{Data: data.NewInteger(big.NewInt(0))}
+	a := New(cc).
+		SetWallet(NewExternalWallet(testAddress(t))).
+		AttachScript(common.PlutusV2Script([]byte{0x01, 0x02})).
+		DisableExecutionUnitsEstimation().
+		CollectFrom(utxo, redeemer, common.ExUnits{Memory: 1, Steps: 1})
+	for i := range extra {
+		a = a.AddDatum(&extra[i])
+	}
+	_, err := a.Complete()
+	return a, err
+}
+
+func TestCompleteResolvesWitnessDatumByHash(t *testing.T) {
+	cc := setupFixedContext()
+	addTestUtxo(cc, testAddress(t), 20_000_000, 0x01, 0)
+	hash, err := cc.AddDatum(*lockDatumData(0x0e, 3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	a, err := spendHashLockedInput(t, cc, hashLockedScriptUtxo(t, hash))
+	if err != nil {
+		t.Fatalf("Complete: %v", err)
+	}
+	if len(a.datums) != 1 {
+		t.Fatalf("witness datums = %d, want 1", len(a.datums))
+	}
+	if got, err := witnessDatumHash(&a.datums[0]); err != nil || got != hash {
+		t.Fatalf("witness datum hash = %s (%v), want %s", got, err, hash)
+	}
+}
+
+func TestCompleteWitnessDatumNeedsLookupOrAddDatum(t *testing.T) {
+	cc := setupFixedContext()
+	addTestUtxo(cc, testAddress(t), 20_000_000, 0x01, 0)
+	datum := lockDatumData(0x0f, 4)
+	datumCbor, err := datum.MarshalCBOR()
+	if err != nil {
+		t.Fatal(err)
+	}
+	utxo := hashLockedScriptUtxo(t, common.Blake2b256Hash(datumCbor))
+	hidden := unsupportedDatumContext{cc}
+
+	if _, err := spendHashLockedInput(t, hidden, utxo); err == nil ||
+		!strings.Contains(err.Error(), "AddDatum") {
+		t.Fatalf("Complete error = %v, want a hint to use AddDatum", err)
+	}
+
+	a, err := spendHashLockedInput(t, hidden, utxo, *datum)
+	if err != nil {
+		t.Fatalf("Complete with AddDatum: %v", err)
+	}
+	if len(a.datums) != 1 {
+		t.Fatalf("witness datums = %d, want 1", len(a.datums))
+	}
+}
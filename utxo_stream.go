@@ -0,0 +1,148 @@
+package apollo
+
+import (
+	"fmt"
+	"iter"
+
+	"github.com/blinklabs-io/gouroboros/ledger/common"
+
+	"github.com/Salvionied/apollo/v2/backend"
+)
+
+// streamedCollateralScan bounds how many UTxOs Complete reads from a stream
+// while looking for a pure-lovelace collateral candidate.
+const streamedCollateralScan = 64
+
+// utxoStream is the part of the input addresses Complete has not read yet.
+// It is open only while Complete runs with a StreamingCoinSelector over a
+// backend.UtxoStreamer; every UTxO it yields is appended to a.utxos, so the
+// prefix read so far is the pool that collateral and selection draw on.
+type utxoStream struct {
+	next func() (common.Utxo, error, bool)
+	stop func()
+	seen map[string]struct{}
+}
+
+// openUtxoStream starts streaming the input addresses and reports whether it
+// did. It does so only when the coin selector can stop reading once its
+// target is covered and the chain context can serve a partial read.
+func (a *Apollo) openUtxoStream() bool {
+	selector := a.coinSelector
+	if selector == nil {
+		selector = defaultCoinSelector
+	}
+	if _, ok := selector.(StreamingCoinSelector); !ok {
+		return false
+	}
+	if _, ok := a.Context.(backend.UtxoStreamer); !ok {
+		return false
+	}
+	seen := make(map[string]struct{}, len(a.utxos))
+	for _, utxo := range a.utxos {
+		seen[utxoRef(utxo)] = struct{}{}
+	}
+	next, stop := iter.Pull2(a.inputUtxoSeq())
+	a.utxoStream = &utxoStream{next: next, stop: stop, seen: seen}
+	return true
+}
+
+// closeUtxoStream releases the stream, leaving the rest of the input
+// addresses unread.
+func (a *Apollo) closeUtxoStream() {
+	if a.utxoStream != nil {
+		a.utxoStream.stop()
+		a.utxoStream = nil
+	}
+}
+
+// inputUtxoSeq yields the UTxOs at the input addresses in order and, as
+// loadUtxos does, falls back to the wallet's addresses when those hold none
+// and no input was preselected.
+func (a *Apollo) inputUtxoSeq() iter.Seq2[common.Utxo, error] {
+	return func(yield func(common.Utxo, error) bool) {
+		empty := len(a.utxos) == 0
+		for _, addr := range a.inputAddresses {
+			for utxo, err := range backend.UtxosSeq(a.requestContext, a.Context, addr) {
+				if err == nil {
+					err = validateUtxo(utxo)
+				}
+				if err != nil {
+					yield(common.Utxo{}, fmt.Errorf("failed to load UTxOs for %s: %w", addr.String(), err))
+					return
+				}
+				empty = false
+				if !yield(utxo, nil) {
+					return
+				}
+			}
+		}
+		if !empty || len(a.preselectedUtxos) > 0 || a.wallet == nil {
+			return
+		}
+		for _, addr := range a.walletInputAddresses() {
+			for utxo, err := range backend.UtxosSeq(a.requestContext, a.Context, addr) {
+				if err == nil {
+					err = validateUtxo(utxo)
+				}
+				if err != nil {
+					yield(common.Utxo{}, fmt.Errorf("failed to load wallet UTxOs: %w", err))
+					return
+				}
+				if !yield(utxo, nil) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// readUtxo appends the next UTxO from the stream to a.utxos, skipping any
+// already in the pool. It reports false once the stream is exhausted, and
+// always when no stream is open.
+func (a *Apollo) readUtxo() (bool, error) {
+	if a.utxoStream == nil {
+		return false, nil
+	}
+	for {
+		utxo, err, ok := a.utxoStream.next()
+		if !ok {
+			return false, nil
+		}
+		if err != nil {
+			return false, err
+		}
+		ref := utxoRef(utxo)
+		if _, ok := a.utxoStream.seen[ref]; ok {
+			continue
+		}
+		a.utxoStream.seen[ref] = struct{}{}
+		a.utxos = append(a.utxos, utxo)
+		return true, nil
+	}
+}
+
+// availableUtxoSeq yields the pool entries not yet used, reading further
+// from the stream only once those already read run out.
+func (a *Apollo) availableUtxoSeq() iter.Seq2[common.Utxo, error] {
+	return func(yield func(common.Utxo, error) bool) {
+		for i := 0; ; i++ {
+			if i == len(a.utxos) {
+				ok, err := a.readUtxo()
+				if err != nil {
+					yield(common.Utxo{}, err)
+					return
+				}
+				if !ok {
+					return
+				}
+			}
+			utxo := a.utxos[i]
+			if a.isUsed(utxoRef(utxo)) {
+				continue
+			}
+			if !yield(utxo, nil) {
+				return
+			}
+		}
+	}
+}
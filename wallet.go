@@ -1,6 +1,8 @@
 package apollo
 
 import (
+	"bytes"
+	"crypto/ed25519"
 	"errors"
 	"fmt"
 
@@ -21,6 +23,34 @@ type Wallet interface {
 	StakePubKeyHash() common.Blake2b224
 }
 
+// InputAddressProvider is an optional extension to Wallet for wallets that
+// own funds at more than one address. When no UTxOs or input addresses are
+// supplied to the builder, Complete loads UTxOs from every returned address
+// instead of only Address.
+type InputAddressProvider interface {
+	InputAddresses() []common.Address
+}
+
+// MultiKeySigner is an optional extension to Wallet for wallets that hold
+// more than one signing key. Sign passes the sorted key hashes the
+// transaction needs a vkey witness for; the wallet returns one witness for
+// each hash it controls and skips the rest.
+type MultiKeySigner interface {
+	SignTxBodyFor(
+		txBodyHash common.Blake2b256,
+		keyHashes []common.Blake2b224,
+	) ([]common.VkeyWitness, error)
+}
+
+// NativeScriptWallet is an optional extension to Wallet for wallets whose
+// address is locked by a native script. Complete attaches NativeScript when
+// the transaction spends from the wallet address, and fees are estimated for
+// one vkey witness per ScriptSigners hash instead of one for PubKeyHash.
+type NativeScriptWallet interface {
+	NativeScript() common.NativeScript
+	ScriptSigners() []common.Blake2b224
+}
+
 // BursaWallet wraps bursa key derivation for HD wallet functionality.
 type BursaWallet struct {
 	mnemonic   string
@@ -230,6 +260,71 @@ func (w KeyPairWallet) GoString() string {
 	return w.String()
 }
 
+// SigningKeyWallet provides signing from a plain (non-extended) Ed25519 key,
+// such as a cardano-cli PaymentSigningKeyShelley_ed25519 file.
+type SigningKeyWallet struct {
+	address    common.Address
+	privateKey ed25519.PrivateKey
+}
+
+// NewSigningKeyWallet creates a wallet from a 32-byte Ed25519 seed or a
+// 64-byte Go ed25519.PrivateKey, and the address it pays from.
+func NewSigningKeyWallet(addr common.Address, skey []byte) (*SigningKeyWallet, error) {
+	var seed []byte
+	switch len(skey) {
+	case ed25519.SeedSize:
+		seed = skey
+	case ed25519.PrivateKeySize:
+		seed = skey[:ed25519.SeedSize]
+		// A mismatched public-key half allows private-scalar recovery from
+		// two signatures over the same message.
+		if !bytes.Equal(ed25519.NewKeyFromSeed(seed)[ed25519.SeedSize:], skey[ed25519.SeedSize:]) {
+			return nil, errors.New("signing key public-key half does not match its seed")
+		}
+	default:
+		return nil, fmt.Errorf("invalid Ed25519 signing key length: expected 32 or 64 bytes, got %d", len(skey))
+	}
+	return &SigningKeyWallet{
+		address:    addr,
+		privateKey: ed25519.NewKeyFromSeed(seed),
+	}, nil
+}
+
+func (w *SigningKeyWallet) Address() common.Address {
+	return w.address
+}
+
+func (w *SigningKeyWallet) SignTxBody(txBodyHash common.Blake2b256) (common.VkeyWitness, error) {
+	return common.VkeyWitness{
+		Vkey:      w.publicKey(),
+		Signature: ed25519.Sign(w.privateKey, txBodyHash.Bytes()),
+	}, nil
+}
+
+func (w *SigningKeyWallet) PubKeyHash() common.Blake2b224 {
+	return common.Blake2b224Hash(w.publicKey())
+}
+
+// StakePubKeyHash returns a zero hash because SigningKeyWallet has no staking key.
+func (w *SigningKeyWallet) StakePubKeyHash() common.Blake2b224 {
+	return common.Blake2b224{}
+}
+
+func (w *SigningKeyWallet) publicKey() []byte {
+	return w.privateKey[ed25519.SeedSize:]
+}
+
+// String returns a safe string representation that does not expose key material.
+// Value receiver so the redaction also applies to dereferenced values.
+func (w SigningKeyWallet) String() string {
+	return fmt.Sprintf("SigningKeyWallet{address: %s}", w.address.String())
+}
+
+// GoString implements fmt.GoStringer to prevent key material from leaking via %#v.
+func (w SigningKeyWallet) GoString() string {
+	return w.String()
+}
+
 // ExternalWallet is an address-only wallet for watch-only flows.
 // It cannot sign transactions.
 type ExternalWallet struct {